@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/yuechangmingzou/nofx-go/internal/ai"
+	"github.com/yuechangmingzou/nofx-go/internal/backtest"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/internal/strategies"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+func main() {
+	var (
+		symbol   = flag.String("symbol", "BTCUSDT", "交易对")
+		dataDir  = flag.String("data", "data/backtest", "历史K线目录（{SYMBOL}_{周期}.json）")
+		strategy = flag.String("strategy", "rule", "决策器：rule 或 ai")
+		fetch    = flag.Bool("fetch", false, "先从Binance下载K线到数据目录")
+		limit    = flag.Int("limit", 1500, "下载时每个周期的K线数量")
+		interval = flag.Duration("interval", 0, "决策间隔（默认使用SCAN_INTERVAL）")
+		output   = flag.String("o", "", "输出文件（JSON格式）")
+	)
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found: %v", err)
+	}
+	if err := config.Load(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := utils.InitLogger(config.Get().LogLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	if *fetch {
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			log.Fatalf("Failed to select exchange: %v", err)
+		}
+		for _, tf := range scanner.ScanTimeframes {
+			candles, err := ex.GetOHLCV(*symbol, tf, *limit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "下载%s K线失败: %v\n", tf, err)
+				os.Exit(1)
+			}
+			if err := backtest.SaveCandles(*dataDir, *symbol, tf, candles); err != nil {
+				fmt.Fprintf(os.Stderr, "保存%s K线失败: %v\n", tf, err)
+				os.Exit(1)
+			}
+			fmt.Printf("已下载 %s %s K线 %d 根\n", *symbol, tf, len(candles))
+		}
+	}
+
+	candles, err := backtest.LoadCandles(*dataDir, *symbol)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载K线失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	var decider backtest.Decider
+	switch *strategy {
+	case "rule":
+		decider = backtest.NewRuleDecider(strategies.GetRuleStrategy(config.Get().StratMaxSlippageBps))
+	case "ai":
+		trader, err := ai.GetAITrader()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化AI交易员失败: %v\n", err)
+			os.Exit(1)
+		}
+		decider = backtest.NewAIDecider(trader)
+	default:
+		fmt.Fprintf(os.Stderr, "未知决策器: %s\n", *strategy)
+		os.Exit(1)
+	}
+
+	btCfg := backtest.DefaultConfig()
+	if *interval > 0 {
+		btCfg.DecisionInterval = *interval
+	}
+
+	result, err := backtest.NewBacktester(btCfg, decider).Run(context.Background(), *symbol, candles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "回测失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	stats := result.Stats
+	fmt.Println("回测结果:")
+	fmt.Printf("  交易对: %s\n", result.Symbol)
+	fmt.Printf("  K线区间: %s ~ %s\n",
+		time.UnixMilli(candles["1m"][0].Time).Format(time.RFC3339),
+		time.UnixMilli(candles["1m"][len(candles["1m"])-1].Time).Format(time.RFC3339))
+	fmt.Printf("  交易次数: %d（盈利%d / 亏损%d）\n", stats.TotalTrades, stats.Wins, stats.Losses)
+	fmt.Printf("  胜率: %.2f%%\n", stats.WinRate*100)
+	fmt.Printf("  盈亏比(Profit Factor): %.2f\n", stats.ProfitFactor)
+	fmt.Printf("  净收益: %.4f USDT (%.2f%%)\n", stats.NetProfit, stats.ReturnPct)
+	fmt.Printf("  手续费: %.4f USDT\n", stats.TotalFees)
+	fmt.Printf("  最大回撤: %.4f USDT (%.2f%%)\n", stats.MaxDrawdown, stats.MaxDrawdownPct)
+	fmt.Printf("  夏普比率: %.2f\n", stats.Sharpe)
+
+	// 保存到文件
+	if *output != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "序列化结果失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "写入文件失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("\n结果已保存到: %s\n", *output)
+	}
+}
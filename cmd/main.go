@@ -176,7 +176,14 @@ func runScanner(ctx context.Context, logger *zap.SugaredLogger) {
 	logger.Info("🚀 市场扫描器启动")
 
 	// 初始化扫描器
-	sc := scanner.GetScanner()
+	sc, err := scanner.GetScanner()
+	if err != nil {
+		logger.Fatalw("初始化扫描器失败", "error", err)
+		return
+	}
+	if sc.StartMarketFeed(ctx) {
+		logger.Info("WebSocket行情流已启用")
+	}
 
 	// 初始化交易机器人（用于处理信号）
 	b, err := bot.GetBot()
@@ -301,7 +308,11 @@ func runBot(ctx context.Context, logger *zap.SugaredLogger) {
 
 // runWebServer 运行Web服务器
 func runWebServer(ctx context.Context, logger *zap.SugaredLogger) {
-	server := web.GetServer()
+	server, err := web.GetServer()
+	if err != nil {
+		logger.Fatalw("初始化Web服务器失败", "error", err)
+		return
+	}
 	if err := server.Run(ctx); err != nil && err != context.Canceled {
 		logger.Errorw("Web服务器错误", "error", err)
 	}
@@ -79,7 +79,7 @@ func (t *AITrader) FormatMarketData(marketData *types.MarketData) (string, error
 	// 获取系统提示词
 	systemPrompt := cfg.AITraderSystemPrompt
 	if systemPrompt == "" {
-		systemPrompt = "你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"
+		systemPrompt = "你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、盘口深度与流动性、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"
 	}
 	systemPromptLower := strings.ToLower(systemPrompt)
 
@@ -107,14 +107,31 @@ func (t *AITrader) FormatMarketData(marketData *types.MarketData) (string, error
 			}
 		}
 		filteredData["cvd"] = marketData.CVD
+		if len(marketData.CVDByTimeframe) > 0 {
+			filteredData["cvd_by_timeframe"] = marketData.CVDByTimeframe
+		}
 		filteredData["obv"] = marketData.OBV
 	}
 
 	// 衍生品与资金数据（如果提示词提到）
-	if containsAny(systemPromptLower, []string{"衍生品", "资金", "funding", "持仓", "open interest"}) {
+	if containsAny(systemPromptLower, []string{"衍生品", "资金", "funding", "持仓", "open interest", "强平", "爆仓", "liquidation"}) {
 		filteredData["funding_rate"] = marketData.FundingRate
 		filteredData["open_interest"] = marketData.OpenInterest
 		filteredData["open_interest_change"] = marketData.OpenInterestChange
+		if marketData.Funding != nil {
+			filteredData["funding"] = marketData.Funding
+		}
+		if marketData.Derivatives != nil {
+			filteredData["derivatives"] = marketData.Derivatives
+		}
+		if len(marketData.Liquidations) > 0 {
+			filteredData["liquidations"] = marketData.Liquidations
+		}
+	}
+
+	// 盘口深度与流动性（如果提示词提到）
+	if marketData.Depth != nil && containsAny(systemPromptLower, []string{"盘口", "深度", "流动性", "滑点", "价差", "order book", "orderbook", "depth", "liquidity", "slippage", "spread"}) {
+		filteredData["depth"] = marketData.Depth
 	}
 
 	// 限制数据大小（使用默认值）
@@ -162,7 +179,7 @@ func (t *AITrader) MakeTradingDecision(ctx context.Context, marketData *types.Ma
 	// 构建提示词
 	systemPrompt := cfg.AITraderSystemPrompt
 	if systemPrompt == "" {
-		systemPrompt = "你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"
+		systemPrompt = "你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、盘口深度与流动性、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"
 	}
 
 	userPrompt := fmt.Sprintf(`策略文档：
@@ -0,0 +1,453 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 平仓原因
+const (
+	ExitStopLoss    = "stop_loss"
+	ExitTakeProfit1 = "take_profit_1"
+	ExitTakeProfit2 = "take_profit_2"
+	ExitSignal      = "signal_close"
+	ExitEndOfData   = "end_of_data"
+)
+
+// Config 回测参数
+type Config struct {
+	InitialBalance   float64
+	FeeRate          float64
+	DefaultNotional  float64       // 信号未指定数量时的名义价值（USDT）
+	TP1Ratio         float64       // TP1分批止盈比例
+	DecisionInterval time.Duration // 决策间隔（对应实盘扫描间隔）
+	EntryTimeout     time.Duration // 限价入场单超时撤单
+	Cooldown         time.Duration // 下单后币种冷却
+	RiskLimits       execution.RiskLimits
+}
+
+// DefaultConfig 从全局配置构建回测参数（与实盘执行参数一致）
+func DefaultConfig() Config {
+	cfg := config.Get()
+	return Config{
+		InitialBalance:   cfg.PaperInitialBalance,
+		FeeRate:          cfg.PaperFeeRate,
+		DefaultNotional:  cfg.StratDefaultNotionalUSDT,
+		TP1Ratio:         cfg.TP1PartialRatio,
+		DecisionInterval: time.Duration(cfg.ScanInterval) * time.Second,
+		EntryTimeout:     time.Duration(cfg.BreakoutTimeoutSec) * time.Second,
+		Cooldown:         time.Duration(cfg.SymbolCooldownSec) * time.Second,
+		RiskLimits:       execution.RiskLimitsFromConfig(cfg),
+	}
+}
+
+// Fill 平仓成交记录
+type Fill struct {
+	Time     int64   `json:"time"` // 毫秒
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Reason   string  `json:"reason"`
+	PnL      float64 `json:"pnl"` // 已扣除该笔手续费
+}
+
+// Trade 一笔完整交易（开仓到全部平仓）
+type Trade struct {
+	Symbol      string  `json:"symbol"`
+	SignalID    string  `json:"signal_id"`
+	Side        string  `json:"side"` // long, short
+	EntryTime   int64   `json:"entry_time"`
+	ExitTime    int64   `json:"exit_time"`
+	EntryPrice  float64 `json:"entry_price"`
+	ExitPrice   float64 `json:"exit_price"` // 加权平均平仓价
+	Quantity    float64 `json:"quantity"`
+	StopLoss    float64 `json:"stop_loss"`
+	TakeProfit  float64 `json:"take_profit"`
+	TakeProfit2 float64 `json:"take_profit_2,omitempty"`
+	Fees        float64 `json:"fees"`
+	PnL         float64 `json:"pnl"` // 净盈亏（含手续费）
+	Exits       []Fill  `json:"exits"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// EquityPoint 权益曲线点
+type EquityPoint struct {
+	Time    int64   `json:"time"` // 毫秒
+	Balance float64 `json:"balance"`
+	Equity  float64 `json:"equity"`
+}
+
+// Result 回测结果
+type Result struct {
+	Symbol string        `json:"symbol"`
+	Trades []Trade       `json:"trades"`
+	Equity []EquityPoint `json:"equity"`
+	Stats  Stats         `json:"stats"`
+}
+
+// Backtester 回测引擎
+// 按1m K线回放历史行情，每个决策间隔构建与Scanner.ScanSymbol相同的市场数据交给决策器，
+// 入场按限价单撮合，出场按守护进程的止损/TP1/TP2分批规则撮合
+type Backtester struct {
+	cfg     Config
+	decider Decider
+}
+
+// NewBacktester 创建回测引擎
+func NewBacktester(cfg Config, decider Decider) *Backtester {
+	return &Backtester{cfg: cfg, decider: decider}
+}
+
+// pendingEntry 等待成交的限价入场单
+type pendingEntry struct {
+	signal   *types.Signal
+	quantity float64
+	placedAt int64
+}
+
+// openPosition 回测持仓
+type openPosition struct {
+	trade     Trade
+	remaining float64
+	tp1Qty    float64
+	tp1Done   bool
+	protected bool // 止损止盈参数有效时守护进程才会挂单
+}
+
+// runState 单次回测状态
+type runState struct {
+	balance    float64
+	pending    *pendingEntry
+	position   *openPosition
+	cooldownTo int64
+	trades     []Trade
+	equity     []EquityPoint
+}
+
+// Run 执行回测
+func (b *Backtester) Run(ctx context.Context, symbol string, candles map[string][]types.OHLCV) (*Result, error) {
+	logger := utils.GetLogger("backtest")
+	symbol = utils.NormalizeSymbol(symbol)
+
+	base := candles["1m"]
+	if len(base) == 0 {
+		return nil, fmt.Errorf("no 1m candles for %s", symbol)
+	}
+
+	durations := make(map[string]time.Duration, len(scanner.ScanTimeframes))
+	for _, tf := range scanner.ScanTimeframes {
+		d, err := scanner.TimeframeDuration(tf)
+		if err != nil {
+			return nil, err
+		}
+		durations[tf] = d
+	}
+
+	interval := b.cfg.DecisionInterval
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	intervalMs := interval.Milliseconds()
+
+	st := &runState{balance: b.cfg.InitialBalance}
+
+	for i, candle := range base {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		closeTime := candle.Time + time.Minute.Milliseconds()
+
+		b.matchEntry(st, candle, closeTime)
+		b.matchExits(st, candle, closeTime)
+
+		if closeTime%intervalMs != 0 && i != len(base)-1 {
+			continue
+		}
+
+		// 构建该时刻的市场数据（只使用已收盘的K线）
+		window := make(map[string][]types.OHLCV, len(scanner.ScanTimeframes))
+		for idx, tf := range scanner.ScanTimeframes {
+			if series := windowAt(candles[tf], durations[tf], closeTime, scanner.ScanLimits[idx]); len(series) > 0 {
+				window[tf] = series
+			}
+		}
+
+		marketData, err := scanner.BuildMarketData(scanner.MarketInputs{
+			Symbol:    symbol,
+			OHLCV:     window,
+			Timestamp: closeTime / 1000,
+		})
+		if err == nil {
+			signal, err := b.decider.Decide(ctx, marketData)
+			if err != nil {
+				logger.Warnw("回测决策失败", "symbol", symbol, "time", closeTime, "error", err)
+			} else if signal != nil {
+				b.applySignal(st, signal, candle, closeTime)
+			}
+		}
+
+		st.equity = append(st.equity, EquityPoint{
+			Time:    closeTime,
+			Balance: st.balance,
+			Equity:  st.balance + st.unrealizedPnl(candle.Close),
+		})
+	}
+
+	// 数据结束时按最后收盘价平掉剩余持仓
+	last := base[len(base)-1]
+	lastClose := last.Time + time.Minute.Milliseconds()
+	if st.position != nil {
+		b.closeRemaining(st, last.Close, lastClose, ExitEndOfData)
+		st.equity = append(st.equity, EquityPoint{Time: lastClose, Balance: st.balance, Equity: st.balance})
+	}
+
+	return &Result{
+		Symbol: symbol,
+		Trades: st.trades,
+		Equity: st.equity,
+		Stats:  ComputeStats(st.trades, st.equity, b.cfg.InitialBalance, interval),
+	}, nil
+}
+
+// applySignal 处理决策信号
+func (b *Backtester) applySignal(st *runState, signal *types.Signal, candle types.OHLCV, at int64) {
+	switch signal.Action {
+	case "close_long", "close_short":
+		side := strings.TrimPrefix(signal.Action, "close_")
+		if st.position != nil && st.position.trade.Side == side {
+			b.closeRemaining(st, candle.Close, at, ExitSignal)
+		}
+		return
+	case "open_long", "open_short":
+	default:
+		return
+	}
+
+	// 单币种回测：已有持仓或挂单时忽略新的开仓信号
+	if st.position != nil || st.pending != nil {
+		return
+	}
+
+	cooldownSec := 0
+	if at < st.cooldownTo {
+		cooldownSec = int((st.cooldownTo - at + 999) / 1000)
+	}
+
+	entry := signal.EntryPrice
+	if entry <= 0 {
+		entry = candle.Close
+	}
+	notional := b.cfg.DefaultNotional
+	if signal.Quantity > 0 {
+		notional = signal.Quantity * entry
+	}
+
+	decision := execution.EvaluateRisk(execution.RiskInput{
+		Symbol:               signal.Symbol,
+		Notional:             notional,
+		Leverage:             signal.Leverage,
+		CooldownRemainingSec: cooldownSec,
+	}, b.cfg.RiskLimits)
+	if !decision.Allowed {
+		return
+	}
+
+	side := "long"
+	if signal.Action == "open_short" {
+		side = "short"
+	}
+	sig := *signal
+	sig.Side = side
+	sig.EntryPrice = entry
+	if sig.SignalID == "" {
+		sig.SignalID = fmt.Sprintf("bt_%s_%d", sig.Symbol, at)
+	}
+
+	st.pending = &pendingEntry{
+		signal:   &sig,
+		quantity: decision.Notional / entry,
+		placedAt: at,
+	}
+	if b.cfg.Cooldown > 0 {
+		st.cooldownTo = at + b.cfg.Cooldown.Milliseconds()
+	}
+}
+
+// matchEntry 撮合限价入场单（价格触及入场价成交，跳空时按开盘价成交）
+func (b *Backtester) matchEntry(st *runState, candle types.OHLCV, closeTime int64) {
+	p := st.pending
+	if p == nil {
+		return
+	}
+
+	if b.cfg.EntryTimeout > 0 && candle.Time-p.placedAt >= b.cfg.EntryTimeout.Milliseconds() {
+		st.pending = nil
+		return
+	}
+
+	entry := p.signal.EntryPrice
+	var fillPrice float64
+	if p.signal.Side == "long" {
+		if candle.Low > entry {
+			return
+		}
+		fillPrice = math.Min(entry, candle.Open)
+	} else {
+		if candle.High < entry {
+			return
+		}
+		fillPrice = math.Max(entry, candle.Open)
+	}
+
+	fee := fillPrice * p.quantity * b.cfg.FeeRate
+	st.balance -= fee
+
+	// 与守护进程一致：TP1按比例分批，剩余部分由TP2（若有）或止损保护
+	ratio := math.Max(0.0, math.Min(b.cfg.TP1Ratio, 1.0))
+	tp1Qty := p.quantity * ratio
+	if tp1Qty <= 0 {
+		tp1Qty = p.quantity
+	}
+
+	st.position = &openPosition{
+		trade: Trade{
+			Symbol:      p.signal.Symbol,
+			SignalID:    p.signal.SignalID,
+			Side:        p.signal.Side,
+			EntryTime:   closeTime,
+			EntryPrice:  fillPrice,
+			Quantity:    p.quantity,
+			StopLoss:    p.signal.StopLoss,
+			TakeProfit:  p.signal.TakeProfit,
+			TakeProfit2: p.signal.TakeProfit2,
+			Fees:        fee,
+			PnL:         -fee,
+			Reason:      p.signal.Reason,
+		},
+		remaining: p.quantity,
+		tp1Qty:    tp1Qty,
+		protected: p.signal.StopLoss > 0 && p.signal.TakeProfit > 0,
+	}
+	st.pending = nil
+}
+
+// matchExits 撮合止损和分批止盈
+// 同一根K线同时触及止损和止盈时按止损优先处理（保守估计）
+func (b *Backtester) matchExits(st *runState, candle types.OHLCV, closeTime int64) {
+	pos := st.position
+	if pos == nil || !pos.protected || pos.trade.EntryTime >= closeTime {
+		return
+	}
+
+	t := pos.trade
+	long := t.Side == "long"
+
+	slHit := (long && candle.Low <= t.StopLoss) || (!long && candle.High >= t.StopLoss)
+	if slHit {
+		price := t.StopLoss
+		if long {
+			price = math.Min(price, candle.Open)
+		} else {
+			price = math.Max(price, candle.Open)
+		}
+		b.closeRemaining(st, price, closeTime, ExitStopLoss)
+		return
+	}
+
+	if !pos.tp1Done && touched(long, candle, t.TakeProfit) {
+		qty := math.Min(pos.tp1Qty, pos.remaining)
+		b.closeQuantity(st, qty, takeProfitPrice(long, candle, t.TakeProfit), closeTime, ExitTakeProfit1)
+		pos.tp1Done = true
+		if st.position == nil {
+			return
+		}
+	}
+
+	if pos.tp1Done && t.TakeProfit2 > 0 && touched(long, candle, t.TakeProfit2) {
+		b.closeRemaining(st, takeProfitPrice(long, candle, t.TakeProfit2), closeTime, ExitTakeProfit2)
+	}
+}
+
+// touched 判断K线是否触及止盈价
+func touched(long bool, candle types.OHLCV, price float64) bool {
+	if long {
+		return candle.High >= price
+	}
+	return candle.Low <= price
+}
+
+// takeProfitPrice 止盈成交价（跳空时按开盘价成交）
+func takeProfitPrice(long bool, candle types.OHLCV, price float64) float64 {
+	if long {
+		return math.Max(price, candle.Open)
+	}
+	return math.Min(price, candle.Open)
+}
+
+// closeRemaining 平掉剩余全部持仓
+func (b *Backtester) closeRemaining(st *runState, price float64, at int64, reason string) {
+	if st.position == nil {
+		return
+	}
+	b.closeQuantity(st, st.position.remaining, price, at, reason)
+}
+
+// closeQuantity 平掉部分持仓，全部平完后记录交易
+func (b *Backtester) closeQuantity(st *runState, qty, price float64, at int64, reason string) {
+	pos := st.position
+	if pos == nil || qty <= 0 {
+		return
+	}
+
+	pnl := (price - pos.trade.EntryPrice) * qty
+	if pos.trade.Side == "short" {
+		pnl = -pnl
+	}
+	fee := price * qty * b.cfg.FeeRate
+	st.balance += pnl - fee
+
+	pos.trade.Fees += fee
+	pos.trade.PnL += pnl - fee
+	pos.trade.Exits = append(pos.trade.Exits, Fill{
+		Time:     at,
+		Price:    price,
+		Quantity: qty,
+		Reason:   reason,
+		PnL:      pnl - fee,
+	})
+	pos.remaining -= qty
+
+	if pos.remaining > 1e-12 {
+		return
+	}
+
+	notional := 0.0
+	for _, fill := range pos.trade.Exits {
+		notional += fill.Price * fill.Quantity
+	}
+	pos.trade.ExitTime = at
+	pos.trade.ExitPrice = notional / pos.trade.Quantity
+	st.trades = append(st.trades, pos.trade)
+	st.position = nil
+}
+
+// unrealizedPnl 计算持仓未实现盈亏
+func (st *runState) unrealizedPnl(price float64) float64 {
+	if st.position == nil {
+		return 0
+	}
+	pnl := (price - st.position.trade.EntryPrice) * st.position.remaining
+	if st.position.trade.Side == "short" {
+		return -pnl
+	}
+	return pnl
+}
@@ -0,0 +1,140 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// candleFile K线文件路径：{dir}/{SYMBOL}_{timeframe}.json
+func candleFile(dir, symbol, timeframe string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.json", utils.NormalizeSymbol(symbol), timeframe))
+}
+
+// SaveCandles 保存K线到JSON文件
+func SaveCandles(dir, symbol, timeframe string, candles []types.OHLCV) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create data dir failed: %w", err)
+	}
+
+	data, err := json.Marshal(candles)
+	if err != nil {
+		return fmt.Errorf("marshal candles failed: %w", err)
+	}
+	return os.WriteFile(candleFile(dir, symbol, timeframe), data, 0o644)
+}
+
+// LoadCandles 加载扫描周期的历史K线
+// 1m K线必须存在；其他周期的文件缺失时由1m K线聚合生成
+func LoadCandles(dir, symbol string) (map[string][]types.OHLCV, error) {
+	candles := make(map[string][]types.OHLCV)
+
+	for _, tf := range scanner.ScanTimeframes {
+		data, err := os.ReadFile(candleFile(dir, symbol, tf))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s candles failed: %w", tf, err)
+		}
+
+		var series []types.OHLCV
+		if err := json.Unmarshal(data, &series); err != nil {
+			return nil, fmt.Errorf("parse %s candles failed: %w", tf, err)
+		}
+		sort.Slice(series, func(i, j int) bool { return series[i].Time < series[j].Time })
+		candles[tf] = series
+	}
+
+	if len(candles["1m"]) == 0 {
+		return nil, fmt.Errorf("no 1m candles found for %s in %s", symbol, dir)
+	}
+
+	for _, tf := range scanner.ScanTimeframes {
+		if len(candles[tf]) > 0 {
+			continue
+		}
+		resampled, err := Resample(candles["1m"], "1m", tf)
+		if err != nil {
+			return nil, err
+		}
+		candles[tf] = resampled
+	}
+
+	return candles, nil
+}
+
+// Resample 将低周期K线聚合为高周期K线（丢弃不完整的K线）
+func Resample(candles []types.OHLCV, from, to string) ([]types.OHLCV, error) {
+	fromDur, err := scanner.TimeframeDuration(from)
+	if err != nil {
+		return nil, err
+	}
+	toDur, err := scanner.TimeframeDuration(to)
+	if err != nil {
+		return nil, err
+	}
+	if toDur < fromDur || toDur%fromDur != 0 {
+		return nil, fmt.Errorf("cannot resample %s to %s", from, to)
+	}
+
+	bucketMs := toDur.Milliseconds()
+	expected := int(toDur / fromDur)
+
+	result := make([]types.OHLCV, 0, len(candles)/expected+1)
+	var current types.OHLCV
+	count := 0
+
+	flush := func() {
+		if count == expected {
+			result = append(result, current)
+		}
+	}
+
+	for _, candle := range candles {
+		bucket := candle.Time - candle.Time%bucketMs
+		if count == 0 || bucket != current.Time {
+			flush()
+			current = types.OHLCV{
+				Open: candle.Open,
+				High: candle.High,
+				Low:  candle.Low,
+				Time: bucket,
+			}
+			count = 0
+		}
+		if candle.High > current.High {
+			current.High = candle.High
+		}
+		if candle.Low < current.Low {
+			current.Low = candle.Low
+		}
+		current.Close = candle.Close
+		current.Volume += candle.Volume
+		current.TakerBuyVolume += candle.TakerBuyVolume
+		count++
+	}
+	flush()
+
+	return result, nil
+}
+
+// windowAt 返回在at时刻（毫秒）之前已收盘的最近limit根K线，避免使用未来数据
+func windowAt(candles []types.OHLCV, duration time.Duration, at int64, limit int) []types.OHLCV {
+	durMs := duration.Milliseconds()
+	end := sort.Search(len(candles), func(i int) bool {
+		return candles[i].Time+durMs > at
+	})
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	return candles[start:end]
+}
@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"context"
+
+	"github.com/yuechangmingzou/nofx-go/internal/ai"
+	"github.com/yuechangmingzou/nofx-go/internal/strategies"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// Decider 回测决策接口（返回nil表示观望）
+type Decider interface {
+	Decide(ctx context.Context, marketData *types.MarketData) (*types.Signal, error)
+}
+
+// RuleDecider 规则策略决策适配器
+type RuleDecider struct {
+	Strategy strategies.RuleStrategy
+}
+
+// NewRuleDecider 创建规则策略决策器
+func NewRuleDecider(strategy strategies.RuleStrategy) *RuleDecider {
+	return &RuleDecider{Strategy: strategy}
+}
+
+// Decide 调用规则策略做出决策
+func (d *RuleDecider) Decide(ctx context.Context, marketData *types.MarketData) (*types.Signal, error) {
+	action, signal, reason, _ := d.Strategy.MakeDecision(marketData)
+	return actionSignal(marketData, action, signal, reason), nil
+}
+
+// AIDecider AI交易员决策适配器
+type AIDecider struct {
+	Trader *ai.AITrader
+}
+
+// NewAIDecider 创建AI决策器
+func NewAIDecider(trader *ai.AITrader) *AIDecider {
+	return &AIDecider{Trader: trader}
+}
+
+// Decide 调用AI交易员做出决策
+func (d *AIDecider) Decide(ctx context.Context, marketData *types.MarketData) (*types.Signal, error) {
+	decision, err := d.Trader.MakeTradingDecision(ctx, marketData)
+	if err != nil {
+		return nil, err
+	}
+	return actionSignal(marketData, decision.Action, decision.Signal, decision.Reason), nil
+}
+
+// actionSignal 将决策动作规范化为信号（wait/hold返回nil）
+func actionSignal(marketData *types.MarketData, action string, signal *types.Signal, reason string) *types.Signal {
+	switch action {
+	case "open_long", "open_short", "close_long", "close_short":
+	default:
+		return nil
+	}
+
+	if signal == nil {
+		signal = &types.Signal{Symbol: marketData.Symbol}
+	}
+	signal.Action = action
+	if signal.Reason == "" {
+		signal.Reason = reason
+	}
+	if signal.Timestamp == 0 {
+		signal.Timestamp = marketData.Timestamp
+	}
+	return signal
+}
@@ -0,0 +1,109 @@
+package backtest
+
+import (
+	"math"
+	"time"
+)
+
+// Stats 回测统计
+type Stats struct {
+	TotalTrades    int     `json:"total_trades"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	WinRate        float64 `json:"win_rate"` // 0-1
+	GrossProfit    float64 `json:"gross_profit"`
+	GrossLoss      float64 `json:"gross_loss"`    // 正数
+	ProfitFactor   float64 `json:"profit_factor"` // 无亏损交易时为0（避免Inf无法序列化）
+	NetProfit      float64 `json:"net_profit"`
+	TotalFees      float64 `json:"total_fees"`
+	ReturnPct      float64 `json:"return_pct"`
+	MaxDrawdown    float64 `json:"max_drawdown"`     // USDT
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"` // 相对峰值的百分比
+	Sharpe         float64 `json:"sharpe"`           // 按权益曲线采样间隔年化，无风险利率取0
+	FinalEquity    float64 `json:"final_equity"`
+}
+
+// ComputeStats 根据交易列表和权益曲线计算统计指标
+func ComputeStats(trades []Trade, equity []EquityPoint, initialBalance float64, interval time.Duration) Stats {
+	stats := Stats{TotalTrades: len(trades), FinalEquity: initialBalance}
+
+	for _, trade := range trades {
+		stats.TotalFees += trade.Fees
+		stats.NetProfit += trade.PnL
+		if trade.PnL > 0 {
+			stats.Wins++
+			stats.GrossProfit += trade.PnL
+		} else {
+			stats.Losses++
+			stats.GrossLoss += -trade.PnL
+		}
+	}
+
+	if stats.TotalTrades > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.TotalTrades)
+	}
+	if stats.GrossLoss > 0 {
+		stats.ProfitFactor = stats.GrossProfit / stats.GrossLoss
+	}
+	if len(equity) > 0 {
+		stats.FinalEquity = equity[len(equity)-1].Equity
+	}
+	if initialBalance > 0 {
+		stats.ReturnPct = (stats.FinalEquity - initialBalance) / initialBalance * 100.0
+	}
+
+	// 最大回撤
+	peak := initialBalance
+	for _, point := range equity {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		drawdown := peak - point.Equity
+		if drawdown > stats.MaxDrawdown {
+			stats.MaxDrawdown = drawdown
+			if peak > 0 {
+				stats.MaxDrawdownPct = drawdown / peak * 100.0
+			}
+		}
+	}
+
+	stats.Sharpe = sharpeRatio(equity, initialBalance, interval)
+	return stats
+}
+
+// sharpeRatio 计算年化夏普比率
+func sharpeRatio(equity []EquityPoint, initialBalance float64, interval time.Duration) float64 {
+	if len(equity) < 2 || interval <= 0 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equity))
+	prev := initialBalance
+	for _, point := range equity {
+		if prev > 0 {
+			returns = append(returns, point.Equity/prev-1)
+		}
+		prev = point.Equity
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(interval)
+	return mean / std * math.Sqrt(periodsPerYear)
+}
@@ -36,10 +36,19 @@ func GetBot() (*Bot, error) {
 			aiTrader = nil
 		}
 
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			return nil, err
+		}
+		execEngine, err := execution.GetExecutionEngine()
+		if err != nil {
+			return nil, err
+		}
+
 		globalBot = &Bot{
 			aiTrader:         aiTrader,
-			execEngine:       execution.GetExecutionEngine(),
-			exchange:         exchange.GetBinanceExchange(),
+			execEngine:       execEngine,
+			exchange:         ex,
 			redis:            utils.GetRedisClient(),
 			warnedAIDisabled: false,
 		}
@@ -70,7 +79,7 @@ func (b *Bot) ProcessSignal(ctx context.Context, marketData *types.MarketData) b
 	mode := b.getAIMode()
 
 	// 获取账户快照（用于AI决策）
-	accountSnapshot := b.getAccountSnapshot()
+	accountSnapshot := b.getAccountSnapshot(ctx)
 	
 	// 补充账户信息到市场数据
 	if accountSnapshot != nil {
@@ -123,7 +132,7 @@ func (b *Bot) ProcessSignal(ctx context.Context, marketData *types.MarketData) b
 
 	if mode == "rule" {
 		// 使用规则策略
-		ruleStrategy := strategies.GetRuleStrategy()
+		ruleStrategy := strategies.GetRuleStrategy(cfg.StratMaxSlippageBps)
 		var fullDecision map[string]interface{}
 		action, signal, reason, fullDecision = ruleStrategy.MakeDecision(marketData)
 
@@ -174,6 +183,16 @@ func (b *Bot) ProcessSignal(ctx context.Context, marketData *types.MarketData) b
 		}
 		b.redis.LTrim(ctx, historyKey, 0, int64(maxLen-1))
 
+		// 开仓信号进入订单生命周期（先于入队记录，执行引擎的状态转换不会早于queued）
+		if action == "open_long" || action == "open_short" {
+			b.execEngine.Lifecycle().Transition(ctx, signal.SignalID, execution.LifecycleTransition{
+				To:           execution.StateQueued,
+				Source:       execution.LifecycleSourceQueue,
+				Symbol:       signal.Symbol,
+				PositionSide: signal.Side,
+			})
+		}
+
 		// 推送到交易队列
 		queueKey := config.GetRedisKey("trade_queue")
 		b.redis.LPush(ctx, queueKey, signalJSON)
@@ -204,6 +223,24 @@ func (b *Bot) ProcessSignal(ctx context.Context, marketData *types.MarketData) b
 	return false
 }
 
+// reconcile 执行一次交易所与Redis状态对账
+func (b *Bot) reconcile(ctx context.Context, trigger string) {
+	cfg := config.Get()
+	report, err := b.execEngine.ReconcileOnce(ctx, trigger, cfg.ReconcileDryRun)
+	if err != nil {
+		utils.GetLogger("bot").Warnw("对账失败", "trigger", trigger, "error", err)
+		return
+	}
+	if trigger == execution.ReconcileTriggerStartup {
+		utils.GetLogger("bot").Infow("启动对账完成",
+			"positions", report.Positions,
+			"open_orders", report.OpenOrders,
+			"issues", len(report.Issues),
+			"dry_run", report.DryRun,
+		)
+	}
+}
+
 // RunBot 运行交易机器人主循环
 func (b *Bot) RunBot(ctx context.Context) error {
 	logger := utils.GetLogger("bot")
@@ -212,13 +249,35 @@ func (b *Bot) RunBot(ctx context.Context) error {
 	logger.Info("🚀 交易机器人启动（生产模式）")
 	logger.Infow("风控参数",
 		"max_notional_per_trade", cfg.MaxNotionalPerTrade,
+		"max_leverage", cfg.MaxLeverage,
 		"max_concurrent_positions", cfg.MaxConcurrentPositions,
+		"symbol_cooldown_sec", cfg.SymbolCooldownSec,
+		"funding_guard_enabled", cfg.FundingGuardEnabled,
+		"entry_timeout_sec", cfg.BreakoutTimeoutSec,
+		"entry_cancel_drift_pct", cfg.EntryCancelDriftPct,
+		"reconcile_enabled", cfg.ReconcileEnabled,
+		"reconcile_dry_run", cfg.ReconcileDryRun,
 		"market_snapshot_max_age_sec", cfg.MarketSnapshotMaxAgeSec,
 		"market_snapshot_ttl_sec", cfg.MarketSnapshotTTLSec,
 	)
 
+	b.execEngine.CheckPositionMode(ctx)
+
+	// 启动对账：崩溃或在交易所手动操作后，持仓、挂单与Redis保护信息可能不一致
+	if cfg.ReconcileEnabled {
+		b.reconcile(ctx, execution.ReconcileTriggerStartup)
+	}
+
+	// 用户数据流：实时接收成交和持仓变化，在线时守护进程只做低频全量对账
+	if b.execEngine.StartUserStream(ctx) {
+		logger.Infow("用户数据流已启用", "fallback_guard_sec", cfg.UserStreamGuardSec)
+	}
+
 	queueKey := config.GetRedisKey("trade_queue")
 	lastGuardTS := time.Now()
+	lastFundingGuardTS := time.Now()
+	lastEntryExpiryTS := time.Now()
+	lastReconcileTS := time.Now()
 
 	for {
 		select {
@@ -231,12 +290,34 @@ func (b *Bot) RunBot(ctx context.Context) error {
 		// 后台守护：每N秒轮询一次，确保持仓有止盈止损
 		now := time.Now()
 		interval := time.Duration(cfg.SLTPGuardIntervalSec) * time.Second
+		if b.execEngine.UserStreamConnected() && cfg.UserStreamGuardSec > cfg.SLTPGuardIntervalSec {
+			interval = time.Duration(cfg.UserStreamGuardSec) * time.Second
+		}
 		if now.Sub(lastGuardTS) >= interval {
 			intervalTag := fmt.Sprintf("%.0fs", interval.Seconds())
 			b.execEngine.EnsureSLTPGuardOnce(ctx, intervalTag)
 			lastGuardTS = now
 		}
 
+		// 资金费率守护：每分钟检查一次，结算前平掉需要支付大额资金费的持仓
+		if now.Sub(lastFundingGuardTS) >= time.Minute {
+			b.execEngine.FundingGuardOnce(ctx)
+			lastFundingGuardTS = now
+		}
+
+		// 入场单生命周期：撤销超时或价格已远离的未成交限价入场单
+		if now.Sub(lastEntryExpiryTS) >= 10*time.Second {
+			b.execEngine.ExpirePendingEntriesOnce(ctx)
+			lastEntryExpiryTS = now
+		}
+
+		// 定时对账
+		if cfg.ReconcileEnabled && cfg.ReconcileIntervalSec > 0 &&
+			now.Sub(lastReconcileTS) >= time.Duration(cfg.ReconcileIntervalSec)*time.Second {
+			b.reconcile(ctx, execution.ReconcileTriggerSchedule)
+			lastReconcileTS = now
+		}
+
 		// 从队列获取信号（阻塞等待）
 		result, err := b.redis.BRPop(ctx, 10*time.Second, queueKey).Result()
 		if err != nil {
@@ -353,15 +434,15 @@ func (b *Bot) getAIMode() string {
 }
 
 // getAccountSnapshot 获取账户快照
-func (b *Bot) getAccountSnapshot() map[string]interface{} {
-	balance, err := b.exchange.GetBalance()
+func (b *Bot) getAccountSnapshot(ctx context.Context) map[string]interface{} {
+	balance, err := b.exchange.GetBalanceContext(ctx)
 	if err != nil {
 		return map[string]interface{}{
 			"error": err.Error()[:200],
 		}
 	}
 
-	positions, err := b.exchange.GetPositions()
+	positions, err := b.exchange.GetPositionsContext(ctx)
 	if err != nil {
 		return map[string]interface{}{
 			"balance": balance,
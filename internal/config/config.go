@@ -17,14 +17,35 @@ type Config struct {
 	RedisPassword string
 	RedisDB       int
 
+	// 交易所选择：binance, binance-testnet, paper, okx, bybit
+	Exchange string
+
 	// Binance配置
 	BinanceAPIKey    string
 	BinanceSecretKey string
 	BinanceTestnet   bool
 
+	// OKX配置
+	OKXAPIKey     string
+	OKXSecretKey  string
+	OKXPassphrase string
+	OKXBaseURL    string
+	OKXSimulated  bool   // 模拟盘（请求头x-simulated-trading: 1）
+	OKXTdMode     string // 保证金模式：cross, isolated
+
+	// Bybit配置
+	BybitAPIKey     string
+	BybitSecretKey  string
+	BybitBaseURL    string
+	BybitRecvWindow int // 毫秒
+
 	// Dry-run模式
 	DryRun bool
 
+	// 模拟交易账户
+	PaperInitialBalance float64
+	PaperFeeRate        float64
+
 	// AI提供商
 	AIProvider string
 
@@ -69,11 +90,31 @@ type Config struct {
 	ScanInterval         int
 	PriceChangeThreshold float64
 	ScanConcurrency      int
+	MarketStreamEnabled  bool    // WebSocket行情流（K线+标记价格）
+	DepthEnabled         bool    // 扫描时获取深度快照并计算盘口指标
+	DepthLimit           int     // 深度快照每侧档位数
+	DepthImbalanceLevels int     // 买卖量失衡统计的前N档
+	DepthBandPct         float64 // 深度统计带宽（相对中间价，0.005即±0.5%）
 
 	// 市场快照配置
 	MarketSnapshotTTLSec    int
 	MarketSnapshotMaxAgeSec int
 
+	// 强平数据配置（只来自!forceOrder@arr强平订单流，没有REST回退）
+	LiquidationEnabled        bool
+	LiquidationWindows        string  // 滚动统计窗口，如"5m,15m,1h"
+	LiquidationSqueezeMinUSDT float64 // 窗口内强平名义价值达到该值才判定挤压
+	LiquidationSqueezeRatio   float64 // 单边强平占比达到该值判定为多头/空头挤压
+
+	// 衍生品情绪数据配置（Binance /futures/data/*，按周期缓存）
+	DerivativesEnabled bool
+	DerivativesPeriod  string // 统计周期：5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d
+	DerivativesLimit   int    // 持仓量变化统计的周期数
+
+	// 资金费率统计配置（预测费率、基差、最近N次结算）
+	FundingStatsEnabled bool
+	FundingHistoryLimit int // 统计的历史结算次数
+
 	// 交易信号配置
 	SignalTTLSec      int
 	MaxTradeQueueSize int
@@ -85,31 +126,62 @@ type Config struct {
 	// 执行引擎风控参数
 	MaxNotionalPerTrade    float64
 	MaxLeverage            float64
+	MarginType             string // ISOLATED, CROSSED（为空时不修改交易所设置）
 	MaxConcurrentPositions int
 	SymbolCooldownSec      int
 	OrderDedupeWindow      int
-	BreakoutTimeoutSec     int
+	OrderMaxRetries        int     // 下单遇到可重试错误（超时、5xx、限流）时的重试次数
+	BreakoutTimeoutSec     int     // 限价入场单未成交超过N秒后撤销（0为不超时）
+	EntryCancelDriftPct    float64 // 最新价向远离入场价方向偏离超过该比例时撤销未成交入场单（0.005即0.5%，0为不检查）
+
+	// 资金费率风控：结算前不开需要支付大额资金费的仓位，可选平掉已有仓位
+	FundingGuardEnabled   bool
+	FundingGuardThreshold float64 // 预测资金费率绝对值达到该值视为不利（0.001即0.1%）
+	FundingGuardWindowMin int     // 距下次结算不足N分钟时生效
+	FundingGuardClose     bool    // 是否平掉已有的不利持仓（否则只拒绝新开仓）
 
 	// 订单审计
 	OrderAuditMaxLen        int
 	OrderAuditEventMaxChars int
 
+	// 订单生命周期（按信号ID记录状态和时间线）
+	OrderLifecycleTTLSec int // 生命周期记录保留时间
+	OrderLifecycleMaxLen int // 最近信号索引保留条数
+
 	// SL/TP守护
 	SLTPGuardIntervalSec float64
 	GuardStatsTTLSec     int
 	ProtectionTTLSec     int
 	TP1PartialRatio      float64
+	UserStreamEnabled    bool
+	UserStreamGuardSec   float64
 	TPMatchTolerancePct  float64
 	TakeProfitOrderType  string
 	MaxTPDeviationPct    float64
 
+	// 交易所与Redis状态对账（启动时和定时执行）
+	ReconcileEnabled           bool
+	ReconcileIntervalSec       int    // 定时对账间隔（0为只在启动时执行）
+	ReconcileDryRun            bool   // 只生成报告，不做任何修复
+	ReconcileUnprotectedPolicy string // 有持仓但没有保护信息（系统开的仓）：adopt（按ATR补设止损止盈）或alert
+	ReconcileUnknownPolicy     string // 系统未记录的持仓（交易所手动开仓）：adopt或alert
+	ReconcileOrphanPolicy      string // 孤立或数量不符的止损止盈单：cancel或alert（默认）
+	ReconcileATRTimeframe      string // 计算ATR的K线周期
+	ReconcileATRPeriod         int
+	ReconcileATRStopMult       float64 // 止损距离为ATR的倍数
+	ReconcileATRTakeProfitMult float64 // 止盈距离为ATR的倍数
+
 	// 交易所配置
 	ExchangeCacheTTLSec          float64
 	BinanceFAPIBaseURL           string
+	BinanceWSBaseURL             string
 	BinanceHTTPTimeoutSec        float64
 	BinanceConnectorLimit        int
 	BinanceConnectorLimitPerHost int
 	BinanceRateLimitMaxSleepSec  float64
+	BinanceRecvWindowMs          int // 签名请求的recvWindow（毫秒，最大60000）
+	BinanceTimeSyncIntervalSec   int // 服务器时间重新同步间隔
+	BinanceHTTPMaxRetries        int // 只读请求遇到可重试错误时的重试次数
 	BinanceMinOnlineDays         int
 
 	// 策略阈值
@@ -143,6 +215,7 @@ type Config struct {
 	StratTP2RMult              float64
 	StratTP2FallbackPct        float64
 	StratDefaultNotionalUSDT   float64
+	StratMaxSlippageBps        float64
 
 	// WebSocket token
 	WSTokenTTLSec int
@@ -247,12 +320,29 @@ func Load() error {
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getIntEnv("REDIS_DB", 0),
 
+		Exchange: strings.ToLower(getEnv("EXCHANGE", "binance")),
+
 		BinanceAPIKey:    utils.DecryptEnv("BINANCE_API_KEY"),
 		BinanceSecretKey: utils.DecryptEnv("BINANCE_SECRET_KEY"),
 		BinanceTestnet:   getBoolEnv("BINANCE_TESTNET", false),
 
+		OKXAPIKey:     utils.DecryptEnv("OKX_API_KEY"),
+		OKXSecretKey:  utils.DecryptEnv("OKX_SECRET_KEY"),
+		OKXPassphrase: utils.DecryptEnv("OKX_PASSPHRASE"),
+		OKXBaseURL:    getEnv("OKX_BASE_URL", "https://www.okx.com"),
+		OKXSimulated:  getBoolEnv("OKX_SIMULATED", false),
+		OKXTdMode:     strings.ToLower(getEnv("OKX_TD_MODE", "cross")),
+
+		BybitAPIKey:     utils.DecryptEnv("BYBIT_API_KEY"),
+		BybitSecretKey:  utils.DecryptEnv("BYBIT_SECRET_KEY"),
+		BybitBaseURL:    getEnv("BYBIT_BASE_URL", "https://api.bybit.com"),
+		BybitRecvWindow: getIntEnv("BYBIT_RECV_WINDOW", 5000),
+
 		DryRun: getBoolEnv("DRY_RUN", true),
 
+		PaperInitialBalance: getFloatEnv("PAPER_INITIAL_BALANCE", 10000.0),
+		PaperFeeRate:        getFloatEnv("PAPER_FEE_RATE", 0.0004),
+
 		AIProvider: strings.ToLower(getEnv("AI_PROVIDER", "deepseek")),
 
 		DeepSeekEnabled:     getBoolEnv("DEEPSEEK_ENABLED", false),
@@ -279,7 +369,7 @@ func Load() error {
 		AIMaxTokens:   getIntEnv("AI_MAX_TOKENS", 4000),
 
 		AITraderSystemPrompt: getEnv("AI_TRADER_SYSTEM_PROMPT",
-			"你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"),
+			"你是一名经验丰富的加密货币合约交易员，请根据提供的市场数据（包括链上数据、衍生品与资金数据、盘口深度与流动性、市场情绪指标、技术分析指标、全球宏观经济环境）自行分析交易并做出交易决策。"),
 
 		StrategyFile: getEnv("STRATEGY_FILE", "strategies/顺势狙击手.txt"),
 		RuleStrategy: strings.ToLower(getEnv("RULE_STRATEGY", "shunshi_sniper")),
@@ -289,10 +379,27 @@ func Load() error {
 		ScanInterval:         getIntEnv("SCAN_INTERVAL", 180),
 		PriceChangeThreshold: getFloatEnv("PRICE_CHANGE_THRESHOLD", 3.0),
 		ScanConcurrency:      getIntEnv("SCAN_CONCURRENCY", 10),
+		MarketStreamEnabled:  getBoolEnv("MARKET_STREAM_ENABLED", true),
+		DepthEnabled:         getBoolEnv("DEPTH_ENABLED", false),
+		DepthLimit:           getIntEnv("DEPTH_LIMIT", 100),
+		DepthImbalanceLevels: getIntEnv("DEPTH_IMBALANCE_LEVELS", 10),
+		DepthBandPct:         getFloatEnv("DEPTH_BAND_PCT", 0.005),
 
 		MarketSnapshotTTLSec:    getIntEnv("MARKET_SNAPSHOT_TTL_SEC", 600),
 		MarketSnapshotMaxAgeSec: getIntEnv("MARKET_SNAPSHOT_MAX_AGE_SEC", 300),
 
+		LiquidationEnabled:        getBoolEnv("LIQUIDATION_ENABLED", false),
+		LiquidationWindows:        getEnv("LIQUIDATION_WINDOWS", "5m,15m,1h"),
+		LiquidationSqueezeMinUSDT: getFloatEnv("LIQUIDATION_SQUEEZE_MIN_USDT", 100000),
+		LiquidationSqueezeRatio:   getFloatEnv("LIQUIDATION_SQUEEZE_RATIO", 0.7),
+
+		DerivativesEnabled: getBoolEnv("DERIVATIVES_ENABLED", false),
+		DerivativesPeriod:  getEnv("DERIVATIVES_PERIOD", "5m"),
+		DerivativesLimit:   getIntEnv("DERIVATIVES_LIMIT", 12),
+
+		FundingStatsEnabled: getBoolEnv("FUNDING_STATS_ENABLED", false),
+		FundingHistoryLimit: getIntEnv("FUNDING_HISTORY_LIMIT", 21),
+
 		SignalTTLSec:      getIntEnv("SIGNAL_TTL_SEC", 3600),
 		MaxTradeQueueSize: getIntEnv("MAX_TRADE_QUEUE_SIZE", 100),
 
@@ -301,28 +408,56 @@ func Load() error {
 
 		MaxNotionalPerTrade:    getFloatEnv("MAX_NOTIONAL_PER_TRADE", 50.0),
 		MaxLeverage:            getFloatEnv("MAX_LEVERAGE", 10.0),
+		MarginType:             strings.ToUpper(getEnv("MARGIN_TYPE", "")),
 		MaxConcurrentPositions: getIntEnv("MAX_CONCURRENT_POSITIONS", 5),
 		SymbolCooldownSec:      getIntEnv("SYMBOL_COOLDOWN_SEC", 120),
 		OrderDedupeWindow:      getIntEnv("ORDER_DEDUPE_WINDOW", 5),
+		OrderMaxRetries:        getIntEnv("ORDER_MAX_RETRIES", 2),
 		BreakoutTimeoutSec:     getIntEnv("BREAKOUT_TIMEOUT_SEC", 120),
+		EntryCancelDriftPct:    getFloatEnv("ENTRY_CANCEL_DRIFT_PCT", 0.005),
+
+		FundingGuardEnabled:   getBoolEnv("FUNDING_GUARD_ENABLED", false),
+		FundingGuardThreshold: getFloatEnv("FUNDING_GUARD_THRESHOLD", 0.001),
+		FundingGuardWindowMin: getIntEnv("FUNDING_GUARD_WINDOW_MIN", 15),
+		FundingGuardClose:     getBoolEnv("FUNDING_GUARD_CLOSE", false),
 
 		OrderAuditMaxLen:        getIntEnv("ORDER_AUDIT_MAX_LEN", 2000),
 		OrderAuditEventMaxChars: getIntEnv("ORDER_AUDIT_EVENT_MAX_CHARS", 2000),
 
+		OrderLifecycleTTLSec: getIntEnv("ORDER_LIFECYCLE_TTL_SEC", 86400*7),
+		OrderLifecycleMaxLen: getIntEnv("ORDER_LIFECYCLE_MAX_LEN", 500),
+
 		SLTPGuardIntervalSec: getFloatEnv("SLTP_GUARD_INTERVAL_SEC", 10.0),
 		GuardStatsTTLSec:     getIntEnv("GUARD_STATS_TTL_SEC", 86400*2),
 		ProtectionTTLSec:     getIntEnv("PROTECTION_TTL_SEC", 86400),
 		TP1PartialRatio:      getFloatEnv("TP1_PARTIAL_RATIO", 0.5),
+		UserStreamEnabled:    getBoolEnv("USER_STREAM_ENABLED", true),
+		UserStreamGuardSec:   getFloatEnv("USER_STREAM_GUARD_SEC", 60.0),
 		TPMatchTolerancePct:  getFloatEnv("TP_MATCH_TOLERANCE_PCT", 0.5),
 		TakeProfitOrderType:  getEnv("TAKE_PROFIT_ORDER_TYPE", "limit"),
 		MaxTPDeviationPct:    getFloatEnv("MAX_TP_DEVIATION_PCT", 25.0),
 
+		ReconcileEnabled:           getBoolEnv("RECONCILE_ENABLED", true),
+		ReconcileIntervalSec:       getIntEnv("RECONCILE_INTERVAL_SEC", 300),
+		ReconcileDryRun:            getBoolEnv("RECONCILE_DRY_RUN", false),
+		ReconcileUnprotectedPolicy: strings.ToLower(getEnv("RECONCILE_UNPROTECTED_POLICY", "adopt")),
+		ReconcileUnknownPolicy:     strings.ToLower(getEnv("RECONCILE_UNKNOWN_POLICY", "alert")),
+		ReconcileOrphanPolicy:      strings.ToLower(getEnv("RECONCILE_ORPHAN_POLICY", "alert")),
+		ReconcileATRTimeframe:      getEnv("RECONCILE_ATR_TIMEFRAME", "15m"),
+		ReconcileATRPeriod:         getIntEnv("RECONCILE_ATR_PERIOD", 14),
+		ReconcileATRStopMult:       getFloatEnv("RECONCILE_ATR_STOP_MULT", 2.0),
+		ReconcileATRTakeProfitMult: getFloatEnv("RECONCILE_ATR_TAKE_PROFIT_MULT", 3.0),
+
 		ExchangeCacheTTLSec:          getFloatEnv("EXCHANGE_CACHE_TTL_SEC", 10.0),
 		BinanceFAPIBaseURL:           getEnv("BINANCE_FAPI_BASE_URL", "https://fapi.binance.com"),
+		BinanceWSBaseURL:             getEnv("BINANCE_WS_BASE_URL", "wss://fstream.binance.com"),
 		BinanceHTTPTimeoutSec:        getFloatEnv("BINANCE_HTTP_TIMEOUT_SEC", 10.0),
 		BinanceConnectorLimit:        getIntEnv("BINANCE_CONNECTOR_LIMIT", 100),
 		BinanceConnectorLimitPerHost: getIntEnv("BINANCE_CONNECTOR_LIMIT_PER_HOST", 30),
 		BinanceRateLimitMaxSleepSec:  getFloatEnv("BINANCE_RATE_LIMIT_MAX_SLEEP_SEC", 1.0),
+		BinanceRecvWindowMs:          getIntEnv("BINANCE_RECV_WINDOW_MS", 5000),
+		BinanceTimeSyncIntervalSec:   getIntEnv("BINANCE_TIME_SYNC_INTERVAL_SEC", 300),
+		BinanceHTTPMaxRetries:        getIntEnv("BINANCE_HTTP_MAX_RETRIES", 2),
 		BinanceMinOnlineDays:         getIntEnv("BINANCE_MIN_ONLINE_DAYS", 30),
 
 		RSIOverbought:      getFloatEnv("RSI_OVERBOUGHT", 78.0),
@@ -353,6 +488,7 @@ func Load() error {
 		StratTP2RMult:              getFloatEnv("STRAT_TP2_R_MULT", 3.0),
 		StratTP2FallbackPct:        getFloatEnv("STRAT_TP2_FALLBACK_PCT", 0.01),
 		StratDefaultNotionalUSDT:   getFloatEnv("STRAT_DEFAULT_NOTIONAL_USDT", 20.0),
+		StratMaxSlippageBps:        getFloatEnv("STRAT_MAX_SLIPPAGE_BPS", 20.0),
 
 		WSTokenTTLSec: getIntEnv("WS_TOKEN_TTL_SEC", 60),
 
@@ -431,6 +567,15 @@ func Load() error {
 		LogLevel: getEnv("LOG_LEVEL", "INFO"),
 	}
 
+	// Binance测试网：未显式配置地址时使用测试网地址
+	if globalConfig.Exchange == "binance-testnet" {
+		globalConfig.BinanceTestnet = true
+	}
+	if globalConfig.BinanceTestnet {
+		globalConfig.BinanceFAPIBaseURL = getEnv("BINANCE_FAPI_BASE_URL", "https://testnet.binancefuture.com")
+		globalConfig.BinanceWSBaseURL = getEnv("BINANCE_WS_BASE_URL", "wss://fstream.binancefuture.com")
+	}
+
 	return nil
 }
 
@@ -33,20 +33,38 @@ func ValidateConfig() error {
 		errors = append(errors, "WEB_BASIC_AUTH_PASS must be at least 8 characters")
 	}
 
-	// 验证Binance配置（如果非DRY_RUN模式）
-	if !cfg.DryRun {
-		if cfg.BinanceAPIKey == "" {
-			errors = append(errors, "BINANCE_API_KEY is required when DRY_RUN=false")
+	// 验证交易所配置（如果非DRY_RUN模式，模拟交易所不需要API密钥）
+	switch cfg.Exchange {
+	case "binance", "binance-testnet":
+		if !cfg.DryRun {
+			if cfg.BinanceAPIKey == "" {
+				errors = append(errors, "BINANCE_API_KEY is required when DRY_RUN=false")
+			}
+			if cfg.BinanceSecretKey == "" {
+				errors = append(errors, "BINANCE_SECRET_KEY is required when DRY_RUN=false")
+			}
+			if len(cfg.BinanceAPIKey) < 20 {
+				errors = append(errors, "BINANCE_API_KEY must be at least 20 characters")
+			}
+			if len(cfg.BinanceSecretKey) < 20 {
+				errors = append(errors, "BINANCE_SECRET_KEY must be at least 20 characters")
+			}
 		}
-		if cfg.BinanceSecretKey == "" {
-			errors = append(errors, "BINANCE_SECRET_KEY is required when DRY_RUN=false")
+	case "okx":
+		if !cfg.DryRun {
+			if cfg.OKXAPIKey == "" || cfg.OKXSecretKey == "" || cfg.OKXPassphrase == "" {
+				errors = append(errors, "OKX_API_KEY, OKX_SECRET_KEY and OKX_PASSPHRASE are required when DRY_RUN=false")
+			}
 		}
-		if len(cfg.BinanceAPIKey) < 20 {
-			errors = append(errors, "BINANCE_API_KEY must be at least 20 characters")
-		}
-		if len(cfg.BinanceSecretKey) < 20 {
-			errors = append(errors, "BINANCE_SECRET_KEY must be at least 20 characters")
+	case "bybit":
+		if !cfg.DryRun {
+			if cfg.BybitAPIKey == "" || cfg.BybitSecretKey == "" {
+				errors = append(errors, "BYBIT_API_KEY and BYBIT_SECRET_KEY are required when DRY_RUN=false")
+			}
 		}
+	case "paper":
+	default:
+		errors = append(errors, fmt.Sprintf("EXCHANGE must be one of binance, binance-testnet, paper, okx, bybit, got %q", cfg.Exchange))
 	}
 
 	// 验证AI配置（如果启用AI模式）
@@ -16,6 +16,7 @@ import (
 // BinanceExchange Binance交易所实现
 type BinanceExchange struct {
 	client    *HTTPClient
+	timeSync  *TimeSync
 	cache     map[string]cacheEntry
 	cacheMu   sync.RWMutex
 	markets   map[string]interface{}
@@ -25,6 +26,7 @@ type BinanceExchange struct {
 type cacheEntry struct {
 	data      interface{}
 	timestamp time.Time
+	expires   time.Time // 非零时按此时间过期，否则按ExchangeCacheTTLSec
 }
 
 var globalBinanceExchange *BinanceExchange
@@ -32,16 +34,53 @@ var globalBinanceExchange *BinanceExchange
 // GetBinanceExchange 获取Binance交易所实例（单例）
 func GetBinanceExchange() *BinanceExchange {
 	if globalBinanceExchange == nil {
-		globalBinanceExchange = &BinanceExchange{
-			client:  GetHTTPClient(),
-			cache:   make(map[string]cacheEntry),
-			markets: make(map[string]interface{}),
-		}
+		globalBinanceExchange = NewBinanceExchange(GetHTTPClient())
 		globalBinanceExchange.loadMarkets()
 	}
 	return globalBinanceExchange
 }
 
+// NewBinanceExchange 使用指定HTTP客户端创建Binance交易所实例（不预加载市场信息）
+func NewBinanceExchange(client *HTTPClient) *BinanceExchange {
+	be := &BinanceExchange{
+		client:  client,
+		cache:   make(map[string]cacheEntry),
+		markets: make(map[string]interface{}),
+	}
+
+	interval := 5 * time.Minute
+	if cfg := config.Get(); cfg != nil && cfg.BinanceTimeSyncIntervalSec > 0 {
+		interval = time.Duration(cfg.BinanceTimeSyncIntervalSec) * time.Second
+	}
+	be.timeSync = NewTimeSync(be.fetchServerTime, interval)
+	return be
+}
+
+// fetchServerTime 获取服务器时间（毫秒）
+func (be *BinanceExchange) fetchServerTime(ctx context.Context) (int64, error) {
+	data, err := be.client.FetchJSON(ctx, "/fapi/v1/time", nil)
+	if err != nil {
+		return 0, fmt.Errorf("fetch server time failed: %w", err)
+	}
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("invalid server time response")
+	}
+	serverTime, err := parseFloatValue(dataMap["serverTime"])
+	if err != nil || serverTime <= 0 {
+		return 0, fmt.Errorf("invalid server time: %v", dataMap["serverTime"])
+	}
+	return int64(serverTime), nil
+}
+
+// serverTimeMillis 签名使用的时间戳（毫秒），按服务器时间偏移校正
+func (be *BinanceExchange) serverTimeMillis(ctx context.Context) int64 {
+	if be.timeSync == nil {
+		return time.Now().UnixMilli()
+	}
+	return be.timeSync.NowMillisContext(ctx)
+}
+
 // loadMarkets 加载市场信息
 func (be *BinanceExchange) loadMarkets() error {
 	ctx, cancel := utils.WithMediumTimeout(context.Background())
@@ -54,6 +93,11 @@ func (be *BinanceExchange) loadMarkets() error {
 
 	// 解析markets数据
 	if dataMap, ok := data.(map[string]interface{}); ok {
+		// 按交易所返回的限额更新权重限流
+		if rateLimits, ok := dataMap["rateLimits"].([]interface{}); ok {
+			be.client.limiter.ApplyRateLimits(rateLimits)
+		}
+
 		if symbols, ok := dataMap["symbols"].([]interface{}); ok {
 			be.marketsMu.Lock()
 			be.markets = make(map[string]interface{})
@@ -76,6 +120,11 @@ func (be *BinanceExchange) loadMarkets() error {
 
 // GetOHLCV 实现Exchange接口
 func (be *BinanceExchange) GetOHLCV(symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	return be.GetOHLCVContext(context.Background(), symbol, timeframe, limit)
+}
+
+// GetOHLCVContext 同GetOHLCV，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
 	// 规范化symbol
 	symbol = be.normalizeSymbol(symbol)
 
@@ -94,7 +143,7 @@ func (be *BinanceExchange) GetOHLCV(symbol, timeframe string, limit int) ([]type
 		"limit":    strconv.Itoa(limit),
 	}
 
-	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(ctx)
 	defer cancel()
 
 	data, err := be.client.FetchJSON(ctx, "/fapi/v1/klines", params)
@@ -136,14 +185,19 @@ func (be *BinanceExchange) GetOHLCV(symbol, timeframe string, limit int) ([]type
 				continue
 			}
 
-			result = append(result, types.OHLCV{
+			candle := types.OHLCV{
 				Open:   open,
 				High:   high,
 				Low:    low,
 				Close:  closePrice,
 				Volume: volume,
 				Time:   int64(timeMs),
-			})
+			}
+			// kline[9]为主动买入成交量（币数量）
+			if len(kline) >= 10 {
+				candle.TakerBuyVolume, _ = parseFloatValue(kline[9])
+			}
+			result = append(result, candle)
 		}
 	}
 
@@ -155,13 +209,18 @@ func (be *BinanceExchange) GetOHLCV(symbol, timeframe string, limit int) ([]type
 
 // GetTickerPrice 获取当前价格
 func (be *BinanceExchange) GetTickerPrice(symbol string) (float64, error) {
+	return be.GetTickerPriceContext(context.Background(), symbol)
+}
+
+// GetTickerPriceContext 同GetTickerPrice，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetTickerPriceContext(ctx context.Context, symbol string) (float64, error) {
 	symbol = be.normalizeSymbol(symbol)
 
 	params := map[string]string{
 		"symbol": symbol,
 	}
 
-	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(ctx)
 	defer cancel()
 
 	data, err := be.client.FetchJSON(ctx, "/fapi/v1/ticker/price", params)
@@ -182,13 +241,18 @@ func (be *BinanceExchange) GetTickerPrice(symbol string) (float64, error) {
 
 // GetFundingRate 获取资金费率
 func (be *BinanceExchange) GetFundingRate(symbol string) (float64, error) {
+	return be.GetFundingRateContext(context.Background(), symbol)
+}
+
+// GetFundingRateContext 同GetFundingRate，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetFundingRateContext(ctx context.Context, symbol string) (float64, error) {
 	symbol = be.normalizeSymbol(symbol)
 
 	params := map[string]string{
 		"symbol": symbol,
 	}
 
-	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(ctx)
 	defer cancel()
 
 	data, err := be.client.FetchJSON(ctx, "/fapi/v1/premiumIndex", params)
@@ -207,15 +271,122 @@ func (be *BinanceExchange) GetFundingRate(symbol string) (float64, error) {
 	return 0, fmt.Errorf("invalid funding rate data format")
 }
 
+// binanceMaxFundingHistory /fapi/v1/fundingRate 单次最多返回1000条
+const binanceMaxFundingHistory = 1000
+
+// GetFundingHistory 获取最近limit次资金费率结算（按时间正序）
+func (be *BinanceExchange) GetFundingHistory(symbol string, limit int) ([]types.FundingRateRecord, error) {
+	return be.GetFundingHistoryContext(context.Background(), symbol, limit)
+}
+
+// GetFundingHistoryContext 同GetFundingHistory，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetFundingHistoryContext(ctx context.Context, symbol string, limit int) ([]types.FundingRateRecord, error) {
+	symbol = be.normalizeSymbol(symbol)
+	if limit <= 0 || limit > binanceMaxFundingHistory {
+		limit = binanceMaxFundingHistory
+	}
+
+	cacheKey := fmt.Sprintf("funding_history:%s:%d", symbol, limit)
+	if cached := be.getCache(cacheKey); cached != nil {
+		if records, ok := cached.([]types.FundingRateRecord); ok {
+			return records, nil
+		}
+	}
+
+	params := map[string]string{
+		"symbol": symbol,
+		"limit":  strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := be.client.FetchJSON(ctx, "/fapi/v1/fundingRate", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding history: %w", err)
+	}
+
+	dataList, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid funding history data format")
+	}
+	records := make([]types.FundingRateRecord, 0, len(dataList))
+	for _, item := range dataList {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rate, err := parseStringOrFloat(itemMap, "fundingRate")
+		if err != nil {
+			continue
+		}
+		fundingTime, _ := parseFloatValue(itemMap["fundingTime"])
+		records = append(records, types.FundingRateRecord{
+			Symbol:      symbol,
+			FundingRate: rate,
+			FundingTime: int64(fundingTime),
+		})
+	}
+
+	// 结算发生在整点（每1/4/8小时），缓存到下一个整点
+	be.setCacheUntil(cacheKey, records, time.Now().Truncate(time.Hour).Add(time.Hour))
+	return records, nil
+}
+
+// GetPremiumIndex 获取标记价格、指数价格和预测资金费率
+func (be *BinanceExchange) GetPremiumIndex(symbol string) (*types.PremiumIndex, error) {
+	return be.GetPremiumIndexContext(context.Background(), symbol)
+}
+
+// GetPremiumIndexContext 同GetPremiumIndex，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetPremiumIndexContext(ctx context.Context, symbol string) (*types.PremiumIndex, error) {
+	symbol = be.normalizeSymbol(symbol)
+
+	params := map[string]string{
+		"symbol": symbol,
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := be.client.FetchJSON(ctx, "/fapi/v1/premiumIndex", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid premium index data format")
+	}
+	// lastFundingRate为本周期按溢价指数实时计算的费率，即下次结算的预测值
+	rate, err := parseStringOrFloat(dataMap, "lastFundingRate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	premium := &types.PremiumIndex{Symbol: symbol, PredictedFundingRate: rate}
+	premium.MarkPrice, _ = parseFloatValue(dataMap["markPrice"])
+	premium.IndexPrice, _ = parseFloatValue(dataMap["indexPrice"])
+	nextFunding, _ := parseFloatValue(dataMap["nextFundingTime"])
+	premium.NextFundingTime = int64(nextFunding)
+	ts, _ := parseFloatValue(dataMap["time"])
+	premium.Timestamp = int64(ts)
+	return premium, nil
+}
+
 // GetOpenInterest 获取持仓量
 func (be *BinanceExchange) GetOpenInterest(symbol string) (float64, error) {
+	return be.GetOpenInterestContext(context.Background(), symbol)
+}
+
+// GetOpenInterestContext 同GetOpenInterest，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetOpenInterestContext(ctx context.Context, symbol string) (float64, error) {
 	symbol = be.normalizeSymbol(symbol)
 
 	params := map[string]string{
 		"symbol": symbol,
 	}
 
-	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(ctx)
 	defer cancel()
 
 	data, err := be.client.FetchJSON(ctx, "/fapi/v1/openInterest", params)
@@ -234,6 +405,58 @@ func (be *BinanceExchange) GetOpenInterest(symbol string) (float64, error) {
 	return 0, fmt.Errorf("invalid open interest data format")
 }
 
+// binanceDepthLimits 深度接口支持的档位数
+var binanceDepthLimits = []int{5, 10, 20, 50, 100, 500, 1000}
+
+// GetOrderBook 获取深度快照
+func (be *BinanceExchange) GetOrderBook(symbol string, limit int) (*types.OrderBook, error) {
+	return be.GetOrderBookContext(context.Background(), symbol, limit)
+}
+
+// GetOrderBookContext 同GetOrderBook，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetOrderBookContext(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	symbol = be.normalizeSymbol(symbol)
+
+	// limit取不小于请求值的合法档位
+	depthLimit := binanceDepthLimits[len(binanceDepthLimits)-1]
+	for _, l := range binanceDepthLimits {
+		if limit <= l {
+			depthLimit = l
+			break
+		}
+	}
+
+	params := map[string]string{
+		"symbol": symbol,
+		"limit":  strconv.Itoa(depthLimit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := be.client.FetchJSON(ctx, "/fapi/v1/depth", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid order book data format")
+	}
+
+	book := &types.OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(dataMap["bids"], 1),
+		Asks:   parseBookLevels(dataMap["asks"], 1),
+	}
+	if ts, err := parseFloatValue(dataMap["T"]); err == nil {
+		book.Timestamp = int64(ts)
+	} else {
+		book.Timestamp = time.Now().UnixMilli()
+	}
+	return book, nil
+}
+
 // GetMarketInfo 获取市场信息
 func (be *BinanceExchange) GetMarketInfo(symbol string) (map[string]interface{}, error) {
 	symbol = be.normalizeSymbol(symbol)
@@ -262,6 +485,11 @@ func (be *BinanceExchange) NormalizeSymbol(symbol string) string {
 
 // normalizeSymbol 规范化交易对符号（内部方法）
 func (be *BinanceExchange) normalizeSymbol(symbol string) string {
+	return normalizeUSDTSymbol(symbol)
+}
+
+// normalizeUSDTSymbol 规范化为 BTCUSDT 形式（Binance/Bybit通用）
+func normalizeUSDTSymbol(symbol string) string {
 	symbol = strings.ToUpper(strings.TrimSpace(symbol))
 	symbol = strings.ReplaceAll(symbol, "/", "")
 	symbol = strings.ReplaceAll(symbol, "-", "")
@@ -284,7 +512,11 @@ func (be *BinanceExchange) getCache(key string) interface{} {
 
 	cfg := config.Get()
 	ttl := time.Duration(cfg.ExchangeCacheTTLSec) * time.Second
-	if time.Since(entry.timestamp) > ttl {
+	expired := time.Since(entry.timestamp) > ttl
+	if !entry.expires.IsZero() {
+		expired = !time.Now().Before(entry.expires)
+	}
+	if expired {
 		// 缓存过期，删除
 		be.cacheMu.RUnlock()
 		be.cacheMu.Lock()
@@ -308,6 +540,18 @@ func (be *BinanceExchange) setCache(key string, data interface{}) {
 	}
 }
 
+// setCacheUntil 设置缓存，到期时间为expires
+func (be *BinanceExchange) setCacheUntil(key string, data interface{}, expires time.Time) {
+	be.cacheMu.Lock()
+	defer be.cacheMu.Unlock()
+
+	be.cache[key] = cacheEntry{
+		data:      data,
+		timestamp: time.Now(),
+		expires:   expires,
+	}
+}
+
 // parseFloatValue 使用utils包中的ParseFloatValue
 func parseFloatValue(v interface{}) (float64, error) {
 	return utils.ParseFloatValue(v)
@@ -4,16 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
 )
 
 // GetBalance 获取账户余额
 func (be *BinanceExchange) GetBalance() (map[string]float64, error) {
+	return be.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext 同GetBalance，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetBalanceContext(ctx context.Context) (map[string]float64, error) {
 	cfg := config.Get()
 	if cfg.DryRun {
 		return map[string]float64{
@@ -25,35 +30,12 @@ func (be *BinanceExchange) GetBalance() (map[string]float64, error) {
 
 	params := map[string]string{}
 
-	reqURL, err := be.buildSignedURL("/fapi/v2/balance", params, http.MethodGet)
-	if err != nil {
-		return nil, fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	cfg = config.Get()
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
-
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	status, body, err := be.sendSigned(ctx, http.MethodGet, "/fapi/v2/balance", params)
 	if err != nil {
-		return nil, fmt.Errorf("read response failed: %w", err)
+		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get balance failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return nil, newBinanceAPIError("get balance", status, body)
 	}
 
 	var balances []map[string]interface{}
@@ -80,9 +62,122 @@ func (be *BinanceExchange) GetBalance() (map[string]float64, error) {
 	return result, nil
 }
 
+// SetLeverage 设置杠杆倍数
+func (be *BinanceExchange) SetLeverage(symbol string, leverage int) error {
+	return be.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，ctx取消或超时时中止请求
+func (be *BinanceExchange) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	symbol = be.normalizeSymbol(symbol)
+	if leverage <= 0 {
+		return fmt.Errorf("invalid leverage: %d", leverage)
+	}
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Leverage would be set",
+			"symbol", symbol,
+			"leverage", leverage,
+		)
+		return nil
+	}
+
+	status, body, err := be.sendSigned(ctx, http.MethodPost, "/fapi/v1/leverage", map[string]string{
+		"symbol":   symbol,
+		"leverage": strconv.Itoa(leverage),
+	})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return newBinanceAPIError("set leverage", status, body)
+	}
+	return nil
+}
+
+// SetMarginType 设置保证金模式（ISOLATED/CROSSED），模式未变化时视为成功
+func (be *BinanceExchange) SetMarginType(symbol string, marginType string) error {
+	return be.SetMarginTypeContext(context.Background(), symbol, marginType)
+}
+
+// SetMarginTypeContext 同SetMarginType，ctx取消或超时时中止请求
+func (be *BinanceExchange) SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error {
+	symbol = be.normalizeSymbol(symbol)
+	marginType = strings.ToUpper(marginType)
+	if marginType != "ISOLATED" && marginType != "CROSSED" {
+		return fmt.Errorf("invalid margin type: %s", marginType)
+	}
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Margin type would be set",
+			"symbol", symbol,
+			"margin_type", marginType,
+		)
+		return nil
+	}
+
+	status, body, err := be.sendSigned(ctx, http.MethodPost, "/fapi/v1/marginType", map[string]string{
+		"symbol":     symbol,
+		"marginType": marginType,
+	})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		if binanceErrorCode(body) == binanceCodeNoNeedChangeMargin {
+			return nil
+		}
+		return newBinanceAPIError("set margin type", status, body)
+	}
+	return nil
+}
+
+// GetPositionMode 查询持仓模式（true为双向持仓）
+func (be *BinanceExchange) GetPositionMode() (bool, error) {
+	return be.GetPositionModeContext(context.Background())
+}
+
+// GetPositionModeContext 同GetPositionMode，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetPositionModeContext(ctx context.Context) (bool, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return true, nil
+	}
+
+	status, body, err := be.sendSigned(ctx, http.MethodGet, "/fapi/v1/positionSide/dual", map[string]string{})
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, newBinanceAPIError("get position mode", status, body)
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false, fmt.Errorf("parse response failed: %w", err)
+	}
+	return parseBoolValue(resp["dualSidePosition"])
+}
+
 // GetMarketInfo 获取市场信息（注意：此方法在binance.go中实现，这里只是占位）
 // 实际实现在binance.go中，因为需要访问markets字段
 
+// GetSymbolFilters 获取交易对下单规则（本地无该交易对时重新加载exchangeInfo，兼容新上线币种）
+func (be *BinanceExchange) GetSymbolFilters(symbol string) (SymbolFilters, error) {
+	market, err := be.GetMarketInfo(symbol)
+	if err != nil {
+		if loadErr := be.loadMarkets(); loadErr != nil {
+			return SymbolFilters{}, loadErr
+		}
+		if market, err = be.GetMarketInfo(symbol); err != nil {
+			return SymbolFilters{}, err
+		}
+	}
+	return ParseSymbolFilters(market), nil
+}
+
 // GetTickSize 获取最小价格跳动单位
 func (be *BinanceExchange) GetTickSize(symbol string) (float64, error) {
 	market, err := be.GetMarketInfo(symbol)
@@ -90,19 +185,9 @@ func (be *BinanceExchange) GetTickSize(symbol string) (float64, error) {
 		return 0.01, err // 默认值
 	}
 
-	// 尝试从filters中获取tickSize
-	if filters, ok := market["filters"].([]interface{}); ok {
-		for _, f := range filters {
-			if filter, ok := f.(map[string]interface{}); ok {
-				if filterType, _ := filter["filterType"].(string); filterType == "PRICE_FILTER" {
-					if tickSize, ok := filter["tickSize"].(string); ok {
-						if ts, err := strconv.ParseFloat(tickSize, 64); err == nil {
-							return ts, nil
-						}
-					}
-				}
-			}
-		}
+	// 优先从PRICE_FILTER获取tickSize
+	if filters := ParseSymbolFilters(market); filters.TickSize > 0 {
+		return filters.TickSize, nil
 	}
 
 	// 兜底：从precision获取
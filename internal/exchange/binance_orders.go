@@ -20,30 +20,30 @@ import (
 	"github.com/yuechangmingzou/nofx-go/internal/utils"
 )
 
+// Binance错误码
+const (
+	binanceCodeTimestamp          = -1021 // 时间戳超出recvWindow
+	binanceCodeUnknownStatus      = -1007 // 等待后端响应超时，执行结果未知
+	binanceCodeNoNeedChangeMargin = -4046 // 保证金模式无需变更
+	binanceCodeDuplicateClientID  = -4116 // 客户端订单ID重复
+)
+
+// binanceMaxRecvWindowMs Binance允许的最大recvWindow
+const binanceMaxRecvWindowMs = 60000
+
+// binanceMaxBatchOrders 单次批量下单的最大订单数
+const binanceMaxBatchOrders = 5
+
 // PlaceOrder 下单
 func (be *BinanceExchange) PlaceOrder(req types.OrderRequest) (*types.Order, error) {
+	return be.PlaceOrderContext(context.Background(), req)
+}
+
+// PlaceOrderContext 同PlaceOrder，ctx取消或超时时中止请求
+func (be *BinanceExchange) PlaceOrderContext(ctx context.Context, req types.OrderRequest) (*types.Order, error) {
 	cfg := config.Get()
 	if cfg.DryRun {
-		// DRY_RUN模式：只记录，不下单
-		logger := utils.GetLogger("exchange")
-		logger.Infow("DRY_RUN: Order would be placed",
-			"symbol", req.Symbol,
-			"side", req.Side,
-			"order_type", req.OrderType,
-			"quantity", req.Quantity,
-			"price", req.Price,
-		)
-		return &types.Order{
-			ID:          "dry_run_" + strconv.FormatInt(time.Now().UnixNano(), 10),
-			Symbol:      be.normalizeSymbol(req.Symbol),
-			Side:        req.Side,
-			PositionSide: req.PositionSide,
-			OrderType:   req.OrderType,
-			Status:      "NEW",
-			Quantity:    req.Quantity,
-			Price:       getFloatValue(req.Price),
-			Timestamp:   time.Now().Unix(),
-		}, nil
+		return be.dryRunOrder(req), nil
 	}
 
 	// 实盘下单需要API密钥和签名
@@ -54,7 +54,170 @@ func (be *BinanceExchange) PlaceOrder(req types.OrderRequest) (*types.Order, err
 	// 规范化symbol
 	symbol := be.normalizeSymbol(req.Symbol)
 
-	// 构建请求参数
+	// 按交易对规则量化数量和价格，不满足规则时直接拒绝，避免交易所返回精度/名义价值错误
+	req, err := be.applySymbolFilters(ctx, symbol, req)
+	if err != nil {
+		return nil, err
+	}
+
+	sentAt := be.serverTimeMillis(ctx)
+	statusCode, body, err := be.sendSigned(ctx, http.MethodPost, "/fapi/v1/order", be.orderParams(symbol, req))
+
+	// 结果不确定（超时、5xx、客户端订单ID重复）时按客户端订单ID查询，已受理则返回该订单，保证重试幂等
+	if req.ClientOrderID != "" && statusCode != http.StatusOK {
+		if existing := be.recoverClientOrder(ctx, symbol, req.ClientOrderID, statusCode, body, err, sentAt); existing != nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, newBinanceAPIError("place order", statusCode, body)
+	}
+
+	var orderResp map[string]interface{}
+	if err := json.Unmarshal(body, &orderResp); err != nil {
+		return nil, fmt.Errorf("parse response failed: %w", err)
+	}
+
+	return parseBinanceOrderResponse(symbol, req, orderResp), nil
+}
+
+// PlaceBatchOrders 批量下单（/fapi/v1/batchOrders，每批最多5笔）
+// 不满足交易对规则的订单不发送，在对应位置返回错误
+func (be *BinanceExchange) PlaceBatchOrders(reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return be.PlaceBatchOrdersContext(context.Background(), reqs)
+}
+
+// PlaceBatchOrdersContext 同PlaceBatchOrders，ctx取消或超时时中止请求
+func (be *BinanceExchange) PlaceBatchOrdersContext(ctx context.Context, reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	results := make([]types.BatchOrderResult, len(reqs))
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		for i, req := range reqs {
+			results[i].Order = be.dryRunOrder(req)
+		}
+		return results, nil
+	}
+
+	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
+		return nil, fmt.Errorf("API keys required for real trading")
+	}
+
+	// 量化并校验，记录需要发送的订单下标
+	prepared := make([]types.OrderRequest, len(reqs))
+	var pending []int
+	for i, req := range reqs {
+		symbol := be.normalizeSymbol(req.Symbol)
+		quantized, err := be.applySymbolFilters(ctx, symbol, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		quantized.Symbol = symbol
+		prepared[i] = quantized
+		pending = append(pending, i)
+	}
+
+	for start := 0; start < len(pending); start += binanceMaxBatchOrders {
+		end := start + binanceMaxBatchOrders
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := pending[start:end]
+
+		batch := make([]map[string]string, 0, len(chunk))
+		for _, idx := range chunk {
+			batch = append(batch, be.orderParams(prepared[idx].Symbol, prepared[idx]))
+		}
+		batchJSON, err := json.Marshal(batch)
+		if err != nil {
+			return nil, fmt.Errorf("encode batch orders failed: %w", err)
+		}
+
+		sentAt := be.serverTimeMillis(ctx)
+		statusCode, body, err := be.sendSigned(ctx, http.MethodPost, "/fapi/v1/batchOrders", map[string]string{
+			"batchOrders": string(batchJSON),
+		})
+		if err == nil && statusCode != http.StatusOK {
+			err = newBinanceAPIError("place batch orders", statusCode, body)
+		}
+
+		var items []map[string]interface{}
+		if err == nil {
+			if jsonErr := json.Unmarshal(body, &items); jsonErr != nil {
+				err = fmt.Errorf("parse response failed: %w", jsonErr)
+			}
+		}
+		if err != nil {
+			// 整批失败：之前的批次已提交，逐笔标记错误（结果不确定时按客户端订单ID找回）
+			for _, idx := range chunk {
+				if prepared[idx].ClientOrderID != "" {
+					if existing := be.recoverClientOrder(ctx, prepared[idx].Symbol, prepared[idx].ClientOrderID, statusCode, body, err, sentAt); existing != nil {
+						results[idx].Order = existing
+						continue
+					}
+				}
+				results[idx].Err = err
+			}
+			continue
+		}
+
+		for j, idx := range chunk {
+			if j >= len(items) {
+				results[idx].Err = fmt.Errorf("batch order missing in response")
+				continue
+			}
+			item := items[j]
+			// 失败的订单返回 {"code":..., "msg":...}
+			if item["orderId"] == nil {
+				if prepared[idx].ClientOrderID != "" {
+					itemBody, _ := json.Marshal(item)
+					if existing := be.recoverClientOrder(ctx, prepared[idx].Symbol, prepared[idx].ClientOrderID, http.StatusBadRequest, itemBody, nil, sentAt); existing != nil {
+						results[idx].Order = existing
+						continue
+					}
+				}
+				results[idx].Err = fmt.Errorf("place order failed: code %s, msg: %s", parseStringValue(item["code"]), parseStringValue(item["msg"]))
+				continue
+			}
+			results[idx].Order = parseBinanceOrderResponse(prepared[idx].Symbol, prepared[idx], item)
+		}
+	}
+
+	return results, nil
+}
+
+// dryRunOrder DRY_RUN模式：只记录，不下单
+func (be *BinanceExchange) dryRunOrder(req types.OrderRequest) *types.Order {
+	logger := utils.GetLogger("exchange")
+	logger.Infow("DRY_RUN: Order would be placed",
+		"symbol", req.Symbol,
+		"side", req.Side,
+		"order_type", req.OrderType,
+		"quantity", req.Quantity,
+		"price", req.Price,
+	)
+	return &types.Order{
+		ID:            "dry_run_" + strconv.FormatInt(time.Now().UnixNano(), 10),
+		Symbol:        be.normalizeSymbol(req.Symbol),
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		OrderType:     req.OrderType,
+		Status:        "NEW",
+		Quantity:      req.Quantity,
+		Price:         getFloatValue(req.Price),
+		StopPrice:     getFloatValue(req.StopPrice),
+		ReduceOnly:    req.ReduceOnly,
+		ClientOrderID: req.ClientOrderID,
+		Timestamp:     time.Now().Unix(),
+	}
+}
+
+// orderParams 构建下单参数（单笔下单和批量下单共用）
+func (be *BinanceExchange) orderParams(symbol string, req types.OrderRequest) map[string]string {
 	params := make(map[string]string)
 	params["symbol"] = symbol
 	params["side"] = strings.ToUpper(req.Side)
@@ -90,43 +253,16 @@ func (be *BinanceExchange) PlaceOrder(req types.OrderRequest) (*types.Order, err
 		params["reduceOnly"] = "true"
 	}
 
-	// 构建签名URL
-	reqURL, err := be.buildSignedURL("/fapi/v1/order", params, http.MethodPost)
-	if err != nil {
-		return nil, fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
-
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response failed: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("place order failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	// 客户端订单ID（幂等下单）
+	if req.ClientOrderID != "" {
+		params["newClientOrderId"] = req.ClientOrderID
 	}
 
-	var orderResp map[string]interface{}
-	if err := json.Unmarshal(body, &orderResp); err != nil {
-		return nil, fmt.Errorf("parse response failed: %w", err)
-	}
+	return params
+}
 
-	// 解析订单响应
+// parseBinanceOrderResponse 解析下单响应
+func parseBinanceOrderResponse(symbol string, req types.OrderRequest, orderResp map[string]interface{}) *types.Order {
 	orderID := parseStringValue(orderResp["orderId"])
 	status := parseStringValue(orderResp["status"])
 	filledQty, _ := parseFloatValue(orderResp["executedQty"])
@@ -152,55 +288,70 @@ func (be *BinanceExchange) PlaceOrder(req types.OrderRequest) (*types.Order, err
 	if req.StopPrice != nil {
 		order.StopPrice = *req.StopPrice
 	}
+	order.ClientOrderID = parseStringValue(orderResp["clientOrderId"])
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = req.ClientOrderID
+	}
 
-	return order, nil
+	return order
+}
+
+// recoverClientOrder 下单结果不确定时按客户端订单ID查询交易所是否已受理
+// 客户端订单ID重复说明同ID挂单已存在，直接返回；其他情况只接受本次发送之后创建的订单，避免误认历史订单
+func (be *BinanceExchange) recoverClientOrder(ctx context.Context, symbol, clientOrderID string, statusCode int, body []byte, sendErr error, sentAtMs int64) *types.Order {
+	code := binanceErrorCode(body)
+	duplicate := code == binanceCodeDuplicateClientID
+	ambiguous := sendErr != nil || statusCode >= http.StatusInternalServerError || code == binanceCodeUnknownStatus
+	if !duplicate && !ambiguous {
+		return nil
+	}
+
+	// 调用方已取消时订单可能已受理，仍需查询结果
+	queryCtx, cancel := utils.WithShortTimeout(context.WithoutCancel(ctx))
+	defer cancel()
+	existing, err := be.queryOrder(queryCtx, symbol, map[string]string{"origClientOrderId": clientOrderID})
+	if err != nil || existing == nil || existing.ID == "" {
+		return nil
+	}
+	if !duplicate && existing.Timestamp*1000 < sentAtMs-1000 {
+		return nil
+	}
+
+	utils.GetLogger("exchange").Infow("Order recovered by client order ID",
+		"symbol", symbol,
+		"client_order_id", clientOrderID,
+		"order_id", existing.ID,
+		"status", existing.Status,
+		"http_status", statusCode,
+		"code", code,
+	)
+	return existing
 }
 
 // GetOpenOrders 获取当前挂单
 func (be *BinanceExchange) GetOpenOrders(symbol string) ([]*types.Order, error) {
+	return be.GetOpenOrdersContext(context.Background(), symbol)
+}
+
+// GetOpenOrdersContext 同GetOpenOrders，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetOpenOrdersContext(ctx context.Context, symbol string) ([]*types.Order, error) {
 	cfg := config.Get()
 	if cfg.DryRun {
 		// DRY_RUN模式：返回空列表
 		return []*types.Order{}, nil
 	}
 
-	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
-		return nil, fmt.Errorf("API keys required")
-	}
-
 	symbol = be.normalizeSymbol(symbol)
 	params := map[string]string{
 		"symbol": symbol,
 	}
 
-	reqURL, err := be.buildSignedURL("/fapi/v1/openOrders", params, http.MethodGet)
-	if err != nil {
-		return nil, fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	statusCode, body, err := be.sendSigned(ctx, http.MethodGet, "/fapi/v1/openOrders", params)
 	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
-
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response failed: %w", err)
+		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get open orders failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, newBinanceAPIError("get open orders", statusCode, body)
 	}
 
 	var ordersResp []map[string]interface{}
@@ -223,20 +374,23 @@ func (be *BinanceExchange) GetOpenOrders(symbol string) ([]*types.Order, error)
 		timeVal, _ := parseFloatValue(o["time"])
 
 		reduceOnly, _ := parseBoolValue(o["reduceOnly"])
+		closePosition, _ := parseBoolValue(o["closePosition"])
 		orders = append(orders, &types.Order{
-			ID:           orderID,
-			Symbol:       symbol,
-			Side:         side,
-			PositionSide: positionSide,
-			OrderType:    orderType,
-			Status:       status,
-			Quantity:     quantity,
-			Price:        price,
-			StopPrice:    stopPrice,
-			FilledQty:    filledQty,
-			AvgPrice:     avgPrice,
-			ReduceOnly:   reduceOnly,
-			Timestamp:    int64(timeVal / 1000),
+			ID:            orderID,
+			Symbol:        symbol,
+			Side:          side,
+			PositionSide:  positionSide,
+			OrderType:     orderType,
+			Status:        status,
+			Quantity:      quantity,
+			Price:         price,
+			StopPrice:     stopPrice,
+			FilledQty:     filledQty,
+			AvgPrice:      avgPrice,
+			ReduceOnly:    reduceOnly,
+			ClosePosition: closePosition,
+			ClientOrderID: parseStringValue(o["clientOrderId"]),
+			Timestamp:     int64(timeVal / 1000),
 		})
 	}
 
@@ -245,6 +399,11 @@ func (be *BinanceExchange) GetOpenOrders(symbol string) ([]*types.Order, error)
 
 // CancelOrder 取消订单
 func (be *BinanceExchange) CancelOrder(symbol, orderID string) error {
+	return be.CancelOrderContext(context.Background(), symbol, orderID)
+}
+
+// CancelOrderContext 同CancelOrder，ctx取消或超时时中止请求
+func (be *BinanceExchange) CancelOrderContext(ctx context.Context, symbol, orderID string) error {
 	cfg := config.Get()
 	if cfg.DryRun {
 		logger := utils.GetLogger("exchange")
@@ -255,44 +414,18 @@ func (be *BinanceExchange) CancelOrder(symbol, orderID string) error {
 		return nil
 	}
 
-	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
-		return fmt.Errorf("API keys required")
-	}
-
 	symbol = be.normalizeSymbol(symbol)
 	params := map[string]string{
 		"symbol":  symbol,
 		"orderId": orderID,
 	}
 
-	reqURL, err := be.buildSignedURL("/fapi/v1/order", params, http.MethodDelete)
-	if err != nil {
-		return fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL, nil)
-	if err != nil {
-		return fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
-
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	statusCode, body, err := be.sendSigned(ctx, http.MethodDelete, "/fapi/v1/order", params)
 	if err != nil {
-		return fmt.Errorf("read response failed: %w", err)
+		return err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("cancel order failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return newBinanceAPIError("cancel order", statusCode, body)
 	}
 
 	return nil
@@ -300,6 +433,11 @@ func (be *BinanceExchange) CancelOrder(symbol, orderID string) error {
 
 // GetOrder 获取订单状态
 func (be *BinanceExchange) GetOrder(symbol, orderID string) (*types.Order, error) {
+	return be.GetOrderContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderContext 同GetOrder，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetOrderContext(ctx context.Context, symbol, orderID string) (*types.Order, error) {
 	cfg := config.Get()
 	if cfg.DryRun {
 		return &types.Order{
@@ -310,44 +448,24 @@ func (be *BinanceExchange) GetOrder(symbol, orderID string) (*types.Order, error
 		}, nil
 	}
 
-	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
-		return nil, fmt.Errorf("API keys required")
-	}
-
-	symbol = be.normalizeSymbol(symbol)
-	params := map[string]string{
-		"symbol":  symbol,
-		"orderId": orderID,
-	}
-
-	reqURL, err := be.buildSignedURL("/fapi/v1/order", params, http.MethodGet)
-	if err != nil {
-		return nil, fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
+	return be.queryOrder(ctx, be.normalizeSymbol(symbol), map[string]string{"orderId": orderID})
+}
 
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
+// GetOrderByClientID 按客户端订单ID查询订单
+func (be *BinanceExchange) GetOrderByClientID(symbol, clientOrderID string) (*types.Order, error) {
+	return be.queryOrder(context.Background(), be.normalizeSymbol(symbol), map[string]string{"origClientOrderId": clientOrderID})
+}
 
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+// queryOrder 查询订单（按orderId或origClientOrderId）
+func (be *BinanceExchange) queryOrder(ctx context.Context, symbol string, params map[string]string) (*types.Order, error) {
+	params["symbol"] = symbol
 
-	body, err := io.ReadAll(resp.Body)
+	statusCode, body, err := be.sendSigned(ctx, http.MethodGet, "/fapi/v1/order", params)
 	if err != nil {
-		return nil, fmt.Errorf("read response failed: %w", err)
+		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get order failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, newBinanceAPIError("get order", statusCode, body)
 	}
 
 	var orderResp map[string]interface{}
@@ -367,27 +485,35 @@ func (be *BinanceExchange) GetOrder(symbol, orderID string) (*types.Order, error
 	avgPrice, _ := parseFloatValue(orderResp["avgPrice"])
 	timeVal, _ := parseFloatValue(orderResp["time"])
 	reduceOnly, _ := parseBoolValue(orderResp["reduceOnly"])
+	closePosition, _ := parseBoolValue(orderResp["closePosition"])
 
 	return &types.Order{
-		ID:           orderIDStr,
-		Symbol:       symbol,
-		Side:         side,
-		PositionSide: positionSide,
-		OrderType:    orderType,
-		Status:       status,
-		Quantity:     quantity,
-		Price:        price,
-		StopPrice:    stopPrice,
-		FilledQty:    filledQty,
-		AvgPrice:     avgPrice,
-		ReduceOnly:   reduceOnly,
-		Timestamp:    int64(timeVal / 1000),
+		ID:            orderIDStr,
+		Symbol:        symbol,
+		Side:          side,
+		PositionSide:  positionSide,
+		OrderType:     orderType,
+		Status:        status,
+		Quantity:      quantity,
+		Price:         price,
+		StopPrice:     stopPrice,
+		FilledQty:     filledQty,
+		AvgPrice:      avgPrice,
+		ReduceOnly:    reduceOnly,
+		ClosePosition: closePosition,
+		ClientOrderID: parseStringValue(orderResp["clientOrderId"]),
+		Timestamp:     int64(timeVal / 1000),
 	}, nil
 }
 
 // GetPosition 获取单个持仓
 func (be *BinanceExchange) GetPosition(symbol string) (*types.Position, error) {
-	positions, err := be.GetPositions()
+	return be.GetPositionContext(context.Background(), symbol)
+}
+
+// GetPositionContext 同GetPosition，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetPositionContext(ctx context.Context, symbol string) (*types.Position, error) {
+	positions, err := be.GetPositionsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -404,45 +530,24 @@ func (be *BinanceExchange) GetPosition(symbol string) (*types.Position, error) {
 
 // GetPositions 获取所有持仓
 func (be *BinanceExchange) GetPositions() ([]*types.Position, error) {
+	return be.GetPositionsContext(context.Background())
+}
+
+// GetPositionsContext 同GetPositions，ctx取消或超时时中止请求
+func (be *BinanceExchange) GetPositionsContext(ctx context.Context) ([]*types.Position, error) {
 	cfg := config.Get()
 	if cfg.DryRun {
 		return []*types.Position{}, nil
 	}
 
-	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
-		return nil, fmt.Errorf("API keys required")
-	}
-
 	params := map[string]string{}
 
-	reqURL, err := be.buildSignedURL("/fapi/v2/positionRisk", params, http.MethodGet)
-	if err != nil {
-		return nil, fmt.Errorf("build signed URL failed: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-
-	httpReq.Header.Set("X-MBX-APIKEY", cfg.BinanceAPIKey)
-
-	resp, err := be.client.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	statusCode, body, err := be.sendSigned(ctx, http.MethodGet, "/fapi/v2/positionRisk", params)
 	if err != nil {
-		return nil, fmt.Errorf("read response failed: %w", err)
+		return nil, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get positions failed: HTTP %d, body: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, newBinanceAPIError("get positions", statusCode, body)
 	}
 
 	var positionsResp []map[string]interface{}
@@ -487,11 +592,17 @@ func (be *BinanceExchange) GetPositions() ([]*types.Position, error) {
 }
 
 // buildSignedURL 构建带签名的URL
-func (be *BinanceExchange) buildSignedURL(endpoint string, params map[string]string, method string) (string, error) {
+func (be *BinanceExchange) buildSignedURL(ctx context.Context, endpoint string, params map[string]string, method string) (string, error) {
 	cfg := config.Get()
 
-	// 添加时间戳
-	params["timestamp"] = strconv.FormatInt(time.Now().Unix()*1000, 10)
+	// 添加时间戳（按服务器时间校正）和recvWindow
+	params["timestamp"] = strconv.FormatInt(be.serverTimeMillis(ctx), 10)
+	if recvWindow := cfg.BinanceRecvWindowMs; recvWindow > 0 {
+		if recvWindow > binanceMaxRecvWindowMs {
+			recvWindow = binanceMaxRecvWindowMs
+		}
+		params["recvWindow"] = strconv.Itoa(recvWindow)
+	}
 
 	// 排序参数
 	keys := make([]string, 0, len(params))
@@ -512,7 +623,7 @@ func (be *BinanceExchange) buildSignedURL(endpoint string, params map[string]str
 	queryString += "&signature=" + signature
 
 	// 构建完整URL
-	baseURL := cfg.BinanceFAPIBaseURL
+	baseURL := be.client.baseURL
 	if !strings.HasSuffix(baseURL, "/") {
 		baseURL += "/"
 	}
@@ -522,6 +633,108 @@ func (be *BinanceExchange) buildSignedURL(endpoint string, params map[string]str
 	return baseURL + endpoint + "?" + queryString, nil
 }
 
+// sendSigned 发送签名请求，返回HTTP状态码和响应体
+// 时间戳超出recvWindow（-1021）时重新同步服务器时间并重试一次
+func (be *BinanceExchange) sendSigned(ctx context.Context, method, endpoint string, params map[string]string) (int, []byte, error) {
+	cfg := config.Get()
+	if cfg.BinanceAPIKey == "" || cfg.BinanceSecretKey == "" {
+		return 0, nil, fmt.Errorf("API keys required")
+	}
+
+	status, body, err := be.doSigned(ctx, method, endpoint, params)
+
+	// 只读请求遇到瞬时错误时重试；下单和撤单不在此重试，由调用方按客户端订单ID处理
+	if method == http.MethodGet {
+		for attempt := 1; attempt <= cfg.BinanceHTTPMaxRetries && retryableSignedResponse(status, body, err); attempt++ {
+			select {
+			case <-ctx.Done():
+				return status, body, err
+			case <-time.After(retryDelay(attempt)):
+			}
+			status, body, err = be.doSigned(ctx, method, endpoint, params)
+		}
+	}
+
+	if err != nil || status == http.StatusOK || be.timeSync == nil || binanceErrorCode(body) != binanceCodeTimestamp {
+		return status, body, err
+	}
+
+	logger := utils.GetLogger("exchange")
+	logger.Warnw("Timestamp outside recvWindow, resyncing server time",
+		"endpoint", endpoint,
+		"offset_ms", be.timeSync.Offset().Milliseconds(),
+	)
+
+	syncCtx, cancel := utils.WithShortTimeout(ctx)
+	syncErr := be.timeSync.Sync(syncCtx)
+	cancel()
+	if syncErr != nil {
+		logger.Warnw("Server time resync failed", "error", syncErr)
+		return status, body, nil
+	}
+
+	// -1021表示请求被拒绝，重试不会重复下单
+	return be.doSigned(ctx, method, endpoint, params)
+}
+
+// doSigned 签名并发送一次请求
+func (be *BinanceExchange) doSigned(ctx context.Context, method, endpoint string, params map[string]string) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 先等待限流再签名，避免排队期间时间戳过期
+	if err := be.client.limiter.Wait(ctx, BinanceRequestCost(method, endpoint, params)); err != nil {
+		return 0, nil, fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+
+	reqURL, err := be.buildSignedURL(ctx, endpoint, params, method)
+	if err != nil {
+		return 0, nil, fmt.Errorf("build signed URL failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, reqURL, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("X-MBX-APIKEY", config.Get().BinanceAPIKey)
+
+	resp, err := be.client.client.Do(httpReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	be.client.limiter.UpdateFromHeaders(resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read response failed: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// retryableSignedResponse 签名请求的结果是否为可重试的瞬时错误（时间戳错误由重新同步处理）
+func retryableSignedResponse(status int, body []byte, err error) bool {
+	if err != nil {
+		return IsRetryable(err)
+	}
+	// 限流时立即重试只会延长封禁
+	if status == http.StatusOK || status == http.StatusTooManyRequests || status == 418 {
+		return false
+	}
+	code := binanceErrorCode(body)
+	return code != binanceCodeTimestamp && classifyBinanceError(status, code) == ErrorRetryable
+}
+
+// binanceErrorCode 解析错误响应中的code（无法解析时返回0）
+func binanceErrorCode(body []byte) int {
+	var errResp map[string]interface{}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return 0
+	}
+	code, _ := parseFloatValue(errResp["code"])
+	return int(code)
+}
+
 // generateSignature 生成HMAC-SHA256签名（内部方法）
 func (be *BinanceExchange) generateSignature(queryString string) string {
 	cfg := config.Get()
@@ -530,6 +743,20 @@ func (be *BinanceExchange) generateSignature(queryString string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
+// applySymbolFilters 量化订单并校验交易对规则（市价单用最新价校验名义价值）
+func (be *BinanceExchange) applySymbolFilters(ctx context.Context, symbol string, req types.OrderRequest) (types.OrderRequest, error) {
+	filters, err := be.GetSymbolFilters(symbol)
+	if err != nil {
+		return req, fmt.Errorf("load symbol filters failed: %w", err)
+	}
+
+	refPrice := 0.0
+	if filters.MinNotional > 0 && !req.ReduceOnly && getFloatValue(req.Price) <= 0 && getFloatValue(req.StopPrice) <= 0 {
+		refPrice, _ = be.GetTickerPriceContext(ctx, symbol)
+	}
+	return filters.Apply(req, refPrice)
+}
+
 // 辅助函数
 func formatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', -1, 64)
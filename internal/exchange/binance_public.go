@@ -2,9 +2,14 @@ package exchange
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
 )
 
 // GetUSDTSymbols 获取所有USDT交易对（公开方法）
@@ -69,32 +74,135 @@ func (be *BinanceExchange) GetTicker24h(symbol string) (map[string]interface{},
 
 // GetOpenInterestHistChange 获取持仓量历史变化
 func (be *BinanceExchange) GetOpenInterestHistChange(symbol string, period string, limit int) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := be.getFuturesData(ctx, "/futures/data/openInterestHist", symbol, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open interest hist: %w", err)
+	}
+	return result, nil
+}
+
+// GetDerivativesData 获取衍生品情绪数据：全市场多空账户比、大户持仓多空比、主动买卖量和持仓量历史
+func (be *BinanceExchange) GetDerivativesData(symbol, period string, limit int) (*types.DerivativesData, error) {
+	return be.GetDerivativesDataContext(context.Background(), symbol, period, limit)
+}
+
+// GetDerivativesDataContext 同GetDerivativesData，ctx取消或超时时中止请求
+// 四个接口并发请求，部分失败时返回已获取的数据，全部失败才返回错误
+func (be *BinanceExchange) GetDerivativesDataContext(ctx context.Context, symbol, period string, limit int) (*types.DerivativesData, error) {
+	endpoints := []string{
+		"/futures/data/globalLongShortAccountRatio",
+		"/futures/data/topLongShortPositionRatio",
+		"/futures/data/takerlongshortRatio",
+		"/futures/data/openInterestHist",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	results := make([][]map[string]interface{}, len(endpoints))
+	errs := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(idx int, endpoint string) {
+			defer wg.Done()
+			results[idx], errs[idx] = be.getFuturesData(ctx, endpoint, symbol, period, limit)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	data := &types.DerivativesData{Period: period}
+	failed := 0
+	for i, rows := range results {
+		if errs[i] != nil || len(rows) == 0 {
+			failed++
+			continue
+		}
+		latest := rows[len(rows)-1] // 按时间升序返回
+		if ts, err := parseFloatValue(latest["timestamp"]); err == nil && int64(ts) > data.Timestamp {
+			data.Timestamp = int64(ts)
+		}
+
+		switch i {
+		case 0:
+			data.LongShortAccountRatio, _ = parseFloatValue(latest["longShortRatio"])
+			data.LongAccountPct, _ = parseFloatValue(latest["longAccount"])
+		case 1:
+			data.TopTraderPositionRatio, _ = parseFloatValue(latest["longShortRatio"])
+		case 2:
+			data.TakerBuySellRatio, _ = parseFloatValue(latest["buySellRatio"])
+			data.TakerBuyVolume, _ = parseFloatValue(latest["buyVol"])
+			data.TakerSellVolume, _ = parseFloatValue(latest["sellVol"])
+		case 3:
+			data.OpenInterestValue, _ = parseFloatValue(latest["sumOpenInterestValue"])
+			first, _ := parseFloatValue(rows[0]["sumOpenInterest"])
+			last, _ := parseFloatValue(latest["sumOpenInterest"])
+			if first > 0 {
+				data.OpenInterestChangePct = (last - first) / first * 100
+			}
+		}
+	}
+	if failed == len(endpoints) {
+		return nil, fmt.Errorf("failed to get derivatives data: %w", errors.Join(errs...))
+	}
+	return data, nil
+}
+
+// getFuturesData 请求合约数据接口（/futures/data/*），结果缓存到下一个周期开始
+func (be *BinanceExchange) getFuturesData(ctx context.Context, endpoint, symbol, period string, limit int) ([]map[string]interface{}, error) {
 	symbol = be.normalizeSymbol(symbol)
 
+	cacheKey := fmt.Sprintf("futures_data:%s:%s:%s:%d", endpoint, symbol, period, limit)
+	if cached := be.getCache(cacheKey); cached != nil {
+		if rows, ok := cached.([]map[string]interface{}); ok {
+			return rows, nil
+		}
+	}
+
 	params := map[string]string{
 		"symbol": symbol,
 		"period": period, // 5m, 15m, 30m, 1h, 2h, 4h, 6h, 12h, 1d
 		"limit":  strconv.Itoa(limit),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	data, err := be.client.FetchJSON(ctx, "/fapi/v1/openInterestHist", params)
+	data, err := be.client.FetchJSON(ctx, endpoint, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get open interest hist: %w", err)
+		return nil, err
 	}
 
-	if dataList, ok := data.([]interface{}); ok {
-		result := make([]map[string]interface{}, 0, len(dataList))
-		for _, item := range dataList {
-			if itemMap, ok := item.(map[string]interface{}); ok {
-				result = append(result, itemMap)
-			}
+	dataList, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s data format", endpoint)
+	}
+	result := make([]map[string]interface{}, 0, len(dataList))
+	for _, item := range dataList {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			result = append(result, itemMap)
 		}
-		return result, nil
 	}
 
-	return nil, fmt.Errorf("invalid open interest hist data format")
+	// 数据按周期更新，缓存到下一个周期开始
+	if d, err := futuresDataPeriod(period); err == nil {
+		next := time.Now().Truncate(d).Add(d)
+		be.setCacheUntil(cacheKey, result, next)
+	}
+	return result, nil
 }
 
+// futuresDataPeriod 解析合约数据接口的统计周期
+func futuresDataPeriod(period string) (time.Duration, error) {
+	switch period {
+	case "5m", "15m", "30m":
+		n, _ := strconv.Atoi(strings.TrimSuffix(period, "m"))
+		return time.Duration(n) * time.Minute, nil
+	case "1h", "2h", "4h", "6h", "12h":
+		n, _ := strconv.Atoi(strings.TrimSuffix(period, "h"))
+		return time.Duration(n) * time.Hour, nil
+	case "1d":
+		return 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid period: %s", period)
+}
@@ -0,0 +1,106 @@
+package binancetest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault 注入的故障：匹配的请求延迟响应或返回指定错误
+type Fault struct {
+	Method string        // 为空时匹配任意方法
+	Path   string        // 接口路径，如/fapi/v1/order
+	Times  int           // 生效次数，0表示一直生效
+	Delay  time.Duration // 响应前等待，超过客户端超时即模拟请求超时
+	Apply  bool          // 先正常处理请求再延迟或返回错误（模拟交易所已受理但响应丢失）
+
+	Status     int // HTTP状态码，为0时只延迟不报错
+	Code       int
+	Msg        string
+	RetryAfter int // 429/418时的Retry-After（秒），为0时不返回
+}
+
+// faultState 故障及剩余次数
+type faultState struct {
+	Fault
+	remaining int
+}
+
+// RateLimited 限流故障（HTTP 429，-1003）
+func RateLimited(method, path string, times int) Fault {
+	return Fault{
+		Method: method,
+		Path:   path,
+		Times:  times,
+		Status: http.StatusTooManyRequests,
+		Code:   CodeTooManyRequests,
+		Msg:    "Too many requests; current limit is 2400 requests per minute.",
+	}
+}
+
+// MarginInsufficient 保证金不足故障（HTTP 400，-2019）
+func MarginInsufficient(method, path string, times int) Fault {
+	return Fault{
+		Method: method,
+		Path:   path,
+		Times:  times,
+		Status: http.StatusBadRequest,
+		Code:   CodeMarginInsufficient,
+		Msg:    "Margin is insufficient.",
+	}
+}
+
+// Timeout 超时故障：响应延迟delay；applied为true时请求照常生效（如订单已创建）
+func Timeout(method, path string, delay time.Duration, applied bool, times int) Fault {
+	return Fault{
+		Method: method,
+		Path:   path,
+		Times:  times,
+		Delay:  delay,
+		Apply:  applied,
+	}
+}
+
+// Inject 注入故障，多个故障匹配同一请求时按注入顺序取第一个
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &faultState{Fault: f, remaining: f.Times})
+}
+
+// ClearFaults 清除全部故障
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault 取出匹配请求的故障并扣减次数
+func (s *Server) takeFault(method, path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Path != path || (f.Method != "" && !strings.EqualFold(f.Method, method)) {
+			continue
+		}
+		fault := f.Fault
+		if f.Times > 0 {
+			f.remaining--
+			if f.remaining <= 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &fault
+	}
+	return nil
+}
+
+// writeFault 写入故障响应
+func writeFault(w http.ResponseWriter, f *Fault) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+	}
+	writeJSON(w, f.Status, &apiError{Code: f.Code, Msg: f.Msg})
+}
@@ -0,0 +1,362 @@
+package binancetest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// fundingInterval 资金费率结算间隔
+const fundingInterval = 8 * time.Hour
+
+// AddSymbol 添加USDT永续交易对（DefaultFilters规则，资金费率0.01%，下次结算为下一个8小时整点）
+func (s *Server) AddSymbol(symbol string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.symbols[symbol] = &symbolState{
+		price:        price,
+		fundingRate:  0.0001,
+		nextFunding:  time.Now().UTC().Truncate(fundingInterval).Add(fundingInterval).UnixMilli(),
+		openInterest: 10000,
+		filters:      DefaultFilters,
+		klines:       make(map[string][]types.OHLCV),
+	}
+}
+
+// SetFilters 设置交易对下单规则
+func (s *Server) SetFilters(symbol string, filters Filters) {
+	s.withSymbol(symbol, func(st *symbolState) { st.filters = filters })
+}
+
+// SetPrice 设置最新价和标记价格，并触发满足条件的挂单
+func (s *Server) SetPrice(symbol string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.symbols[symbol]
+	if !ok {
+		return
+	}
+	st.price = price
+	s.triggerOrders(symbol, price)
+}
+
+// SetIndexPrice 设置指数价格（为0时等于最新价）
+func (s *Server) SetIndexPrice(symbol string, price float64) {
+	s.withSymbol(symbol, func(st *symbolState) { st.indexPrice = price })
+}
+
+// SetFunding 设置预测资金费率和下次结算时间
+func (s *Server) SetFunding(symbol string, rate float64, next time.Time) {
+	s.withSymbol(symbol, func(st *symbolState) {
+		st.fundingRate = rate
+		st.nextFunding = next.UnixMilli()
+	})
+}
+
+// SetOpenInterest 设置持仓量（币数量）
+func (s *Server) SetOpenInterest(symbol string, oi float64) {
+	s.withSymbol(symbol, func(st *symbolState) { st.openInterest = oi })
+}
+
+// SetKlines 设置指定周期的K线（按时间正序），未设置的周期按最新价生成
+func (s *Server) SetKlines(symbol, interval string, candles []types.OHLCV) {
+	s.withSymbol(symbol, func(st *symbolState) {
+		st.klines[interval] = append([]types.OHLCV(nil), candles...)
+	})
+}
+
+// withSymbol 加锁修改交易对状态（交易对不存在时忽略）
+func (s *Server) withSymbol(symbol string, fn func(st *symbolState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.symbols[symbol]; ok {
+		fn(st)
+	}
+}
+
+// symbol 获取交易对状态（调用方持有锁）
+func (s *Server) symbol(symbol string) (*symbolState, *apiError) {
+	st, ok := s.symbols[symbol]
+	if !ok {
+		return nil, newAPIError(CodeInvalidSymbol, "Invalid symbol.")
+	}
+	return st, nil
+}
+
+// handleTime 服务器时间
+func (s *Server) handleTime(params map[string]string) (int, interface{}) {
+	return http.StatusOK, map[string]interface{}{"serverTime": time.Now().UnixMilli()}
+}
+
+// handleExchangeInfo 交易规则和限额
+func (s *Server) handleExchangeInfo(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbols := make([]map[string]interface{}, 0, len(s.symbols))
+	for name, st := range s.symbols {
+		symbols = append(symbols, map[string]interface{}{
+			"symbol":       name,
+			"status":       "TRADING",
+			"contractType": "PERPETUAL",
+			"quoteAsset":   "USDT",
+			"onboardDate":  time.Now().AddDate(-1, 0, 0).UnixMilli(),
+			"filters": []map[string]interface{}{
+				{"filterType": "PRICE_FILTER", "tickSize": formatFloat(st.filters.TickSize), "minPrice": formatFloat(st.filters.TickSize), "maxPrice": "10000000"},
+				{"filterType": "LOT_SIZE", "stepSize": formatFloat(st.filters.StepSize), "minQty": formatFloat(st.filters.MinQty), "maxQty": "100000"},
+				{"filterType": "MARKET_LOT_SIZE", "stepSize": formatFloat(st.filters.StepSize), "minQty": formatFloat(st.filters.MinQty), "maxQty": "100000"},
+				{"filterType": "MIN_NOTIONAL", "notional": formatFloat(st.filters.MinNotional)},
+			},
+		})
+	}
+	return http.StatusOK, map[string]interface{}{
+		"timezone":   "UTC",
+		"serverTime": time.Now().UnixMilli(),
+		"rateLimits": []map[string]interface{}{
+			{"rateLimitType": "REQUEST_WEIGHT", "interval": "MINUTE", "intervalNum": 1, "limit": 2400},
+			{"rateLimitType": "ORDERS", "interval": "MINUTE", "intervalNum": 1, "limit": 1200},
+			{"rateLimitType": "ORDERS", "interval": "SECOND", "intervalNum": 10, "limit": 300},
+		},
+		"symbols": symbols,
+	}
+}
+
+// handleKlines K线：返回SetKlines设置的数据，未设置时生成围绕最新价小幅波动、最后收盘价等于最新价的K线
+func (s *Server) handleKlines(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.symbol(params["symbol"])
+	if err != nil {
+		return err.status, err
+	}
+	d, ok := intervalDuration(params["interval"])
+	if !ok {
+		return http.StatusBadRequest, newAPIError(-1120, "Invalid interval.")
+	}
+	limit := 500
+	if v, err := strconv.Atoi(params["limit"]); err == nil && v > 0 {
+		limit = v
+	}
+
+	candles := st.klines[params["interval"]]
+	if candles == nil {
+		candles = generateKlines(st.price, d, limit)
+	}
+	if len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+
+	rows := make([][]interface{}, 0, len(candles))
+	for _, c := range candles {
+		rows = append(rows, []interface{}{
+			c.Time,
+			formatFloat(c.Open), formatFloat(c.High), formatFloat(c.Low), formatFloat(c.Close),
+			formatFloat(c.Volume),
+			c.Time + d.Milliseconds() - 1,
+			formatFloat(c.Volume * c.Close),
+			100,
+			formatFloat(c.TakerBuyVolume),
+			formatFloat(c.TakerBuyVolume * c.Close),
+			"0",
+		})
+	}
+	return http.StatusOK, rows
+}
+
+// generateKlines 生成limit根K线，最后一根为当前周期
+func generateKlines(price float64, d time.Duration, limit int) []types.OHLCV {
+	start := time.Now().Truncate(d).Add(-time.Duration(limit-1) * d)
+	candles := make([]types.OHLCV, 0, limit)
+	prev := price
+	for i := 0; i < limit; i++ {
+		closePrice := price * (1 + 0.002*math.Sin(float64(i)/3))
+		if i == limit-1 {
+			closePrice = price
+		}
+		candles = append(candles, types.OHLCV{
+			Time:           start.Add(time.Duration(i) * d).UnixMilli(),
+			Open:           prev,
+			High:           math.Max(prev, closePrice) * 1.001,
+			Low:            math.Min(prev, closePrice) * 0.999,
+			Close:          closePrice,
+			Volume:         100,
+			TakerBuyVolume: 50,
+		})
+		prev = closePrice
+	}
+	return candles
+}
+
+// intervalDuration 解析K线周期
+func intervalDuration(interval string) (time.Duration, bool) {
+	if len(interval) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	switch interval[len(interval)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute, true
+	case 'h':
+		return time.Duration(n) * time.Hour, true
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, true
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// handleTickerPrice 最新价（不带symbol时返回全部）
+func (s *Server) handleTickerPrice(params map[string]string) (int, interface{}) {
+	return s.perSymbol(params, func(name string, st *symbolState) map[string]interface{} {
+		return map[string]interface{}{
+			"symbol": name,
+			"price":  formatFloat(st.price),
+			"time":   time.Now().UnixMilli(),
+		}
+	})
+}
+
+// handlePremiumIndex 标记价格、指数价格和资金费率（不带symbol时返回全部）
+func (s *Server) handlePremiumIndex(params map[string]string) (int, interface{}) {
+	return s.perSymbol(params, func(name string, st *symbolState) map[string]interface{} {
+		index := st.indexPrice
+		if index == 0 {
+			index = st.price
+		}
+		return map[string]interface{}{
+			"symbol":               name,
+			"markPrice":            formatFloat(st.price),
+			"indexPrice":           formatFloat(index),
+			"estimatedSettlePrice": formatFloat(index),
+			"lastFundingRate":      formatFloat(st.fundingRate),
+			"interestRate":         "0.00010000",
+			"nextFundingTime":      st.nextFunding,
+			"time":                 time.Now().UnixMilli(),
+		}
+	})
+}
+
+// handleFundingRate 资金费率历史：按当前预测费率生成已结算的记录（按时间正序，默认100条）
+func (s *Server) handleFundingRate(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.symbol(params["symbol"])
+	if err != nil {
+		return err.status, err
+	}
+	limit := 100
+	if v, err := strconv.Atoi(params["limit"]); err == nil && v > 0 {
+		limit = v
+	}
+
+	records := make([]map[string]interface{}, 0, limit)
+	last := st.nextFunding - fundingInterval.Milliseconds()
+	for i := limit - 1; i >= 0; i-- {
+		records = append(records, map[string]interface{}{
+			"symbol":      params["symbol"],
+			"fundingTime": last - int64(i)*fundingInterval.Milliseconds(),
+			"fundingRate": formatFloat(st.fundingRate),
+			"markPrice":   formatFloat(st.price),
+		})
+	}
+	return http.StatusOK, records
+}
+
+// handleFuturesData 合约数据接口（多空比、主动买卖量、持仓量历史）：没有统计数据，返回空数组
+func (s *Server) handleFuturesData(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.symbol(params["symbol"]); err != nil {
+		return err.status, err
+	}
+	return http.StatusOK, []map[string]interface{}{}
+}
+
+// handleOpenInterest 持仓量
+func (s *Server) handleOpenInterest(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.symbol(params["symbol"])
+	if err != nil {
+		return err.status, err
+	}
+	return http.StatusOK, map[string]interface{}{
+		"symbol":       params["symbol"],
+		"openInterest": formatFloat(st.openInterest),
+		"time":         time.Now().UnixMilli(),
+	}
+}
+
+// handleDepth 深度快照：最新价两侧按tickSize逐档挂单，每档10
+func (s *Server) handleDepth(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.symbol(params["symbol"])
+	if err != nil {
+		return err.status, err
+	}
+	limit := 20
+	if v, err := strconv.Atoi(params["limit"]); err == nil && v > 0 {
+		limit = v
+	}
+
+	tick := st.filters.TickSize
+	if tick <= 0 {
+		tick = 0.01
+	}
+	bids := make([][]string, 0, limit)
+	asks := make([][]string, 0, limit)
+	for i := 0; i < limit; i++ {
+		bids = append(bids, []string{formatFloat(roundTo(st.price-tick*float64(i+1), tick)), "10"})
+		asks = append(asks, []string{formatFloat(roundTo(st.price+tick*float64(i+1), tick)), "10"})
+	}
+	now := time.Now().UnixMilli()
+	return http.StatusOK, map[string]interface{}{
+		"lastUpdateId": now,
+		"E":            now,
+		"T":            now,
+		"bids":         bids,
+		"asks":         asks,
+	}
+}
+
+// perSymbol 按symbol返回单条，不带symbol时返回全部交易对
+func (s *Server) perSymbol(params map[string]string, item func(name string, st *symbolState) map[string]interface{}) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name := params["symbol"]; name != "" {
+		st, err := s.symbol(name)
+		if err != nil {
+			return err.status, err
+		}
+		return http.StatusOK, item(name, st)
+	}
+	items := make([]map[string]interface{}, 0, len(s.symbols))
+	for name, st := range s.symbols {
+		items = append(items, item(name, st))
+	}
+	return http.StatusOK, items
+}
+
+// roundTo 按步长取整（消除浮点误差）
+func roundTo(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	r, _ := strconv.ParseFloat(fmt.Sprintf("%.8f", math.Round(v/step)*step), 64)
+	return r
+}
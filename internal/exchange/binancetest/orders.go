@@ -0,0 +1,667 @@
+package binancetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLeverage 未设置杠杆时的默认倍数
+const defaultLeverage = 20
+
+// Order 模拟的订单
+type Order struct {
+	OrderID       int64
+	ClientOrderID string
+	Symbol        string
+	Side          string // BUY, SELL
+	PositionSide  string // BOTH, LONG, SHORT
+	Type          string // MARKET, LIMIT, STOP_MARKET, TAKE_PROFIT_MARKET, STOP, TAKE_PROFIT
+	Status        string // NEW, FILLED, CANCELED, EXPIRED
+	TimeInForce   string
+	Quantity      float64
+	Price         float64
+	StopPrice     float64
+	ExecutedQty   float64
+	AvgPrice      float64
+	ReduceOnly    bool
+	Time          int64
+	UpdateTime    int64
+}
+
+// Position 模拟的持仓
+type Position struct {
+	Symbol       string
+	PositionSide string  // BOTH, LONG, SHORT
+	Amount       float64 // 同positionAmt：多头为正，空头为负
+	EntryPrice   float64
+	Leverage     int
+}
+
+// validOrderTypes 支持的订单类型
+var validOrderTypes = map[string]bool{
+	"MARKET":             true,
+	"LIMIT":              true,
+	"STOP_MARKET":        true,
+	"TAKE_PROFIT_MARKET": true,
+	"STOP":               true,
+	"TAKE_PROFIT":        true,
+}
+
+// handlePlaceOrder 下单
+func (s *Server) handlePlaceOrder(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.placeOrder(params)
+	if err != nil {
+		return err.status, err
+	}
+	return http.StatusOK, orderJSON(order)
+}
+
+// handleBatchOrders 批量下单：逐笔处理，失败的订单在对应位置返回{"code","msg"}
+func (s *Server) handleBatchOrders(params map[string]string) (int, interface{}) {
+	var batch []map[string]string
+	if err := json.Unmarshal([]byte(params["batchOrders"]), &batch); err != nil || len(batch) == 0 {
+		err := newAPIError(CodeMandatoryParam, "Mandatory parameter 'batchOrders' was not sent, was empty/null, or malformed.")
+		return err.status, err
+	}
+	if len(batch) > 5 {
+		err := newAPIError(-1130, "Data sent for parameter 'batchOrders' is not valid.")
+		return err.status, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]interface{}, 0, len(batch))
+	for _, item := range batch {
+		order, err := s.placeOrder(item)
+		if err != nil {
+			results = append(results, err)
+			continue
+		}
+		results = append(results, orderJSON(order))
+	}
+	return http.StatusOK, results
+}
+
+// placeOrder 校验并创建订单，市价单和可成交的限价单立即成交（调用方持有锁）
+func (s *Server) placeOrder(params map[string]string) (*Order, *apiError) {
+	symbol := params["symbol"]
+	st, err := s.symbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	side := strings.ToUpper(params["side"])
+	if side != "BUY" && side != "SELL" {
+		return nil, newAPIError(CodeInvalidSide, "Invalid side.")
+	}
+	orderType := strings.ToUpper(params["type"])
+	if !validOrderTypes[orderType] {
+		return nil, newAPIError(CodeInvalidOrderType, "Invalid orderType.")
+	}
+
+	qty := parseFloat(params["quantity"])
+	if qty <= 0 {
+		return nil, newAPIError(CodeMandatoryParam, "Mandatory parameter 'quantity' was not sent, was empty/null, or malformed.")
+	}
+	if !onStep(qty, st.filters.StepSize) {
+		return nil, newAPIError(CodePrecision, "Precision is over the maximum defined for this asset.")
+	}
+	if qty < st.filters.MinQty {
+		return nil, newAPIError(CodeFilterFailure, "Filter failure: LOT_SIZE")
+	}
+
+	price := parseFloat(params["price"])
+	stopPrice := parseFloat(params["stopPrice"])
+	needsPrice := orderType == "LIMIT" || orderType == "STOP" || orderType == "TAKE_PROFIT"
+	needsStop := orderType != "MARKET" && orderType != "LIMIT"
+	if needsPrice && price <= 0 {
+		return nil, newAPIError(CodeMandatoryParam, "Mandatory parameter 'price' was not sent, was empty/null, or malformed.")
+	}
+	if needsStop && stopPrice <= 0 {
+		return nil, newAPIError(CodeMandatoryParam, "Mandatory parameter 'stopPrice' was not sent, was empty/null, or malformed.")
+	}
+	for _, p := range []float64{price, stopPrice} {
+		if p > 0 && !onStep(p, st.filters.TickSize) {
+			return nil, newAPIError(CodePrecision, "Precision is over the maximum defined for this asset.")
+		}
+	}
+
+	refPrice := st.price
+	if price > 0 {
+		refPrice = price
+	}
+	reduceOnly := strings.EqualFold(params["reduceOnly"], "true")
+	if !reduceOnly && qty*refPrice < st.filters.MinNotional {
+		return nil, newAPIError(CodeMinNotional, fmt.Sprintf("Order's notional must be no smaller than %s (unless you choose reduce only).", formatFloat(st.filters.MinNotional)))
+	}
+
+	positionSide := strings.ToUpper(params["positionSide"])
+	if positionSide == "" {
+		positionSide = "BOTH"
+	}
+	if (positionSide == "BOTH") == s.dualSide {
+		return nil, newAPIError(CodePositionSide, "Order's position side does not match user's setting.")
+	}
+
+	clientID := params["newClientOrderId"]
+	if clientID != "" {
+		for _, o := range s.orders {
+			if o.ClientOrderID == clientID && o.Status == "NEW" {
+				return nil, newAPIError(CodeDuplicateClientID, "ClientOrderId is duplicated.")
+			}
+		}
+	} else {
+		clientID = fmt.Sprintf("fake_%d", s.nextID+1)
+	}
+
+	// 平仓单：双向持仓下卖出LONG/买入SHORT即为平仓
+	closing := reduceOnly || (positionSide == "LONG" && side == "SELL") || (positionSide == "SHORT" && side == "BUY")
+	if closing && s.closableQty(symbol, side, positionSide) <= 0 {
+		return nil, newAPIError(CodeReduceOnlyReject, "ReduceOnly Order is rejected.")
+	}
+
+	if needsStop && triggered(orderType, side, stopPrice, st.price) {
+		return nil, newAPIError(CodeWouldTrigger, "Order would immediately trigger.")
+	}
+
+	if !closing && (orderType == "MARKET" || orderType == "LIMIT") {
+		margin := qty * refPrice / float64(s.leverageOf(symbol))
+		if margin > s.availableBalance() {
+			return nil, newAPIError(CodeMarginInsufficient, "Margin is insufficient.")
+		}
+	}
+
+	s.nextID++
+	now := time.Now().UnixMilli()
+	order := &Order{
+		OrderID:       s.nextID,
+		ClientOrderID: clientID,
+		Symbol:        symbol,
+		Side:          side,
+		PositionSide:  positionSide,
+		Type:          orderType,
+		Status:        "NEW",
+		TimeInForce:   params["timeInForce"],
+		Quantity:      qty,
+		Price:         price,
+		StopPrice:     stopPrice,
+		ReduceOnly:    reduceOnly,
+		Time:          now,
+		UpdateTime:    now,
+	}
+	if order.TimeInForce == "" {
+		order.TimeInForce = "GTC"
+	}
+	s.orders = append(s.orders, order)
+
+	switch {
+	case orderType == "MARKET":
+		s.fill(order, st.price)
+	case orderType == "LIMIT" && marketable(side, price, st.price):
+		s.fill(order, price)
+	}
+	return order, nil
+}
+
+// handleQueryOrder 按orderId或origClientOrderId查询订单
+func (s *Server) handleQueryOrder(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.findOrder(params)
+	if err != nil {
+		return err.status, err
+	}
+	return http.StatusOK, orderJSON(order)
+}
+
+// handleCancelOrder 撤单（只能撤销NEW状态的订单）
+func (s *Server) handleCancelOrder(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.findOrder(params)
+	if err != nil {
+		if err.Code == CodeNoSuchOrder {
+			err = newAPIError(CodeCancelRejected, "Unknown order sent.")
+		}
+		return err.status, err
+	}
+	if order.Status != "NEW" {
+		err := newAPIError(CodeCancelRejected, "Unknown order sent.")
+		return err.status, err
+	}
+	order.Status = "CANCELED"
+	order.UpdateTime = time.Now().UnixMilli()
+	return http.StatusOK, orderJSON(order)
+}
+
+// handleOpenOrders 当前挂单（不带symbol时返回全部）
+func (s *Server) handleOpenOrders(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]map[string]interface{}, 0)
+	for _, o := range s.openOrders(params["symbol"]) {
+		result = append(result, orderJSON(o))
+	}
+	return http.StatusOK, result
+}
+
+// findOrder 按orderId或origClientOrderId查找订单（调用方持有锁）
+func (s *Server) findOrder(params map[string]string) (*Order, *apiError) {
+	orderID, _ := strconv.ParseInt(params["orderId"], 10, 64)
+	clientID := params["origClientOrderId"]
+	if orderID == 0 && clientID == "" {
+		return nil, newAPIError(CodeMandatoryParam, "Either orderId or origClientOrderId must be sent.")
+	}
+	// 同一客户端订单ID可能对应多笔历史订单，取最新的一笔
+	for i := len(s.orders) - 1; i >= 0; i-- {
+		o := s.orders[i]
+		if o.Symbol != params["symbol"] {
+			continue
+		}
+		if (orderID != 0 && o.OrderID == orderID) || (orderID == 0 && o.ClientOrderID == clientID) {
+			return o, nil
+		}
+	}
+	return nil, newAPIError(CodeNoSuchOrder, "Order does not exist.")
+}
+
+// openOrders 指定交易对的NEW状态订单，symbol为空时返回全部（调用方持有锁）
+func (s *Server) openOrders(symbol string) []*Order {
+	result := make([]*Order, 0)
+	for _, o := range s.orders {
+		if o.Status == "NEW" && (symbol == "" || o.Symbol == symbol) {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// triggerOrders 价格变化后触发条件单、成交穿价的限价单（调用方持有锁）
+func (s *Server) triggerOrders(symbol string, price float64) {
+	for _, o := range s.orders {
+		if o.Symbol != symbol || o.Status != "NEW" {
+			continue
+		}
+		switch o.Type {
+		case "LIMIT":
+			if marketable(o.Side, o.Price, price) {
+				s.fill(o, o.Price)
+			}
+		case "STOP_MARKET", "TAKE_PROFIT_MARKET", "STOP", "TAKE_PROFIT":
+			if !triggered(o.Type, o.Side, o.StopPrice, price) {
+				continue
+			}
+			// 只减仓的条件单触发时已无持仓则过期
+			if o.ReduceOnly && s.closableQty(o.Symbol, o.Side, o.PositionSide) <= 0 {
+				o.Status = "EXPIRED"
+				o.UpdateTime = time.Now().UnixMilli()
+				continue
+			}
+			fillPrice := price
+			if o.Type == "STOP" || o.Type == "TAKE_PROFIT" {
+				fillPrice = o.Price
+			}
+			s.fill(o, fillPrice)
+		}
+	}
+}
+
+// fill 订单全部成交并更新持仓和余额（调用方持有锁）
+func (s *Server) fill(o *Order, price float64) {
+	qty := o.Quantity
+	closing := o.ReduceOnly || (o.PositionSide == "LONG" && o.Side == "SELL") || (o.PositionSide == "SHORT" && o.Side == "BUY")
+	if closing {
+		// 平仓数量不超过持仓
+		qty = math.Min(qty, s.closableQty(o.Symbol, o.Side, o.PositionSide))
+	}
+
+	delta := qty
+	if o.Side == "SELL" {
+		delta = -qty
+	}
+
+	key := o.Symbol + ":" + o.PositionSide
+	pos := s.positions[key]
+	if pos == nil {
+		pos = &Position{Symbol: o.Symbol, PositionSide: o.PositionSide}
+		s.positions[key] = pos
+	}
+	pos.Leverage = s.leverageOf(o.Symbol)
+
+	switch {
+	case pos.Amount == 0 || (pos.Amount > 0) == (delta > 0):
+		// 开仓或加仓：加权平均开仓价
+		total := math.Abs(pos.Amount) + math.Abs(delta)
+		pos.EntryPrice = (pos.EntryPrice*math.Abs(pos.Amount) + price*math.Abs(delta)) / total
+		pos.Amount += delta
+	default:
+		// 减仓：已实现盈亏计入余额，超出部分反向开仓（单向持仓）
+		closed := math.Min(math.Abs(delta), math.Abs(pos.Amount))
+		direction := 1.0
+		if pos.Amount < 0 {
+			direction = -1
+		}
+		s.wallet += (price - pos.EntryPrice) * closed * direction
+		pos.Amount += delta
+		switch {
+		case math.Abs(pos.Amount) < 1e-12:
+			pos.Amount = 0
+			pos.EntryPrice = 0
+		case (pos.Amount > 0) != (direction > 0):
+			pos.EntryPrice = price
+		}
+	}
+	if pos.Amount == 0 {
+		delete(s.positions, key)
+	}
+
+	o.Status = "FILLED"
+	o.ExecutedQty = qty
+	o.AvgPrice = price
+	o.UpdateTime = time.Now().UnixMilli()
+}
+
+// closableQty 订单可平仓数量（调用方持有锁）
+func (s *Server) closableQty(symbol, side, positionSide string) float64 {
+	pos := s.positions[symbol+":"+positionSide]
+	if pos == nil {
+		return 0
+	}
+	// 卖出平多头、买入平空头
+	if (side == "SELL" && pos.Amount > 0) || (side == "BUY" && pos.Amount < 0) {
+		return math.Abs(pos.Amount)
+	}
+	return 0
+}
+
+// availableBalance 可用余额：钱包余额加未实现盈亏减去占用保证金（调用方持有锁）
+func (s *Server) availableBalance() float64 {
+	available := s.wallet
+	for _, p := range s.positions {
+		st := s.symbols[p.Symbol]
+		if st == nil {
+			continue
+		}
+		available += (st.price - p.EntryPrice) * p.Amount
+		available -= math.Abs(p.Amount) * st.price / float64(s.leverageOf(p.Symbol))
+	}
+	return available
+}
+
+// leverageOf 交易对杠杆倍数（调用方持有锁）
+func (s *Server) leverageOf(symbol string) int {
+	if lev := s.leverage[symbol]; lev > 0 {
+		return lev
+	}
+	return defaultLeverage
+}
+
+// handlePositionRisk 持仓（只返回非空仓位）
+func (s *Server) handlePositionRisk(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]map[string]interface{}, 0, len(s.positions))
+	for _, p := range s.positions {
+		if params["symbol"] != "" && p.Symbol != params["symbol"] {
+			continue
+		}
+		mark := s.symbols[p.Symbol].price
+		marginType := strings.ToLower(s.marginType[p.Symbol])
+		if marginType == "" || marginType == "crossed" {
+			marginType = "cross"
+		}
+		result = append(result, map[string]interface{}{
+			"symbol":           p.Symbol,
+			"positionAmt":      formatFloat(p.Amount),
+			"entryPrice":       formatFloat(p.EntryPrice),
+			"markPrice":        formatFloat(mark),
+			"unRealizedProfit": formatFloat((mark - p.EntryPrice) * p.Amount),
+			"leverage":         strconv.Itoa(s.leverageOf(p.Symbol)),
+			"positionSide":     p.PositionSide,
+			"marginType":       marginType,
+			"updateTime":       time.Now().UnixMilli(),
+		})
+	}
+	return http.StatusOK, result
+}
+
+// handleBalance USDT余额
+func (s *Server) handleBalance(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return http.StatusOK, []map[string]interface{}{{
+		"asset":              "USDT",
+		"balance":            formatFloat(s.wallet),
+		"availableBalance":   formatFloat(s.availableBalance()),
+		"crossWalletBalance": formatFloat(s.wallet),
+		"updateTime":         time.Now().UnixMilli(),
+	}}
+}
+
+// handleLeverage 设置杠杆（1-125）
+func (s *Server) handleLeverage(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbol := params["symbol"]
+	if _, err := s.symbol(symbol); err != nil {
+		return err.status, err
+	}
+	lev, err := strconv.Atoi(params["leverage"])
+	if err != nil || lev < 1 || lev > 125 {
+		apiErr := newAPIError(CodeInvalidLeverage, "Leverage is not valid")
+		return apiErr.status, apiErr
+	}
+	s.leverage[symbol] = lev
+	return http.StatusOK, map[string]interface{}{
+		"symbol":           symbol,
+		"leverage":         lev,
+		"maxNotionalValue": "1000000",
+	}
+}
+
+// handleMarginType 设置保证金模式：未变化返回-4046，有持仓时返回-4048
+func (s *Server) handleMarginType(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbol := params["symbol"]
+	if _, err := s.symbol(symbol); err != nil {
+		return err.status, err
+	}
+	marginType := strings.ToUpper(params["marginType"])
+	if marginType != "ISOLATED" && marginType != "CROSSED" {
+		err := newAPIError(-1130, "Data sent for parameter 'marginType' is not valid.")
+		return err.status, err
+	}
+	current := s.marginType[symbol]
+	if current == "" {
+		current = "CROSSED"
+	}
+	if current == marginType {
+		err := newAPIError(CodeNoNeedChangeMargin, "No need to change margin type.")
+		return err.status, err
+	}
+	for _, p := range s.positions {
+		if p.Symbol == symbol {
+			err := newAPIError(CodeMarginTypeWithPos, "Margin type cannot be changed if there exists position.")
+			return err.status, err
+		}
+	}
+	s.marginType[symbol] = marginType
+	return http.StatusOK, map[string]interface{}{"code": 200, "msg": "success"}
+}
+
+// handlePositionMode 查询持仓模式
+func (s *Server) handlePositionMode(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return http.StatusOK, map[string]interface{}{"dualSidePosition": s.dualSide}
+}
+
+// handleNewListenKey 申请listenKey（已有有效listenKey时返回同一个）
+func (s *Server) handleNewListenKey(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listenKey == "" {
+		s.listenKey = fmt.Sprintf("fakeListenKey%d", time.Now().UnixNano())
+	}
+	return http.StatusOK, map[string]interface{}{"listenKey": s.listenKey}
+}
+
+// handleKeepaliveListenKey 续期listenKey，不存在时返回-1125
+func (s *Server) handleKeepaliveListenKey(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listenKey == "" {
+		apiErr := newAPIError(CodeListenKeyNotExist, "This listenKey does not exist.")
+		return apiErr.status, apiErr
+	}
+	return http.StatusOK, map[string]interface{}{"listenKey": s.listenKey}
+}
+
+// handleCloseListenKey 关闭listenKey
+func (s *Server) handleCloseListenKey(params map[string]string) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listenKey = ""
+	return http.StatusOK, map[string]interface{}{}
+}
+
+// SetBalance 设置USDT钱包余额
+func (s *Server) SetBalance(balance float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wallet = balance
+}
+
+// SetDualSidePosition 设置持仓模式（true为双向持仓）
+func (s *Server) SetDualSidePosition(dual bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dualSide = dual
+}
+
+// Orders 交易对的全部订单（按创建顺序，返回副本），symbol为空时返回全部
+func (s *Server) Orders(symbol string) []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Order, 0)
+	for _, o := range s.orders {
+		if symbol == "" || o.Symbol == symbol {
+			result = append(result, *o)
+		}
+	}
+	return result
+}
+
+// OpenOrders 交易对的NEW状态订单（返回副本），symbol为空时返回全部
+func (s *Server) OpenOrders(symbol string) []Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Order, 0)
+	for _, o := range s.openOrders(symbol) {
+		result = append(result, *o)
+	}
+	return result
+}
+
+// Positions 当前非空持仓（返回副本）
+func (s *Server) Positions() []Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Position, 0, len(s.positions))
+	for _, p := range s.positions {
+		result = append(result, *p)
+	}
+	return result
+}
+
+// Balance USDT钱包余额（含已实现盈亏）
+func (s *Server) Balance() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wallet
+}
+
+// Leverage 交易对杠杆倍数
+func (s *Server) Leverage(symbol string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leverageOf(symbol)
+}
+
+// orderJSON 订单响应
+func orderJSON(o *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"orderId":       o.OrderID,
+		"clientOrderId": o.ClientOrderID,
+		"symbol":        o.Symbol,
+		"side":          o.Side,
+		"positionSide":  o.PositionSide,
+		"type":          o.Type,
+		"origType":      o.Type,
+		"status":        o.Status,
+		"timeInForce":   o.TimeInForce,
+		"origQty":       formatFloat(o.Quantity),
+		"price":         formatFloat(o.Price),
+		"stopPrice":     formatFloat(o.StopPrice),
+		"executedQty":   formatFloat(o.ExecutedQty),
+		"cumQuote":      formatFloat(o.ExecutedQty * o.AvgPrice),
+		"avgPrice":      formatFloat(o.AvgPrice),
+		"reduceOnly":    o.ReduceOnly,
+		"time":          o.Time,
+		"updateTime":    o.UpdateTime,
+	}
+}
+
+// triggered 条件单是否触发：止损单价格朝不利方向突破，止盈单朝有利方向突破
+func triggered(orderType, side string, stopPrice, price float64) bool {
+	switch orderType {
+	case "STOP_MARKET", "STOP":
+		if side == "BUY" {
+			return price >= stopPrice
+		}
+		return price <= stopPrice
+	case "TAKE_PROFIT_MARKET", "TAKE_PROFIT":
+		if side == "BUY" {
+			return price <= stopPrice
+		}
+		return price >= stopPrice
+	}
+	return false
+}
+
+// marketable 限价单在当前价是否可成交
+func marketable(side string, limit, price float64) bool {
+	if side == "BUY" {
+		return price <= limit
+	}
+	return price >= limit
+}
+
+// onStep 数值是否为步长整数倍（允许浮点误差）
+func onStep(v, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	n := v / step
+	return math.Abs(n-math.Round(n)) < 1e-6
+}
@@ -0,0 +1,336 @@
+// Package binancetest 进程内模拟的Binance U本位合约API，用于离线集成测试
+//
+// Server基于httptest实现行情接口（K线、最新价、标记价格、持仓量、深度、exchangeInfo、资金费率历史，
+// 合约数据接口/futures/data/*返回空数组）、用户数据流listenKey的申请/续期/关闭（不提供WebSocket推送）
+// 和签名接口（下单/查单/撤单、批量下单、挂单、持仓、余额、杠杆、保证金模式、持仓模式），
+// 校验API Key、HMAC签名和recvWindow，在内存中维护订单、持仓和余额；
+// 通过SetPrice推动价格触发条件单，通过Inject按接口注入限流、业务错误和超时。
+//
+// 使用方式：
+//
+//	srv := binancetest.NewServer()
+//	defer srv.Close()
+//	srv.AddSymbol("BTCUSDT", 50000)
+//	for k, v := range srv.Env() {
+//		t.Setenv(k, v)
+//	}
+//	config.Load()
+//	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(srv.URL, 5*time.Second))
+package binancetest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 默认API密钥（Env返回，可在NewServer之后修改）
+const (
+	DefaultAPIKey    = "fake-api-key"
+	DefaultSecretKey = "fake-secret-key"
+)
+
+// defaultRecvWindowMs 请求未携带recvWindow时的默认值
+const defaultRecvWindowMs = 5000
+
+// Binance错误码
+const (
+	CodeTooManyRequests    = -1003
+	CodeTimestamp          = -1021
+	CodeInvalidSignature   = -1022
+	CodeMandatoryParam     = -1102
+	CodeInvalidOrderType   = -1116
+	CodeInvalidSide        = -1117
+	CodeInvalidSymbol      = -1121
+	CodeListenKeyNotExist  = -1125
+	CodeFilterFailure      = -1013
+	CodePrecision          = -1111
+	CodeCancelRejected     = -2011
+	CodeNoSuchOrder        = -2013
+	CodeRejectedAPIKey     = -2015
+	CodeMarginInsufficient = -2019
+	CodeWouldTrigger       = -2021
+	CodeReduceOnlyReject   = -2022
+	CodeInvalidLeverage    = -4028
+	CodeNoNeedChangeMargin = -4046
+	CodeMarginTypeWithPos  = -4048
+	CodePositionSide       = -4061
+	CodeDuplicateClientID  = -4116
+	CodeMinNotional        = -4164
+)
+
+// Filters 交易对下单规则
+type Filters struct {
+	TickSize    float64
+	StepSize    float64
+	MinQty      float64
+	MinNotional float64
+}
+
+// DefaultFilters AddSymbol使用的下单规则
+var DefaultFilters = Filters{TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MinNotional: 5}
+
+// symbolState 交易对行情状态
+type symbolState struct {
+	price        float64
+	indexPrice   float64 // 为0时等于price
+	fundingRate  float64
+	nextFunding  int64
+	openInterest float64
+	filters      Filters
+	klines       map[string][]types.OHLCV
+}
+
+// Server 模拟的Binance U本位合约API
+type Server struct {
+	*httptest.Server
+
+	APIKey    string
+	SecretKey string
+
+	mu         sync.Mutex
+	symbols    map[string]*symbolState
+	orders     []*Order
+	nextID     int64
+	positions  map[string]*Position // key为symbol:positionSide
+	wallet     float64
+	leverage   map[string]int
+	marginType map[string]string
+	dualSide   bool
+	listenKey  string
+	faults     []*faultState
+	calls      map[string]int // key为"METHOD path"
+}
+
+// route 接口处理函数，返回HTTP状态码和响应体
+type route struct {
+	signed  bool
+	keyed   bool // 只校验API Key（listenKey接口）
+	handler func(params map[string]string) (int, interface{})
+}
+
+// apiError Binance错误响应
+type apiError struct {
+	status int
+	Code   int    `json:"code"`
+	Msg    string `json:"msg"`
+}
+
+// newAPIError 业务错误（HTTP 400）
+func newAPIError(code int, msg string) *apiError {
+	return &apiError{status: http.StatusBadRequest, Code: code, Msg: msg}
+}
+
+// NewServer 启动模拟服务器：双向持仓，USDT余额10000，没有交易对（通过AddSymbol添加）
+func NewServer() *Server {
+	s := &Server{
+		APIKey:     DefaultAPIKey,
+		SecretKey:  DefaultSecretKey,
+		symbols:    make(map[string]*symbolState),
+		positions:  make(map[string]*Position),
+		wallet:     10000,
+		leverage:   make(map[string]int),
+		marginType: make(map[string]string),
+		dualSide:   true,
+		calls:      make(map[string]int),
+		nextID:     1000,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Env 指向本服务器的环境变量（实盘模式），供t.Setenv后config.Load使用
+func (s *Server) Env() map[string]string {
+	return map[string]string{
+		"EXCHANGE":              "binance",
+		"DRY_RUN":               "false",
+		"BINANCE_TESTNET":       "false",
+		"BINANCE_FAPI_BASE_URL": s.URL,
+		"BINANCE_API_KEY":       s.APIKey,
+		"BINANCE_SECRET_KEY":    s.SecretKey,
+	}
+}
+
+// routes 接口路由表
+func (s *Server) routes() map[string]route {
+	return map[string]route{
+		"GET /fapi/v1/time":         {handler: s.handleTime},
+		"GET /fapi/v1/exchangeInfo": {handler: s.handleExchangeInfo},
+		"GET /fapi/v1/klines":       {handler: s.handleKlines},
+		"GET /fapi/v1/ticker/price": {handler: s.handleTickerPrice},
+		"GET /fapi/v1/premiumIndex": {handler: s.handlePremiumIndex},
+		"GET /fapi/v1/openInterest": {handler: s.handleOpenInterest},
+		"GET /fapi/v1/depth":        {handler: s.handleDepth},
+		"GET /fapi/v1/fundingRate":  {handler: s.handleFundingRate},
+
+		"GET /futures/data/openInterestHist":            {handler: s.handleFuturesData},
+		"GET /futures/data/globalLongShortAccountRatio": {handler: s.handleFuturesData},
+		"GET /futures/data/topLongShortPositionRatio":   {handler: s.handleFuturesData},
+		"GET /futures/data/takerlongshortRatio":         {handler: s.handleFuturesData},
+
+		"POST /fapi/v1/listenKey":   {keyed: true, handler: s.handleNewListenKey},
+		"PUT /fapi/v1/listenKey":    {keyed: true, handler: s.handleKeepaliveListenKey},
+		"DELETE /fapi/v1/listenKey": {keyed: true, handler: s.handleCloseListenKey},
+
+		"POST /fapi/v1/order":            {signed: true, handler: s.handlePlaceOrder},
+		"GET /fapi/v1/order":             {signed: true, handler: s.handleQueryOrder},
+		"DELETE /fapi/v1/order":          {signed: true, handler: s.handleCancelOrder},
+		"POST /fapi/v1/batchOrders":      {signed: true, handler: s.handleBatchOrders},
+		"GET /fapi/v1/openOrders":        {signed: true, handler: s.handleOpenOrders},
+		"GET /fapi/v2/positionRisk":      {signed: true, handler: s.handlePositionRisk},
+		"GET /fapi/v2/balance":           {signed: true, handler: s.handleBalance},
+		"POST /fapi/v1/leverage":         {signed: true, handler: s.handleLeverage},
+		"POST /fapi/v1/marginType":       {signed: true, handler: s.handleMarginType},
+		"GET /fapi/v1/positionSide/dual": {signed: true, handler: s.handlePositionMode},
+	}
+}
+
+// serveHTTP 记录调用、匹配故障、校验签名后分发到接口
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	s.mu.Lock()
+	s.calls[key]++
+	s.mu.Unlock()
+
+	rt, ok := s.routes()[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, newAPIError(CodeMandatoryParam, err.Error()))
+		return
+	}
+	params := make(map[string]string, len(r.Form))
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+
+	fault := s.takeFault(r.Method, r.URL.Path)
+
+	// 未受理的故障：等待后直接返回错误
+	if fault != nil && !fault.Apply {
+		if !wait(r, fault.Delay) {
+			return
+		}
+		if fault.Status != 0 {
+			writeFault(w, fault)
+			return
+		}
+	}
+
+	if rt.signed {
+		if err := s.verifySignature(r, params); err != nil {
+			writeJSON(w, err.status, err)
+			return
+		}
+	} else if rt.keyed {
+		if err := s.verifyAPIKey(r); err != nil {
+			writeJSON(w, err.status, err)
+			return
+		}
+	}
+
+	status, body := rt.handler(params)
+
+	// 已受理的故障：请求已生效，但响应延迟或替换为错误（模拟响应丢失）
+	if fault != nil && fault.Apply {
+		if !wait(r, fault.Delay) {
+			return
+		}
+		if fault.Status != 0 {
+			writeFault(w, fault)
+			return
+		}
+	}
+	writeJSON(w, status, body)
+}
+
+// verifyAPIKey 校验请求头中的API Key
+func (s *Server) verifyAPIKey(r *http.Request) *apiError {
+	if r.Header.Get("X-MBX-APIKEY") != s.APIKey {
+		return &apiError{status: http.StatusUnauthorized, Code: CodeRejectedAPIKey, Msg: "Invalid API-key, IP, or permissions for action."}
+	}
+	return nil
+}
+
+// verifySignature 校验API Key、签名和时间戳
+func (s *Server) verifySignature(r *http.Request, params map[string]string) *apiError {
+	if err := s.verifyAPIKey(r); err != nil {
+		return err
+	}
+
+	raw := r.URL.RawQuery
+	idx := strings.LastIndex(raw, "&signature=")
+	if idx < 0 {
+		return newAPIError(CodeInvalidSignature, "Signature for this request is not valid.")
+	}
+	mac := hmac.New(sha256.New, []byte(s.SecretKey))
+	mac.Write([]byte(raw[:idx]))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(raw[idx+len("&signature="):])) {
+		return newAPIError(CodeInvalidSignature, "Signature for this request is not valid.")
+	}
+
+	ts, err := strconv.ParseInt(params["timestamp"], 10, 64)
+	if err != nil {
+		return newAPIError(CodeMandatoryParam, "Mandatory parameter 'timestamp' was not sent, was empty/null, or malformed.")
+	}
+	recvWindow := int64(defaultRecvWindowMs)
+	if v, err := strconv.ParseInt(params["recvWindow"], 10, 64); err == nil && v > 0 {
+		recvWindow = v
+	}
+	now := time.Now().UnixMilli()
+	if ts > now+1000 || now-ts > recvWindow {
+		return newAPIError(CodeTimestamp, "Timestamp for this request is outside of the recvWindow.")
+	}
+	return nil
+}
+
+// Calls 接口被调用的次数（包括被注入故障的请求）
+func (s *Server) Calls(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method+" "+path]
+}
+
+// wait 等待d后返回true；客户端先断开（超时或取消）时返回false
+func wait(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// formatFloat 按Binance格式输出数值字符串
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseFloat 解析数值参数（为空或格式错误时返回0）
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,444 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const (
+	bybitCategory      = "linear"
+	bybitSettleCoin    = "USDT"
+	bybitMaxCandles    = 1000 // /v5/market/kline 单次最多返回1000根
+	bybitMaxBookDepth  = 500  // /v5/market/orderbook linear单侧最多500档
+	bybitMaxFunding    = 200  // /v5/market/funding/history 单次最多返回200条
+	bybitBackoffTarget = "bybit"
+	bybitRetCodeLimit  = 10006 // 请求过于频繁
+)
+
+// BybitExchange Bybit v5 USDT永续合约（linear）实现
+type BybitExchange struct {
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	recvWindow int
+
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+}
+
+var globalBybitExchange *BybitExchange
+
+// GetBybitExchange 获取Bybit交易所实例（单例）
+func GetBybitExchange() *BybitExchange {
+	if globalBybitExchange == nil {
+		cfg := config.Get()
+		globalBybitExchange = NewBybitExchange(cfg.BybitBaseURL, cfg.BybitAPIKey, cfg.BybitSecretKey)
+		if cfg.BybitRecvWindow > 0 {
+			globalBybitExchange.recvWindow = cfg.BybitRecvWindow
+		}
+	}
+	return globalBybitExchange
+}
+
+// NewBybitExchange 创建Bybit交易所
+func NewBybitExchange(baseURL, apiKey, secretKey string) *BybitExchange {
+	return &BybitExchange{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		secretKey:   secretKey,
+		recvWindow:  5000,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		rateLimiter: NewRateLimiter(10.0, 20),
+	}
+}
+
+// bybitInterval K线周期转换（分钟数或D/W/M）
+func bybitInterval(timeframe string) (string, error) {
+	switch timeframe {
+	case "1m", "3m", "5m", "15m", "30m":
+		return strings.TrimSuffix(timeframe, "m"), nil
+	case "1h", "2h", "4h", "6h", "12h":
+		hours, _ := strconv.Atoi(strings.TrimSuffix(timeframe, "h"))
+		return strconv.Itoa(hours * 60), nil
+	case "1d":
+		return "D", nil
+	case "1w":
+		return "W", nil
+	case "1M":
+		return "M", nil
+	}
+	return "", fmt.Errorf("unsupported timeframe: %s", timeframe)
+}
+
+// GetOHLCV 获取K线（Bybit返回倒序，这里转换为时间正序）
+func (bb *BybitExchange) GetOHLCV(symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	return bb.GetOHLCVContext(context.Background(), symbol, timeframe, limit)
+}
+
+// GetOHLCVContext 同GetOHLCV，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	interval, err := bybitInterval(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > bybitMaxCandles {
+		limit = bybitMaxCandles
+	}
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   normalizeUSDTSymbol(symbol),
+		"interval": interval,
+		"limit":    strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/market/kline", params, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OHLCV: %w", err)
+	}
+
+	list, _ := result["list"].([]interface{})
+	candles := make([]types.OHLCV, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		k, ok := list[i].([]interface{})
+		if !ok || len(k) < 6 {
+			continue
+		}
+		ts, err := parseFloatValue(k[0])
+		if err != nil {
+			continue
+		}
+		candle := types.OHLCV{Time: int64(ts)}
+		candle.Open, _ = parseFloatValue(k[1])
+		candle.High, _ = parseFloatValue(k[2])
+		candle.Low, _ = parseFloatValue(k[3])
+		candle.Close, _ = parseFloatValue(k[4])
+		candle.Volume, _ = parseFloatValue(k[5])
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+// GetTickerPrice 获取最新成交价
+func (bb *BybitExchange) GetTickerPrice(symbol string) (float64, error) {
+	return bb.GetTickerPriceContext(context.Background(), symbol)
+}
+
+// GetTickerPriceContext 同GetTickerPrice，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetTickerPriceContext(ctx context.Context, symbol string) (float64, error) {
+	ticker, err := bb.ticker(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ticker price: %w", err)
+	}
+	return parseNumericField(ticker, "lastPrice")
+}
+
+// GetFundingRate 获取当前资金费率
+func (bb *BybitExchange) GetFundingRate(symbol string) (float64, error) {
+	return bb.GetFundingRateContext(context.Background(), symbol)
+}
+
+// GetFundingRateContext 同GetFundingRate，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetFundingRateContext(ctx context.Context, symbol string) (float64, error) {
+	ticker, err := bb.ticker(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get funding rate: %w", err)
+	}
+	return parseNumericField(ticker, "fundingRate")
+}
+
+// GetFundingHistory 获取最近limit次资金费率结算（Bybit返回倒序，这里转换为时间正序）
+func (bb *BybitExchange) GetFundingHistory(symbol string, limit int) ([]types.FundingRateRecord, error) {
+	return bb.GetFundingHistoryContext(context.Background(), symbol, limit)
+}
+
+// GetFundingHistoryContext 同GetFundingHistory，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetFundingHistoryContext(ctx context.Context, symbol string, limit int) ([]types.FundingRateRecord, error) {
+	if limit <= 0 || limit > bybitMaxFunding {
+		limit = bybitMaxFunding
+	}
+	symbol = normalizeUSDTSymbol(symbol)
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   symbol,
+		"limit":    strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/market/funding/history", params, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding history: %w", err)
+	}
+
+	list, _ := result["list"].([]interface{})
+	records := make([]types.FundingRateRecord, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		item, ok := list[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rate, err := parseNumericField(item, "fundingRate")
+		if err != nil {
+			continue
+		}
+		fundingTime, _ := parseFloatValue(item["fundingRateTimestamp"])
+		records = append(records, types.FundingRateRecord{
+			Symbol:      symbol,
+			FundingRate: rate,
+			FundingTime: int64(fundingTime),
+		})
+	}
+	return records, nil
+}
+
+// GetPremiumIndex 获取标记价格、指数价格和预测资金费率（均来自ticker）
+func (bb *BybitExchange) GetPremiumIndex(symbol string) (*types.PremiumIndex, error) {
+	return bb.GetPremiumIndexContext(context.Background(), symbol)
+}
+
+// GetPremiumIndexContext 同GetPremiumIndex，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetPremiumIndexContext(ctx context.Context, symbol string) (*types.PremiumIndex, error) {
+	ticker, err := bb.ticker(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	rate, err := parseNumericField(ticker, "fundingRate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	premium := &types.PremiumIndex{
+		Symbol:               normalizeUSDTSymbol(symbol),
+		PredictedFundingRate: rate,
+		Timestamp:            time.Now().UnixMilli(),
+	}
+	premium.MarkPrice, _ = parseNumericField(ticker, "markPrice")
+	premium.IndexPrice, _ = parseNumericField(ticker, "indexPrice")
+	nextFunding, _ := parseFloatValue(ticker["nextFundingTime"])
+	premium.NextFundingTime = int64(nextFunding)
+	return premium, nil
+}
+
+// GetOpenInterest 获取持仓量（币数量）
+func (bb *BybitExchange) GetOpenInterest(symbol string) (float64, error) {
+	return bb.GetOpenInterestContext(context.Background(), symbol)
+}
+
+// GetOpenInterestContext 同GetOpenInterest，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetOpenInterestContext(ctx context.Context, symbol string) (float64, error) {
+	params := map[string]string{
+		"category":     bybitCategory,
+		"symbol":       normalizeUSDTSymbol(symbol),
+		"intervalTime": "5min",
+		"limit":        "1",
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/market/open-interest", params, nil, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	item, err := firstListItem(result)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	return parseNumericField(item, "openInterest")
+}
+
+// GetOrderBook 获取深度快照
+func (bb *BybitExchange) GetOrderBook(symbol string, limit int) (*types.OrderBook, error) {
+	return bb.GetOrderBookContext(context.Background(), symbol, limit)
+}
+
+// GetOrderBookContext 同GetOrderBook，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetOrderBookContext(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if limit <= 0 || limit > bybitMaxBookDepth {
+		limit = bybitMaxBookDepth
+	}
+	symbol = normalizeUSDTSymbol(symbol)
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   symbol,
+		"limit":    strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/market/orderbook", params, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	book := &types.OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(result["b"], 1),
+		Asks:   parseBookLevels(result["a"], 1),
+	}
+	if ts, err := parseFloatValue(result["ts"]); err == nil {
+		book.Timestamp = int64(ts)
+	}
+	return book, nil
+}
+
+// ticker 获取单个交易对的行情快照
+func (bb *BybitExchange) ticker(ctx context.Context, symbol string) (map[string]interface{}, error) {
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   normalizeUSDTSymbol(symbol),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/market/tickers", params, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return firstListItem(result)
+}
+
+// firstListItem 返回result.list的第一条数据
+func firstListItem(result map[string]interface{}) (map[string]interface{}, error) {
+	list, _ := result["list"].([]interface{})
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	item, ok := list[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data format")
+	}
+	return item, nil
+}
+
+// request 发送请求并返回result字段，signed为true时添加签名头
+func (bb *BybitExchange) request(ctx context.Context, method, path string, params map[string]string, body interface{}, signed bool) (map[string]interface{}, error) {
+	backoff := GetGlobalBackoff()
+	backoff.WaitBackoff(bybitBackoffTarget)
+	bb.rateLimiter.Wait(1)
+
+	var query string
+	if len(params) > 0 {
+		q := url.Values{}
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		query = q.Encode()
+	}
+
+	var bodyStr string
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal body failed: %w", err)
+		}
+		bodyStr = string(payload)
+	}
+
+	fullURL := bb.baseURL + path
+	if query != "" {
+		fullURL += "?" + query
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewBufferString(bodyStr))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if signed {
+		if bb.apiKey == "" || bb.secretKey == "" {
+			return nil, fmt.Errorf("API keys required")
+		}
+		// GET签名内容为查询字符串，POST为JSON body
+		payload := query
+		if method != http.MethodGet {
+			payload = bodyStr
+		}
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		recvWindow := strconv.Itoa(bb.recvWindow)
+		httpReq.Header.Set("X-BAPI-API-KEY", bb.apiKey)
+		httpReq.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		httpReq.Header.Set("X-BAPI-RECV-WINDOW", recvWindow)
+		httpReq.Header.Set("X-BAPI-SIGN", bb.sign(timestamp, recvWindow, payload))
+	}
+
+	resp, err := bb.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response failed: %w", err)
+	}
+
+	var result map[string]interface{}
+	jsonErr := json.Unmarshal(respBody, &result)
+
+	// IP限频返回HTTP 403/429，账户限频返回retCode 10006
+	retCode := -1
+	if jsonErr == nil {
+		if code, err := parseFloatValue(result["retCode"]); err == nil {
+			retCode = int(code)
+		}
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests || retCode == bybitRetCodeLimit {
+		var retryAfter *float64
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			retryAfter = ParseRetryAfter(v)
+		}
+		waitSec := backoff.OnRateLimited(bybitBackoffTarget, http.StatusTooManyRequests, retryAfter)
+		utils.GetLogger("exchange").Warnw("Bybit API rate limited",
+			"path", path,
+			"status", resp.StatusCode,
+			"wait_sec", waitSec,
+		)
+		return nil, fmt.Errorf("%w: HTTP %d, wait %.1fs", ErrRateLimited, resp.StatusCode, waitSec)
+	}
+
+	if jsonErr != nil {
+		return nil, newBybitAPIError(resp.StatusCode, 0, string(respBody))
+	}
+	if retCode != 0 {
+		return nil, newBybitAPIError(resp.StatusCode, retCode, parseStringValue(result["retMsg"]))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newBybitAPIError(resp.StatusCode, 0, string(respBody))
+	}
+
+	backoff.ResetBackoff(bybitBackoffTarget)
+	data, _ := result["result"].(map[string]interface{})
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return data, nil
+}
+
+// sign 生成签名：Hex(HMAC-SHA256(timestamp + apiKey + recvWindow + payload))
+func (bb *BybitExchange) sign(timestamp, recvWindow, payload string) string {
+	mac := hmac.New(sha256.New, []byte(bb.secretKey))
+	mac.Write([]byte(timestamp + bb.apiKey + recvWindow + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
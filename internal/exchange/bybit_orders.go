@@ -0,0 +1,652 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const (
+	bybitMaxPages                   = 20     // 分页查询最多翻页次数
+	bybitRetCodeLeverageNotModified = 110043 // 杠杆未变化
+)
+
+// PlaceOrder 下单（数量为币数量）
+// STOP/STOP_MARKET/TAKE_PROFIT/TAKE_PROFIT_MARKET 以条件单下单；TakeProfit/StopLoss 作为随单止盈止损附带
+func (bb *BybitExchange) PlaceOrder(req types.OrderRequest) (*types.Order, error) {
+	return bb.PlaceOrderContext(context.Background(), req)
+}
+
+// PlaceOrderContext 同PlaceOrder，ctx取消或超时时中止请求
+func (bb *BybitExchange) PlaceOrderContext(ctx context.Context, req types.OrderRequest) (*types.Order, error) {
+	symbol := normalizeUSDTSymbol(req.Symbol)
+	side := strings.ToUpper(req.Side)
+	orderType := strings.ToUpper(req.OrderType)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Bybit order would be placed",
+			"symbol", symbol,
+			"side", side,
+			"order_type", orderType,
+			"quantity", req.Quantity,
+			"price", req.Price,
+		)
+		return &types.Order{
+			ID:           "dry_run_" + strconv.FormatInt(time.Now().UnixNano(), 10),
+			Symbol:       symbol,
+			Side:         side,
+			PositionSide: strings.ToUpper(req.PositionSide),
+			OrderType:    orderType,
+			Status:       "NEW",
+			Quantity:     req.Quantity,
+			Price:        getFloatValue(req.Price),
+			Timestamp:    time.Now().Unix(),
+		}, nil
+	}
+
+	if side != "BUY" && side != "SELL" {
+		return nil, fmt.Errorf("invalid side: %s", req.Side)
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity: %f", req.Quantity)
+	}
+
+	body := map[string]interface{}{
+		"category":    bybitCategory,
+		"symbol":      symbol,
+		"side":        bybitSide(side),
+		"qty":         formatFloat(req.Quantity),
+		"positionIdx": bybitPositionIdx(req.PositionSide),
+	}
+	if req.ReduceOnly {
+		body["reduceOnly"] = true
+	}
+	if req.ClientOrderID != "" {
+		body["orderLinkId"] = req.ClientOrderID
+	}
+
+	switch orderType {
+	case "MARKET":
+		body["orderType"] = "Market"
+	case "LIMIT":
+		if req.Price == nil || *req.Price <= 0 {
+			return nil, fmt.Errorf("price required for LIMIT order")
+		}
+		body["orderType"] = "Limit"
+		body["price"] = formatFloat(*req.Price)
+		switch strings.ToUpper(req.TimeInForce) {
+		case "IOC":
+			body["timeInForce"] = "IOC"
+		case "FOK":
+			body["timeInForce"] = "FOK"
+		case "GTX":
+			body["timeInForce"] = "PostOnly"
+		default:
+			body["timeInForce"] = "GTC"
+		}
+	case "STOP", "STOP_MARKET", "TAKE_PROFIT", "TAKE_PROFIT_MARKET":
+		if req.StopPrice == nil || *req.StopPrice <= 0 {
+			return nil, fmt.Errorf("stop price required for %s order", orderType)
+		}
+		body["orderType"] = "Market"
+		if orderType == "STOP" || orderType == "TAKE_PROFIT" {
+			if req.Price == nil || *req.Price <= 0 {
+				return nil, fmt.Errorf("price required for %s order", orderType)
+			}
+			body["orderType"] = "Limit"
+			body["price"] = formatFloat(*req.Price)
+		}
+		body["triggerPrice"] = formatFloat(*req.StopPrice)
+		body["triggerDirection"] = bybitTriggerDirection(orderType, side)
+		body["triggerBy"] = "LastPrice"
+	default:
+		return nil, fmt.Errorf("unsupported order type: %s", req.OrderType)
+	}
+
+	// 随单止盈止损（全仓位模式，触发后市价平仓）
+	if req.TakeProfit != nil && *req.TakeProfit > 0 {
+		body["takeProfit"] = formatFloat(*req.TakeProfit)
+		body["tpTriggerBy"] = "LastPrice"
+	}
+	if req.StopLoss != nil && *req.StopLoss > 0 {
+		body["stopLoss"] = formatFloat(*req.StopLoss)
+		body["slTriggerBy"] = "LastPrice"
+	}
+	if body["takeProfit"] != nil || body["stopLoss"] != nil {
+		body["tpslMode"] = "Full"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodPost, "/v5/order/create", nil, body, true)
+	if err != nil {
+		return nil, fmt.Errorf("place order failed: %w", err)
+	}
+
+	order := &types.Order{
+		ID:            parseStringValue(result["orderId"]),
+		Symbol:        symbol,
+		Side:          side,
+		PositionSide:  strings.ToUpper(req.PositionSide),
+		OrderType:     orderType,
+		Status:        "NEW",
+		Quantity:      req.Quantity,
+		ReduceOnly:    req.ReduceOnly,
+		ClientOrderID: req.ClientOrderID,
+		Timestamp:     time.Now().Unix(),
+	}
+	if req.Price != nil {
+		order.Price = *req.Price
+	}
+	if req.StopPrice != nil {
+		order.StopPrice = *req.StopPrice
+	}
+	return order, nil
+}
+
+// CancelOrder 取消订单（普通单和条件单共用同一接口）
+func (bb *BybitExchange) CancelOrder(symbol, orderID string) error {
+	return bb.CancelOrderContext(context.Background(), symbol, orderID)
+}
+
+// CancelOrderContext 同CancelOrder，ctx取消或超时时中止请求
+func (bb *BybitExchange) CancelOrderContext(ctx context.Context, symbol, orderID string) error {
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Bybit order would be cancelled",
+			"order_id", orderID,
+			"symbol", symbol,
+		)
+		return nil
+	}
+
+	body := map[string]string{
+		"category": bybitCategory,
+		"symbol":   normalizeUSDTSymbol(symbol),
+		"orderId":  orderID,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := bb.request(ctx, http.MethodPost, "/v5/order/cancel", nil, body, true); err != nil {
+		return fmt.Errorf("cancel order failed: %w", err)
+	}
+	return nil
+}
+
+// GetOrder 查询订单（实时订单中找不到时查询历史订单）
+func (bb *BybitExchange) GetOrder(symbol, orderID string) (*types.Order, error) {
+	return bb.GetOrderContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderContext 同GetOrder，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetOrderContext(ctx context.Context, symbol, orderID string) (*types.Order, error) {
+	symbol = normalizeUSDTSymbol(symbol)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		return &types.Order{
+			ID:        orderID,
+			Symbol:    symbol,
+			Status:    "FILLED",
+			Timestamp: time.Now().Unix(),
+		}, nil
+	}
+
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   symbol,
+		"orderId":  orderID,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for _, path := range []string{"/v5/order/realtime", "/v5/order/history"} {
+		result, err := bb.request(ctx, http.MethodGet, path, params, nil, true)
+		if err != nil {
+			return nil, fmt.Errorf("get order failed: %w", err)
+		}
+		if item, err := firstListItem(result); err == nil {
+			return parseBybitOrder(item), nil
+		}
+	}
+	return nil, fmt.Errorf("order not found: %s", orderID)
+}
+
+// GetOpenOrders 获取当前挂单（包含未触发的条件单），symbol为空时返回全部
+func (bb *BybitExchange) GetOpenOrders(symbol string) ([]*types.Order, error) {
+	return bb.GetOpenOrdersContext(context.Background(), symbol)
+}
+
+// GetOpenOrdersContext 同GetOpenOrders，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetOpenOrdersContext(ctx context.Context, symbol string) ([]*types.Order, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return []*types.Order{}, nil
+	}
+
+	params := map[string]string{
+		"category": bybitCategory,
+		"openOnly": "0",
+		"limit":    "50",
+	}
+	if symbol != "" {
+		params["symbol"] = normalizeUSDTSymbol(symbol)
+	} else {
+		params["settleCoin"] = bybitSettleCoin
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	items, err := bb.listAll(ctx, "/v5/order/realtime", params)
+	if err != nil {
+		return nil, fmt.Errorf("get open orders failed: %w", err)
+	}
+
+	orders := make([]*types.Order, 0, len(items))
+	for _, item := range items {
+		orders = append(orders, parseBybitOrder(item))
+	}
+	return orders, nil
+}
+
+// GetPosition 获取单个持仓
+func (bb *BybitExchange) GetPosition(symbol string) (*types.Position, error) {
+	return bb.GetPositionContext(context.Background(), symbol)
+}
+
+// GetPositionContext 同GetPosition，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetPositionContext(ctx context.Context, symbol string) (*types.Position, error) {
+	positions, err := bb.GetPositionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = normalizeUSDTSymbol(symbol)
+	for _, pos := range positions {
+		if pos.Symbol == symbol {
+			return pos, nil
+		}
+	}
+	return nil, nil // 无持仓
+}
+
+// GetPositions 获取所有持仓（双向持仓模式下多空分别返回）
+func (bb *BybitExchange) GetPositions() ([]*types.Position, error) {
+	return bb.GetPositionsContext(context.Background())
+}
+
+// GetPositionsContext 同GetPositions，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetPositionsContext(ctx context.Context) ([]*types.Position, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return []*types.Position{}, nil
+	}
+
+	params := map[string]string{
+		"category":   bybitCategory,
+		"settleCoin": bybitSettleCoin,
+		"limit":      "200",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	items, err := bb.listAll(ctx, "/v5/position/list", params)
+	if err != nil {
+		return nil, fmt.Errorf("get positions failed: %w", err)
+	}
+
+	positions := make([]*types.Position, 0)
+	for _, p := range items {
+		size, _ := parseFloatValue(p["size"])
+		if size == 0 {
+			continue // 跳过空仓
+		}
+
+		// 双向持仓按positionIdx判断，单向持仓按side判断
+		side := bybitPositionSide(p["positionIdx"])
+		if side == "" {
+			side = "LONG"
+			if parseStringValue(p["side"]) == "Sell" {
+				side = "SHORT"
+			}
+		}
+
+		entryPrice, _ := parseFloatValue(p["avgPrice"])
+		markPrice, _ := parseFloatValue(p["markPrice"])
+		unrealizedPnl, _ := parseFloatValue(p["unrealisedPnl"])
+		leverage, _ := parseFloatValue(p["leverage"])
+
+		positions = append(positions, &types.Position{
+			Symbol:        parseStringValue(p["symbol"]),
+			Side:          side,
+			Size:          size,
+			EntryPrice:    entryPrice,
+			MarkPrice:     markPrice,
+			UnrealizedPnl: unrealizedPnl,
+			Leverage:      int(leverage),
+		})
+	}
+	return positions, nil
+}
+
+// GetBalance 获取USDT余额（统一账户）
+func (bb *BybitExchange) GetBalance() (map[string]float64, error) {
+	return bb.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext 同GetBalance，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetBalanceContext(ctx context.Context) (map[string]float64, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return map[string]float64{
+			"total": 10000.0,
+			"free":  10000.0,
+			"used":  0.0,
+		}, nil
+	}
+
+	params := map[string]string{
+		"accountType": "UNIFIED",
+		"coin":        bybitSettleCoin,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/account/wallet-balance", params, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("get balance failed: %w", err)
+	}
+
+	balance := map[string]float64{
+		"total": 0.0,
+		"free":  0.0,
+		"used":  0.0,
+	}
+	account, err := firstListItem(result)
+	if err != nil {
+		return balance, nil
+	}
+	coins, _ := account["coin"].([]interface{})
+	for _, item := range coins {
+		c, ok := item.(map[string]interface{})
+		if !ok || parseStringValue(c["coin"]) != bybitSettleCoin {
+			continue
+		}
+		balance["total"], _ = parseFloatValue(c["equity"])
+		positionIM, _ := parseFloatValue(c["totalPositionIM"])
+		orderIM, _ := parseFloatValue(c["totalOrderIM"])
+		balance["used"] = positionIM + orderIM
+		balance["free"] = balance["total"] - balance["used"]
+		break
+	}
+	return balance, nil
+}
+
+// SetLeverage 设置杠杆倍数（多空相同），杠杆未变化时视为成功
+func (bb *BybitExchange) SetLeverage(symbol string, leverage int) error {
+	return bb.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，ctx取消或超时时中止请求
+func (bb *BybitExchange) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	if leverage <= 0 {
+		return fmt.Errorf("invalid leverage: %d", leverage)
+	}
+	symbol = normalizeUSDTSymbol(symbol)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Bybit leverage would be set",
+			"symbol", symbol,
+			"leverage", leverage,
+		)
+		return nil
+	}
+
+	body := map[string]string{
+		"category":     bybitCategory,
+		"symbol":       symbol,
+		"buyLeverage":  strconv.Itoa(leverage),
+		"sellLeverage": strconv.Itoa(leverage),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := bb.request(ctx, http.MethodPost, "/v5/position/set-leverage", nil, body, true); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == bybitRetCodeLeverageNotModified {
+			return nil
+		}
+		return fmt.Errorf("set leverage failed: %w", err)
+	}
+	return nil
+}
+
+// SetMarginType 设置保证金模式
+// 统一账户的保证金模式为账户级别设置，对所有交易对生效
+func (bb *BybitExchange) SetMarginType(symbol string, marginType string) error {
+	return bb.SetMarginTypeContext(context.Background(), symbol, marginType)
+}
+
+// SetMarginTypeContext 同SetMarginType，ctx取消或超时时中止请求
+func (bb *BybitExchange) SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error {
+	var mode string
+	switch strings.ToUpper(marginType) {
+	case "ISOLATED":
+		mode = "ISOLATED_MARGIN"
+	case "CROSSED":
+		mode = "REGULAR_MARGIN"
+	default:
+		return fmt.Errorf("invalid margin type: %s", marginType)
+	}
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: Bybit margin mode would be set",
+			"margin_mode", mode,
+		)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := bb.request(ctx, http.MethodPost, "/v5/account/set-margin-mode", nil, map[string]string{"setMarginMode": mode}, true); err != nil {
+		return fmt.Errorf("set margin type failed: %w", err)
+	}
+	return nil
+}
+
+// PlaceBatchOrders 批量下单（逐笔下单）
+func (bb *BybitExchange) PlaceBatchOrders(reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return bb.PlaceBatchOrdersContext(context.Background(), reqs)
+}
+
+// PlaceBatchOrdersContext 同PlaceBatchOrders，ctx取消或超时时中止请求
+func (bb *BybitExchange) PlaceBatchOrdersContext(ctx context.Context, reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return placeOrdersSequentially(ctx, bb.PlaceOrderContext, reqs), nil
+}
+
+// GetPositionMode 查询持仓模式
+// Bybit没有单独的查询接口，双向持仓模式下持仓列表按positionIdx 1/2返回多空两条记录
+func (bb *BybitExchange) GetPositionMode() (bool, error) {
+	return bb.GetPositionModeContext(context.Background())
+}
+
+// GetPositionModeContext 同GetPositionMode，ctx取消或超时时中止请求
+func (bb *BybitExchange) GetPositionModeContext(ctx context.Context) (bool, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return true, nil
+	}
+
+	params := map[string]string{
+		"category": bybitCategory,
+		"symbol":   "BTCUSDT",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := bb.request(ctx, http.MethodGet, "/v5/position/list", params, nil, true)
+	if err != nil {
+		return false, fmt.Errorf("get position mode failed: %w", err)
+	}
+	item, err := firstListItem(result)
+	if err != nil {
+		return false, fmt.Errorf("get position mode failed: %w", err)
+	}
+	return bybitPositionSide(item["positionIdx"]) != "", nil
+}
+
+// listAll 按nextPageCursor翻页获取result.list全部数据
+func (bb *BybitExchange) listAll(ctx context.Context, path string, params map[string]string) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0)
+	for page := 0; page < bybitMaxPages; page++ {
+		result, err := bb.request(ctx, http.MethodGet, path, params, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		list, _ := result["list"].([]interface{})
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				items = append(items, m)
+			}
+		}
+
+		cursor := parseStringValue(result["nextPageCursor"])
+		if cursor == "" || len(list) == 0 {
+			break
+		}
+		next := make(map[string]string, len(params)+1)
+		for k, v := range params {
+			next[k] = v
+		}
+		next["cursor"] = cursor
+		params = next
+	}
+	return items, nil
+}
+
+// parseBybitOrder 解析订单（条件单映射为STOP_MARKET/TAKE_PROFIT_MARKET等）
+func parseBybitOrder(m map[string]interface{}) *types.Order {
+	side := strings.ToUpper(parseStringValue(m["side"]))
+
+	orderType := "LIMIT"
+	if parseStringValue(m["orderType"]) == "Market" {
+		orderType = "MARKET"
+	}
+	if stopOrderType := parseStringValue(m["stopOrderType"]); stopOrderType != "" {
+		kind := "STOP"
+		switch stopOrderType {
+		case "TakeProfit", "PartialTakeProfit":
+			kind = "TAKE_PROFIT"
+		case "Stop":
+			// 普通条件单按触发方向判断：卖出上涨触发或买入下跌触发为止盈
+			direction, _ := parseFloatValue(m["triggerDirection"])
+			if (side == "SELL" && direction == 1) || (side == "BUY" && direction == 2) {
+				kind = "TAKE_PROFIT"
+			}
+		}
+		if orderType == "MARKET" {
+			kind += "_MARKET"
+		}
+		orderType = kind
+	}
+
+	order := &types.Order{
+		ID:           parseStringValue(m["orderId"]),
+		Symbol:       parseStringValue(m["symbol"]),
+		Side:         side,
+		PositionSide: bybitPositionSide(m["positionIdx"]),
+		OrderType:    orderType,
+		Status:       bybitOrderStatus(parseStringValue(m["orderStatus"])),
+	}
+	order.Quantity, _ = parseFloatValue(m["qty"])
+	order.FilledQty, _ = parseFloatValue(m["cumExecQty"])
+	order.Price, _ = parseFloatValue(m["price"])
+	order.AvgPrice, _ = parseFloatValue(m["avgPrice"])
+	order.StopPrice, _ = parseFloatValue(m["triggerPrice"])
+	order.ClientOrderID = parseStringValue(m["orderLinkId"])
+	order.ReduceOnly, _ = parseBoolValue(m["reduceOnly"])
+	createdTime, _ := parseFloatValue(m["createdTime"])
+	order.Timestamp = int64(createdTime / 1000)
+	return order
+}
+
+// bybitSide 买卖方向转换（BUY -> Buy）
+func bybitSide(side string) string {
+	if side == "SELL" {
+		return "Sell"
+	}
+	return "Buy"
+}
+
+// bybitPositionIdx 持仓方向转换：0单向持仓，1双向多仓，2双向空仓
+func bybitPositionIdx(positionSide string) int {
+	switch strings.ToUpper(positionSide) {
+	case "LONG":
+		return 1
+	case "SHORT":
+		return 2
+	}
+	return 0
+}
+
+// bybitPositionSide positionIdx转换为持仓方向（单向持仓返回空）
+func bybitPositionSide(v interface{}) string {
+	idx, _ := parseFloatValue(v)
+	switch idx {
+	case 1:
+		return "LONG"
+	case 2:
+		return "SHORT"
+	}
+	return ""
+}
+
+// bybitTriggerDirection 条件单触发方向：1价格上涨到触发价，2价格下跌到触发价
+func bybitTriggerDirection(orderType, side string) int {
+	rising := side == "BUY" // 止损：买入平空在上涨时触发
+	if strings.HasPrefix(orderType, "TAKE_PROFIT") {
+		rising = !rising
+	}
+	if rising {
+		return 1
+	}
+	return 2
+}
+
+// bybitOrderStatus 订单状态转换为Binance风格
+func bybitOrderStatus(status string) string {
+	switch status {
+	case "New", "Untriggered", "Triggered":
+		return "NEW"
+	case "PartiallyFilled":
+		return "PARTIALLY_FILLED"
+	case "Filled":
+		return "FILLED"
+	case "Cancelled", "PartiallyFilledCanceled", "Deactivated":
+		return "CANCELED"
+	case "Rejected":
+		return "REJECTED"
+	}
+	return strings.ToUpper(status)
+}
@@ -0,0 +1,177 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited 触发交易所限流（已进入退避窗口）
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrorClass 交易所错误分类
+type ErrorClass string
+
+const (
+	ErrorRetryable ErrorClass = "retryable" // 瞬时错误（超时、5xx、限流），可重试
+	ErrorRejected  ErrorClass = "rejected"  // 业务拒绝（保证金不足、精度、订单不存在等），重试无效
+	ErrorFatal     ErrorClass = "fatal"     // 致命错误（密钥、签名、权限等），需要人工处理
+	ErrorCanceled  ErrorClass = "canceled"  // 调用方取消（如程序退出），不重试也不告警
+)
+
+// APIError 交易所接口返回的错误
+type APIError struct {
+	Exchange   string
+	Op         string // 操作，如"place order"
+	HTTPStatus int
+	Code       int // 交易所错误码（无法解析时为0）
+	Message    string
+	Class      ErrorClass
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	switch {
+	case e.Op != "" && e.Code != 0:
+		return fmt.Sprintf("%s failed: HTTP %d, code %d: %s", e.Op, e.HTTPStatus, e.Code, e.Message)
+	case e.Op != "":
+		return fmt.Sprintf("%s failed: HTTP %d, body: %s", e.Op, e.HTTPStatus, e.Message)
+	case e.Code != 0:
+		return fmt.Sprintf("%s error %d: %s", e.Exchange, e.Code, e.Message)
+	default:
+		return fmt.Sprintf("HTTP %d: %s", e.HTTPStatus, e.Message)
+	}
+}
+
+// Retryable 是否可重试
+func (e *APIError) Retryable() bool {
+	return e.Class == ErrorRetryable
+}
+
+// ClassifyError 错误分类：交易所错误按错误码，调用方取消单独归类，网络和超时错误可重试，其余视为致命
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class
+	}
+	if errors.Is(err, ErrOrderFilter) {
+		return ErrorRejected
+	}
+	// 限流等待和HTTP请求被取消时返回context.Canceled（HTTP请求的错误同时实现net.Error，需先判断）
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorRetryable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorRetryable
+	}
+	return ErrorFatal
+}
+
+// IsRetryable 是否为可重试错误
+func IsRetryable(err error) bool {
+	return ClassifyError(err) == ErrorRetryable
+}
+
+// retryDelay 第attempt次重试前的等待时间（指数退避，最长2秒）
+func retryDelay(attempt int) time.Duration {
+	delay := 200 * time.Millisecond << uint(attempt-1)
+	if delay > 2*time.Second {
+		delay = 2 * time.Second
+	}
+	return delay
+}
+
+// newBinanceAPIError 解析Binance错误响应（{"code":-2019,"msg":"Margin is insufficient."}）
+func newBinanceAPIError(op string, status int, body []byte) *APIError {
+	code := 0
+	msg := string(body)
+	var resp map[string]interface{}
+	if json.Unmarshal(body, &resp) == nil {
+		if c, err := parseFloatValue(resp["code"]); err == nil {
+			code = int(c)
+		}
+		if m := parseStringValue(resp["msg"]); m != "" {
+			msg = m
+		}
+	}
+	return &APIError{
+		Exchange:   NameBinance,
+		Op:         op,
+		HTTPStatus: status,
+		Code:       code,
+		Message:    msg,
+		Class:      classifyBinanceError(status, code),
+	}
+}
+
+// classifyBinanceError Binance错误分类
+func classifyBinanceError(status, code int) ErrorClass {
+	switch code {
+	case -1000, // UNKNOWN
+		-1001, // DISCONNECTED
+		-1003, // TOO_MANY_REQUESTS
+		-1006, // UNEXPECTED_RESP
+		-1007, // TIMEOUT
+		-1008, // SERVER_BUSY
+		-1015, // TOO_MANY_ORDERS
+		-1021: // INVALID_TIMESTAMP
+		return ErrorRetryable
+	case -1002, // UNAUTHORIZED
+		-1022, // INVALID_SIGNATURE
+		-2014, // BAD_API_KEY_FMT
+		-2015: // REJECTED_MBX_KEY（密钥无效、IP未授权或权限不足）
+		return ErrorFatal
+	}
+	return classifyHTTPStatus(status, code)
+}
+
+// classifyHTTPStatus 按HTTP状态码分类（无法识别错误码时使用）
+func classifyHTTPStatus(status, code int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests || status == 418 || status >= http.StatusInternalServerError:
+		return ErrorRetryable
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorFatal
+	case code != 0 || status >= http.StatusBadRequest:
+		return ErrorRejected
+	}
+	return ErrorFatal
+}
+
+// newOKXAPIError OKX错误（code为顶层code或交易接口的sCode）
+func newOKXAPIError(status int, code, msg string) *APIError {
+	n, _ := strconv.Atoi(code)
+	class := classifyHTTPStatus(status, n)
+	switch n {
+	case 50001, 50004, 50011, 50013, 50026: // 服务不可用、超时、限流、系统繁忙
+		class = ErrorRetryable
+	case 50100, 50110, 50111, 50113, 50119: // 账户冻结、IP未授权、密钥或签名无效
+		class = ErrorFatal
+	}
+	return &APIError{Exchange: NameOKX, HTTPStatus: status, Code: n, Message: msg, Class: class}
+}
+
+// newBybitAPIError Bybit错误（retCode）
+func newBybitAPIError(status, code int, msg string) *APIError {
+	class := classifyHTTPStatus(status, code)
+	switch code {
+	case 10000, 10006, 10016: // 超时、限流、服务错误
+		class = ErrorRetryable
+	case 10003, 10004, 10005, 10010, 33004: // 密钥无效、签名错误、权限不足、IP未授权、密钥过期
+		class = ErrorFatal
+	}
+	return &APIError{Exchange: NameBybit, HTTPStatus: status, Code: code, Message: msg, Class: class}
+}
@@ -1,9 +1,51 @@
 package exchange
 
 import (
+	"context"
+
 	"github.com/yuechangmingzou/nofx-go/pkg/types"
 )
 
 // 确保BinanceExchange实现了types.Exchange接口（编译时检查）
 var _ types.Exchange = (*BinanceExchange)(nil)
 
+
+// 确保PaperExchange实现了types.Exchange接口（编译时检查）
+var _ types.Exchange = (*PaperExchange)(nil)
+
+// 确保OKXExchange实现了types.Exchange接口（编译时检查）
+var _ types.Exchange = (*OKXExchange)(nil)
+
+// 确保BybitExchange实现了types.Exchange接口（编译时检查）
+var _ types.Exchange = (*BybitExchange)(nil)
+
+// placeOrdersSequentially 逐笔下单（没有批量接口或批量接口不支持条件单的交易所使用）
+func placeOrdersSequentially(ctx context.Context, place func(context.Context, types.OrderRequest) (*types.Order, error), reqs []types.OrderRequest) []types.BatchOrderResult {
+	results := make([]types.BatchOrderResult, len(reqs))
+	for i, req := range reqs {
+		results[i].Order, results[i].Err = place(ctx, req)
+	}
+	return results
+}
+
+// parseBookLevels 解析深度档位（[[价格, 数量, ...], ...]），数量乘以qtyScale换算为币数量
+func parseBookLevels(raw interface{}, qtyScale float64) []types.OrderBookLevel {
+	rows, _ := raw.([]interface{})
+	levels := make([]types.OrderBookLevel, 0, len(rows))
+	for _, row := range rows {
+		r, ok := row.([]interface{})
+		if !ok || len(r) < 2 {
+			continue
+		}
+		price, err := parseFloatValue(r[0])
+		if err != nil || price <= 0 {
+			continue
+		}
+		qty, err := parseFloatValue(r[1])
+		if err != nil || qty <= 0 {
+			continue
+		}
+		levels = append(levels, types.OrderBookLevel{Price: price, Quantity: qty * qtyScale})
+	}
+	return levels
+}
@@ -0,0 +1,180 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// ErrOrderFilter 订单不满足交易对规则（下单前拦截，未发送到交易所）
+var ErrOrderFilter = errors.New("order rejected by symbol filters")
+
+// SymbolFilters 交易对下单规则（来自exchangeInfo的filters）
+type SymbolFilters struct {
+	Symbol string
+
+	// PRICE_FILTER
+	TickSize float64
+	MinPrice float64
+	MaxPrice float64
+
+	// LOT_SIZE（限价单和条件单）
+	StepSize float64
+	MinQty   float64
+	MaxQty   float64
+
+	// MARKET_LOT_SIZE（市价单，未提供时使用LOT_SIZE）
+	MarketStepSize float64
+	MarketMinQty   float64
+	MarketMaxQty   float64
+
+	// MIN_NOTIONAL（只减仓订单不受限制）
+	MinNotional float64
+}
+
+// ParseSymbolFilters 从exchangeInfo的交易对信息解析下单规则
+func ParseSymbolFilters(market map[string]interface{}) SymbolFilters {
+	f := SymbolFilters{Symbol: parseStringValue(market["symbol"])}
+
+	filters, _ := market["filters"].([]interface{})
+	for _, item := range filters {
+		filter, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch parseStringValue(filter["filterType"]) {
+		case "PRICE_FILTER":
+			f.TickSize, _ = parseFloatValue(filter["tickSize"])
+			f.MinPrice, _ = parseFloatValue(filter["minPrice"])
+			f.MaxPrice, _ = parseFloatValue(filter["maxPrice"])
+		case "LOT_SIZE":
+			f.StepSize, _ = parseFloatValue(filter["stepSize"])
+			f.MinQty, _ = parseFloatValue(filter["minQty"])
+			f.MaxQty, _ = parseFloatValue(filter["maxQty"])
+		case "MARKET_LOT_SIZE":
+			f.MarketStepSize, _ = parseFloatValue(filter["stepSize"])
+			f.MarketMinQty, _ = parseFloatValue(filter["minQty"])
+			f.MarketMaxQty, _ = parseFloatValue(filter["maxQty"])
+		case "MIN_NOTIONAL":
+			// 合约为notional，现货为minNotional
+			notional, err := parseFloatValue(filter["notional"])
+			if err != nil {
+				notional, _ = parseFloatValue(filter["minNotional"])
+			}
+			f.MinNotional = notional
+		}
+	}
+	return f
+}
+
+// lotSize 返回订单类型对应的数量规则
+func (f SymbolFilters) lotSize(orderType string) (step, minQty, maxQty float64) {
+	step, minQty, maxQty = f.StepSize, f.MinQty, f.MaxQty
+	if strings.ToUpper(orderType) == "MARKET" {
+		if f.MarketStepSize > 0 {
+			step = f.MarketStepSize
+		}
+		if f.MarketMinQty > 0 {
+			minQty = f.MarketMinQty
+		}
+		if f.MarketMaxQty > 0 {
+			maxQty = f.MarketMaxQty
+		}
+	}
+	return step, minQty, maxQty
+}
+
+// QuantizeQuantity 数量按stepSize向下取整
+func (f SymbolFilters) QuantizeQuantity(quantity float64, orderType string) float64 {
+	step, _, _ := f.lotSize(orderType)
+	return floorToStep(quantity, step)
+}
+
+// QuantizePrice 价格按tickSize取最近的整数倍
+func (f SymbolFilters) QuantizePrice(price float64) float64 {
+	if f.TickSize <= 0 {
+		return price
+	}
+	return trimToStep(math.Round(price/f.TickSize)*f.TickSize, f.TickSize)
+}
+
+// Apply 量化订单数量和价格并校验规则，refPrice用于市价单的名义价值校验
+// 返回新的订单请求，不修改调用方的价格指针
+func (f SymbolFilters) Apply(req types.OrderRequest, refPrice float64) (types.OrderRequest, error) {
+	orderType := strings.ToUpper(req.OrderType)
+	_, minQty, maxQty := f.lotSize(orderType)
+
+	out := req
+	out.Quantity = f.QuantizeQuantity(req.Quantity, orderType)
+	if out.Quantity <= 0 || out.Quantity < minQty {
+		return req, fmt.Errorf("%w: %s quantity %s below minQty %s (stepSize %s)",
+			ErrOrderFilter, f.Symbol, formatFloat(req.Quantity), formatFloat(minQty), formatFloat(f.StepSize))
+	}
+	if maxQty > 0 && out.Quantity > maxQty {
+		return req, fmt.Errorf("%w: %s quantity %s above maxQty %s",
+			ErrOrderFilter, f.Symbol, formatFloat(out.Quantity), formatFloat(maxQty))
+	}
+
+	if req.Price != nil && *req.Price > 0 {
+		price := f.QuantizePrice(*req.Price)
+		if err := f.checkPrice(price); err != nil {
+			return req, err
+		}
+		out.Price = &price
+		refPrice = price
+	}
+	if req.StopPrice != nil && *req.StopPrice > 0 {
+		stopPrice := f.QuantizePrice(*req.StopPrice)
+		if err := f.checkPrice(stopPrice); err != nil {
+			return req, err
+		}
+		out.StopPrice = &stopPrice
+		if out.Price == nil {
+			refPrice = stopPrice
+		}
+	}
+
+	if f.MinNotional > 0 && !req.ReduceOnly && refPrice > 0 {
+		if notional := out.Quantity * refPrice; notional < f.MinNotional {
+			return req, fmt.Errorf("%w: %s notional %.4f below MIN_NOTIONAL %s",
+				ErrOrderFilter, f.Symbol, notional, formatFloat(f.MinNotional))
+		}
+	}
+
+	return out, nil
+}
+
+// checkPrice 校验价格范围
+func (f SymbolFilters) checkPrice(price float64) error {
+	if price <= 0 || (f.MinPrice > 0 && price < f.MinPrice) {
+		return fmt.Errorf("%w: %s price %s below minPrice %s",
+			ErrOrderFilter, f.Symbol, formatFloat(price), formatFloat(f.MinPrice))
+	}
+	if f.MaxPrice > 0 && price > f.MaxPrice {
+		return fmt.Errorf("%w: %s price %s above maxPrice %s",
+			ErrOrderFilter, f.Symbol, formatFloat(price), formatFloat(f.MaxPrice))
+	}
+	return nil
+}
+
+// floorToStep 按步长向下取整（容忍浮点误差）
+func floorToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	return trimToStep(math.Floor(value/step+1e-9)*step, step)
+}
+
+// trimToStep 按步长的小数位数格式化，消除浮点误差（如0.30000000000000004）
+func trimToStep(value, step float64) float64 {
+	decimals := 0
+	for s := step; s < 1 && decimals < 10; s *= 10 {
+		decimals++
+	}
+	trimmed, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64)
+	return trimmed
+}
@@ -3,6 +3,7 @@ package exchange
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,9 +16,9 @@ import (
 
 // HTTPClient HTTP客户端封装
 type HTTPClient struct {
-	client      *http.Client
-	rateLimiter *RateLimiter
-	baseURL     string
+	client  *http.Client
+	limiter *WeightLimiter
+	baseURL string
 }
 
 var globalHTTPClient *HTTPClient
@@ -26,25 +27,68 @@ var globalHTTPClient *HTTPClient
 func GetHTTPClient() *HTTPClient {
 	if globalHTTPClient == nil {
 		cfg := config.Get()
-		globalHTTPClient = &HTTPClient{
-			client: &http.Client{
-				Timeout: time.Duration(cfg.BinanceHTTPTimeoutSec) * time.Second,
-			},
-			rateLimiter: NewRateLimiter(10.0, 20), // 10 req/s, capacity 20
-			baseURL:     cfg.BinanceFAPIBaseURL,
-		}
+		globalHTTPClient = NewHTTPClient(cfg.BinanceFAPIBaseURL, time.Duration(cfg.BinanceHTTPTimeoutSec)*time.Second)
 	}
 	return globalHTTPClient
 }
 
+// NewHTTPClient 创建指向指定地址的HTTP客户端
+func NewHTTPClient(baseURL string, timeout time.Duration) *HTTPClient {
+	return &HTTPClient{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		limiter: NewWeightLimiter(),
+		baseURL: baseURL,
+	}
+}
+
+// Limiter 请求权重限流器（签名请求与公开请求共用）
+func (c *HTTPClient) Limiter() *WeightLimiter {
+	return c.limiter
+}
+
 // FetchJSON 获取JSON数据（带限流和重试）
+// 超时、网络错误和5xx等可重试错误按指数退避重试；限流由退避窗口控制，不立即重试
 func (c *HTTPClient) FetchJSON(ctx context.Context, endpoint string, params map[string]string) (interface{}, error) {
+	maxRetries := 0
+	if cfg := config.Get(); cfg != nil {
+		maxRetries = cfg.BinanceHTTPMaxRetries
+	}
+
+	result, err := c.fetchOnce(ctx, endpoint, params)
+	for attempt := 1; err != nil && attempt <= maxRetries; attempt++ {
+		if !IsRetryable(err) || errors.Is(err, ErrRateLimited) || ctx.Err() != nil {
+			break
+		}
+
+		logger := utils.GetLogger("exchange")
+		logger.Debugw("Retrying request",
+			"endpoint", endpoint,
+			"attempt", attempt,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(retryDelay(attempt)):
+		}
+		result, err = c.fetchOnce(ctx, endpoint, params)
+	}
+	return result, err
+}
+
+// fetchOnce 发送一次GET请求
+func (c *HTTPClient) fetchOnce(ctx context.Context, endpoint string, params map[string]string) (interface{}, error) {
 	// 等待退避窗口（如果有）
 	globalBackoff := GetGlobalBackoff()
 	globalBackoff.WaitBackoff("binance")
 
-	// 应用限流
-	c.rateLimiter.Wait(1)
+	// 按接口权重限流
+	if err := c.limiter.Wait(ctx, BinanceRequestCost(http.MethodGet, endpoint, params)); err != nil {
+		return nil, fmt.Errorf("rate limiter wait failed: %w", err)
+	}
 
 	// 构建URL
 	u, err := url.Parse(c.baseURL + endpoint)
@@ -73,6 +117,7 @@ func (c *HTTPClient) FetchJSON(ctx context.Context, endpoint string, params map[
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	c.limiter.UpdateFromHeaders(resp.Header)
 
 	// 处理响应
 	if resp.StatusCode == http.StatusOK {
@@ -109,10 +154,10 @@ func (c *HTTPClient) FetchJSON(ctx context.Context, endpoint string, params map[
 			"wait_sec", waitSec,
 		)
 
-		return nil, fmt.Errorf("rate limited: HTTP %d, wait %.1fs", resp.StatusCode, waitSec)
+		return nil, fmt.Errorf("%w: HTTP %d, wait %.1fs", ErrRateLimited, resp.StatusCode, waitSec)
 	} else {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, newBinanceAPIError("GET "+endpoint, resp.StatusCode, body)
 	}
 }
 
@@ -0,0 +1,498 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const (
+	// MarkPriceStream 全市场标记价格流（3秒推送一次，包含资金费率）
+	MarkPriceStream = "!markPrice@arr"
+	// ForceOrderStream 全市场强平订单流（每个交易对每秒最多推送一条）
+	ForceOrderStream = "!forceOrder@arr"
+
+	marketStreamMaxStreams    = 1024                   // 单个连接最多订阅1024个流
+	marketStreamSubscribeSize = 200                    // 每条SUBSCRIBE消息携带的流数量
+	marketStreamMsgInterval   = 250 * time.Millisecond // 每秒最多10条控制消息
+	marketStreamReadTimeout   = 2 * time.Minute
+	marketStreamMaxBackoff    = 60 * time.Second
+)
+
+// KlineEvent K线推送事件
+type KlineEvent struct {
+	Symbol   string      `json:"symbol"`
+	Interval string      `json:"interval"`
+	Candle   types.OHLCV `json:"candle"`
+	Closed   bool        `json:"closed"` // K线是否已收盘
+}
+
+// MarkPriceUpdate 标记价格推送
+type MarkPriceUpdate struct {
+	Symbol          string  `json:"symbol"`
+	MarkPrice       float64 `json:"mark_price"`
+	IndexPrice      float64 `json:"index_price"`
+	FundingRate     float64 `json:"funding_rate"`
+	NextFundingTime int64   `json:"next_funding_time"`
+	EventTime       int64   `json:"event_time"`
+}
+
+// PremiumIndex 转换为标记价格与资金费率数据（与REST /fapi/v1/premiumIndex 一致）
+func (u MarkPriceUpdate) PremiumIndex() *types.PremiumIndex {
+	return &types.PremiumIndex{
+		Symbol:               u.Symbol,
+		MarkPrice:            u.MarkPrice,
+		IndexPrice:           u.IndexPrice,
+		PredictedFundingRate: u.FundingRate,
+		NextFundingTime:      u.NextFundingTime,
+		Timestamp:            u.EventTime,
+	}
+}
+
+// LiquidationEvent 强平订单
+// Side为强平单方向：SELL表示多头被强平，BUY表示空头被强平
+type LiquidationEvent struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Price    float64 `json:"price"`    // 成交均价（未成交时为委托价）
+	Quantity float64 `json:"quantity"` // 成交数量（币数量）
+	Time     int64   `json:"time"`     // 成交时间（毫秒）
+}
+
+// Notional 强平名义价值（USDT）
+func (e LiquidationEvent) Notional() float64 {
+	return e.Price * e.Quantity
+}
+
+// KlineStreamName K线流名称（如btcusdt@kline_1m）
+func KlineStreamName(symbol, interval string) string {
+	return fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+}
+
+// ParseMarketStreamMessage 解析行情流消息（支持组合流包装格式）
+// 返回*KlineEvent、[]MarkPriceUpdate、*LiquidationEvent，订阅响应和未知消息返回nil
+func ParseMarketStreamMessage(data []byte) (interface{}, error) {
+	var msg interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("parse market stream message failed: %w", err)
+	}
+
+	// 组合流格式：{"stream":"...","data":...}
+	if wrapper, ok := msg.(map[string]interface{}); ok {
+		if payload, ok := wrapper["data"]; ok {
+			msg = payload
+		}
+	}
+
+	switch payload := msg.(type) {
+	case []interface{}:
+		updates := make([]MarkPriceUpdate, 0, len(payload))
+		for _, item := range payload {
+			m, ok := item.(map[string]interface{})
+			if !ok || parseStringValue(m["e"]) != "markPriceUpdate" {
+				continue
+			}
+			updates = append(updates, parseMarkPriceUpdate(m))
+		}
+		return updates, nil
+
+	case map[string]interface{}:
+		switch parseStringValue(payload["e"]) {
+		case "kline":
+			k, ok := payload["k"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid kline payload")
+			}
+			event := &KlineEvent{
+				Symbol:   parseStringValue(k["s"]),
+				Interval: parseStringValue(k["i"]),
+			}
+			openTime, _ := parseFloatValue(k["t"])
+			event.Candle.Time = int64(openTime)
+			event.Candle.Open, _ = parseFloatValue(k["o"])
+			event.Candle.High, _ = parseFloatValue(k["h"])
+			event.Candle.Low, _ = parseFloatValue(k["l"])
+			event.Candle.Close, _ = parseFloatValue(k["c"])
+			event.Candle.Volume, _ = parseFloatValue(k["v"])
+			event.Candle.TakerBuyVolume, _ = parseFloatValue(k["V"])
+			event.Closed, _ = parseBoolValue(k["x"])
+			if event.Symbol == "" {
+				event.Symbol = parseStringValue(payload["s"])
+			}
+			return event, nil
+
+		case "markPriceUpdate":
+			return []MarkPriceUpdate{parseMarkPriceUpdate(payload)}, nil
+
+		case "forceOrder":
+			o, ok := payload["o"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid force order payload")
+			}
+			event := &LiquidationEvent{
+				Symbol: parseStringValue(o["s"]),
+				Side:   parseStringValue(o["S"]),
+			}
+			event.Price, _ = parseFloatValue(o["ap"])
+			if event.Price == 0 {
+				event.Price, _ = parseFloatValue(o["p"])
+			}
+			event.Quantity, _ = parseFloatValue(o["z"])
+			if event.Quantity == 0 {
+				event.Quantity, _ = parseFloatValue(o["q"])
+			}
+			tradeTime, _ := parseFloatValue(o["T"])
+			event.Time = int64(tradeTime)
+			return event, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// parseMarkPriceUpdate 解析单条标记价格
+func parseMarkPriceUpdate(m map[string]interface{}) MarkPriceUpdate {
+	update := MarkPriceUpdate{Symbol: parseStringValue(m["s"])}
+	update.MarkPrice, _ = parseFloatValue(m["p"])
+	update.IndexPrice, _ = parseFloatValue(m["i"])
+	update.FundingRate, _ = parseFloatValue(m["r"])
+	nextFunding, _ := parseFloatValue(m["T"])
+	update.NextFundingTime = int64(nextFunding)
+	eventTime, _ := parseFloatValue(m["E"])
+	update.EventTime = int64(eventTime)
+	return update
+}
+
+// MarketStream Binance组合行情流（K线 + 全市场标记价格，可选全市场强平订单）
+type MarketStream struct {
+	wsBaseURL    string
+	timeframes   []string
+	liquidations bool // 是否订阅全市场强平订单流
+
+	subMu   sync.Mutex
+	symbols map[string]bool // 期望订阅的交易对
+	active  map[string]bool // 当前连接上已订阅的K线流
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	nextID  int64
+
+	handlersMu    sync.RWMutex
+	klineHandlers []func(*KlineEvent)
+	markHandlers  []func([]MarkPriceUpdate)
+	liqHandlers   []func(*LiquidationEvent)
+
+	connected atomic.Bool
+}
+
+// NewMarketStream 创建行情流，timeframes 为每个交易对订阅的K线周期
+func NewMarketStream(wsBaseURL string, timeframes []string) *MarketStream {
+	return &MarketStream{
+		wsBaseURL:  strings.TrimRight(wsBaseURL, "/"),
+		timeframes: timeframes,
+		symbols:    make(map[string]bool),
+		active:     make(map[string]bool),
+	}
+}
+
+// OnKline 注册K线回调
+func (s *MarketStream) OnKline(handler func(*KlineEvent)) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.klineHandlers = append(s.klineHandlers, handler)
+}
+
+// OnMarkPrice 注册标记价格回调
+func (s *MarketStream) OnMarkPrice(handler func([]MarkPriceUpdate)) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.markHandlers = append(s.markHandlers, handler)
+}
+
+// OnLiquidation 注册强平订单回调，同时在下次连接时订阅全市场强平订单流
+func (s *MarketStream) OnLiquidation(handler func(*LiquidationEvent)) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.liqHandlers = append(s.liqHandlers, handler)
+	s.liquidations = true
+}
+
+// IsConnected 行情流是否已连接
+func (s *MarketStream) IsConnected() bool {
+	return s.connected.Load()
+}
+
+// MaxSymbols 单个连接可订阅的交易对上限
+func (s *MarketStream) MaxSymbols() int {
+	if len(s.timeframes) == 0 {
+		return marketStreamMaxStreams
+	}
+	// 标记价格流和强平订单流各占一个名额
+	return (marketStreamMaxStreams - 2) / len(s.timeframes)
+}
+
+// SetSymbols 设置订阅的交易对，已连接时增量发送SUBSCRIBE/UNSUBSCRIBE
+// 返回实际订阅的交易对（超过连接上限的部分被忽略，由调用方回退到REST）
+func (s *MarketStream) SetSymbols(symbols []string) []string {
+	normalized := make([]string, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		normalized = append(normalized, symbol)
+	}
+	if max := s.MaxSymbols(); len(normalized) > max {
+		utils.GetLogger("market_stream").Warnw("订阅交易对超过单连接上限，超出部分使用REST",
+			"requested", len(normalized),
+			"max", max,
+		)
+		normalized = normalized[:max]
+	}
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.symbols = make(map[string]bool, len(normalized))
+	for _, symbol := range normalized {
+		s.symbols[symbol] = true
+	}
+	if s.conn != nil {
+		if err := s.syncSubscriptions(); err != nil {
+			utils.GetLogger("market_stream").Warnw("更新行情订阅失败", "error", err)
+		}
+	}
+	return normalized
+}
+
+// Symbols 当前订阅的交易对
+func (s *MarketStream) Symbols() []string {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	symbols := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Run 运行行情流（阻塞，断线自动重连，直到ctx取消）
+func (s *MarketStream) Run(ctx context.Context) {
+	logger := utils.GetLogger("market_stream")
+	backoff := time.Second
+
+	for {
+		started := time.Now()
+		err := s.runOnce(ctx)
+		s.connected.Store(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		logger.Warnw("行情流断开，准备重连", "error", err, "wait", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > marketStreamMaxBackoff {
+			backoff = marketStreamMaxBackoff
+		}
+	}
+}
+
+// runOnce 保持一次组合流连接
+func (s *MarketStream) runOnce(ctx context.Context) error {
+	logger := utils.GetLogger("market_stream")
+
+	// 连接时只携带全市场流，K线流通过SUBSCRIBE分批订阅，避免URL过长
+	streams := MarkPriceStream
+	s.handlersMu.RLock()
+	if s.liquidations {
+		streams += "/" + ForceOrderStream
+	}
+	s.handlersMu.RUnlock()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.wsBaseURL+"/stream?streams="+streams, nil)
+	if err != nil {
+		return fmt.Errorf("dial market stream failed: %w", err)
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(marketStreamReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(marketStreamReadTimeout))
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	s.subMu.Lock()
+	s.conn = conn
+	s.active = make(map[string]bool)
+	err = s.syncSubscriptions()
+	s.subMu.Unlock()
+
+	defer func() {
+		s.subMu.Lock()
+		s.conn = nil
+		s.active = make(map[string]bool)
+		s.subMu.Unlock()
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	s.connected.Store(true)
+	logger.Infow("行情流已连接", "symbols", len(s.Symbols()))
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("read market stream failed: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(marketStreamReadTimeout))
+
+		event, err := ParseMarketStreamMessage(message)
+		if err != nil {
+			logger.Debugw("解析行情流消息失败", "error", err)
+			continue
+		}
+
+		switch ev := event.(type) {
+		case *KlineEvent:
+			s.dispatchKline(ev)
+		case []MarkPriceUpdate:
+			s.dispatchMarkPrice(ev)
+		case *LiquidationEvent:
+			s.dispatchLiquidation(ev)
+		}
+	}
+}
+
+// syncSubscriptions 将连接上的订阅与期望状态对齐（调用方持有subMu）
+func (s *MarketStream) syncSubscriptions() error {
+	wanted := make(map[string]bool)
+	for symbol := range s.symbols {
+		for _, tf := range s.timeframes {
+			wanted[KlineStreamName(symbol, tf)] = true
+		}
+	}
+
+	var subscribe, unsubscribe []string
+	for stream := range wanted {
+		if !s.active[stream] {
+			subscribe = append(subscribe, stream)
+		}
+	}
+	for stream := range s.active {
+		if !wanted[stream] {
+			unsubscribe = append(unsubscribe, stream)
+		}
+	}
+	sort.Strings(subscribe)
+	sort.Strings(unsubscribe)
+
+	if err := s.sendControl("UNSUBSCRIBE", unsubscribe); err != nil {
+		return err
+	}
+	for _, stream := range unsubscribe {
+		delete(s.active, stream)
+	}
+
+	if err := s.sendControl("SUBSCRIBE", subscribe); err != nil {
+		return err
+	}
+	for _, stream := range subscribe {
+		s.active[stream] = true
+	}
+	return nil
+}
+
+// sendControl 分批发送订阅控制消息
+func (s *MarketStream) sendControl(method string, streams []string) error {
+	for start := 0; start < len(streams); start += marketStreamSubscribeSize {
+		end := start + marketStreamSubscribeSize
+		if end > len(streams) {
+			end = len(streams)
+		}
+		if start > 0 {
+			time.Sleep(marketStreamMsgInterval)
+		}
+
+		s.nextID++
+		msg := map[string]interface{}{
+			"method": method,
+			"params": streams[start:end],
+			"id":     s.nextID,
+		}
+
+		s.writeMu.Lock()
+		s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		err := s.conn.WriteJSON(msg)
+		s.writeMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", strings.ToLower(method), err)
+		}
+	}
+	return nil
+}
+
+// dispatchKline 分发K线事件
+func (s *MarketStream) dispatchKline(event *KlineEvent) {
+	s.handlersMu.RLock()
+	handlers := s.klineHandlers
+	s.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// dispatchMarkPrice 分发标记价格
+func (s *MarketStream) dispatchMarkPrice(updates []MarkPriceUpdate) {
+	s.handlersMu.RLock()
+	handlers := s.markHandlers
+	s.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(updates)
+	}
+}
+
+// dispatchLiquidation 分发强平订单
+func (s *MarketStream) dispatchLiquidation(event *LiquidationEvent) {
+	s.handlersMu.RLock()
+	handlers := s.liqHandlers
+	s.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,520 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const (
+	okxInstTypeSwap  = "SWAP"
+	okxMaxCandles    = 300     // /api/v5/market/candles 单次最多返回300根
+	okxMaxBookDepth  = 400     // /api/v5/market/books 单侧最多400档
+	okxMaxFunding    = 100     // /api/v5/public/funding-rate-history 单次最多返回100条
+	okxAlgoIDPrefix  = "algo_" // 条件单（止损/止盈）ID前缀，用于区分普通订单
+	okxBackoffTarget = "okx"
+)
+
+// OKXExchange OKX USDT永续合约实现
+type OKXExchange struct {
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	passphrase string
+	simulated  bool
+	tdMode     string
+
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+
+	instruments   map[string]okxInstrument
+	instrumentsMu sync.RWMutex
+}
+
+// okxInstrument 合约元数据
+type okxInstrument struct {
+	InstID string
+	CtVal  float64 // 每张合约对应的币数量
+	LotSz  float64 // 下单数量精度（张）
+	MinSz  float64 // 最小下单数量（张）
+	TickSz float64 // 价格精度
+}
+
+var globalOKXExchange *OKXExchange
+
+// GetOKXExchange 获取OKX交易所实例（单例）
+func GetOKXExchange() *OKXExchange {
+	if globalOKXExchange == nil {
+		cfg := config.Get()
+		globalOKXExchange = NewOKXExchange(cfg.OKXBaseURL, cfg.OKXAPIKey, cfg.OKXSecretKey, cfg.OKXPassphrase)
+		globalOKXExchange.simulated = cfg.OKXSimulated
+		if cfg.OKXTdMode != "" {
+			globalOKXExchange.tdMode = cfg.OKXTdMode
+		}
+	}
+	return globalOKXExchange
+}
+
+// NewOKXExchange 创建OKX交易所
+func NewOKXExchange(baseURL, apiKey, secretKey, passphrase string) *OKXExchange {
+	return &OKXExchange{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      apiKey,
+		secretKey:   secretKey,
+		passphrase:  passphrase,
+		tdMode:      "cross",
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		rateLimiter: NewRateLimiter(10.0, 20),
+		instruments: make(map[string]okxInstrument),
+	}
+}
+
+// OKXInstID 交易对转换为OKX合约ID（BTCUSDT -> BTC-USDT-SWAP）
+func OKXInstID(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if strings.HasSuffix(symbol, "-SWAP") {
+		return symbol
+	}
+	symbol = strings.NewReplacer("/", "", "-", "", "_", "", ":USDT", "").Replace(symbol)
+	base := strings.TrimSuffix(symbol, "USDT")
+	return base + "-USDT-SWAP"
+}
+
+// OKXSymbol OKX合约ID转换为交易对（BTC-USDT-SWAP -> BTCUSDT）
+func OKXSymbol(instID string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(strings.ToUpper(instID), "-SWAP"), "-", "")
+}
+
+// okxBar K线周期转换（小时及以上使用UTC对齐，与Binance保持一致）
+func okxBar(timeframe string) string {
+	switch timeframe {
+	case "1h", "2h", "4h":
+		return strings.ToUpper(timeframe)
+	case "6h", "12h", "1d", "3d":
+		return strings.ToUpper(timeframe) + "utc"
+	case "1w":
+		return "1Wutc"
+	case "1M":
+		return "1Mutc"
+	}
+	return timeframe
+}
+
+// GetOHLCV 获取K线（OKX返回倒序，这里转换为时间正序）
+func (ox *OKXExchange) GetOHLCV(symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	return ox.GetOHLCVContext(context.Background(), symbol, timeframe, limit)
+}
+
+// GetOHLCVContext 同GetOHLCV，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	if limit <= 0 || limit > okxMaxCandles {
+		limit = okxMaxCandles
+	}
+	params := map[string]string{
+		"instId": OKXInstID(symbol),
+		"bar":    okxBar(timeframe),
+		"limit":  strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/market/candles", params, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OHLCV: %w", err)
+	}
+
+	result := make([]types.OHLCV, 0, len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		k, ok := data[i].([]interface{})
+		if !ok || len(k) < 7 {
+			continue
+		}
+		ts, err := parseFloatValue(k[0])
+		if err != nil {
+			continue
+		}
+		candle := types.OHLCV{Time: int64(ts)}
+		candle.Open, _ = parseFloatValue(k[1])
+		candle.High, _ = parseFloatValue(k[2])
+		candle.Low, _ = parseFloatValue(k[3])
+		candle.Close, _ = parseFloatValue(k[4])
+		// k[5]为张数，k[6]为币数量（与Binance成交量单位一致）
+		candle.Volume, _ = parseFloatValue(k[6])
+		result = append(result, candle)
+	}
+
+	return result, nil
+}
+
+// GetTickerPrice 获取最新成交价
+func (ox *OKXExchange) GetTickerPrice(symbol string) (float64, error) {
+	return ox.GetTickerPriceContext(context.Background(), symbol)
+}
+
+// GetTickerPriceContext 同GetTickerPrice，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetTickerPriceContext(ctx context.Context, symbol string) (float64, error) {
+	item, err := ox.fetchFirst(ctx, "/api/v5/market/ticker", map[string]string{"instId": OKXInstID(symbol)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ticker price: %w", err)
+	}
+	return parseNumericField(item, "last")
+}
+
+// GetFundingRate 获取当前资金费率
+func (ox *OKXExchange) GetFundingRate(symbol string) (float64, error) {
+	return ox.GetFundingRateContext(context.Background(), symbol)
+}
+
+// GetFundingRateContext 同GetFundingRate，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetFundingRateContext(ctx context.Context, symbol string) (float64, error) {
+	item, err := ox.fetchFirst(ctx, "/api/v5/public/funding-rate", map[string]string{"instId": OKXInstID(symbol)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get funding rate: %w", err)
+	}
+	return parseNumericField(item, "fundingRate")
+}
+
+// GetFundingHistory 获取最近limit次资金费率结算（OKX返回倒序，这里转换为时间正序）
+func (ox *OKXExchange) GetFundingHistory(symbol string, limit int) ([]types.FundingRateRecord, error) {
+	return ox.GetFundingHistoryContext(context.Background(), symbol, limit)
+}
+
+// GetFundingHistoryContext 同GetFundingHistory，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetFundingHistoryContext(ctx context.Context, symbol string, limit int) ([]types.FundingRateRecord, error) {
+	if limit <= 0 || limit > okxMaxFunding {
+		limit = okxMaxFunding
+	}
+	params := map[string]string{
+		"instId": OKXInstID(symbol),
+		"limit":  strconv.Itoa(limit),
+	}
+
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/public/funding-rate-history", params, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding history: %w", err)
+	}
+
+	records := make([]types.FundingRateRecord, 0, len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		item, ok := data[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// realizedRate为实际结算费率，缺失时取fundingRate
+		rate, err := parseNumericField(item, "realizedRate")
+		if err != nil {
+			if rate, err = parseNumericField(item, "fundingRate"); err != nil {
+				continue
+			}
+		}
+		fundingTime, _ := parseFloatValue(item["fundingTime"])
+		records = append(records, types.FundingRateRecord{
+			Symbol:      OKXSymbol(parseStringValue(item["instId"])),
+			FundingRate: rate,
+			FundingTime: int64(fundingTime),
+		})
+	}
+	return records, nil
+}
+
+// GetPremiumIndex 获取标记价格、指数价格和预测资金费率
+func (ox *OKXExchange) GetPremiumIndex(symbol string) (*types.PremiumIndex, error) {
+	return ox.GetPremiumIndexContext(context.Background(), symbol)
+}
+
+// GetPremiumIndexContext 同GetPremiumIndex，ctx取消或超时时中止请求
+// 资金费率、标记价格、指数价格分别来自三个接口
+func (ox *OKXExchange) GetPremiumIndexContext(ctx context.Context, symbol string) (*types.PremiumIndex, error) {
+	instID := OKXInstID(symbol)
+
+	funding, err := ox.fetchFirst(ctx, "/api/v5/public/funding-rate", map[string]string{"instId": instID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	rate, err := parseNumericField(funding, "fundingRate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	// fundingTime为本周期（即将结算）的结算时间
+	nextFunding, _ := parseFloatValue(funding["fundingTime"])
+	premium := &types.PremiumIndex{
+		Symbol:               OKXSymbol(instID),
+		PredictedFundingRate: rate,
+		NextFundingTime:      int64(nextFunding),
+	}
+
+	mark, err := ox.fetchFirst(ctx, "/api/v5/public/mark-price", map[string]string{
+		"instType": okxInstTypeSwap,
+		"instId":   instID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mark price: %w", err)
+	}
+	premium.MarkPrice, _ = parseNumericField(mark, "markPx")
+	ts, _ := parseFloatValue(mark["ts"])
+	premium.Timestamp = int64(ts)
+
+	index, err := ox.fetchFirst(ctx, "/api/v5/market/index-tickers", map[string]string{
+		"instId": strings.TrimSuffix(instID, "-SWAP"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index price: %w", err)
+	}
+	premium.IndexPrice, _ = parseNumericField(index, "idxPx")
+	return premium, nil
+}
+
+// GetOpenInterest 获取持仓量（币数量）
+func (ox *OKXExchange) GetOpenInterest(symbol string) (float64, error) {
+	return ox.GetOpenInterestContext(context.Background(), symbol)
+}
+
+// GetOpenInterestContext 同GetOpenInterest，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetOpenInterestContext(ctx context.Context, symbol string) (float64, error) {
+	item, err := ox.fetchFirst(ctx, "/api/v5/public/open-interest", map[string]string{
+		"instType": okxInstTypeSwap,
+		"instId":   OKXInstID(symbol),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open interest: %w", err)
+	}
+	return parseNumericField(item, "oiCcy")
+}
+
+// GetOrderBook 获取深度快照（数量按ctVal换算为币数量）
+func (ox *OKXExchange) GetOrderBook(symbol string, limit int) (*types.OrderBook, error) {
+	return ox.GetOrderBookContext(context.Background(), symbol, limit)
+}
+
+// GetOrderBookContext 同GetOrderBook，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetOrderBookContext(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if limit <= 0 || limit > okxMaxBookDepth {
+		limit = okxMaxBookDepth
+	}
+	instID := OKXInstID(symbol)
+	inst, err := ox.instrument(instID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	item, err := ox.fetchFirst(ctx, "/api/v5/market/books", map[string]string{
+		"instId": instID,
+		"sz":     strconv.Itoa(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order book: %w", err)
+	}
+
+	book := &types.OrderBook{
+		Symbol: normalizeUSDTSymbol(symbol),
+		Bids:   parseBookLevels(item["bids"], inst.CtVal),
+		Asks:   parseBookLevels(item["asks"], inst.CtVal),
+	}
+	if ts, err := parseFloatValue(item["ts"]); err == nil {
+		book.Timestamp = int64(ts)
+	}
+	return book, nil
+}
+
+// fetchFirst 请求公共接口并返回第一条数据
+func (ox *OKXExchange) fetchFirst(ctx context.Context, path string, params map[string]string) (map[string]interface{}, error) {
+	ctx, cancel := utils.WithMediumTimeout(ctx)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, path, params, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	item, ok := data[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid data format")
+	}
+	return item, nil
+}
+
+// instrument 获取合约元数据（首次使用时加载全部SWAP合约）
+func (ox *OKXExchange) instrument(instID string) (okxInstrument, error) {
+	ox.instrumentsMu.RLock()
+	inst, ok := ox.instruments[instID]
+	ox.instrumentsMu.RUnlock()
+	if ok {
+		return inst, nil
+	}
+
+	if err := ox.loadInstruments(); err != nil {
+		return okxInstrument{}, err
+	}
+
+	ox.instrumentsMu.RLock()
+	defer ox.instrumentsMu.RUnlock()
+	if inst, ok := ox.instruments[instID]; ok {
+		return inst, nil
+	}
+	return okxInstrument{}, fmt.Errorf("instrument not found: %s", instID)
+}
+
+// loadInstruments 加载USDT永续合约元数据
+func (ox *OKXExchange) loadInstruments() error {
+	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/public/instruments", map[string]string{"instType": okxInstTypeSwap}, nil, false)
+	if err != nil {
+		return fmt.Errorf("failed to load instruments: %w", err)
+	}
+
+	instruments := make(map[string]okxInstrument, len(data))
+	for _, item := range data {
+		m, ok := item.(map[string]interface{})
+		if !ok || parseStringValue(m["settleCcy"]) != "USDT" {
+			continue
+		}
+		inst := okxInstrument{InstID: parseStringValue(m["instId"])}
+		inst.CtVal, _ = parseFloatValue(m["ctVal"])
+		inst.LotSz, _ = parseFloatValue(m["lotSz"])
+		inst.MinSz, _ = parseFloatValue(m["minSz"])
+		inst.TickSz, _ = parseFloatValue(m["tickSz"])
+		if inst.InstID == "" || inst.CtVal <= 0 {
+			continue
+		}
+		instruments[inst.InstID] = inst
+	}
+
+	ox.instrumentsMu.Lock()
+	ox.instruments = instruments
+	ox.instrumentsMu.Unlock()
+
+	utils.GetLogger("exchange").Infow("OKX instruments loaded", "count", len(instruments))
+	return nil
+}
+
+// toContracts 币数量转换为张数（按lotSz向下取整）
+func (inst okxInstrument) toContracts(quantity float64) (float64, error) {
+	contracts := floorToStep(quantity/inst.CtVal, inst.LotSz)
+	if contracts <= 0 || contracts < inst.MinSz {
+		return 0, fmt.Errorf("quantity %s below minimum size for %s (%s contracts x %s)",
+			formatFloat(quantity), inst.InstID, formatFloat(inst.MinSz), formatFloat(inst.CtVal))
+	}
+	return contracts, nil
+}
+
+// request 发送请求并返回data字段，signed为true时添加签名头
+func (ox *OKXExchange) request(ctx context.Context, method, path string, params map[string]string, body interface{}, signed bool) ([]interface{}, error) {
+	backoff := GetGlobalBackoff()
+	backoff.WaitBackoff(okxBackoffTarget)
+	ox.rateLimiter.Wait(1)
+
+	requestPath := path
+	if len(params) > 0 {
+		q := url.Values{}
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		requestPath += "?" + q.Encode()
+	}
+
+	var bodyStr string
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal body failed: %w", err)
+		}
+		bodyStr = string(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, ox.baseURL+requestPath, bytes.NewBufferString(bodyStr))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if ox.simulated {
+		httpReq.Header.Set("x-simulated-trading", "1")
+	}
+	if signed {
+		if ox.apiKey == "" || ox.secretKey == "" || ox.passphrase == "" {
+			return nil, fmt.Errorf("API keys required")
+		}
+		timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		httpReq.Header.Set("OK-ACCESS-KEY", ox.apiKey)
+		httpReq.Header.Set("OK-ACCESS-SIGN", ox.sign(timestamp, method, requestPath, bodyStr))
+		httpReq.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		httpReq.Header.Set("OK-ACCESS-PASSPHRASE", ox.passphrase)
+	}
+
+	resp, err := ox.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var retryAfter *float64
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			retryAfter = ParseRetryAfter(v)
+		}
+		waitSec := backoff.OnRateLimited(okxBackoffTarget, resp.StatusCode, retryAfter)
+		utils.GetLogger("exchange").Warnw("OKX API rate limited",
+			"path", path,
+			"wait_sec", waitSec,
+		)
+		return nil, fmt.Errorf("%w: HTTP %d, wait %.1fs", ErrRateLimited, resp.StatusCode, waitSec)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, newOKXAPIError(resp.StatusCode, "", string(respBody))
+	}
+
+	data, _ := result["data"].([]interface{})
+	if code := parseStringValue(result["code"]); code != "0" {
+		// 交易接口的具体错误在data[].sCode/sMsg中
+		msg := parseStringValue(result["msg"])
+		if len(data) > 0 {
+			if item, ok := data[0].(map[string]interface{}); ok && parseStringValue(item["sMsg"]) != "" {
+				code = parseStringValue(item["sCode"])
+				msg = parseStringValue(item["sMsg"])
+			}
+		}
+		return nil, newOKXAPIError(resp.StatusCode, code, msg)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newOKXAPIError(resp.StatusCode, "", string(respBody))
+	}
+
+	backoff.ResetBackoff(okxBackoffTarget)
+	return data, nil
+}
+
+// sign 生成签名：Base64(HMAC-SHA256(timestamp + method + requestPath + body))
+func (ox *OKXExchange) sign(timestamp, method, requestPath, body string) string {
+	mac := hmac.New(sha256.New, []byte(ox.secretKey))
+	mac.Write([]byte(timestamp + strings.ToUpper(method) + requestPath + body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
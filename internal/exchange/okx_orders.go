@@ -0,0 +1,634 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// PlaceOrder 下单（数量为币数量，内部换算为张数）
+// STOP/STOP_MARKET/TAKE_PROFIT/TAKE_PROFIT_MARKET 通过条件单接口下单，返回的订单ID带algo_前缀
+func (ox *OKXExchange) PlaceOrder(req types.OrderRequest) (*types.Order, error) {
+	return ox.PlaceOrderContext(context.Background(), req)
+}
+
+// PlaceOrderContext 同PlaceOrder，ctx取消或超时时中止请求
+func (ox *OKXExchange) PlaceOrderContext(ctx context.Context, req types.OrderRequest) (*types.Order, error) {
+	instID := OKXInstID(req.Symbol)
+	symbol := OKXSymbol(instID)
+	orderType := strings.ToUpper(req.OrderType)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: OKX order would be placed",
+			"inst_id", instID,
+			"side", req.Side,
+			"order_type", orderType,
+			"quantity", req.Quantity,
+			"price", req.Price,
+		)
+		return &types.Order{
+			ID:           "dry_run_" + strconv.FormatInt(time.Now().UnixNano(), 10),
+			Symbol:       symbol,
+			Side:         strings.ToUpper(req.Side),
+			PositionSide: strings.ToUpper(req.PositionSide),
+			OrderType:    orderType,
+			Status:       "NEW",
+			Quantity:     req.Quantity,
+			Price:        getFloatValue(req.Price),
+			Timestamp:    time.Now().Unix(),
+		}, nil
+	}
+
+	inst, err := ox.instrument(instID)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := inst.toContracts(req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"instId": instID,
+		"tdMode": ox.tdMode,
+		"side":   strings.ToLower(req.Side),
+		"sz":     formatFloat(contracts),
+	}
+	if req.PositionSide != "" {
+		body["posSide"] = strings.ToLower(req.PositionSide)
+	}
+	if req.ReduceOnly {
+		body["reduceOnly"] = true
+	}
+
+	path := "/api/v5/trade/order"
+	isAlgo := false
+	switch orderType {
+	case "MARKET":
+		body["ordType"] = "market"
+	case "LIMIT":
+		if req.Price == nil || *req.Price <= 0 {
+			return nil, fmt.Errorf("price required for LIMIT order")
+		}
+		body["px"] = formatFloat(*req.Price)
+		switch strings.ToUpper(req.TimeInForce) {
+		case "IOC":
+			body["ordType"] = "ioc"
+		case "FOK":
+			body["ordType"] = "fok"
+		case "GTX":
+			body["ordType"] = "post_only"
+		default:
+			body["ordType"] = "limit"
+		}
+	case "STOP", "STOP_MARKET", "TAKE_PROFIT", "TAKE_PROFIT_MARKET":
+		if req.StopPrice == nil || *req.StopPrice <= 0 {
+			return nil, fmt.Errorf("stop price required for %s order", orderType)
+		}
+		// 触发后市价成交用-1
+		ordPx := "-1"
+		if orderType == "STOP" || orderType == "TAKE_PROFIT" {
+			if req.Price == nil || *req.Price <= 0 {
+				return nil, fmt.Errorf("price required for %s order", orderType)
+			}
+			ordPx = formatFloat(*req.Price)
+		}
+		prefix := "sl"
+		if strings.HasPrefix(orderType, "TAKE_PROFIT") {
+			prefix = "tp"
+		}
+		body["ordType"] = "conditional"
+		body[prefix+"TriggerPx"] = formatFloat(*req.StopPrice)
+		body[prefix+"OrdPx"] = ordPx
+		body[prefix+"TriggerPxType"] = "last"
+		path = "/api/v5/trade/order-algo"
+		isAlgo = true
+	default:
+		return nil, fmt.Errorf("unsupported order type: %s", req.OrderType)
+	}
+	if req.ClientOrderID != "" {
+		if isAlgo {
+			body["algoClOrdId"] = req.ClientOrderID
+		} else {
+			body["clOrdId"] = req.ClientOrderID
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodPost, path, nil, body, true)
+	if err != nil {
+		return nil, fmt.Errorf("place order failed: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("place order failed: empty response")
+	}
+	resp, _ := data[0].(map[string]interface{})
+
+	orderID := parseStringValue(resp["ordId"])
+	if isAlgo {
+		orderID = okxAlgoIDPrefix + parseStringValue(resp["algoId"])
+	}
+
+	order := &types.Order{
+		ID:            orderID,
+		Symbol:        symbol,
+		Side:          strings.ToUpper(req.Side),
+		PositionSide:  strings.ToUpper(req.PositionSide),
+		OrderType:     orderType,
+		Status:        "NEW",
+		Quantity:      contracts * inst.CtVal,
+		ReduceOnly:    req.ReduceOnly,
+		ClientOrderID: req.ClientOrderID,
+		Timestamp:     time.Now().Unix(),
+	}
+	if req.Price != nil {
+		order.Price = *req.Price
+	}
+	if req.StopPrice != nil {
+		order.StopPrice = *req.StopPrice
+	}
+	return order, nil
+}
+
+// CancelOrder 取消订单（algo_前缀的ID走条件单撤单接口）
+func (ox *OKXExchange) CancelOrder(symbol, orderID string) error {
+	return ox.CancelOrderContext(context.Background(), symbol, orderID)
+}
+
+// CancelOrderContext 同CancelOrder，ctx取消或超时时中止请求
+func (ox *OKXExchange) CancelOrderContext(ctx context.Context, symbol, orderID string) error {
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: OKX order would be cancelled",
+			"order_id", orderID,
+			"symbol", symbol,
+		)
+		return nil
+	}
+
+	instID := OKXInstID(symbol)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var err error
+	if algoID, ok := strings.CutPrefix(orderID, okxAlgoIDPrefix); ok {
+		body := []map[string]string{{"instId": instID, "algoId": algoID}}
+		_, err = ox.request(ctx, http.MethodPost, "/api/v5/trade/cancel-algos", nil, body, true)
+	} else {
+		body := map[string]string{"instId": instID, "ordId": orderID}
+		_, err = ox.request(ctx, http.MethodPost, "/api/v5/trade/cancel-order", nil, body, true)
+	}
+	if err != nil {
+		return fmt.Errorf("cancel order failed: %w", err)
+	}
+	return nil
+}
+
+// GetOrder 查询订单
+func (ox *OKXExchange) GetOrder(symbol, orderID string) (*types.Order, error) {
+	return ox.GetOrderContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderContext 同GetOrder，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetOrderContext(ctx context.Context, symbol, orderID string) (*types.Order, error) {
+	instID := OKXInstID(symbol)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		return &types.Order{
+			ID:        orderID,
+			Symbol:    OKXSymbol(instID),
+			Status:    "FILLED",
+			Timestamp: time.Now().Unix(),
+		}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	algoID, isAlgo := strings.CutPrefix(orderID, okxAlgoIDPrefix)
+
+	var data []interface{}
+	var err error
+	if isAlgo {
+		data, err = ox.request(ctx, http.MethodGet, "/api/v5/trade/order-algo", map[string]string{"algoId": algoID}, nil, true)
+	} else {
+		data, err = ox.request(ctx, http.MethodGet, "/api/v5/trade/order", map[string]string{"instId": instID, "ordId": orderID}, nil, true)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get order failed: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("order not found: %s", orderID)
+	}
+	item, ok := data[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid order data format")
+	}
+
+	if isAlgo {
+		return ox.parseAlgoOrder(item), nil
+	}
+	return ox.parseOrder(item), nil
+}
+
+// GetOpenOrders 获取当前挂单（包含普通挂单和未触发的条件单），symbol为空时返回全部
+func (ox *OKXExchange) GetOpenOrders(symbol string) ([]*types.Order, error) {
+	return ox.GetOpenOrdersContext(context.Background(), symbol)
+}
+
+// GetOpenOrdersContext 同GetOpenOrders，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetOpenOrdersContext(ctx context.Context, symbol string) ([]*types.Order, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return []*types.Order{}, nil
+	}
+
+	params := map[string]string{"instType": okxInstTypeSwap}
+	if symbol != "" {
+		params["instId"] = OKXInstID(symbol)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pending, err := ox.request(ctx, http.MethodGet, "/api/v5/trade/orders-pending", params, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("get open orders failed: %w", err)
+	}
+
+	algoParams := map[string]string{"ordType": "conditional"}
+	for k, v := range params {
+		algoParams[k] = v
+	}
+	algos, err := ox.request(ctx, http.MethodGet, "/api/v5/trade/orders-algo-pending", algoParams, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("get open algo orders failed: %w", err)
+	}
+
+	orders := make([]*types.Order, 0, len(pending)+len(algos))
+	for _, item := range pending {
+		if m, ok := item.(map[string]interface{}); ok {
+			orders = append(orders, ox.parseOrder(m))
+		}
+	}
+	for _, item := range algos {
+		if m, ok := item.(map[string]interface{}); ok {
+			orders = append(orders, ox.parseAlgoOrder(m))
+		}
+	}
+	return orders, nil
+}
+
+// GetPosition 获取单个持仓
+func (ox *OKXExchange) GetPosition(symbol string) (*types.Position, error) {
+	return ox.GetPositionContext(context.Background(), symbol)
+}
+
+// GetPositionContext 同GetPosition，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetPositionContext(ctx context.Context, symbol string) (*types.Position, error) {
+	positions, err := ox.GetPositionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = OKXSymbol(OKXInstID(symbol))
+	for _, pos := range positions {
+		if pos.Symbol == symbol {
+			return pos, nil
+		}
+	}
+	return nil, nil // 无持仓
+}
+
+// GetPositions 获取所有持仓（数量换算为币数量）
+func (ox *OKXExchange) GetPositions() ([]*types.Position, error) {
+	return ox.GetPositionsContext(context.Background())
+}
+
+// GetPositionsContext 同GetPositions，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetPositionsContext(ctx context.Context) ([]*types.Position, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return []*types.Position{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/account/positions", map[string]string{"instType": okxInstTypeSwap}, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("get positions failed: %w", err)
+	}
+
+	positions := make([]*types.Position, 0)
+	for _, item := range data {
+		p, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		contracts, _ := parseFloatValue(p["pos"])
+		if contracts == 0 {
+			continue // 跳过空仓
+		}
+
+		instID := parseStringValue(p["instId"])
+		inst, err := ox.instrument(instID)
+		if err != nil {
+			continue
+		}
+
+		side := strings.ToUpper(parseStringValue(p["posSide"]))
+		if side != "LONG" && side != "SHORT" {
+			// 单向持仓模式（net）按数量符号判断
+			side = "LONG"
+			if contracts < 0 {
+				side = "SHORT"
+			}
+		}
+		if contracts < 0 {
+			contracts = -contracts
+		}
+
+		entryPrice, _ := parseFloatValue(p["avgPx"])
+		markPrice, _ := parseFloatValue(p["markPx"])
+		unrealizedPnl, _ := parseFloatValue(p["upl"])
+		leverage, _ := parseFloatValue(p["lever"])
+
+		positions = append(positions, &types.Position{
+			Symbol:        OKXSymbol(instID),
+			Side:          side,
+			Size:          contracts * inst.CtVal,
+			EntryPrice:    entryPrice,
+			MarkPrice:     markPrice,
+			UnrealizedPnl: unrealizedPnl,
+			Leverage:      int(leverage),
+		})
+	}
+	return positions, nil
+}
+
+// GetBalance 获取USDT余额
+func (ox *OKXExchange) GetBalance() (map[string]float64, error) {
+	return ox.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext 同GetBalance，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetBalanceContext(ctx context.Context) (map[string]float64, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return map[string]float64{
+			"total": 10000.0,
+			"free":  10000.0,
+			"used":  0.0,
+		}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/account/balance", map[string]string{"ccy": "USDT"}, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("get balance failed: %w", err)
+	}
+
+	result := map[string]float64{
+		"total": 0.0,
+		"free":  0.0,
+		"used":  0.0,
+	}
+	if len(data) == 0 {
+		return result, nil
+	}
+	account, _ := data[0].(map[string]interface{})
+	details, _ := account["details"].([]interface{})
+	for _, item := range details {
+		d, ok := item.(map[string]interface{})
+		if !ok || parseStringValue(d["ccy"]) != "USDT" {
+			continue
+		}
+		result["total"], _ = parseFloatValue(d["eq"])
+		// 统一账户使用availEq，简单账户只有availBal
+		free, err := parseFloatValue(d["availEq"])
+		if err != nil {
+			free, _ = parseFloatValue(d["availBal"])
+		}
+		result["free"] = free
+		result["used"] = result["total"] - result["free"]
+		break
+	}
+	return result, nil
+}
+
+// SetLeverage 设置杠杆倍数（逐仓模式下多空分别设置）
+func (ox *OKXExchange) SetLeverage(symbol string, leverage int) error {
+	return ox.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，ctx取消或超时时中止请求
+func (ox *OKXExchange) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	if leverage <= 0 {
+		return fmt.Errorf("invalid leverage: %d", leverage)
+	}
+	instID := OKXInstID(symbol)
+
+	cfg := config.Get()
+	if cfg.DryRun {
+		utils.GetLogger("exchange").Infow("DRY_RUN: OKX leverage would be set",
+			"inst_id", instID,
+			"leverage", leverage,
+		)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	posSides := []string{""}
+	if ox.tdMode == "isolated" {
+		posSides = []string{"long", "short"}
+	}
+	for _, posSide := range posSides {
+		body := map[string]string{
+			"instId":  instID,
+			"lever":   strconv.Itoa(leverage),
+			"mgnMode": ox.tdMode,
+		}
+		if posSide != "" {
+			body["posSide"] = posSide
+		}
+		if _, err := ox.request(ctx, http.MethodPost, "/api/v5/account/set-leverage", nil, body, true); err != nil {
+			return fmt.Errorf("set leverage failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetMarginType 设置保证金模式
+// OKX的保证金模式随订单指定（tdMode），这里切换之后所有订单使用的模式
+func (ox *OKXExchange) SetMarginType(symbol string, marginType string) error {
+	return ox.SetMarginTypeContext(context.Background(), symbol, marginType)
+}
+
+// SetMarginTypeContext 同SetMarginType，ctx取消或超时时中止请求
+func (ox *OKXExchange) SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error {
+	switch strings.ToUpper(marginType) {
+	case "ISOLATED":
+		ox.tdMode = "isolated"
+	case "CROSSED":
+		ox.tdMode = "cross"
+	default:
+		return fmt.Errorf("invalid margin type: %s", marginType)
+	}
+	return nil
+}
+
+// PlaceBatchOrders 批量下单
+// OKX的batch-orders不支持策略委托（止盈止损单走order-algo），这里逐笔下单
+func (ox *OKXExchange) PlaceBatchOrders(reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return ox.PlaceBatchOrdersContext(context.Background(), reqs)
+}
+
+// PlaceBatchOrdersContext 同PlaceBatchOrders，ctx取消或超时时中止请求
+func (ox *OKXExchange) PlaceBatchOrdersContext(ctx context.Context, reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return placeOrdersSequentially(ctx, ox.PlaceOrderContext, reqs), nil
+}
+
+// GetPositionMode 查询持仓模式（long_short_mode为双向持仓）
+func (ox *OKXExchange) GetPositionMode() (bool, error) {
+	return ox.GetPositionModeContext(context.Background())
+}
+
+// GetPositionModeContext 同GetPositionMode，ctx取消或超时时中止请求
+func (ox *OKXExchange) GetPositionModeContext(ctx context.Context) (bool, error) {
+	cfg := config.Get()
+	if cfg.DryRun {
+		return true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	data, err := ox.request(ctx, http.MethodGet, "/api/v5/account/config", nil, nil, true)
+	if err != nil {
+		return false, fmt.Errorf("get position mode failed: %w", err)
+	}
+	if len(data) == 0 {
+		return false, fmt.Errorf("get position mode failed: empty response")
+	}
+	account, _ := data[0].(map[string]interface{})
+	return parseStringValue(account["posMode"]) == "long_short_mode", nil
+}
+
+// parseOrder 解析普通订单
+func (ox *OKXExchange) parseOrder(m map[string]interface{}) *types.Order {
+	instID := parseStringValue(m["instId"])
+	ctVal := 1.0
+	if inst, err := ox.instrument(instID); err == nil {
+		ctVal = inst.CtVal
+	}
+
+	orderType := "LIMIT"
+	if parseStringValue(m["ordType"]) == "market" {
+		orderType = "MARKET"
+	}
+
+	order := &types.Order{
+		ID:           parseStringValue(m["ordId"]),
+		Symbol:       OKXSymbol(instID),
+		Side:         strings.ToUpper(parseStringValue(m["side"])),
+		PositionSide: okxPositionSide(m["posSide"]),
+		OrderType:    orderType,
+		Status:       okxOrderStatus(parseStringValue(m["state"])),
+	}
+	sz, _ := parseFloatValue(m["sz"])
+	filled, _ := parseFloatValue(m["accFillSz"])
+	order.Quantity = sz * ctVal
+	order.FilledQty = filled * ctVal
+	order.Price, _ = parseFloatValue(m["px"])
+	order.AvgPrice, _ = parseFloatValue(m["avgPx"])
+	order.ReduceOnly, _ = parseBoolValue(m["reduceOnly"])
+	order.ClientOrderID = parseStringValue(m["clOrdId"])
+	cTime, _ := parseFloatValue(m["cTime"])
+	order.Timestamp = int64(cTime / 1000)
+	return order
+}
+
+// parseAlgoOrder 解析条件单（映射为STOP_MARKET/TAKE_PROFIT_MARKET等）
+func (ox *OKXExchange) parseAlgoOrder(m map[string]interface{}) *types.Order {
+	instID := parseStringValue(m["instId"])
+	ctVal := 1.0
+	if inst, err := ox.instrument(instID); err == nil {
+		ctVal = inst.CtVal
+	}
+
+	prefix, orderType := "sl", "STOP"
+	if parseStringValue(m["slTriggerPx"]) == "" && parseStringValue(m["tpTriggerPx"]) != "" {
+		prefix, orderType = "tp", "TAKE_PROFIT"
+	}
+	ordPx := parseStringValue(m[prefix+"OrdPx"])
+	if ordPx == "-1" || ordPx == "" {
+		orderType += "_MARKET"
+	}
+
+	order := &types.Order{
+		ID:           okxAlgoIDPrefix + parseStringValue(m["algoId"]),
+		Symbol:       OKXSymbol(instID),
+		Side:         strings.ToUpper(parseStringValue(m["side"])),
+		PositionSide: okxPositionSide(m["posSide"]),
+		OrderType:    orderType,
+		Status:       okxOrderStatus(parseStringValue(m["state"])),
+	}
+	sz, _ := parseFloatValue(m["sz"])
+	order.Quantity = sz * ctVal
+	order.StopPrice, _ = parseFloatValue(m[prefix+"TriggerPx"])
+	order.ClientOrderID = parseStringValue(m["algoClOrdId"])
+	if !strings.HasSuffix(orderType, "_MARKET") {
+		order.Price, _ = parseFloatValue(ordPx)
+	}
+	if order.Status == "FILLED" {
+		actualSz, _ := parseFloatValue(m["actualSz"])
+		order.FilledQty = actualSz * ctVal
+		order.AvgPrice, _ = parseFloatValue(m["actualPx"])
+	}
+	order.ReduceOnly, _ = parseBoolValue(m["reduceOnly"])
+	cTime, _ := parseFloatValue(m["cTime"])
+	order.Timestamp = int64(cTime / 1000)
+	return order
+}
+
+// okxPositionSide 持仓方向转换（net返回空）
+func okxPositionSide(v interface{}) string {
+	side := strings.ToUpper(parseStringValue(v))
+	if side == "LONG" || side == "SHORT" {
+		return side
+	}
+	return ""
+}
+
+// okxOrderStatus 订单状态转换为Binance风格
+func okxOrderStatus(state string) string {
+	switch state {
+	case "live":
+		return "NEW"
+	case "partially_filled", "partially_effective":
+		return "PARTIALLY_FILLED"
+	case "filled", "effective":
+		return "FILLED"
+	case "canceled", "mmp_canceled":
+		return "CANCELED"
+	case "order_failed":
+		return "REJECTED"
+	}
+	return strings.ToUpper(state)
+}
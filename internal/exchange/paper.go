@@ -0,0 +1,860 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const (
+	// paperDefaultLeverage 模拟账户默认杠杆（与Binance新开仓默认值一致）
+	paperDefaultLeverage = 20
+	// paperMaxClosedOrders 保留的已结束订单数量（供GetOrder查询）
+	paperMaxClosedOrders = 500
+)
+
+// PaperExchange 模拟交易所
+// 使用虚拟USDT余额，按实时或回放的ticker价格撮合订单，支持双向持仓（Hedge Mode）
+type PaperExchange struct {
+	market  types.Exchange    // 行情来源（为nil时只能使用UpdatePrice回放价格）
+	redis   utils.RedisClient // 为nil时不持久化
+	feeRate float64
+
+	mu     sync.Mutex
+	state  *paperState
+	prices map[string]float64 // 回放价格（优先于实时行情）
+}
+
+// paperState 模拟账户状态（整体持久化到Redis）
+type paperState struct {
+	Balance   float64                   `json:"balance"` // 钱包余额（含已实现盈亏和手续费）
+	Orders    map[string]*types.Order   `json:"orders"`  // 订单（已结束的订单保留最近paperMaxClosedOrders条）
+	Positions map[string]*paperPosition `json:"positions"`
+	Leverage  map[string]int            `json:"leverage"`
+	NextID    int64                     `json:"next_id"`
+}
+
+// paperPosition 模拟持仓
+type paperPosition struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // LONG, SHORT
+	Size       float64 `json:"size"`
+	EntryPrice float64 `json:"entry_price"`
+	MarkPrice  float64 `json:"mark_price"`
+	Leverage   int     `json:"leverage"`
+}
+
+var globalPaperExchange *PaperExchange
+
+// GetPaperExchange 获取模拟交易所实例（单例，行情来自Binance）
+func GetPaperExchange() *PaperExchange {
+	if globalPaperExchange == nil {
+		cfg := config.Get()
+		globalPaperExchange = NewPaperExchange(GetBinanceExchange(), utils.GetRedisClient(), cfg.PaperInitialBalance, cfg.PaperFeeRate)
+	}
+	return globalPaperExchange
+}
+
+// NewPaperExchange 创建模拟交易所
+// 如果Redis中已有模拟账户状态则恢复，否则以initialBalance开户
+func NewPaperExchange(market types.Exchange, redis utils.RedisClient, initialBalance, feeRate float64) *PaperExchange {
+	pe := &PaperExchange{
+		market:  market,
+		redis:   redis,
+		feeRate: feeRate,
+		prices:  make(map[string]float64),
+	}
+	if !pe.loadState() {
+		pe.state = newPaperState(initialBalance)
+	}
+	return pe
+}
+
+func newPaperState(balance float64) *paperState {
+	return &paperState{
+		Balance:   balance,
+		Orders:    make(map[string]*types.Order),
+		Positions: make(map[string]*paperPosition),
+		Leverage:  make(map[string]int),
+	}
+}
+
+// Reset 重置模拟账户
+func (pe *PaperExchange) Reset(initialBalance float64) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.state = newPaperState(initialBalance)
+	pe.saveState()
+}
+
+// UpdatePrice 推送回放价格并触发撮合
+func (pe *PaperExchange) UpdatePrice(symbol string, price float64) {
+	if price <= 0 {
+		return
+	}
+	symbol = utils.NormalizeSymbol(symbol)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.prices[symbol] = price
+	if pe.matchSymbol(symbol, price) {
+		pe.saveState()
+	}
+}
+
+// GetOHLCV 获取K线数据（委托给行情来源）
+func (pe *PaperExchange) GetOHLCV(symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	return pe.GetOHLCVContext(context.Background(), symbol, timeframe, limit)
+}
+
+// GetOHLCVContext 同GetOHLCV，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	if pe.market == nil {
+		return nil, fmt.Errorf("paper exchange has no market data source")
+	}
+	return pe.market.GetOHLCVContext(ctx, symbol, timeframe, limit)
+}
+
+// GetTickerPrice 获取当前价格（回放价格优先）
+func (pe *PaperExchange) GetTickerPrice(symbol string) (float64, error) {
+	return pe.GetTickerPriceContext(context.Background(), symbol)
+}
+
+// GetTickerPriceContext 同GetTickerPrice，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetTickerPriceContext(ctx context.Context, symbol string) (float64, error) {
+	symbol = utils.NormalizeSymbol(symbol)
+
+	pe.mu.Lock()
+	price, ok := pe.prices[symbol]
+	pe.mu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	if pe.market == nil {
+		return 0, fmt.Errorf("no price available for %s", symbol)
+	}
+	return pe.market.GetTickerPriceContext(ctx, symbol)
+}
+
+// GetFundingRate 获取资金费率（委托给行情来源）
+func (pe *PaperExchange) GetFundingRate(symbol string) (float64, error) {
+	return pe.GetFundingRateContext(context.Background(), symbol)
+}
+
+// GetFundingRateContext 同GetFundingRate，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetFundingRateContext(ctx context.Context, symbol string) (float64, error) {
+	if pe.market == nil {
+		return 0, nil
+	}
+	return pe.market.GetFundingRateContext(ctx, symbol)
+}
+
+// GetOpenInterest 获取持仓量（委托给行情来源）
+func (pe *PaperExchange) GetOpenInterest(symbol string) (float64, error) {
+	return pe.GetOpenInterestContext(context.Background(), symbol)
+}
+
+// GetOpenInterestContext 同GetOpenInterest，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetOpenInterestContext(ctx context.Context, symbol string) (float64, error) {
+	if pe.market == nil {
+		return 0, nil
+	}
+	return pe.market.GetOpenInterestContext(ctx, symbol)
+}
+
+// GetOrderBook 获取深度快照（委托给行情来源）
+func (pe *PaperExchange) GetOrderBook(symbol string, limit int) (*types.OrderBook, error) {
+	return pe.GetOrderBookContext(context.Background(), symbol, limit)
+}
+
+// GetOrderBookContext 同GetOrderBook，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetOrderBookContext(ctx context.Context, symbol string, limit int) (*types.OrderBook, error) {
+	if pe.market == nil {
+		return nil, fmt.Errorf("paper exchange has no market data source")
+	}
+	return pe.market.GetOrderBookContext(ctx, symbol, limit)
+}
+
+// GetFundingHistory 获取资金费率结算历史（委托给行情来源）
+func (pe *PaperExchange) GetFundingHistory(symbol string, limit int) ([]types.FundingRateRecord, error) {
+	return pe.GetFundingHistoryContext(context.Background(), symbol, limit)
+}
+
+// GetFundingHistoryContext 同GetFundingHistory，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetFundingHistoryContext(ctx context.Context, symbol string, limit int) ([]types.FundingRateRecord, error) {
+	if pe.market == nil {
+		return nil, nil
+	}
+	return pe.market.GetFundingHistoryContext(ctx, symbol, limit)
+}
+
+// GetPremiumIndex 获取标记价格和预测资金费率（委托给行情来源）
+func (pe *PaperExchange) GetPremiumIndex(symbol string) (*types.PremiumIndex, error) {
+	return pe.GetPremiumIndexContext(context.Background(), symbol)
+}
+
+// GetPremiumIndexContext 同GetPremiumIndex，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetPremiumIndexContext(ctx context.Context, symbol string) (*types.PremiumIndex, error) {
+	if pe.market == nil {
+		return nil, fmt.Errorf("paper exchange has no market data source")
+	}
+	return pe.market.GetPremiumIndexContext(ctx, symbol)
+}
+
+// GetMarketInfo 获取交易对元信息（委托给行情来源）
+func (pe *PaperExchange) GetMarketInfo(symbol string) (map[string]interface{}, error) {
+	provider, ok := pe.market.(MarketInfoProvider)
+	if !ok {
+		return nil, fmt.Errorf("paper exchange market data source has no market info")
+	}
+	return provider.GetMarketInfo(symbol)
+}
+
+// GetTicker24h 获取24小时Ticker数据（委托给行情来源）
+func (pe *PaperExchange) GetTicker24h(symbol string) (map[string]interface{}, error) {
+	provider, ok := pe.market.(Ticker24hProvider)
+	if !ok {
+		return nil, fmt.Errorf("paper exchange market data source has no 24h ticker")
+	}
+	return provider.GetTicker24h(symbol)
+}
+
+// GetDerivativesDataContext 获取衍生品情绪数据（委托给行情来源）
+func (pe *PaperExchange) GetDerivativesDataContext(ctx context.Context, symbol, period string, limit int) (*types.DerivativesData, error) {
+	provider, ok := pe.market.(DerivativesProvider)
+	if !ok {
+		return nil, fmt.Errorf("paper exchange market data source has no derivatives data")
+	}
+	return provider.GetDerivativesDataContext(ctx, symbol, period, limit)
+}
+
+// PlaceOrder 下单（市价单立即成交，其余挂单等待价格触发）
+func (pe *PaperExchange) PlaceOrder(req types.OrderRequest) (*types.Order, error) {
+	return pe.PlaceOrderContext(context.Background(), req)
+}
+
+// PlaceOrderContext 同PlaceOrder，ctx取消或超时时中止请求
+func (pe *PaperExchange) PlaceOrderContext(ctx context.Context, req types.OrderRequest) (*types.Order, error) {
+	symbol := utils.NormalizeSymbol(req.Symbol)
+	orderType := strings.ToUpper(req.OrderType)
+	side := strings.ToUpper(req.Side)
+
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity: %v", req.Quantity)
+	}
+	if side != "BUY" && side != "SELL" {
+		return nil, fmt.Errorf("invalid side: %s", req.Side)
+	}
+
+	switch orderType {
+	case "LIMIT", "STOP", "TAKE_PROFIT":
+		if req.Price == nil || *req.Price <= 0 {
+			return nil, fmt.Errorf("price required for %s order", orderType)
+		}
+	case "MARKET":
+	default:
+		if !isTriggerOrder(orderType) {
+			return nil, fmt.Errorf("unsupported order type: %s", req.OrderType)
+		}
+	}
+	if isTriggerOrder(orderType) && (req.StopPrice == nil || *req.StopPrice <= 0) {
+		return nil, fmt.Errorf("stopPrice required for %s order", orderType)
+	}
+
+	price, err := pe.GetTickerPriceContext(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price for paper order: %w", err)
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	// 与交易所一致：相同客户端订单ID的挂单已存在时不重复下单
+	if req.ClientOrderID != "" {
+		for _, existing := range pe.state.Orders {
+			if existing.ClientOrderID == req.ClientOrderID && existing.Status == "NEW" {
+				result := *existing
+				return &result, nil
+			}
+		}
+	}
+
+	pe.state.NextID++
+	order := &types.Order{
+		ID:            "paper_" + strconv.FormatInt(pe.state.NextID, 10),
+		Symbol:        symbol,
+		Side:          side,
+		PositionSide:  paperPositionSide(req),
+		OrderType:     orderType,
+		Quantity:      req.Quantity,
+		Price:         getFloatValue(req.Price),
+		StopPrice:     getFloatValue(req.StopPrice),
+		Status:        "NEW",
+		ReduceOnly:    req.ReduceOnly,
+		ClientOrderID: req.ClientOrderID,
+		Timestamp:     time.Now().Unix(),
+	}
+
+	if !order.ReduceOnly && pe.opensPosition(order) {
+		if err := pe.checkMargin(order, price); err != nil {
+			return nil, err
+		}
+	}
+
+	pe.state.Orders[order.ID] = order
+	pe.matchSymbol(symbol, price)
+	pe.saveState()
+
+	result := *order
+	return &result, nil
+}
+
+// CancelOrder 撤单
+func (pe *PaperExchange) CancelOrder(symbol, orderID string) error {
+	return pe.CancelOrderContext(context.Background(), symbol, orderID)
+}
+
+// CancelOrderContext 同CancelOrder，ctx取消或超时时中止请求
+func (pe *PaperExchange) CancelOrderContext(ctx context.Context, symbol, orderID string) error {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	order, ok := pe.state.Orders[orderID]
+	if !ok {
+		return fmt.Errorf("order not found: %s", orderID)
+	}
+	if order.Status != "NEW" {
+		return fmt.Errorf("order %s is already %s", orderID, order.Status)
+	}
+	order.Status = "CANCELED"
+	pe.pruneOrders()
+	pe.saveState()
+	return nil
+}
+
+// GetOrder 查询订单
+func (pe *PaperExchange) GetOrder(symbol, orderID string) (*types.Order, error) {
+	return pe.GetOrderContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderContext 同GetOrder，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetOrderContext(ctx context.Context, symbol, orderID string) (*types.Order, error) {
+	symbol = utils.NormalizeSymbol(symbol)
+	pe.refresh(symbol)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	order, ok := pe.state.Orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("order not found: %s", orderID)
+	}
+	result := *order
+	return &result, nil
+}
+
+// GetOpenOrders 查询挂单
+func (pe *PaperExchange) GetOpenOrders(symbol string) ([]*types.Order, error) {
+	return pe.GetOpenOrdersContext(context.Background(), symbol)
+}
+
+// GetOpenOrdersContext 同GetOpenOrders，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetOpenOrdersContext(ctx context.Context, symbol string) ([]*types.Order, error) {
+	symbol = utils.NormalizeSymbol(symbol)
+	pe.refresh(symbol)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	orders := make([]*types.Order, 0)
+	for _, order := range pe.state.Orders {
+		if order.Symbol == symbol && order.Status == "NEW" {
+			o := *order
+			orders = append(orders, &o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return paperOrderSeq(orders[i].ID) < paperOrderSeq(orders[j].ID) })
+	return orders, nil
+}
+
+// GetPosition 查询单个币种持仓
+func (pe *PaperExchange) GetPosition(symbol string) (*types.Position, error) {
+	return pe.GetPositionContext(context.Background(), symbol)
+}
+
+// GetPositionContext 同GetPosition，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetPositionContext(ctx context.Context, symbol string) (*types.Position, error) {
+	positions, err := pe.GetPositionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = utils.NormalizeSymbol(symbol)
+	for _, pos := range positions {
+		if pos.Symbol == symbol {
+			return pos, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetPositions 查询所有持仓（按最新价格计算未实现盈亏）
+func (pe *PaperExchange) GetPositions() ([]*types.Position, error) {
+	return pe.GetPositionsContext(context.Background())
+}
+
+// GetPositionsContext 同GetPositions，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetPositionsContext(ctx context.Context) ([]*types.Position, error) {
+	pe.refresh(pe.activeSymbols()...)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	positions := make([]*types.Position, 0, len(pe.state.Positions))
+	for _, pos := range pe.state.Positions {
+		if pos.Size <= 0 {
+			continue
+		}
+		positions = append(positions, &types.Position{
+			Symbol:        pos.Symbol,
+			Side:          pos.Side,
+			Size:          pos.Size,
+			EntryPrice:    pos.EntryPrice,
+			MarkPrice:     pos.MarkPrice,
+			UnrealizedPnl: pos.unrealizedPnl(),
+			Leverage:      pos.Leverage,
+		})
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Symbol != positions[j].Symbol {
+			return positions[i].Symbol < positions[j].Symbol
+		}
+		return positions[i].Side < positions[j].Side
+	})
+	return positions, nil
+}
+
+// GetBalance 获取模拟账户余额（与BinanceExchange返回格式一致）
+func (pe *PaperExchange) GetBalance() (map[string]float64, error) {
+	return pe.GetBalanceContext(context.Background())
+}
+
+// GetBalanceContext 同GetBalance，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetBalanceContext(ctx context.Context) (map[string]float64, error) {
+	pe.refresh(pe.activeSymbols()...)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	used := pe.usedMargin()
+	return map[string]float64{
+		"total": pe.state.Balance,
+		"free":  pe.state.Balance + pe.totalUnrealizedPnl() - used,
+		"used":  used,
+	}, nil
+}
+
+// SetLeverage 设置币种杠杆（影响之后开仓的保证金占用）
+func (pe *PaperExchange) SetLeverage(symbol string, leverage int) error {
+	return pe.SetLeverageContext(context.Background(), symbol, leverage)
+}
+
+// SetLeverageContext 同SetLeverage，ctx取消或超时时中止请求
+func (pe *PaperExchange) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	if leverage <= 0 {
+		return fmt.Errorf("invalid leverage: %d", leverage)
+	}
+	symbol = utils.NormalizeSymbol(symbol)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.state.Leverage[symbol] = leverage
+	pe.saveState()
+	return nil
+}
+
+// SetMarginType 设置保证金模式（模拟账户统一按全仓计算，只校验参数）
+func (pe *PaperExchange) SetMarginType(symbol string, marginType string) error {
+	return pe.SetMarginTypeContext(context.Background(), symbol, marginType)
+}
+
+// SetMarginTypeContext 同SetMarginType，ctx取消或超时时中止请求
+func (pe *PaperExchange) SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error {
+	switch strings.ToUpper(marginType) {
+	case "ISOLATED", "CROSSED":
+		return nil
+	}
+	return fmt.Errorf("invalid margin type: %s", marginType)
+}
+
+// GetPositionMode 查询持仓模式（模拟账户始终为双向持仓）
+func (pe *PaperExchange) GetPositionMode() (bool, error) {
+	return pe.GetPositionModeContext(context.Background())
+}
+
+// GetPositionModeContext 同GetPositionMode，ctx取消或超时时中止请求
+func (pe *PaperExchange) GetPositionModeContext(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// PlaceBatchOrders 批量下单（按顺序逐笔撮合）
+func (pe *PaperExchange) PlaceBatchOrders(reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return pe.PlaceBatchOrdersContext(context.Background(), reqs)
+}
+
+// PlaceBatchOrdersContext 同PlaceBatchOrders，ctx取消或超时时中止请求
+func (pe *PaperExchange) PlaceBatchOrdersContext(ctx context.Context, reqs []types.OrderRequest) ([]types.BatchOrderResult, error) {
+	return placeOrdersSequentially(ctx, pe.PlaceOrderContext, reqs), nil
+}
+
+// refresh 从行情来源拉取最新价格并撮合（回放价格存在时不拉取）
+func (pe *PaperExchange) refresh(symbols ...string) {
+	if pe.market == nil {
+		return
+	}
+
+	for _, symbol := range symbols {
+		pe.mu.Lock()
+		_, replay := pe.prices[symbol]
+		pe.mu.Unlock()
+		if replay {
+			continue
+		}
+
+		price, err := pe.market.GetTickerPrice(symbol)
+		if err != nil || price <= 0 {
+			continue
+		}
+
+		pe.mu.Lock()
+		if pe.matchSymbol(symbol, price) {
+			pe.saveState()
+		}
+		pe.mu.Unlock()
+	}
+}
+
+// activeSymbols 返回有挂单或持仓的币种
+func (pe *PaperExchange) activeSymbols() []string {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, order := range pe.state.Orders {
+		if order.Status == "NEW" {
+			seen[order.Symbol] = true
+		}
+	}
+	for _, pos := range pe.state.Positions {
+		if pos.Size > 0 {
+			seen[pos.Symbol] = true
+		}
+	}
+
+	symbols := make([]string, 0, len(seen))
+	for symbol := range seen {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// matchSymbol 按最新价格撮合该币种的挂单并更新标记价格（调用方持有锁）
+// 返回状态是否发生变化
+func (pe *PaperExchange) matchSymbol(symbol string, price float64) bool {
+	changed := false
+
+	for _, pos := range pe.state.Positions {
+		if pos.Symbol == symbol && pos.MarkPrice != price {
+			pos.MarkPrice = price
+			changed = true
+		}
+	}
+
+	// 按订单号顺序撮合，保证结果确定
+	ids := make([]string, 0)
+	for id, order := range pe.state.Orders {
+		if order.Symbol == symbol && order.Status == "NEW" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return paperOrderSeq(ids[i]) < paperOrderSeq(ids[j]) })
+
+	for _, id := range ids {
+		order := pe.state.Orders[id]
+		fillPrice, ok := paperFillPrice(order, price)
+		if !ok {
+			continue
+		}
+		pe.fillOrder(order, fillPrice)
+		changed = true
+	}
+
+	if changed {
+		pe.pruneOrders()
+	}
+	return changed
+}
+
+// paperFillPrice 判断订单在当前价格下是否成交，返回成交价
+func paperFillPrice(order *types.Order, price float64) (float64, bool) {
+	buy := order.Side == "BUY"
+
+	switch order.OrderType {
+	case "MARKET":
+		return price, true
+	case "LIMIT":
+		if buy && price <= order.Price {
+			return price, true
+		}
+		if !buy && price >= order.Price {
+			return price, true
+		}
+	case "STOP", "STOP_MARKET":
+		// 止损单：买单价格上穿触发，卖单价格下穿触发
+		if (buy && price >= order.StopPrice) || (!buy && price <= order.StopPrice) {
+			if order.OrderType == "STOP" {
+				return order.Price, true
+			}
+			return price, true
+		}
+	case "TAKE_PROFIT", "TAKE_PROFIT_MARKET":
+		// 止盈单：买单价格下穿触发，卖单价格上穿触发
+		if (buy && price <= order.StopPrice) || (!buy && price >= order.StopPrice) {
+			if order.OrderType == "TAKE_PROFIT" {
+				return order.Price, true
+			}
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+// fillOrder 成交订单并更新持仓和余额（调用方持有锁）
+func (pe *PaperExchange) fillOrder(order *types.Order, fillPrice float64) {
+	logger := utils.GetLogger("paper_exchange")
+
+	key := order.Symbol + ":" + order.PositionSide
+	pos := pe.state.Positions[key]
+	qty := order.Quantity
+
+	if pe.opensPosition(order) && !order.ReduceOnly {
+		if pos == nil {
+			pos = &paperPosition{
+				Symbol:   order.Symbol,
+				Side:     order.PositionSide,
+				Leverage: pe.leverageFor(order.Symbol),
+			}
+			pe.state.Positions[key] = pos
+		}
+		pos.EntryPrice = (pos.EntryPrice*pos.Size + fillPrice*qty) / (pos.Size + qty)
+		pos.Size += qty
+		pos.MarkPrice = fillPrice
+	} else {
+		// 平仓（reduceOnly订单最多平掉现有持仓）
+		if pos == nil || pos.Size <= 0 {
+			order.Status = "EXPIRED"
+			return
+		}
+		qty = math.Min(qty, pos.Size)
+		pnl := (fillPrice - pos.EntryPrice) * qty
+		if pos.Side == "SHORT" {
+			pnl = -pnl
+		}
+		pe.state.Balance += pnl
+		pos.Size -= qty
+		pos.MarkPrice = fillPrice
+		if pos.Size <= 1e-12 {
+			delete(pe.state.Positions, key)
+		}
+	}
+
+	fee := fillPrice * qty * pe.feeRate
+	pe.state.Balance -= fee
+
+	order.Status = "FILLED"
+	order.FilledQty = qty
+	order.AvgPrice = fillPrice
+
+	logger.Infow("PAPER: Order filled",
+		"symbol", order.Symbol,
+		"order_id", order.ID,
+		"side", order.Side,
+		"position_side", order.PositionSide,
+		"order_type", order.OrderType,
+		"quantity", qty,
+		"price", fillPrice,
+		"fee", fee,
+	)
+}
+
+// pruneOrders 清理过多的已结束订单（调用方持有锁）
+func (pe *PaperExchange) pruneOrders() {
+	closed := make([]string, 0)
+	for id, order := range pe.state.Orders {
+		if order.Status != "NEW" {
+			closed = append(closed, id)
+		}
+	}
+	if len(closed) <= paperMaxClosedOrders {
+		return
+	}
+
+	sort.Slice(closed, func(i, j int) bool { return paperOrderSeq(closed[i]) < paperOrderSeq(closed[j]) })
+	for _, id := range closed[:len(closed)-paperMaxClosedOrders] {
+		delete(pe.state.Orders, id)
+	}
+}
+
+// opensPosition 判断订单方向是否为开仓（LONG买入/SHORT卖出）
+func (pe *PaperExchange) opensPosition(order *types.Order) bool {
+	return (order.PositionSide == "LONG" && order.Side == "BUY") ||
+		(order.PositionSide == "SHORT" && order.Side == "SELL")
+}
+
+// checkMargin 检查可用保证金（调用方持有锁）
+func (pe *PaperExchange) checkMargin(order *types.Order, price float64) error {
+	refPrice := price
+	if order.Price > 0 {
+		refPrice = order.Price
+	}
+	required := refPrice * order.Quantity / float64(pe.leverageFor(order.Symbol))
+	available := pe.state.Balance + pe.totalUnrealizedPnl() - pe.usedMargin()
+	if required > available {
+		return fmt.Errorf("insufficient margin: required %.4f, available %.4f", required, available)
+	}
+	return nil
+}
+
+// usedMargin 计算占用保证金（调用方持有锁）
+func (pe *PaperExchange) usedMargin() float64 {
+	used := 0.0
+	for _, pos := range pe.state.Positions {
+		if pos.Leverage > 0 {
+			used += pos.EntryPrice * pos.Size / float64(pos.Leverage)
+		}
+	}
+	return used
+}
+
+// totalUnrealizedPnl 计算总未实现盈亏（调用方持有锁）
+func (pe *PaperExchange) totalUnrealizedPnl() float64 {
+	total := 0.0
+	for _, pos := range pe.state.Positions {
+		total += pos.unrealizedPnl()
+	}
+	return total
+}
+
+// leverageFor 获取币种杠杆（调用方持有锁）
+func (pe *PaperExchange) leverageFor(symbol string) int {
+	if lev, ok := pe.state.Leverage[symbol]; ok && lev > 0 {
+		return lev
+	}
+	return paperDefaultLeverage
+}
+
+// unrealizedPnl 计算持仓未实现盈亏
+func (p *paperPosition) unrealizedPnl() float64 {
+	if p.MarkPrice <= 0 {
+		return 0
+	}
+	pnl := (p.MarkPrice - p.EntryPrice) * p.Size
+	if p.Side == "SHORT" {
+		return -pnl
+	}
+	return pnl
+}
+
+// paperPositionSide 推断订单持仓方向（未指定时按单向持仓习惯推断）
+func paperPositionSide(req types.OrderRequest) string {
+	if req.PositionSide != "" {
+		return strings.ToUpper(req.PositionSide)
+	}
+	buy := strings.ToUpper(req.Side) == "BUY"
+	if req.ReduceOnly {
+		buy = !buy
+	}
+	if buy {
+		return "LONG"
+	}
+	return "SHORT"
+}
+
+// isTriggerOrder 判断是否为条件单
+func isTriggerOrder(orderType string) bool {
+	switch orderType {
+	case "STOP", "STOP_MARKET", "TAKE_PROFIT", "TAKE_PROFIT_MARKET":
+		return true
+	}
+	return false
+}
+
+// paperOrderSeq 解析模拟订单序号
+func paperOrderSeq(id string) int64 {
+	seq, _ := strconv.ParseInt(strings.TrimPrefix(id, "paper_"), 10, 64)
+	return seq
+}
+
+// loadState 从Redis恢复模拟账户状态
+func (pe *PaperExchange) loadState() bool {
+	if pe.redis == nil {
+		return false
+	}
+
+	ctx, cancel := utils.WithRedisTimeout(context.Background())
+	defer cancel()
+
+	raw, err := pe.redis.Get(ctx, config.GetRedisKey("paper:state")).Result()
+	if err != nil || raw == "" {
+		return false
+	}
+
+	state := newPaperState(0)
+	if err := json.Unmarshal([]byte(raw), state); err != nil {
+		logger := utils.GetLogger("paper_exchange")
+		logger.Warnw("模拟账户状态解析失败，重新开户", "error", err)
+		return false
+	}
+	if state.Orders == nil {
+		state.Orders = make(map[string]*types.Order)
+	}
+	if state.Positions == nil {
+		state.Positions = make(map[string]*paperPosition)
+	}
+	if state.Leverage == nil {
+		state.Leverage = make(map[string]int)
+	}
+	pe.state = state
+	return true
+}
+
+// saveState 持久化模拟账户状态（调用方持有锁）
+func (pe *PaperExchange) saveState() {
+	if pe.redis == nil {
+		return
+	}
+
+	stateJSON, err := json.Marshal(pe.state)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := utils.WithRedisTimeout(context.Background())
+	defer cancel()
+	pe.redis.Set(ctx, config.GetRedisKey("paper:state"), stateJSON, 0)
+}
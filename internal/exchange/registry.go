@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 交易所名称（对应EXCHANGE配置）
+const (
+	NameBinance        = "binance"
+	NameBinanceTestnet = "binance-testnet"
+	NamePaper          = "paper"
+	NameOKX            = "okx"
+	NameBybit          = "bybit"
+)
+
+// Factory 交易所构造函数
+type Factory func() types.Exchange
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		// 测试网与主网共用实现，地址由配置切换
+		NameBinance:        func() types.Exchange { return GetBinanceExchange() },
+		NameBinanceTestnet: func() types.Exchange { return GetBinanceExchange() },
+		NamePaper:          func() types.Exchange { return GetPaperExchange() },
+		NameOKX:            func() types.Exchange { return GetOKXExchange() },
+		NameBybit:          func() types.Exchange { return GetBybitExchange() },
+	}
+
+	globalExchange     types.Exchange
+	globalExchangeName string
+	globalExchangeMu   sync.Mutex
+)
+
+// Register 注册交易所（同名覆盖）
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[strings.ToLower(name)] = factory
+}
+
+// Names 已注册的交易所名称
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New 按名称创建交易所
+func New(name string) (types.Exchange, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[strings.ToLower(strings.TrimSpace(name))]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown exchange %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(), nil
+}
+
+// Selected 当前配置选择的交易所名称
+func Selected() string {
+	if cfg := config.Get(); cfg != nil && cfg.Exchange != "" {
+		return cfg.Exchange
+	}
+	return NameBinance
+}
+
+// GetExchange 获取配置选择的交易所实例（单例，EXCHANGE变化后重新创建）
+// 名称未注册时返回错误，不回退到实盘交易所
+func GetExchange() (types.Exchange, error) {
+	globalExchangeMu.Lock()
+	defer globalExchangeMu.Unlock()
+
+	name := Selected()
+	if globalExchange != nil && globalExchangeName == name {
+		return globalExchange, nil
+	}
+
+	ex, err := New(name)
+	if err != nil {
+		return nil, err
+	}
+	utils.GetLogger("exchange").Infow("Exchange selected", "exchange", name)
+
+	globalExchange = ex
+	globalExchangeName = name
+	return globalExchange, nil
+}
+
+// BinanceAccount 当前交易所是否为Binance账户（用户数据流仅对Binance账户可用）
+func BinanceAccount() bool {
+	name := Selected()
+	return name == NameBinance || name == NameBinanceTestnet
+}
+
+// BinanceMarketData 当前交易所行情是否来自Binance（WebSocket行情流仅对Binance行情可用）
+func BinanceMarketData() bool {
+	return BinanceAccount() || Selected() == NamePaper
+}
+
+// MarketInfoProvider 可查询交易对元信息（如上市时间onboardDate）的交易所
+type MarketInfoProvider interface {
+	GetMarketInfo(symbol string) (map[string]interface{}, error)
+}
+
+// Ticker24hProvider 可查询24小时Ticker统计的交易所
+type Ticker24hProvider interface {
+	GetTicker24h(symbol string) (map[string]interface{}, error)
+}
+
+// DerivativesProvider 可查询衍生品情绪数据（多空比、主动买卖量）的交易所
+type DerivativesProvider interface {
+	GetDerivativesDataContext(ctx context.Context, symbol, period string, limit int) (*types.DerivativesData, error)
+}
@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+// timeSyncRetryDelay 同步失败后的重试间隔
+const timeSyncRetryDelay = 30 * time.Second
+
+// TimeSync 服务器时间同步：测量本地时钟与交易所服务器的偏移，签名请求使用校正后的时间戳
+// 不启动后台协程，取时间时发现同步已过期则先重新同步
+type TimeSync struct {
+	fetch    func(ctx context.Context) (int64, error) // 获取服务器时间（毫秒）
+	interval time.Duration
+
+	mu       sync.Mutex
+	offset   int64 // 毫秒，服务器时间 - 本地时间
+	nextSync time.Time
+}
+
+// NewTimeSync 创建时间同步器，interval为重新同步间隔
+func NewTimeSync(fetch func(ctx context.Context) (int64, error), interval time.Duration) *TimeSync {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &TimeSync{
+		fetch:    fetch,
+		interval: interval,
+	}
+}
+
+// Sync 立即同步一次（按请求往返中点估算偏移）
+func (ts *TimeSync) Sync(ctx context.Context) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.syncLocked(ctx)
+}
+
+// syncLocked 同步偏移（调用方持有锁）
+func (ts *TimeSync) syncLocked(ctx context.Context) error {
+	start := time.Now()
+	serverTime, err := ts.fetch(ctx)
+	if err != nil {
+		ts.nextSync = time.Now().Add(timeSyncRetryDelay)
+		return err
+	}
+	end := time.Now()
+
+	midpoint := start.UnixMilli() + end.Sub(start).Milliseconds()/2
+	offset := serverTime - midpoint
+	if diff := offset - ts.offset; diff > 1000 || diff < -1000 {
+		utils.GetLogger("exchange").Infow("Server time offset updated",
+			"offset_ms", offset,
+			"previous_ms", ts.offset,
+			"rtt_ms", end.Sub(start).Milliseconds(),
+		)
+	}
+	ts.offset = offset
+	ts.nextSync = end.Add(ts.interval)
+	return nil
+}
+
+// Offset 当前偏移（服务器时间 - 本地时间）
+func (ts *TimeSync) Offset() time.Duration {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return time.Duration(ts.offset) * time.Millisecond
+}
+
+// NowMillis 校正后的服务器时间（毫秒），同步过期时先重新同步，失败时沿用上次的偏移
+func (ts *TimeSync) NowMillis() int64 {
+	return ts.NowMillisContext(context.Background())
+}
+
+// NowMillisContext 同NowMillis，重新同步随ctx取消
+func (ts *TimeSync) NowMillisContext(ctx context.Context) int64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if time.Now().After(ts.nextSync) {
+		ctx, cancel := utils.WithShortTimeout(ctx)
+		if err := ts.syncLocked(ctx); err != nil {
+			utils.GetLogger("exchange").Warnw("Server time sync failed", "error", err)
+		}
+		cancel()
+	}
+	return time.Now().UnixMilli() + ts.offset
+}
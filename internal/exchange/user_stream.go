@@ -0,0 +1,464 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 用户数据流事件类型
+const (
+	EventOrderTradeUpdate = "ORDER_TRADE_UPDATE"
+	EventAccountUpdate    = "ACCOUNT_UPDATE"
+	EventListenKeyExpired = "listenKeyExpired"
+)
+
+const (
+	listenKeyEndpoint        = "/fapi/v1/listenKey"
+	listenKeyKeepalivePeriod = 30 * time.Minute // listenKey 60分钟过期，每30分钟续期
+	userStreamReadTimeout    = 10 * time.Minute // Binance每3分钟发送ping，超时视为断线
+	userStreamMaxBackoff     = 60 * time.Second
+)
+
+// OrderUpdateEvent 订单更新事件（ORDER_TRADE_UPDATE）
+type OrderUpdateEvent struct {
+	EventTime       int64   `json:"event_time"`
+	Symbol          string  `json:"symbol"`
+	OrderID         string  `json:"order_id"`
+	ClientOrderID   string  `json:"client_order_id"`
+	Side            string  `json:"side"`
+	PositionSide    string  `json:"position_side"`
+	OrderType       string  `json:"order_type"`     // 原始订单类型（STOP_MARKET触发后仍为STOP_MARKET）
+	ExecutionType   string  `json:"execution_type"` // NEW, TRADE, CANCELED, EXPIRED, AMENDMENT
+	Status          string  `json:"status"`         // NEW, PARTIALLY_FILLED, FILLED, CANCELED, EXPIRED
+	Quantity        float64 `json:"quantity"`
+	Price           float64 `json:"price"`
+	StopPrice       float64 `json:"stop_price"`
+	AvgPrice        float64 `json:"avg_price"`
+	LastFilledQty   float64 `json:"last_filled_qty"`
+	LastFilledPrice float64 `json:"last_filled_price"`
+	FilledQty       float64 `json:"filled_qty"`
+	Commission      float64 `json:"commission"`
+	RealizedProfit  float64 `json:"realized_profit"`
+	ReduceOnly      bool    `json:"reduce_only"`
+	ClosePosition   bool    `json:"close_position"`
+	TradeTime       int64   `json:"trade_time"`
+}
+
+// ToOrder 转换为订单结构
+func (e *OrderUpdateEvent) ToOrder() *types.Order {
+	return &types.Order{
+		ID:           e.OrderID,
+		Symbol:       e.Symbol,
+		Side:         e.Side,
+		PositionSide: e.PositionSide,
+		OrderType:    e.OrderType,
+		Quantity:     e.Quantity,
+		Price:        e.Price,
+		StopPrice:    e.StopPrice,
+		Status:       e.Status,
+		FilledQty:    e.FilledQty,
+		AvgPrice:     e.AvgPrice,
+		ReduceOnly:   e.ReduceOnly || e.ClosePosition,
+		Timestamp:    e.TradeTime / 1000,
+	}
+}
+
+// BalanceUpdate 余额变化
+type BalanceUpdate struct {
+	Asset              string  `json:"asset"`
+	WalletBalance      float64 `json:"wallet_balance"`
+	CrossWalletBalance float64 `json:"cross_wallet_balance"`
+	BalanceChange      float64 `json:"balance_change"`
+}
+
+// PositionUpdate 持仓变化
+type PositionUpdate struct {
+	Symbol        string  `json:"symbol"`
+	PositionSide  string  `json:"position_side"` // LONG, SHORT, BOTH
+	Amount        float64 `json:"amount"`        // 带符号的持仓数量
+	EntryPrice    float64 `json:"entry_price"`
+	UnrealizedPnl float64 `json:"unrealized_pnl"`
+}
+
+// Side 持仓方向（单向持仓模式按数量符号判断）
+func (p PositionUpdate) Side() string {
+	side := strings.ToUpper(p.PositionSide)
+	if side == "LONG" || side == "SHORT" {
+		return side
+	}
+	if p.Amount < 0 {
+		return "SHORT"
+	}
+	return "LONG"
+}
+
+// Size 持仓数量（绝对值）
+func (p PositionUpdate) Size() float64 {
+	if p.Amount < 0 {
+		return -p.Amount
+	}
+	return p.Amount
+}
+
+// AccountUpdateEvent 账户更新事件（ACCOUNT_UPDATE）
+type AccountUpdateEvent struct {
+	EventTime int64            `json:"event_time"`
+	Reason    string           `json:"reason"` // ORDER, FUNDING_FEE, DEPOSIT等
+	Balances  []BalanceUpdate  `json:"balances"`
+	Positions []PositionUpdate `json:"positions"`
+}
+
+// ParseUserDataEvent 解析用户数据流消息
+// 返回*OrderUpdateEvent、*AccountUpdateEvent，listenKey过期返回事件类型字符串，未知事件返回nil
+func ParseUserDataEvent(data []byte) (interface{}, error) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("parse user data event failed: %w", err)
+	}
+
+	eventType := parseStringValue(msg["e"])
+	eventTime, _ := parseFloatValue(msg["E"])
+
+	switch eventType {
+	case EventOrderTradeUpdate:
+		o, ok := msg["o"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s payload", eventType)
+		}
+
+		orderType := parseStringValue(o["ot"])
+		if orderType == "" {
+			orderType = parseStringValue(o["o"])
+		}
+
+		event := &OrderUpdateEvent{
+			EventTime:     int64(eventTime),
+			Symbol:        parseStringValue(o["s"]),
+			OrderID:       parseStringValue(o["i"]),
+			ClientOrderID: parseStringValue(o["c"]),
+			Side:          parseStringValue(o["S"]),
+			PositionSide:  parseStringValue(o["ps"]),
+			OrderType:     orderType,
+			ExecutionType: parseStringValue(o["x"]),
+			Status:        parseStringValue(o["X"]),
+		}
+		event.Quantity, _ = parseFloatValue(o["q"])
+		event.Price, _ = parseFloatValue(o["p"])
+		event.StopPrice, _ = parseFloatValue(o["sp"])
+		event.AvgPrice, _ = parseFloatValue(o["ap"])
+		event.LastFilledQty, _ = parseFloatValue(o["l"])
+		event.LastFilledPrice, _ = parseFloatValue(o["L"])
+		event.FilledQty, _ = parseFloatValue(o["z"])
+		event.Commission, _ = parseFloatValue(o["n"])
+		event.RealizedProfit, _ = parseFloatValue(o["rp"])
+		event.ReduceOnly, _ = parseBoolValue(o["R"])
+		event.ClosePosition, _ = parseBoolValue(o["cp"])
+		tradeTime, _ := parseFloatValue(o["T"])
+		event.TradeTime = int64(tradeTime)
+		return event, nil
+
+	case EventAccountUpdate:
+		a, ok := msg["a"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s payload", eventType)
+		}
+
+		event := &AccountUpdateEvent{
+			EventTime: int64(eventTime),
+			Reason:    parseStringValue(a["m"]),
+		}
+		if balances, ok := a["B"].([]interface{}); ok {
+			for _, item := range balances {
+				b, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				update := BalanceUpdate{Asset: parseStringValue(b["a"])}
+				update.WalletBalance, _ = parseFloatValue(b["wb"])
+				update.CrossWalletBalance, _ = parseFloatValue(b["cw"])
+				update.BalanceChange, _ = parseFloatValue(b["bc"])
+				event.Balances = append(event.Balances, update)
+			}
+		}
+		if positions, ok := a["P"].([]interface{}); ok {
+			for _, item := range positions {
+				p, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				update := PositionUpdate{
+					Symbol:       parseStringValue(p["s"]),
+					PositionSide: parseStringValue(p["ps"]),
+				}
+				update.Amount, _ = parseFloatValue(p["pa"])
+				update.EntryPrice, _ = parseFloatValue(p["ep"])
+				update.UnrealizedPnl, _ = parseFloatValue(p["up"])
+				event.Positions = append(event.Positions, update)
+			}
+		}
+		return event, nil
+
+	case EventListenKeyExpired:
+		return eventType, nil
+	}
+
+	return nil, nil
+}
+
+// UserDataStream Binance用户数据流（listenKey WebSocket）
+type UserDataStream struct {
+	restBaseURL string
+	wsBaseURL   string
+	apiKey      string
+	httpClient  *http.Client
+
+	handlersMu      sync.RWMutex
+	orderHandlers   []func(*OrderUpdateEvent)
+	accountHandlers []func(*AccountUpdateEvent)
+
+	connected atomic.Bool
+}
+
+var (
+	globalUserDataStream     *UserDataStream
+	globalUserDataStreamOnce sync.Once
+)
+
+// GetUserDataStream 获取用户数据流实例（单例）
+func GetUserDataStream() *UserDataStream {
+	globalUserDataStreamOnce.Do(func() {
+		cfg := config.Get()
+		globalUserDataStream = NewUserDataStream(cfg.BinanceFAPIBaseURL, cfg.BinanceWSBaseURL, cfg.BinanceAPIKey)
+	})
+	return globalUserDataStream
+}
+
+// NewUserDataStream 创建用户数据流
+// restBaseURL 用于申请/续期listenKey，wsBaseURL 为WebSocket地址（如wss://fstream.binance.com）
+func NewUserDataStream(restBaseURL, wsBaseURL, apiKey string) *UserDataStream {
+	return &UserDataStream{
+		restBaseURL: strings.TrimRight(restBaseURL, "/"),
+		wsBaseURL:   strings.TrimRight(wsBaseURL, "/"),
+		apiKey:      apiKey,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OnOrderUpdate 注册订单更新回调
+func (s *UserDataStream) OnOrderUpdate(handler func(*OrderUpdateEvent)) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.orderHandlers = append(s.orderHandlers, handler)
+}
+
+// OnAccountUpdate 注册账户更新回调
+func (s *UserDataStream) OnAccountUpdate(handler func(*AccountUpdateEvent)) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.accountHandlers = append(s.accountHandlers, handler)
+}
+
+// IsConnected 数据流是否已连接
+func (s *UserDataStream) IsConnected() bool {
+	return s.connected.Load()
+}
+
+// Run 运行用户数据流（阻塞，断线自动重连，直到ctx取消）
+func (s *UserDataStream) Run(ctx context.Context) {
+	logger := utils.GetLogger("user_stream")
+	backoff := time.Second
+
+	for {
+		started := time.Now()
+		err := s.runOnce(ctx)
+		s.connected.Store(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 连接稳定运行过一段时间则重置退避
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		logger.Warnw("用户数据流断开，准备重连", "error", err, "wait", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > userStreamMaxBackoff {
+			backoff = userStreamMaxBackoff
+		}
+	}
+}
+
+// runOnce 申请listenKey并保持一次WebSocket连接
+func (s *UserDataStream) runOnce(ctx context.Context) error {
+	logger := utils.GetLogger("user_stream")
+
+	listenKey, err := s.createListenKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, s.wsBaseURL+"/ws/"+listenKey, nil)
+	if err != nil {
+		return fmt.Errorf("dial user stream failed: %w", err)
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// ctx取消或续期失败时关闭连接，使ReadMessage返回
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	go s.keepalive(connCtx, cancel)
+
+	conn.SetReadDeadline(time.Now().Add(userStreamReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(userStreamReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	s.connected.Store(true)
+	logger.Info("用户数据流已连接")
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("read user stream failed: %w", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(userStreamReadTimeout))
+
+		event, err := ParseUserDataEvent(message)
+		if err != nil {
+			logger.Debugw("解析用户数据流消息失败", "error", err)
+			continue
+		}
+
+		switch ev := event.(type) {
+		case *OrderUpdateEvent:
+			s.dispatchOrder(ev)
+		case *AccountUpdateEvent:
+			s.dispatchAccount(ev)
+		case string:
+			if ev == EventListenKeyExpired {
+				return fmt.Errorf("listenKey expired")
+			}
+		}
+	}
+}
+
+// keepalive 定期续期listenKey，失败时断开连接以重新申请
+func (s *UserDataStream) keepalive(ctx context.Context, disconnect context.CancelFunc) {
+	logger := utils.GetLogger("user_stream")
+	ticker := time.NewTicker(listenKeyKeepalivePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.listenKeyRequest(ctx, http.MethodPut); err != nil {
+				logger.Warnw("listenKey续期失败", "error", err)
+				disconnect()
+				return
+			}
+		}
+	}
+}
+
+// createListenKey 申请listenKey
+func (s *UserDataStream) createListenKey(ctx context.Context) (string, error) {
+	body, err := s.doListenKeyRequest(ctx, http.MethodPost)
+	if err != nil {
+		return "", err
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parse response failed: %w", err)
+	}
+
+	listenKey := parseStringValue(resp["listenKey"])
+	if listenKey == "" {
+		return "", fmt.Errorf("empty listenKey in response")
+	}
+	return listenKey, nil
+}
+
+// listenKeyRequest 续期或关闭listenKey
+func (s *UserDataStream) listenKeyRequest(ctx context.Context, method string) error {
+	_, err := s.doListenKeyRequest(ctx, method)
+	return err
+}
+
+// doListenKeyRequest 发送listenKey请求（只需API Key，无需签名）
+func (s *UserDataStream) doListenKeyRequest(ctx context.Context, method string) ([]byte, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, method, s.restBaseURL+listenKeyEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("X-MBX-APIKEY", s.apiKey)
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listenKey %s failed: HTTP %d, body: %s", method, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// dispatchOrder 分发订单更新事件
+func (s *UserDataStream) dispatchOrder(event *OrderUpdateEvent) {
+	s.handlersMu.RLock()
+	handlers := s.orderHandlers
+	s.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// dispatchAccount 分发账户更新事件
+func (s *UserDataStream) dispatchAccount(event *AccountUpdateEvent) {
+	s.handlersMu.RLock()
+	handlers := s.accountHandlers
+	s.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
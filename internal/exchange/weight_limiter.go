@@ -0,0 +1,335 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+// Binance合约默认限额（exchangeInfo的rateLimits会覆盖）
+const (
+	defaultWeightLimit1M  = 2400
+	defaultOrderLimit10S  = 300
+	defaultOrderLimit1M   = 1200
+	normalWeightShare     = 0.8 // 普通请求最多使用的权重比例，其余留给订单请求
+	weightLimiterMaxSleep = time.Second
+)
+
+// RequestPriority 请求优先级
+type RequestPriority int
+
+const (
+	PriorityNormal RequestPriority = iota // 行情、扫描等
+	PriorityOrder                         // 下单、撤单、杠杆设置等
+)
+
+// RequestCost 请求消耗
+type RequestCost struct {
+	Weight   int // 请求权重
+	Orders   int // 计入下单频率的订单数
+	Priority RequestPriority
+}
+
+// WeightLimiter 按请求权重限流（对应Binance的REQUEST_WEIGHT和ORDERS限额）
+// 本地预扣权重，并用响应头 X-MBX-USED-WEIGHT-1M / X-MBX-ORDER-COUNT-* 校正，在触发429之前放慢请求
+type WeightLimiter struct {
+	mu sync.Mutex
+
+	weightLimit   int
+	orderLimit10s int
+	orderLimit1m  int
+
+	weightWindow  time.Time // 当前分钟窗口起点
+	usedWeight    int
+	order10sStart time.Time
+	orderCount10s int
+	order1mStart  time.Time
+	orderCount1m  int
+
+	pendingOrders int // 等待中的订单请求数，普通请求让行
+}
+
+// NewWeightLimiter 创建权重限流器（使用Binance合约默认限额）
+func NewWeightLimiter() *WeightLimiter {
+	return &WeightLimiter{
+		weightLimit:   defaultWeightLimit1M,
+		orderLimit10s: defaultOrderLimit10S,
+		orderLimit1m:  defaultOrderLimit1M,
+	}
+}
+
+// SetLimits 设置限额（<=0的值保持不变）
+func (wl *WeightLimiter) SetLimits(weight1m, orders10s, orders1m int) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	if weight1m > 0 {
+		wl.weightLimit = weight1m
+	}
+	if orders10s > 0 {
+		wl.orderLimit10s = orders10s
+	}
+	if orders1m > 0 {
+		wl.orderLimit1m = orders1m
+	}
+}
+
+// ApplyRateLimits 从exchangeInfo的rateLimits更新限额
+func (wl *WeightLimiter) ApplyRateLimits(rateLimits []interface{}) {
+	var weight1m, orders10s, orders1m int
+	for _, item := range rateLimits {
+		rl, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		limit, _ := parseFloatValue(rl["limit"])
+		intervalNum, _ := parseFloatValue(rl["intervalNum"])
+		interval := parseStringValue(rl["interval"])
+
+		switch parseStringValue(rl["rateLimitType"]) {
+		case "REQUEST_WEIGHT":
+			if interval == "MINUTE" && intervalNum == 1 {
+				weight1m = int(limit)
+			}
+		case "ORDERS":
+			if interval == "SECOND" && intervalNum == 10 {
+				orders10s = int(limit)
+			} else if interval == "MINUTE" && intervalNum == 1 {
+				orders1m = int(limit)
+			}
+		}
+	}
+	wl.SetLimits(weight1m, orders10s, orders1m)
+}
+
+// Wait 等待直到可以发送请求（预扣权重和订单数），ctx取消时返回错误
+func (wl *WeightLimiter) Wait(ctx context.Context, cost RequestCost) error {
+	if cost.Priority == PriorityOrder {
+		wl.mu.Lock()
+		wl.pendingOrders++
+		wl.mu.Unlock()
+		defer func() {
+			wl.mu.Lock()
+			wl.pendingOrders--
+			wl.mu.Unlock()
+		}()
+	}
+
+	logged := false
+	for {
+		wait := wl.tryAcquire(cost, time.Now())
+		if wait <= 0 {
+			return nil
+		}
+		if !logged {
+			used, limit := wl.Usage()
+			utils.GetLogger("exchange").Debugw("Request weight throttled",
+				"weight", cost.Weight,
+				"orders", cost.Orders,
+				"priority", cost.Priority,
+				"used_weight", used,
+				"weight_limit", limit,
+				"wait_ms", wait.Milliseconds(),
+			)
+			logged = true
+		}
+
+		if wait > weightLimiterMaxSleep {
+			wait = weightLimiterMaxSleep
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryAcquire 尝试预扣，成功返回0，否则返回建议等待时间
+func (wl *WeightLimiter) tryAcquire(cost RequestCost, now time.Time) time.Duration {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.rollWindows(now)
+
+	budget := wl.weightLimit
+	if cost.Priority != PriorityOrder {
+		// 有订单请求等待时普通请求让行
+		if wl.pendingOrders > 0 {
+			return 50 * time.Millisecond
+		}
+		budget = int(float64(wl.weightLimit) * normalWeightShare)
+	}
+	if wl.usedWeight > 0 && wl.usedWeight+cost.Weight > budget {
+		return wl.weightWindow.Add(time.Minute).Sub(now)
+	}
+
+	if cost.Orders > 0 {
+		if wl.orderCount10s > 0 && wl.orderCount10s+cost.Orders > wl.orderLimit10s {
+			return wl.order10sStart.Add(10 * time.Second).Sub(now)
+		}
+		if wl.orderCount1m > 0 && wl.orderCount1m+cost.Orders > wl.orderLimit1m {
+			return wl.order1mStart.Add(time.Minute).Sub(now)
+		}
+		wl.orderCount10s += cost.Orders
+		wl.orderCount1m += cost.Orders
+	}
+	wl.usedWeight += cost.Weight
+	return 0
+}
+
+// rollWindows 进入新窗口时清零计数（Binance按整分钟/整10秒计数）
+func (wl *WeightLimiter) rollWindows(now time.Time) {
+	if minute := now.Truncate(time.Minute); !minute.Equal(wl.weightWindow) {
+		wl.weightWindow = minute
+		wl.usedWeight = 0
+	}
+	if tenSec := now.Truncate(10 * time.Second); !tenSec.Equal(wl.order10sStart) {
+		wl.order10sStart = tenSec
+		wl.orderCount10s = 0
+	}
+	if minute := now.Truncate(time.Minute); !minute.Equal(wl.order1mStart) {
+		wl.order1mStart = minute
+		wl.orderCount1m = 0
+	}
+}
+
+// UpdateFromHeaders 用响应头校正已用权重和订单数
+// 取本地预扣与交易所返回的较大值（预扣中包含尚未返回的请求）
+func (wl *WeightLimiter) UpdateFromHeaders(h http.Header) {
+	usedWeight, hasWeight := headerInt(h, "X-MBX-USED-WEIGHT-1M")
+	orders10s, has10s := headerInt(h, "X-MBX-ORDER-COUNT-10S")
+	orders1m, has1m := headerInt(h, "X-MBX-ORDER-COUNT-1M")
+	if !hasWeight && !has10s && !has1m {
+		return
+	}
+
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.rollWindows(time.Now())
+	if hasWeight && usedWeight > wl.usedWeight {
+		wl.usedWeight = usedWeight
+	}
+	if has10s && orders10s > wl.orderCount10s {
+		wl.orderCount10s = orders10s
+	}
+	if has1m && orders1m > wl.orderCount1m {
+		wl.orderCount1m = orders1m
+	}
+}
+
+// Usage 当前分钟窗口已用权重和上限
+func (wl *WeightLimiter) Usage() (used, limit int) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	wl.rollWindows(time.Now())
+	return wl.usedWeight, wl.weightLimit
+}
+
+// headerInt 解析整数响应头
+func headerInt(h http.Header, key string) (int, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// BinanceRequestCost 计算Binance合约接口的请求消耗（权重参考官方文档）
+func BinanceRequestCost(method, endpoint string, params map[string]string) RequestCost {
+	cost := RequestCost{Weight: 1, Priority: PriorityNormal}
+	hasSymbol := params["symbol"] != ""
+
+	switch endpoint {
+	case "/fapi/v1/klines", "/fapi/v1/continuousKlines", "/fapi/v1/markPriceKlines":
+		cost.Weight = klinesWeight(params["limit"])
+	case "/fapi/v1/depth":
+		cost.Weight = depthWeight(params["limit"])
+	case "/fapi/v1/ticker/24hr":
+		if !hasSymbol {
+			cost.Weight = 40
+		}
+	case "/fapi/v1/ticker/price", "/fapi/v1/ticker/bookTicker":
+		if !hasSymbol {
+			cost.Weight = 2
+		}
+	case "/fapi/v1/premiumIndex":
+		if !hasSymbol {
+			cost.Weight = 10
+		}
+	case "/fapi/v2/balance", "/fapi/v2/account", "/fapi/v2/positionRisk",
+		"/fapi/v1/allOrders", "/fapi/v1/userTrades", "/fapi/v1/income":
+		cost.Weight = 5
+	case "/fapi/v1/positionSide/dual":
+		cost.Weight = 30
+	case "/fapi/v1/openOrders":
+		if !hasSymbol {
+			cost.Weight = 40
+		}
+	case "/fapi/v1/order":
+		cost.Priority = PriorityOrder
+		if method == http.MethodPost {
+			cost.Orders = 1
+		}
+	case "/fapi/v1/batchOrders":
+		cost.Weight = 5
+		cost.Priority = PriorityOrder
+		if method == http.MethodPost {
+			var orders []interface{}
+			if json.Unmarshal([]byte(params["batchOrders"]), &orders) == nil && len(orders) > 0 {
+				cost.Orders = len(orders)
+			} else {
+				cost.Orders = 1
+			}
+		}
+	case "/fapi/v1/allOpenOrders", "/fapi/v1/leverage", "/fapi/v1/marginType":
+		cost.Priority = PriorityOrder
+	}
+	return cost
+}
+
+// klinesWeight K线接口权重（按limit）
+func klinesWeight(limit string) int {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		n = 500 // 默认limit
+	}
+	switch {
+	case n < 100:
+		return 1
+	case n < 500:
+		return 2
+	case n <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// depthWeight 深度接口权重（按limit）
+func depthWeight(limit string) int {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		n = 500 // 默认limit
+	}
+	switch {
+	case n <= 50:
+		return 2
+	case n <= 100:
+		return 5
+	case n <= 500:
+		return 10
+	default:
+		return 20
+	}
+}
@@ -0,0 +1,37 @@
+package execution
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// 订单角色（参与客户端订单ID计算）
+const (
+	OrderRoleEntry = "entry"
+	OrderRoleSL    = "sl"
+	OrderRoleTP1   = "tp1"
+	OrderRoleTP2   = "tp2"
+)
+
+// ClientOrderID 由信号ID和订单角色生成确定性的客户端订单ID
+// 同一信号的同一角色重试时ID不变，交易所据此拒绝重复下单；
+// 只含字母和数字且不超过32位，满足Binance/OKX/Bybit的格式要求
+func ClientOrderID(signalID, role string) string {
+	if signalID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(signalID + ":" + role))
+	return "nx" + role + hex.EncodeToString(sum[:])[:24]
+}
+
+// protectionRole 保护单对应的订单角色
+func protectionRole(tpLevel int) string {
+	switch tpLevel {
+	case 0:
+		return OrderRoleSL
+	case 1:
+		return OrderRoleTP1
+	default:
+		return OrderRoleTP2
+	}
+}
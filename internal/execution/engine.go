@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/yuechangmingzou/nofx-go/internal/config"
@@ -16,19 +17,40 @@ import (
 type ExecutionEngine struct {
 	exchange types.Exchange
 	redis    utils.RedisClient
+
+	// 用户数据流（可选），在线时订单确认和守护进程由事件驱动
+	stream    *exchange.UserDataStream
+	waitersMu sync.Mutex
+	waiters   map[string]chan *exchange.OrderUpdateEvent
+
+	// 已应用到交易所的杠杆和保证金模式（按币种缓存，避免每次下单重复设置）
+	settingsMu  sync.Mutex
+	leverages   map[string]int
+	marginTypes map[string]string
 }
 
 var globalEngine *ExecutionEngine
 
 // GetExecutionEngine 获取执行引擎实例（单例）
-func GetExecutionEngine() *ExecutionEngine {
+func GetExecutionEngine() (*ExecutionEngine, error) {
 	if globalEngine == nil {
-		globalEngine = &ExecutionEngine{
-			exchange: exchange.GetBinanceExchange(),
-			redis:    utils.GetRedisClient(),
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			return nil, err
 		}
+		globalEngine = NewExecutionEngine(ex, utils.GetRedisClient())
+	}
+	return globalEngine, nil
+}
+
+// NewExecutionEngine 创建执行引擎
+func NewExecutionEngine(ex types.Exchange, redis utils.RedisClient) *ExecutionEngine {
+	return &ExecutionEngine{
+		exchange:    ex,
+		redis:       redis,
+		leverages:   make(map[string]int),
+		marginTypes: make(map[string]string),
 	}
-	return globalEngine
 }
 
 // PlaceOrderFromSignal 从交易信号下单
@@ -83,6 +105,65 @@ func (e *ExecutionEngine) PlaceOrderFromSignal(ctx context.Context, signal *type
 		return false, "入场价格无效", nil
 	}
 
+	// 风控检查：单笔名义价值、杠杆、持仓数、币种冷却
+	risk := e.checkRisk(ctx, signal, notionalUSDT)
+	if !risk.Allowed {
+		e.saveAudit(ctx, map[string]interface{}{
+			"ts":          time.Now().Unix(),
+			"event":       "risk_rejected",
+			"symbol":      symbol,
+			"signal_id":   signalID,
+			"action":      signal.Action,
+			"reason_code": risk.ReasonCode,
+			"reason":      risk.Reason,
+			"notional":    notionalUSDT,
+			"leverage":    signal.Leverage,
+		})
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:           StateRejected,
+			Source:       LifecycleSourceEngine,
+			Symbol:       symbol,
+			PositionSide: signal.Side,
+			Reason:       risk.ReasonCode,
+		})
+		return false, fmt.Sprintf("风控拒绝: %s", risk.Reason), nil
+	}
+	if len(risk.Adjustments) > 0 {
+		e.saveAudit(ctx, map[string]interface{}{
+			"ts":              time.Now().Unix(),
+			"event":           "risk_adjusted",
+			"symbol":          symbol,
+			"signal_id":       signalID,
+			"adjustments":     risk.Adjustments,
+			"notional_before": notionalUSDT,
+			"notional_after":  risk.Notional,
+			"leverage_before": signal.Leverage,
+			"leverage_after":  risk.Leverage,
+		})
+		notionalUSDT = risk.Notional
+		signal.Leverage = risk.Leverage
+	}
+
+	// 开仓前应用杠杆和保证金模式（失败时不下单，避免以错误杠杆开仓）
+	if _, err := e.EnsureLeverage(ctx, symbol, signal.Leverage); err != nil {
+		e.saveAudit(ctx, map[string]interface{}{
+			"ts":        time.Now().Unix(),
+			"event":     "leverage_failed",
+			"symbol":    symbol,
+			"signal_id": signalID,
+			"leverage":  signal.Leverage,
+			"error":     err.Error(),
+		})
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:           StateRejected,
+			Source:       LifecycleSourceEngine,
+			Symbol:       symbol,
+			PositionSide: signal.Side,
+			Reason:       "leverage_failed",
+		})
+		return false, fmt.Sprintf("设置杠杆失败: %v", err), nil
+	}
+
 	// 第五步：下单
 	orderReq := types.OrderRequest{
 		Symbol:       symbol,
@@ -92,50 +173,129 @@ func (e *ExecutionEngine) PlaceOrderFromSignal(ctx context.Context, signal *type
 		Quantity:     notionalUSDT / signal.EntryPrice,
 		Price:        &signal.EntryPrice,
 		TimeInForce:  "GTC",
+		// 同一信号重试时ID不变，下单超时后重发不会重复开仓
+		ClientOrderID: ClientOrderID(signalID, OrderRoleEntry),
 	}
 
-	order, err := e.exchange.PlaceOrder(orderReq)
+	order, err := e.placeOrderWithRetry(ctx, orderReq)
 	if err != nil {
+		event, class := orderFailureEvent(err, "order_failed")
 		e.saveAudit(ctx, map[string]interface{}{
-			"ts":     time.Now().Unix(),
-			"event":  "order_failed",
-			"symbol": symbol,
-			"error":  err.Error(),
+			"ts":          time.Now().Unix(),
+			"event":       event,
+			"symbol":      symbol,
+			"signal_id":   signalID,
+			"quantity":    orderReq.Quantity,
+			"error":       err.Error(),
+			"error_class": string(class),
+		})
+		if class == exchange.ErrorFatal {
+			e.alertOrderFailure(symbol, event, err)
+		}
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:           StateRejected,
+			Source:       LifecycleSourceEngine,
+			Symbol:       symbol,
+			PositionSide: signal.Side,
+			Reason:       event,
 		})
 		return false, fmt.Sprintf("下单失败: %v", err), nil
 	}
 
-	// 第六步：订单确认（异步，不阻塞主流程）
+	// 下单成功后进入币种冷却期
+	e.setCooldown(ctx, symbol)
+
+	e.recordLifecycle(ctx, signalID, LifecycleTransition{
+		To:           StateSubmitted,
+		Source:       LifecycleSourceEngine,
+		Symbol:       symbol,
+		PositionSide: signal.Side,
+		OrderID:      order.ID,
+		Quantity:     order.Quantity,
+	})
+	if order.Status == "FILLED" {
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:        StateFilled,
+			Source:    LifecycleSourceEngine,
+			OrderID:   order.ID,
+			FilledQty: order.FilledQty,
+			AvgPrice:  order.AvgPrice,
+		})
+	}
+
+	// 第六步：未成交的限价入场单交给入场单生命周期管理（超时或价格远离时撤单）
+	// 先于保护信息记录：守护进程据此保留尚未成交的入场单的保护信息
+	if order.Status != "FILLED" {
+		e.trackPendingEntry(ctx, &PendingEntry{
+			OrderID:       order.ID,
+			ClientOrderID: orderReq.ClientOrderID,
+			SignalID:      signalID,
+			Symbol:        symbol,
+			Side:          orderReq.Side,
+			PositionSide:  orderReq.PositionSide,
+			EntryPrice:    signal.EntryPrice,
+			Quantity:      order.Quantity,
+			PlacedAt:      time.Now().Unix(),
+		})
+	}
+
+	// 保存保护信息（成交后挂单和守护进程补挂都以此为准）
+	hasProtection := signal.StopLoss > 0 || signal.TakeProfit > 0
+	if hasProtection {
+		takeProfit2 := signal.TakeProfit2
+		if takeProfit2 <= 0 {
+			takeProfit2 = 0 // 明确设置为0
+		}
+		e.SaveProtection(ctx, symbol, signal.Side, signal.StopLoss, signal.TakeProfit, takeProfit2, signalID)
+	}
+
+	// 第七步：订单确认（异步，不阻塞主流程）
 	// 使用goroutine异步确认，避免阻塞
+	positionSide := strings.ToUpper(signal.Side)
 	go func() {
 		confirmCtx, confirmCancel := utils.WithLongTimeout(context.Background())
 		defer confirmCancel()
-		confirmed, confirmReason := e.confirmOrder(confirmCtx, symbol, order.ID, utils.LongTimeout)
+		confirmed, confirmReason, filled := e.confirmOrder(confirmCtx, symbol, order.ID, utils.LongTimeout)
 		if !confirmed {
 			logger.Warnw("订单确认失败",
 				"symbol", symbol,
 				"order_id", order.ID,
 				"reason", confirmReason,
 			)
-		} else {
-			logger.Infow("订单确认成功",
-				"symbol", symbol,
-				"order_id", order.ID,
-			)
+			return
 		}
-	}()
-
-	// 第七步：保存保护信息（用于守护进程）
-	if signal.StopLoss > 0 || signal.TakeProfit > 0 {
-		takeProfit2 := signal.TakeProfit2
-		if takeProfit2 <= 0 {
-			takeProfit2 = 0 // 明确设置为0
+		logger.Infow("订单确认成功",
+			"symbol", symbol,
+			"order_id", order.ID,
+		)
+		e.untrackPendingEntry(confirmCtx, symbol, order.ID)
+		source := LifecycleSourcePoll
+		if e.UserStreamConnected() {
+			source = LifecycleSourceStream
+		}
+		// 以交易所返回的成交数量和均价为准
+		filledQty := filled.FilledQty
+		if filledQty <= 0 {
+			filledQty = order.Quantity
+		}
+		// 下单即成交的订单已在上面记录为filled
+		if order.Status != "FILLED" {
+			e.recordLifecycle(confirmCtx, signalID, LifecycleTransition{
+				To:        StateFilled,
+				Source:    source,
+				OrderID:   order.ID,
+				FilledQty: filledQty,
+				AvgPrice:  filled.AvgPrice,
+			})
 		}
-		e.SaveProtection(ctx, symbol, signal.Side, signal.StopLoss, signal.TakeProfit, takeProfit2, signalID)
-	}
 
-	// 第八步：下止损单（由守护进程补挂，这里先保存保护信息）
-	// 注意：实际下单由守护进程确保，避免重复下单
+		// 第八步：入场成交后一次请求挂出止损、TP1、TP2，守护进程只补挂缺失的部分
+		if hasProtection {
+			protectCtx, protectCancel := utils.WithMediumTimeout(context.Background())
+			defer protectCancel()
+			e.protectAfterFill(protectCtx, symbol, positionSide, filledQty)
+		}
+	}()
 
 	// 第九步：保存交易历史
 	e.pushTradeHistory(ctx, map[string]interface{}{
@@ -180,7 +340,7 @@ func (e *ExecutionEngine) ClosePositionFromAction(ctx context.Context, signal *t
 	}
 
 	// 获取当前持仓
-	position, err := e.exchange.GetPosition(symbol)
+	position, err := e.exchange.GetPositionContext(ctx, symbol)
 	if err != nil {
 		return false, fmt.Sprintf("获取持仓失败: %v", err), nil
 	}
@@ -203,6 +363,14 @@ func (e *ExecutionEngine) ClosePositionFromAction(ctx context.Context, signal *t
 	}
 	defer e.releaseLock(ctx, lockKey, lockToken)
 
+	// 持仓对应的开仓信号进入closing
+	signalID := e.protectionSignalID(ctx, symbol, positionSide)
+	e.recordLifecycle(ctx, signalID, LifecycleTransition{
+		To:     StateClosing,
+		Source: LifecycleSourceEngine,
+		Reason: action,
+	})
+
 	// 下平仓单（市价单）
 	orderReq := types.OrderRequest{
 		Symbol:       symbol,
@@ -213,17 +381,40 @@ func (e *ExecutionEngine) ClosePositionFromAction(ctx context.Context, signal *t
 		ReduceOnly:   true,
 	}
 
-	order, err := e.exchange.PlaceOrder(orderReq)
+	order, err := e.exchange.PlaceOrderContext(ctx, orderReq)
 	if err != nil {
+		class := exchange.ClassifyError(err)
 		e.saveAudit(ctx, map[string]interface{}{
-			"ts":     time.Now().Unix(),
-			"event":  "close_failed",
-			"symbol": symbol,
-			"error":  err.Error(),
+			"ts":          time.Now().Unix(),
+			"event":       "close_failed",
+			"symbol":      symbol,
+			"error":       err.Error(),
+			"error_class": string(class),
+		})
+		// 平仓失败意味着持仓暴露，除业务拒绝和主动取消外都需要告警
+		if class != exchange.ErrorRejected && class != exchange.ErrorCanceled {
+			e.alertOrderFailure(symbol, "close_failed", err)
+		}
+		// 平仓失败，持仓仍由止损止盈保护
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:     StateProtected,
+			Source: LifecycleSourceEngine,
+			Reason: "close_failed",
 		})
 		return false, fmt.Sprintf("平仓失败: %v", err), nil
 	}
 
+	if order.Status == "FILLED" {
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:        StateClosed,
+			Source:    LifecycleSourceEngine,
+			OrderID:   order.ID,
+			FilledQty: order.FilledQty,
+			AvgPrice:  order.AvgPrice,
+			Reason:    action,
+		})
+	}
+
 	// 保存交易历史
 	e.pushTradeHistory(ctx, map[string]interface{}{
 		"ts":       time.Now().Unix(),
@@ -251,14 +442,14 @@ func (e *ExecutionEngine) mapSide(action string) string {
 	return "SELL"
 }
 
-// placeStopLossOrder 下止损单
-func (e *ExecutionEngine) placeStopLossOrder(ctx context.Context, symbol, side string, quantity, stopPrice float64) (*types.Order, error) {
+// stopLossRequest 构建止损单请求
+func stopLossRequest(symbol, side string, quantity, stopPrice float64) types.OrderRequest {
 	orderSide := "SELL"
 	if side == "SHORT" {
 		orderSide = "BUY"
 	}
 
-	orderReq := types.OrderRequest{
+	return types.OrderRequest{
 		Symbol:       symbol,
 		Side:         orderSide,
 		PositionSide: strings.ToUpper(side),
@@ -267,18 +458,16 @@ func (e *ExecutionEngine) placeStopLossOrder(ctx context.Context, symbol, side s
 		StopPrice:    &stopPrice,
 		ReduceOnly:   true,
 	}
-
-	return e.exchange.PlaceOrder(orderReq)
 }
 
-// placeTakeProfitOrder 下止盈单
-func (e *ExecutionEngine) placeTakeProfitOrder(ctx context.Context, symbol, side string, quantity, tpPrice float64) (*types.Order, error) {
+// takeProfitRequest 构建止盈单请求
+func takeProfitRequest(symbol, side string, quantity, tpPrice float64) types.OrderRequest {
 	orderSide := "SELL"
 	if side == "SHORT" {
 		orderSide = "BUY"
 	}
 
-	orderReq := types.OrderRequest{
+	return types.OrderRequest{
 		Symbol:       symbol,
 		Side:         orderSide,
 		PositionSide: strings.ToUpper(side),
@@ -287,35 +476,75 @@ func (e *ExecutionEngine) placeTakeProfitOrder(ctx context.Context, symbol, side
 		StopPrice:    &tpPrice,
 		ReduceOnly:   true,
 	}
+}
 
-	return e.exchange.PlaceOrder(orderReq)
+// protectAfterFill 入场成交后立即批量挂出止损止盈（与守护进程共用锁和检查逻辑，不会重复挂单）
+func (e *ExecutionEngine) protectAfterFill(ctx context.Context, symbol, positionSide string, filledQty float64) {
+	size := filledQty
+	if positions, err := e.exchange.GetPositionsContext(ctx); err == nil {
+		for _, pos := range positions {
+			if pos.Symbol == symbol && strings.ToUpper(pos.Side) == positionSide && pos.Size > 0 {
+				size = pos.Size // 加仓时按整体持仓保护
+				break
+			}
+		}
+	}
+	if size <= 0 {
+		return
+	}
+	e.ensureProtection(ctx, symbol, positionSide, size, "entry_fill")
 }
 
-// confirmOrder 确认订单状态
-func (e *ExecutionEngine) confirmOrder(ctx context.Context, symbol, orderID string, timeout time.Duration) (bool, string) {
+// confirmOrder 确认订单状态，成交时返回订单的成交数量和均价
+// 用户数据流在线时等待成交事件，否则每2秒轮询一次
+func (e *ExecutionEngine) confirmOrder(ctx context.Context, symbol, orderID string, timeout time.Duration) (bool, string, *types.Order) {
 	deadline := time.Now().Add(timeout)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	updates, unregister := e.waitOrderUpdate(orderID)
+	defer unregister()
+
+	// 事件可能在注册等待前已到达，首次总是查询一次
+	polled := false
+
 	for {
 		select {
 		case <-ctx.Done():
-			return false, "上下文取消"
+			return false, "上下文取消", nil
+		case event := <-updates:
+			if event.Status == "FILLED" {
+				return true, "订单已成交", &types.Order{
+					ID:        event.OrderID,
+					Symbol:    event.Symbol,
+					Status:    event.Status,
+					Quantity:  event.Quantity,
+					FilledQty: event.FilledQty,
+					AvgPrice:  event.AvgPrice,
+				}
+			}
+			if event.Status == "CANCELED" || event.Status == "REJECTED" || event.Status == "EXPIRED" {
+				return false, fmt.Sprintf("订单状态: %s", event.Status), nil
+			}
 		case <-ticker.C:
 			if time.Now().After(deadline) {
-				return false, "确认超时"
+				return false, "确认超时", nil
+			}
+			if polled && e.UserStreamConnected() {
+				continue
 			}
+			polled = true
 
-			order, err := e.exchange.GetOrder(symbol, orderID)
+			order, err := e.exchange.GetOrderContext(ctx, symbol, orderID)
 			if err != nil {
 				continue
 			}
 
 			if order.Status == "FILLED" {
-				return true, "订单已成交"
+				return true, "订单已成交", order
 			}
 			if order.Status == "CANCELED" || order.Status == "REJECTED" {
-				return false, fmt.Sprintf("订单状态: %s", order.Status)
+				return false, fmt.Sprintf("订单状态: %s", order.Status), nil
 			}
 		}
 	}
@@ -0,0 +1,272 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 入场单撤销原因
+const (
+	EntryExpiryTimeout = "timeout"     // 超过BreakoutTimeoutSec未成交
+	EntryExpiryDrift   = "price_drift" // 价格向远离入场价方向偏离超过EntryCancelDriftPct
+)
+
+// PendingEntry 未成交的限价入场单
+type PendingEntry struct {
+	OrderID       string  `json:"order_id"`
+	ClientOrderID string  `json:"client_order_id"`
+	SignalID      string  `json:"signal_id"`
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"`          // BUY, SELL
+	PositionSide  string  `json:"position_side"` // LONG, SHORT
+	EntryPrice    float64 `json:"entry_price"`
+	Quantity      float64 `json:"quantity"`
+	PlacedAt      int64   `json:"placed_at"` // 秒
+}
+
+// EntryExpiryReason 判断入场单是否应撤销，返回撤销原因（不需要撤销时为空）
+// 买单在价格上涨、卖单在价格下跌时视为远离；timeoutSec或driftPct不大于0时不检查对应条件
+func EntryExpiryReason(entry *PendingEntry, price float64, now time.Time, timeoutSec int, driftPct float64) string {
+	if entry == nil {
+		return ""
+	}
+	if timeoutSec > 0 && entry.PlacedAt > 0 && now.Unix()-entry.PlacedAt >= int64(timeoutSec) {
+		return EntryExpiryTimeout
+	}
+	if driftPct > 0 && price > 0 && entry.EntryPrice > 0 {
+		drift := (price - entry.EntryPrice) / entry.EntryPrice
+		if strings.ToUpper(entry.Side) == "SELL" {
+			drift = -drift
+		}
+		if drift >= driftPct {
+			return EntryExpiryDrift
+		}
+	}
+	return ""
+}
+
+// pendingEntriesKey 交易对的未成交入场单（Hash，field为订单ID）
+func pendingEntriesKey(symbol string) string {
+	return config.GetRedisKey(fmt.Sprintf("pending_entries:%s", strings.ToUpper(symbol)))
+}
+
+// trackPendingEntry 记录未成交的入场单
+func (e *ExecutionEngine) trackPendingEntry(ctx context.Context, entry *PendingEntry) {
+	cfg := config.Get()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	key := pendingEntriesKey(entry.Symbol)
+	e.redis.HSet(ctx, key, entry.OrderID, string(data))
+	if cfg.ProtectionTTLSec > 0 {
+		e.redis.Expire(ctx, key, time.Duration(cfg.ProtectionTTLSec)*time.Second)
+	}
+}
+
+// untrackPendingEntry 入场单已结束（成交、撤销或过期），停止跟踪
+func (e *ExecutionEngine) untrackPendingEntry(ctx context.Context, symbol, orderID string) {
+	e.redis.HDel(ctx, pendingEntriesKey(symbol), orderID)
+}
+
+// hasPendingEntry 交易对该方向是否有未成交的入场单
+func (e *ExecutionEngine) hasPendingEntry(ctx context.Context, symbol, positionSide string) (bool, error) {
+	fields, err := e.redis.HGetAll(ctx, pendingEntriesKey(symbol)).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, raw := range fields {
+		var entry PendingEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err == nil && strings.EqualFold(entry.PositionSide, positionSide) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PendingEntries 读取全部未成交入场单（按交易对分组）
+func (e *ExecutionEngine) PendingEntries(ctx context.Context) (map[string][]*PendingEntry, error) {
+	pattern := config.GetRedisKey("pending_entries:*")
+
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := e.redis.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	result := make(map[string][]*PendingEntry)
+	for _, key := range keys {
+		fields, err := e.redis.HGetAll(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+		for _, raw := range fields {
+			var entry PendingEntry
+			if err := json.Unmarshal([]byte(raw), &entry); err != nil || entry.OrderID == "" {
+				continue
+			}
+			result[entry.Symbol] = append(result[entry.Symbol], &entry)
+		}
+	}
+	return result, nil
+}
+
+// ExpirePendingEntriesOnce 入场单生命周期管理（单次执行）：
+// 已成交的停止跟踪；超时或价格远离的撤单；撤单或交易所取消后未成交则清除对应的保护信息，部分成交则按成交数量挂保护单
+func (e *ExecutionEngine) ExpirePendingEntriesOnce(ctx context.Context) {
+	logger := utils.GetLogger("execution")
+	cfg := config.Get()
+
+	entries, err := e.PendingEntries(ctx)
+	if err != nil {
+		logger.Debugw("读取未成交入场单失败", "error", err)
+		return
+	}
+
+	for symbol, list := range entries {
+		var price float64
+		if cfg.EntryCancelDriftPct > 0 {
+			price, _ = e.exchange.GetTickerPriceContext(ctx, symbol)
+		}
+		for _, entry := range list {
+			e.expireEntry(ctx, entry, price)
+		}
+	}
+}
+
+// expireEntry 检查单个入场单
+func (e *ExecutionEngine) expireEntry(ctx context.Context, entry *PendingEntry, price float64) {
+	logger := utils.GetLogger("execution")
+	cfg := config.Get()
+
+	order, err := e.exchange.GetOrderContext(ctx, entry.Symbol, entry.OrderID)
+	if err != nil {
+		// 订单不存在（业务拒绝）时停止跟踪，其他错误下次重试
+		if exchange.ClassifyError(err) == exchange.ErrorRejected {
+			e.untrackPendingEntry(ctx, entry.Symbol, entry.OrderID)
+		}
+		return
+	}
+
+	switch order.Status {
+	case "FILLED":
+		e.untrackPendingEntry(ctx, entry.Symbol, entry.OrderID)
+		return
+	case "CANCELED", "EXPIRED", "REJECTED":
+		e.finishEntry(ctx, entry, order, "exchange_"+strings.ToLower(order.Status))
+		return
+	}
+
+	reason := EntryExpiryReason(entry, price, time.Now(), cfg.BreakoutTimeoutSec, cfg.EntryCancelDriftPct)
+	if reason == "" {
+		return
+	}
+
+	// 与下单共用锁，避免撤单与同币种新信号交错
+	lockKey := fmt.Sprintf("execution:lock:%s", entry.Symbol)
+	lockToken, err := e.acquireLock(ctx, lockKey, 30*time.Second)
+	if err != nil {
+		return
+	}
+	defer e.releaseLock(ctx, lockKey, lockToken)
+
+	if err := e.exchange.CancelOrderContext(ctx, entry.Symbol, entry.OrderID); err != nil {
+		logger.Warnw("撤销未成交入场单失败",
+			"symbol", entry.Symbol,
+			"order_id", entry.OrderID,
+			"reason", reason,
+			"error", err,
+		)
+		return
+	}
+
+	// 撤单前可能已有部分成交，以撤单后的状态为准
+	if final, err := e.exchange.GetOrderContext(ctx, entry.Symbol, entry.OrderID); err == nil && final != nil {
+		order = final
+	}
+	e.finishEntry(ctx, entry, order, reason)
+
+	logger.Infow("未成交入场单已撤销",
+		"symbol", entry.Symbol,
+		"order_id", entry.OrderID,
+		"signal_id", entry.SignalID,
+		"reason", reason,
+		"filled_qty", order.FilledQty,
+		"price", price,
+	)
+}
+
+// finishEntry 入场单已结束但未完全成交：记录审计；未成交时清除保护信息，部分成交时按成交数量挂保护单
+func (e *ExecutionEngine) finishEntry(ctx context.Context, entry *PendingEntry, order *types.Order, reason string) {
+	e.untrackPendingEntry(ctx, entry.Symbol, entry.OrderID)
+
+	protectionCleared := false
+	if order.FilledQty > 0 {
+		e.protectAfterFill(ctx, entry.Symbol, entry.PositionSide, order.FilledQty)
+	} else {
+		protectionCleared = e.clearEntryProtection(ctx, entry)
+		state := StateCancelled
+		if order.Status == "REJECTED" {
+			state = StateRejected
+		}
+		e.recordLifecycle(ctx, entry.SignalID, LifecycleTransition{
+			To:      state,
+			Source:  LifecycleSourceExpiry,
+			OrderID: entry.OrderID,
+			Reason:  reason,
+		})
+	}
+
+	e.saveAudit(ctx, map[string]interface{}{
+		"ts":                 time.Now().Unix(),
+		"event":              "entry_expired",
+		"symbol":             entry.Symbol,
+		"signal_id":          entry.SignalID,
+		"order_id":           entry.OrderID,
+		"position_side":      entry.PositionSide,
+		"entry":              entry.EntryPrice,
+		"quantity":           entry.Quantity,
+		"filled_qty":         order.FilledQty,
+		"status":             order.Status,
+		"reason":             reason,
+		"age_sec":            time.Now().Unix() - entry.PlacedAt,
+		"protection_cleared": protectionCleared,
+	})
+}
+
+// clearEntryProtection 删除未成交入场单对应的保护信息
+// 保护信息已被其他信号覆盖，或该方向仍有持仓（之前的仓位）时保留
+func (e *ExecutionEngine) clearEntryProtection(ctx context.Context, entry *PendingEntry) bool {
+	signalID := e.protectionSignalID(ctx, entry.Symbol, entry.PositionSide)
+	if signalID == "" || signalID != entry.SignalID {
+		return false
+	}
+
+	positions, err := e.exchange.GetPositionsContext(ctx)
+	if err != nil {
+		return false
+	}
+	for _, pos := range positions {
+		if pos.Symbol == entry.Symbol && strings.ToUpper(pos.Side) == entry.PositionSide && pos.Size > 0 {
+			return false
+		}
+	}
+	key := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", entry.Symbol, entry.PositionSide))
+	return e.redis.Del(ctx, key).Err() == nil
+}
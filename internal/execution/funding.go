@@ -0,0 +1,123 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// FundingAdverse 判断持仓方向在下次结算时是否需要支付资金费（费率为正时多头支付，为负时空头支付）
+func FundingAdverse(positionSide string, rate float64) bool {
+	switch strings.ToUpper(positionSide) {
+	case "LONG":
+		return rate > 0
+	case "SHORT":
+		return rate < 0
+	}
+	return false
+}
+
+// FundingGuardHit 判断是否触发资金费率风控：距结算不足windowMin分钟、需要支付资金费且费率绝对值达到threshold
+// threshold或windowMin不大于0时不触发
+func FundingGuardHit(positionSide string, rate, minutesToFunding, threshold float64, windowMin int) bool {
+	if threshold <= 0 || windowMin <= 0 || minutesToFunding <= 0 {
+		return false
+	}
+	return minutesToFunding <= float64(windowMin) &&
+		FundingAdverse(positionSide, rate) &&
+		math.Abs(rate) >= threshold
+}
+
+// minutesToFunding 距下次结算的分钟数（结算时间未知或已过时返回0）
+func minutesToFunding(nextFundingTime int64, now time.Time) float64 {
+	if nextFundingTime <= 0 {
+		return 0
+	}
+	minutes := float64(nextFundingTime-now.UnixMilli()) / float64(time.Minute/time.Millisecond)
+	if minutes < 0 {
+		return 0
+	}
+	return minutes
+}
+
+// FundingGuardOnce 资金费率守护：结算前平掉需要支付大额资金费的持仓（FundingGuardEnabled且FundingGuardClose时生效）
+func (e *ExecutionEngine) FundingGuardOnce(ctx context.Context) {
+	cfg := config.Get()
+	if !cfg.FundingGuardEnabled || !cfg.FundingGuardClose {
+		return
+	}
+	logger := utils.GetLogger("execution")
+
+	positions, err := e.exchange.GetPositionsContext(ctx)
+	if err != nil {
+		logger.Warnw("资金费率守护获取持仓失败", "error", err)
+		return
+	}
+
+	premiums := make(map[string]*types.PremiumIndex)
+	for _, pos := range positions {
+		if pos == nil || pos.Size <= 0 {
+			continue
+		}
+		symbol := strings.ToUpper(pos.Symbol)
+
+		premium, ok := premiums[symbol]
+		if !ok {
+			premium, err = e.exchange.GetPremiumIndexContext(ctx, symbol)
+			if err != nil {
+				logger.Warnw("资金费率守护获取资金费率失败", "symbol", symbol, "error", err)
+			}
+			premiums[symbol] = premium
+		}
+		if premium == nil {
+			continue
+		}
+
+		minutes := minutesToFunding(premium.NextFundingTime, time.Now())
+		rate := premium.PredictedFundingRate
+		if !FundingGuardHit(pos.Side, rate, minutes, cfg.FundingGuardThreshold, cfg.FundingGuardWindowMin) {
+			continue
+		}
+
+		action := "close_long"
+		if strings.ToUpper(pos.Side) == "SHORT" {
+			action = "close_short"
+		}
+		ok, reason, order := e.ClosePositionFromAction(ctx, &types.Signal{
+			Symbol: symbol,
+			Action: action,
+			Reason: fmt.Sprintf("资金费率%.4f%%将于%.0f分钟后结算", rate*100, minutes),
+		})
+
+		event := map[string]interface{}{
+			"ts":                 time.Now().Unix(),
+			"event":              "funding_guard_close",
+			"symbol":             symbol,
+			"position_side":      pos.Side,
+			"size":               pos.Size,
+			"funding_rate":       rate,
+			"minutes_to_funding": minutes,
+			"ok":                 ok,
+			"reason":             reason,
+		}
+		if order != nil {
+			event["order_id"] = order.ID
+		}
+		e.saveAudit(ctx, event)
+
+		logger.Infow("资金费率守护平仓",
+			"symbol", symbol,
+			"position_side", pos.Side,
+			"funding_rate", rate,
+			"minutes_to_funding", minutes,
+			"ok", ok,
+			"reason", reason,
+		)
+	}
+}
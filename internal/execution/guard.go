@@ -16,20 +16,15 @@ import (
 // EnsureSLTPGuardOnce 确保止损止盈守护（单次执行）
 func (e *ExecutionEngine) EnsureSLTPGuardOnce(ctx context.Context, intervalTag string) {
 	logger := utils.GetLogger("execution_guard")
-	cfg := config.Get()
 
 	// 获取所有持仓
-	positions, err := e.exchange.GetPositions()
+	positions, err := e.exchange.GetPositionsContext(ctx)
 	if err != nil {
 		logger.Warnw("获取持仓失败", "error", err)
 		return
 	}
 
-	if len(positions) == 0 {
-		return
-	}
-
-	// 构建持仓映射
+	// 构建持仓映射（无持仓时仍需清理最后平掉的仓位的残留订单和保护信息）
 	posMap := make(map[string]map[string]float64)
 	for _, pos := range positions {
 		if posMap[pos.Symbol] == nil {
@@ -40,171 +35,208 @@ func (e *ExecutionEngine) EnsureSLTPGuardOnce(ctx context.Context, intervalTag s
 
 	// 遍历每个持仓，检查并补挂止损止盈
 	for _, pos := range positions {
-		symbol := pos.Symbol
-		side := strings.ToLower(pos.Side)
-		positionSide := strings.ToUpper(pos.Side)
-		size := pos.Size
-
-		if size <= 0 {
+		if pos.Size <= 0 {
 			continue
 		}
+		e.ensureProtection(ctx, pos.Symbol, strings.ToUpper(pos.Side), pos.Size, intervalTag)
+	}
 
-		// 获取分布式锁
-		lockKey := fmt.Sprintf("guard:lock:%s:%s", symbol, positionSide)
-		// 使用60秒TTL，确保有足够时间完成操作
-		lockToken, err := e.acquireLock(ctx, lockKey, 60*time.Second)
-		if err != nil {
+	// 清理已平仓的保护信息
+	e.cleanupProtection(ctx, posMap)
+}
+
+// ensureProtection 检查单个持仓的止损止盈单，缺失时补挂
+func (e *ExecutionEngine) ensureProtection(ctx context.Context, symbol, positionSide string, size float64, intervalTag string) {
+	logger := utils.GetLogger("execution_guard")
+	cfg := config.Get()
+	side := strings.ToLower(positionSide)
+
+	// 获取分布式锁
+	lockKey := fmt.Sprintf("guard:lock:%s:%s", symbol, positionSide)
+	// 使用60秒TTL，确保有足够时间完成操作
+	lockToken, err := e.acquireLock(ctx, lockKey, 60*time.Second)
+	if err != nil {
+		return
+	}
+	defer e.releaseLock(ctx, lockKey, lockToken)
+
+	// 从Redis读取保护信息
+	protectionKey := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", symbol, positionSide))
+	protectionJSON, err := e.redis.Get(ctx, protectionKey).Result()
+	if err != nil {
+		// 没有保护信息，跳过
+		return
+	}
+
+	var protection map[string]interface{}
+	if err := json.Unmarshal([]byte(protectionJSON), &protection); err != nil {
+		return
+	}
+
+	stopLoss := utils.GetFloat(protection, "stop_loss", 0)
+	takeProfit1 := utils.GetFloat(protection, "take_profit_1", 0)
+	takeProfit2 := utils.GetFloat(protection, "take_profit_2", 0)
+	tp1Ratio := utils.GetFloat(protection, "tp1_ratio", cfg.TP1PartialRatio)
+	signalID := utils.GetString(protection, "signal_id", "")
+
+	if stopLoss <= 0 || takeProfit1 <= 0 {
+		e.saveAudit(ctx, map[string]interface{}{
+			"ts":            time.Now().Unix(),
+			"event":         "guard_invalid_protection_params",
+			"symbol":        symbol,
+			"side":          side,
+			"interval":      intervalTag,
+			"stop_loss":     stopLoss,
+			"take_profit_1": takeProfit1,
+		})
+		return
+	}
+
+	// 获取当前挂单，检查是否已有止损止盈单
+	hasSL := false
+	hasTP1 := false
+	hasTP2 := false
+
+	// 平仓方向：多仓卖出，空仓买入
+	closeSide := "SELL"
+	if positionSide == "SHORT" {
+		closeSide = "BUY"
+	}
+
+	// 查询失败时无法判断已有哪些保护单，本轮跳过，避免重复挂单
+	orders, err := e.exchange.GetOpenOrdersContext(ctx, symbol)
+	if err != nil {
+		logger.Warnw("查询挂单失败，跳过本轮保护检查",
+			"symbol", symbol,
+			"side", side,
+			"error", err,
+		)
+		return
+	}
+	for _, o := range orders {
+		if !o.ReduceOnly || o.Side != closeSide {
 			continue
 		}
+		// 检查止损单
+		if isStopLossOrder(o) {
+			hasSL = true
+		}
+		// 检查止盈单，根据价格判断是TP1还是TP2
+		if isTakeProfitOrder(o) {
+			tpPrice := getTakeProfitPrice(o)
+			if takeProfit1 > 0 && math.Abs(tpPrice-takeProfit1) < math.Abs(tpPrice-takeProfit2) {
+				hasTP1 = true
+			} else if takeProfit2 > 0 {
+				hasTP2 = true
+			}
+		}
+	}
 
-		func() {
-			defer e.releaseLock(ctx, lockKey, lockToken)
+	// 计算分批止盈数量
+	amt1, amt2 := splitTakeProfit(size, tp1Ratio)
+	needTP2 := takeProfit2 > 0 && amt2 > 0
 
-			// 从Redis读取保护信息
-			protectionKey := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", symbol, positionSide))
-			protectionJSON, err := e.redis.Get(ctx, protectionKey).Result()
-			if err != nil {
-				// 没有保护信息，跳过
-				return
-			}
+	// 收集缺失的保护单，一次请求挂出，避免持仓只被部分保护
+	var legs []protectionLeg
+	if !hasSL {
+		legs = append(legs, protectionLeg{amount: size, price: stopLoss})
+	}
+	if !hasTP1 {
+		legs = append(legs, protectionLeg{tpLevel: 1, amount: amt1, price: takeProfit1})
+	}
+	if needTP2 && !hasTP2 {
+		legs = append(legs, protectionLeg{tpLevel: 2, amount: amt2, price: takeProfit2})
+	}
+	if len(legs) == 0 {
+		e.markProtected(ctx, signalID, size, intervalTag)
+		return
+	}
 
-			var protection map[string]interface{}
-			if err := json.Unmarshal([]byte(protectionJSON), &protection); err != nil {
-				return
-			}
+	results, err := e.exchange.PlaceBatchOrdersContext(ctx, protectionRequests(symbol, positionSide, legs, signalID))
+	if err != nil {
+		logger.Warnw("补挂保护单失败",
+			"symbol", symbol,
+			"error", err,
+		)
+		return
+	}
 
-			stopLoss := utils.GetFloat(protection, "stop_loss", 0)
-			takeProfit1 := utils.GetFloat(protection, "take_profit_1", 0)
-			takeProfit2 := utils.GetFloat(protection, "take_profit_2", 0)
-			tp1Ratio := utils.GetFloat(protection, "tp1_ratio", cfg.TP1PartialRatio)
-			signalID := utils.GetString(protection, "signal_id", "")
-
-			if stopLoss <= 0 || takeProfit1 <= 0 {
-				e.saveAudit(ctx, map[string]interface{}{
-					"ts":            time.Now().Unix(),
-					"event":         "guard_invalid_protection_params",
-					"symbol":        symbol,
-					"side":          side,
-					"interval":      intervalTag,
-					"stop_loss":     stopLoss,
-					"take_profit_1": takeProfit1,
-				})
-				return
+	for i, leg := range legs {
+		if i >= len(results) || results[i].Err != nil || results[i].Order == nil {
+			var legErr error
+			if i < len(results) {
+				legErr = results[i].Err
 			}
+			logger.Warnw("补挂保护单失败",
+				"symbol", symbol,
+				"tp_level", leg.tpLevel,
+				"error", legErr,
+			)
+			continue
+		}
 
-			// 获取当前挂单，检查是否已有止损止盈单
-			hasSL := false
-			hasTP1 := false
-			hasTP2 := false
-			
-			orders, err := e.exchange.GetOpenOrders(symbol)
-			if err == nil && orders != nil {
-				for _, o := range orders {
-					// 检查止损单
-					if o.ReduceOnly && (o.OrderType == "STOP" || o.OrderType == "STOP_MARKET") {
-						if (side == "LONG" && o.Side == "SELL") || (side == "SHORT" && o.Side == "BUY") {
-							hasSL = true
-						}
-					}
-					// 检查止盈单
-					if o.ReduceOnly && (o.OrderType == "TAKE_PROFIT" || o.OrderType == "TAKE_PROFIT_MARKET") {
-						if (side == "LONG" && o.Side == "SELL") || (side == "SHORT" && o.Side == "BUY") {
-							// 根据价格判断是TP1还是TP2
-							if takeProfit1 > 0 && math.Abs(o.Price-takeProfit1) < math.Abs(o.Price-takeProfit2) {
-								hasTP1 = true
-							} else if takeProfit2 > 0 {
-								hasTP2 = true
-							}
-						}
-					}
-				}
-			}
+		audit := map[string]interface{}{
+			"ts":        time.Now().Unix(),
+			"symbol":    symbol,
+			"signal_id": signalID,
+			"side":      side,
+			"amount":    leg.amount,
+			"order_id":  results[i].Order.ID,
+			"interval":  intervalTag,
+		}
+		if leg.tpLevel == 0 {
+			audit["event"] = "guard_stop_loss_placed"
+			audit["stop_loss"] = leg.price
+		} else {
+			audit["event"] = "guard_take_profit_placed"
+			audit["tp_level"] = leg.tpLevel
+			audit["take_profit"] = leg.price
+		}
+		e.saveAudit(ctx, audit)
+		if leg.tpLevel == 0 {
+			hasSL = true
+		}
+	}
 
-			// 计算分批止盈数量
-			tp1Ratio = math.Max(0.0, math.Min(tp1Ratio, 1.0))
-			amt1 := math.Round(size*tp1Ratio*1e8) / 1e8
-			amt2 := math.Round(math.Max(0.0, size-amt1)*1e8) / 1e8
-			if amt1 <= 0 {
-				amt1 = size
-				amt2 = 0
-			}
-			needTP2 := takeProfit2 > 0 && amt2 > 0
-
-			// 补挂止损单
-			if !hasSL {
-				slOrder, err := e.placeStopLossOrder(ctx, symbol, positionSide, size, stopLoss)
-				if err != nil {
-					logger.Warnw("补挂止损单失败",
-						"symbol", symbol,
-						"error", err,
-					)
-				} else {
-					e.saveAudit(ctx, map[string]interface{}{
-						"ts":        time.Now().Unix(),
-						"event":     "guard_stop_loss_placed",
-						"symbol":    symbol,
-						"signal_id": signalID,
-						"side":      side,
-						"amount":    size,
-						"stop_loss": stopLoss,
-						"order_id":  slOrder.ID,
-						"interval":  intervalTag,
-					})
-				}
-			}
+	// 止损已挂出即视为受保护
+	if hasSL {
+		e.markProtected(ctx, signalID, size, intervalTag)
+	}
+}
 
-			// 补挂止盈单1
-			if !hasTP1 {
-				tpOrder1, err := e.placeTakeProfitOrder(ctx, symbol, positionSide, amt1, takeProfit1)
-				if err != nil {
-					logger.Warnw("补挂止盈单1失败",
-						"symbol", symbol,
-						"error", err,
-					)
-				} else {
-					e.saveAudit(ctx, map[string]interface{}{
-						"ts":          time.Now().Unix(),
-						"event":       "guard_take_profit_placed",
-						"symbol":      symbol,
-						"signal_id":   signalID,
-						"side":        side,
-						"amount":      amt1,
-						"tp_level":    1,
-						"take_profit": takeProfit1,
-						"order_id":    tpOrder1.ID,
-						"interval":    intervalTag,
-					})
-				}
-			}
+// protectionLeg 保护单（止损或分批止盈）
+type protectionLeg struct {
+	tpLevel int // 0为止损，1/2为止盈级别
+	amount  float64
+	price   float64
+}
 
-			// 补挂止盈单2
-			if needTP2 && !hasTP2 {
-				tpOrder2, err := e.placeTakeProfitOrder(ctx, symbol, positionSide, amt2, takeProfit2)
-				if err != nil {
-					logger.Warnw("补挂止盈单2失败",
-						"symbol", symbol,
-						"error", err,
-					)
-				} else {
-					e.saveAudit(ctx, map[string]interface{}{
-						"ts":          time.Now().Unix(),
-						"event":       "guard_take_profit_placed",
-						"symbol":      symbol,
-						"signal_id":   signalID,
-						"side":        side,
-						"amount":      amt2,
-						"tp_level":    2,
-						"take_profit": takeProfit2,
-						"order_id":    tpOrder2.ID,
-						"interval":    intervalTag,
-					})
-				}
-			}
-		}()
+// protectionRequests 构建保护单请求（客户端订单ID由信号ID和角色确定，重复补挂会被交易所识别）
+func protectionRequests(symbol, positionSide string, legs []protectionLeg, signalID string) []types.OrderRequest {
+	reqs := make([]types.OrderRequest, 0, len(legs))
+	for _, leg := range legs {
+		var req types.OrderRequest
+		if leg.tpLevel == 0 {
+			req = stopLossRequest(symbol, positionSide, leg.amount, leg.price)
+		} else {
+			req = takeProfitRequest(symbol, positionSide, leg.amount, leg.price)
+		}
+		req.ClientOrderID = ClientOrderID(signalID, protectionRole(leg.tpLevel))
+		reqs = append(reqs, req)
 	}
+	return reqs
+}
 
-	// 清理已平仓的保护信息
-	e.cleanupProtection(ctx, posMap)
+// splitTakeProfit 按TP1比例拆分止盈数量
+func splitTakeProfit(size, tp1Ratio float64) (float64, float64) {
+	tp1Ratio = math.Max(0.0, math.Min(tp1Ratio, 1.0))
+	amt1 := math.Round(size*tp1Ratio*1e8) / 1e8
+	amt2 := math.Round(math.Max(0.0, size-amt1)*1e8) / 1e8
+	if amt1 <= 0 {
+		amt1 = size
+		amt2 = 0
+	}
+	return amt1, amt2
 }
 
 // cleanupProtection 清理已平仓的保护信息
@@ -251,38 +283,9 @@ func (e *ExecutionEngine) cleanupProtection(ctx context.Context, posMap map[stri
 			continue // 仍有持仓，不清理
 		}
 
-		// 持仓已平，撤销残留的reduceOnly订单
-		orders, err := e.exchange.GetOpenOrders(symbol)
-		if err == nil && orders != nil {
-			cancelled := 0
-			for _, o := range orders {
-				if !isReduceOnly(o) {
-					continue
-				}
-				if getOrderPositionSide(o) != positionSide {
-					continue
-				}
-
-				// 撤销订单
-				if err := e.exchange.CancelOrder(symbol, o.ID); err == nil {
-					cancelled++
-				}
-			}
-
-			if cancelled > 0 {
-				cancelledTotal += cancelled
-				e.saveAudit(ctx, map[string]interface{}{
-					"ts":            time.Now().Unix(),
-					"event":         "auto_cancel_reduceonly_after_flat",
-					"symbol":        symbol,
-					"position_side": positionSide,
-					"count":         cancelled,
-				})
-			}
-		}
-
-		// 删除保护信息
-		if err := e.redis.Del(ctx, key).Err(); err == nil {
+		cancelled, deleted := e.cleanupFlatPosition(ctx, symbol, positionSide)
+		cancelledTotal += cancelled
+		if deleted {
 			deletedProt++
 		}
 	}
@@ -295,6 +298,61 @@ func (e *ExecutionEngine) cleanupProtection(ctx context.Context, posMap map[stri
 	}
 }
 
+// cleanupFlatPosition 持仓已平：撤销残留的reduceOnly订单并删除保护信息
+// 该方向有未成交的入场单时不清理，其保护信息只由入场单生命周期管理在撤单后清除；
+// 入场单成交后才停止跟踪，因此随后重新确认持仓，避免读取持仓后入场单刚成交时误删
+func (e *ExecutionEngine) cleanupFlatPosition(ctx context.Context, symbol, positionSide string) (int, bool) {
+	if pending, err := e.hasPendingEntry(ctx, symbol, positionSide); err != nil || pending {
+		return 0, false
+	}
+	if size, err := e.positionSize(ctx, symbol, positionSide); err != nil || size > 0 {
+		return 0, false
+	}
+
+	cancelled := 0
+
+	orders, err := e.exchange.GetOpenOrdersContext(ctx, symbol)
+	if err == nil && orders != nil {
+		for _, o := range orders {
+			if !isReduceOnly(o) {
+				continue
+			}
+			if getOrderPositionSide(o) != positionSide {
+				continue
+			}
+
+			// 撤销订单
+			if err := e.exchange.CancelOrderContext(ctx, symbol, o.ID); err == nil {
+				cancelled++
+			}
+		}
+
+		if cancelled > 0 {
+			e.saveAudit(ctx, map[string]interface{}{
+				"ts":            time.Now().Unix(),
+				"event":         "auto_cancel_reduceonly_after_flat",
+				"symbol":        symbol,
+				"position_side": positionSide,
+				"count":         cancelled,
+			})
+		}
+	}
+
+	// 持仓已平：对应信号的生命周期结束（入场单未成交时状态不允许转换，不受影响）
+	if signalID := e.protectionSignalID(ctx, symbol, positionSide); signalID != "" {
+		e.recordLifecycle(ctx, signalID, LifecycleTransition{
+			To:     StateClosed,
+			Source: LifecycleSourceGuard,
+			Reason: "position_flat",
+		})
+	}
+
+	// 删除保护信息
+	key := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", symbol, positionSide))
+	deleted := e.redis.Del(ctx, key).Err() == nil
+	return cancelled, deleted
+}
+
 // isReduceOnly 判断订单是否是reduceOnly
 func isReduceOnly(order *types.Order) bool {
 	// Binance API返回的订单中，reduceOnly字段在响应中
@@ -303,6 +361,27 @@ func isReduceOnly(order *types.Order) bool {
 		order.OrderType == "STOP" || order.OrderType == "STOP_MARKET"
 }
 
+// isClosingOrder 判断条件单是否用于平仓：reduceOnly/closePosition、系统挂的止损止盈单，
+// 或双向持仓下与持仓方向相反的单（平多SELL、平空BUY）；手动挂的突破入场单（如LONG方向的BUY止损单）不算
+func isClosingOrder(order *types.Order) bool {
+	if order.ReduceOnly || order.ClosePosition {
+		return true
+	}
+	for _, role := range []string{OrderRoleSL, OrderRoleTP1, OrderRoleTP2} {
+		if strings.HasPrefix(order.ClientOrderID, "nx"+role) {
+			return true
+		}
+	}
+	side := strings.ToUpper(order.Side)
+	switch getOrderPositionSide(order) {
+	case "LONG":
+		return side == "SELL"
+	case "SHORT":
+		return side == "BUY"
+	}
+	return false
+}
+
 // isStopLossOrder 判断是否是止损单
 func isStopLossOrder(order *types.Order) bool {
 	return order.OrderType == "STOP" || order.OrderType == "STOP_MARKET"
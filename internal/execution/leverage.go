@@ -0,0 +1,70 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+// EnsureLeverage 开仓前设置币种杠杆（不超过MaxLeverage）和配置的保证金模式，返回实际使用的杠杆
+// 已设置过的相同值不再发送；leverage<=0表示信号未指定，保持交易所当前杠杆
+func (e *ExecutionEngine) EnsureLeverage(ctx context.Context, symbol string, leverage int) (int, error) {
+	cfg := config.Get()
+	symbol = strings.ToUpper(symbol)
+
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+
+	if e.leverages == nil {
+		e.leverages = make(map[string]int)
+	}
+	if e.marginTypes == nil {
+		e.marginTypes = make(map[string]string)
+	}
+
+	// 保证金模式需要在开仓前设置（有持仓时交易所会拒绝修改）
+	if cfg.MarginType != "" && e.marginTypes[symbol] != cfg.MarginType {
+		if err := e.exchange.SetMarginTypeContext(ctx, symbol, cfg.MarginType); err != nil {
+			return 0, fmt.Errorf("set margin type %s failed: %w", cfg.MarginType, err)
+		}
+		e.marginTypes[symbol] = cfg.MarginType
+	}
+
+	if leverage <= 0 {
+		return 0, nil
+	}
+	if cfg.MaxLeverage > 0 && float64(leverage) > cfg.MaxLeverage {
+		leverage = int(cfg.MaxLeverage)
+	}
+	if e.leverages[symbol] == leverage {
+		return leverage, nil
+	}
+
+	if err := e.exchange.SetLeverageContext(ctx, symbol, leverage); err != nil {
+		return 0, fmt.Errorf("set leverage %dx failed: %w", leverage, err)
+	}
+	e.leverages[symbol] = leverage
+
+	utils.GetLogger("execution").Infow("杠杆已设置",
+		"symbol", symbol,
+		"leverage", leverage,
+	)
+	return leverage, nil
+}
+
+// CheckPositionMode 检查持仓模式（执行引擎按双向持仓下单，单向持仓时交易所会拒绝positionSide参数）
+func (e *ExecutionEngine) CheckPositionMode(ctx context.Context) {
+	logger := utils.GetLogger("execution")
+
+	hedge, err := e.exchange.GetPositionModeContext(ctx)
+	if err != nil {
+		logger.Warnw("查询持仓模式失败", "error", err)
+		return
+	}
+	if !hedge {
+		logger.Warnw("当前为单向持仓模式，执行引擎需要双向持仓（Hedge Mode），请在交易所切换后重启")
+	}
+}
@@ -0,0 +1,399 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+// OrderState 订单生命周期状态
+type OrderState string
+
+const (
+	StateQueued          OrderState = "queued"           // 信号已进入交易队列
+	StateSubmitted       OrderState = "submitted"        // 入场单已提交
+	StatePartiallyFilled OrderState = "partially_filled" // 入场单部分成交
+	StateFilled          OrderState = "filled"           // 入场单全部成交
+	StateProtected       OrderState = "protected"        // 止损止盈已挂出
+	StateClosing         OrderState = "closing"          // 平仓单已提交
+	StateClosed          OrderState = "closed"           // 持仓已平
+	StateCancelled       OrderState = "cancelled"        // 入场单未成交即撤销或过期
+	StateRejected        OrderState = "rejected"         // 风控、杠杆或交易所拒绝
+)
+
+// 状态转换来源
+const (
+	LifecycleSourceQueue  = "queue"  // 交易机器人推送信号
+	LifecycleSourceEngine = "engine" // 执行引擎下单/平仓
+	LifecycleSourceStream = "stream" // 用户数据流事件
+	LifecycleSourcePoll   = "poll"   // 轮询订单状态
+	LifecycleSourceGuard  = "guard"  // 止损止盈守护
+	LifecycleSourceExpiry = "expiry" // 入场单生命周期管理
+)
+
+// lifecycleTransitions 允许的状态转换（空状态表示尚无记录）
+var lifecycleTransitions = map[OrderState][]OrderState{
+	"":                   {StateQueued, StateSubmitted, StateRejected},
+	StateQueued:          {StateSubmitted, StateRejected, StateCancelled},
+	StateSubmitted:       {StatePartiallyFilled, StateFilled, StateCancelled, StateRejected},
+	StatePartiallyFilled: {StatePartiallyFilled, StateFilled, StateProtected, StateClosing, StateClosed, StateCancelled},
+	StateFilled:          {StateProtected, StateClosing, StateClosed},
+	StateProtected:       {StateClosing, StateClosed},
+	StateClosing:         {StateClosed, StateProtected},
+}
+
+// CanTransition 判断状态转换是否合法（closed、cancelled、rejected为终态）
+func CanTransition(from, to OrderState) bool {
+	for _, next := range lifecycleTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal 是否为终态
+func (s OrderState) IsTerminal() bool {
+	return s == StateClosed || s == StateCancelled || s == StateRejected
+}
+
+// allowedFrom 可以转换到to的全部状态，编码为",a,b,"供Lua脚本匹配
+func allowedFrom(to OrderState) string {
+	var b strings.Builder
+	b.WriteString(",")
+	for from, nexts := range lifecycleTransitions {
+		for _, next := range nexts {
+			if next == to {
+				b.WriteString(string(from))
+				b.WriteString(",")
+				break
+			}
+		}
+	}
+	return b.String()
+}
+
+// LifecycleTransition 一次状态转换（零值字段不覆盖已有记录）
+type LifecycleTransition struct {
+	To           OrderState
+	Source       string
+	Symbol       string
+	PositionSide string
+	OrderID      string
+	Quantity     float64
+	FilledQty    float64
+	AvgPrice     float64
+	Reason       string
+}
+
+// LifecycleEvent 时间线中的一次状态转换
+type LifecycleEvent struct {
+	TS        int64      `json:"ts"`
+	From      OrderState `json:"from"`
+	To        OrderState `json:"to"`
+	Source    string     `json:"source"`
+	OrderID   string     `json:"order_id,omitempty"`
+	FilledQty float64    `json:"filled_qty,omitempty"`
+	AvgPrice  float64    `json:"avg_price,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// OrderLifecycle 信号的订单生命周期
+type OrderLifecycle struct {
+	SignalID     string           `json:"signal_id"`
+	Symbol       string           `json:"symbol"`
+	PositionSide string           `json:"position_side"`
+	State        OrderState       `json:"state"`
+	OrderID      string           `json:"order_id,omitempty"`
+	Quantity     float64          `json:"quantity"`
+	FilledQty    float64          `json:"filled_qty"`
+	AvgPrice     float64          `json:"avg_price"`
+	CreatedAt    int64            `json:"created_at"`
+	UpdatedAt    int64            `json:"updated_at"`
+	Timeline     []LifecycleEvent `json:"timeline"`
+}
+
+// LifecycleStore 订单生命周期存储（Redis）
+// order_lifecycle:{signalID} 保存当前状态（Hash），order_lifecycle:{signalID}:timeline 保存转换记录（List），
+// order_lifecycle_order:{orderID} 为入场单到信号的索引，order_lifecycles 为按创建时间排序的最近信号
+type LifecycleStore struct {
+	redis utils.RedisClient
+}
+
+// NewLifecycleStore 创建订单生命周期存储
+func NewLifecycleStore(redis utils.RedisClient) *LifecycleStore {
+	return &LifecycleStore{redis: redis}
+}
+
+// transitionScript 校验当前状态后原子地更新状态、字段和时间线，返回{是否转换, 转换前状态}
+// KEYS: 状态Hash、时间线List；ARGV: 目标状态、允许的前置状态、事件JSON（from留空由脚本填入）、TTL秒、当前时间、字段/值对
+var transitionScript = redis.NewScript(`
+local cur = redis.call("HGET", KEYS[1], "state") or ""
+if not string.find(ARGV[2], "," .. cur .. ",", 1, true) then
+	return {0, cur}
+end
+for i = 6, #ARGV, 2 do
+	redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call("HSETNX", KEYS[1], "created_at", ARGV[5])
+redis.call("HSET", KEYS[1], "state", ARGV[1], "updated_at", ARGV[5])
+redis.call("RPUSH", KEYS[2], (string.gsub(ARGV[3], '"from":""', '"from":"' .. cur .. '"', 1)))
+redis.call("EXPIRE", KEYS[1], ARGV[4])
+redis.call("EXPIRE", KEYS[2], ARGV[4])
+return {1, cur}
+`)
+
+// lifecycleKey 信号的生命周期状态
+func lifecycleKey(signalID string) string {
+	return config.GetRedisKey(fmt.Sprintf("order_lifecycle:%s", signalID))
+}
+
+// lifecycleTimelineKey 信号的状态转换记录
+func lifecycleTimelineKey(signalID string) string {
+	return config.GetRedisKey(fmt.Sprintf("order_lifecycle:%s:timeline", signalID))
+}
+
+// lifecycleOrderKey 入场单到信号ID的索引
+func lifecycleOrderKey(orderID string) string {
+	return config.GetRedisKey(fmt.Sprintf("order_lifecycle_order:%s", orderID))
+}
+
+// lifecycleTTL 生命周期记录保留时间
+func lifecycleTTL() time.Duration {
+	if ttl := config.Get().OrderLifecycleTTLSec; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return 7 * 24 * time.Hour
+}
+
+// Transition 执行状态转换，返回是否转换及转换前的状态
+// 当前状态不允许转换到目标状态时不做修改（重复事件、乱序事件和终态后的事件都会被忽略）
+func (s *LifecycleStore) Transition(ctx context.Context, signalID string, t LifecycleTransition) (bool, OrderState, error) {
+	if s == nil || s.redis == nil || signalID == "" || t.To == "" {
+		return false, "", nil
+	}
+
+	now := time.Now()
+	event, err := json.Marshal(LifecycleEvent{
+		TS:        now.Unix(),
+		To:        t.To,
+		Source:    t.Source,
+		OrderID:   t.OrderID,
+		FilledQty: t.FilledQty,
+		AvgPrice:  t.AvgPrice,
+		Reason:    t.Reason,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	ttl := lifecycleTTL()
+	args := []interface{}{string(t.To), allowedFrom(t.To), string(event), int64(ttl.Seconds()), now.Unix(), "signal_id", signalID}
+	if t.Symbol != "" {
+		args = append(args, "symbol", t.Symbol)
+	}
+	if t.PositionSide != "" {
+		args = append(args, "position_side", strings.ToUpper(t.PositionSide))
+	}
+	if t.OrderID != "" {
+		args = append(args, "order_id", t.OrderID)
+	}
+	if t.Quantity > 0 {
+		args = append(args, "quantity", formatLifecycleFloat(t.Quantity))
+	}
+	if t.FilledQty > 0 {
+		args = append(args, "filled_qty", formatLifecycleFloat(t.FilledQty))
+	}
+	if t.AvgPrice > 0 {
+		args = append(args, "avg_price", formatLifecycleFloat(t.AvgPrice))
+	}
+
+	res, err := transitionScript.Run(ctx, s.redis, []string{lifecycleKey(signalID), lifecycleTimelineKey(signalID)}, args...).Slice()
+	if err != nil {
+		return false, "", err
+	}
+	if len(res) != 2 {
+		return false, "", fmt.Errorf("unexpected transition result: %v", res)
+	}
+	applied, _ := res[0].(int64)
+	from, _ := res[1].(string)
+	if applied != 1 {
+		return false, OrderState(from), nil
+	}
+
+	// 新记录写入最近信号索引，入场单写入订单索引
+	if from == "" {
+		s.redis.ZAdd(ctx, config.GetRedisKey("order_lifecycles"), redis.Z{Score: float64(now.Unix()), Member: signalID})
+		maxLen := config.Get().OrderLifecycleMaxLen
+		if maxLen <= 0 {
+			maxLen = 500
+		}
+		s.redis.ZRemRangeByRank(ctx, config.GetRedisKey("order_lifecycles"), 0, int64(-maxLen-1))
+	}
+	if t.To == StateSubmitted && t.OrderID != "" {
+		s.redis.Set(ctx, lifecycleOrderKey(t.OrderID), signalID, ttl)
+	}
+	return true, OrderState(from), nil
+}
+
+// SignalForOrder 按入场单ID查找信号ID（不存在时返回空）
+func (s *LifecycleStore) SignalForOrder(ctx context.Context, orderID string) string {
+	if s == nil || s.redis == nil || orderID == "" {
+		return ""
+	}
+	signalID, err := s.redis.Get(ctx, lifecycleOrderKey(orderID)).Result()
+	if err != nil {
+		return ""
+	}
+	return signalID
+}
+
+// Get 读取信号的生命周期和完整时间线（不存在时返回nil）
+func (s *LifecycleStore) Get(ctx context.Context, signalID string) (*OrderLifecycle, error) {
+	fields, err := s.redis.HGetAll(ctx, lifecycleKey(signalID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	lc := &OrderLifecycle{
+		SignalID:     signalID,
+		Symbol:       fields["symbol"],
+		PositionSide: fields["position_side"],
+		State:        OrderState(fields["state"]),
+		OrderID:      fields["order_id"],
+		Timeline:     []LifecycleEvent{},
+	}
+	lc.Quantity, _ = strconv.ParseFloat(fields["quantity"], 64)
+	lc.FilledQty, _ = strconv.ParseFloat(fields["filled_qty"], 64)
+	lc.AvgPrice, _ = strconv.ParseFloat(fields["avg_price"], 64)
+	lc.CreatedAt, _ = strconv.ParseInt(fields["created_at"], 10, 64)
+	lc.UpdatedAt, _ = strconv.ParseInt(fields["updated_at"], 10, 64)
+
+	items, err := s.redis.LRange(ctx, lifecycleTimelineKey(signalID), 0, -1).Result()
+	if err != nil {
+		return lc, nil
+	}
+	for _, item := range items {
+		var event LifecycleEvent
+		if err := json.Unmarshal([]byte(item), &event); err == nil {
+			lc.Timeline = append(lc.Timeline, event)
+		}
+	}
+	return lc, nil
+}
+
+// Recent 最近创建的生命周期（不含时间线，按创建时间倒序）
+func (s *LifecycleStore) Recent(ctx context.Context, limit int) ([]*OrderLifecycle, error) {
+	ids, err := s.redis.ZRevRange(ctx, config.GetRedisKey("order_lifecycles"), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*OrderLifecycle, 0, len(ids))
+	for _, id := range ids {
+		lc, err := s.Get(ctx, id)
+		if err != nil || lc == nil {
+			continue
+		}
+		lc.Timeline = nil
+		result = append(result, lc)
+	}
+	return result, nil
+}
+
+// formatLifecycleFloat 数值字段的字符串表示
+func formatLifecycleFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Lifecycle 订单生命周期存储
+func (e *ExecutionEngine) Lifecycle() *LifecycleStore {
+	return NewLifecycleStore(e.redis)
+}
+
+// recordLifecycle 记录状态转换（失败只记录日志，不影响交易流程）
+func (e *ExecutionEngine) recordLifecycle(ctx context.Context, signalID string, t LifecycleTransition) bool {
+	applied, _, err := e.Lifecycle().Transition(ctx, signalID, t)
+	if err != nil {
+		utils.GetLogger("execution").Debugw("记录订单生命周期失败",
+			"signal_id", signalID,
+			"to", t.To,
+			"error", err,
+		)
+	}
+	return applied
+}
+
+// markProtected 持仓已有止损止盈：入场单尚未确认成交时先记为filled
+func (e *ExecutionEngine) markProtected(ctx context.Context, signalID string, size float64, reason string) {
+	applied, from, err := e.Lifecycle().Transition(ctx, signalID, LifecycleTransition{
+		To:        StateProtected,
+		Source:    LifecycleSourceGuard,
+		FilledQty: size,
+		Reason:    reason,
+	})
+	if err != nil || applied || from != StateSubmitted {
+		return
+	}
+	e.recordLifecycle(ctx, signalID, LifecycleTransition{To: StateFilled, Source: LifecycleSourceGuard, FilledQty: size, Reason: reason})
+	e.recordLifecycle(ctx, signalID, LifecycleTransition{To: StateProtected, Source: LifecycleSourceGuard, FilledQty: size, Reason: reason})
+}
+
+// recordOrderEvent 按入场单的订单事件推进生命周期（非本系统入场单的事件忽略）
+func (e *ExecutionEngine) recordOrderEvent(ctx context.Context, event *exchange.OrderUpdateEvent) {
+	var state OrderState
+	switch event.Status {
+	case "PARTIALLY_FILLED":
+		state = StatePartiallyFilled
+	case "FILLED":
+		state = StateFilled
+	case "CANCELED", "EXPIRED":
+		// 部分成交后撤销：持仓已存在，由入场单生命周期管理按成交数量挂保护单
+		if event.FilledQty > 0 {
+			return
+		}
+		state = StateCancelled
+	case "REJECTED":
+		state = StateRejected
+	default:
+		return
+	}
+
+	signalID := e.Lifecycle().SignalForOrder(ctx, event.OrderID)
+	if signalID == "" {
+		return
+	}
+	e.recordLifecycle(ctx, signalID, LifecycleTransition{
+		To:        state,
+		Source:    LifecycleSourceStream,
+		OrderID:   event.OrderID,
+		FilledQty: event.FilledQty,
+		AvgPrice:  event.AvgPrice,
+		Reason:    strings.ToLower(event.ExecutionType),
+	})
+}
+
+// protectionSignalID 持仓保护信息对应的信号ID（没有保护信息时为空）
+func (e *ExecutionEngine) protectionSignalID(ctx context.Context, symbol, positionSide string) string {
+	key := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", symbol, strings.ToUpper(positionSide)))
+	protectionJSON, err := e.redis.Get(ctx, key).Result()
+	if err != nil {
+		return ""
+	}
+	var protection map[string]interface{}
+	if err := json.Unmarshal([]byte(protectionJSON), &protection); err != nil {
+		return ""
+	}
+	return utils.GetString(protection, "signal_id", "")
+}
@@ -0,0 +1,107 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// placeOrderWithRetry 下单，遇到可重试错误（超时、5xx、限流）时重试
+// 只有带客户端订单ID的订单才重试：交易所按ID去重，已受理的订单会被找回而不是重复提交
+func (e *ExecutionEngine) placeOrderWithRetry(ctx context.Context, req types.OrderRequest) (*types.Order, error) {
+	order, err := e.exchange.PlaceOrderContext(ctx, req)
+	if err == nil || req.ClientOrderID == "" {
+		return order, err
+	}
+
+	logger := utils.GetLogger("execution")
+	maxRetries := config.Get().OrderMaxRetries
+	for attempt := 1; attempt <= maxRetries && exchange.IsRetryable(err); attempt++ {
+		delay := time.Duration(attempt) * 500 * time.Millisecond
+		logger.Warnw("下单遇到可重试错误，稍后重试",
+			"symbol", req.Symbol,
+			"client_order_id", req.ClientOrderID,
+			"attempt", attempt,
+			"delay_ms", delay.Milliseconds(),
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+
+		order, err = e.exchange.PlaceOrderContext(ctx, req)
+		if err == nil {
+			return order, nil
+		}
+	}
+	return nil, err
+}
+
+// orderFailureEvent 按错误分类确定审计事件
+func orderFailureEvent(err error, failedEvent string) (string, exchange.ErrorClass) {
+	class := exchange.ClassifyError(err)
+	switch {
+	case errors.Is(err, exchange.ErrOrderFilter):
+		// 不满足交易对规则（精度/最小数量/最小名义价值）时订单未发送
+		return "filter_rejected", class
+	case class == exchange.ErrorRejected:
+		// 交易所业务拒绝（保证金不足、价格超限等），信号作废，无需重试
+		return "order_rejected", class
+	case class == exchange.ErrorCanceled:
+		// 下单过程中上下文被取消（如程序退出），订单可能未发送，不告警
+		return "order_canceled", class
+	}
+	return failedEvent, class
+}
+
+var (
+	alerter     *utils.Alerter
+	alerterOnce sync.Once
+)
+
+// getAlerter 获取告警推送（未启用告警时返回nil）
+func getAlerter() *utils.Alerter {
+	alerterOnce.Do(func() {
+		cfg := config.Get()
+		if !cfg.AlertEnabled || cfg.AlertWebhookURL == "" {
+			return
+		}
+		alerter = utils.NewAlerter(
+			cfg.AlertWebhookURL,
+			time.Duration(cfg.AlertDedupeTTLSec)*time.Second,
+			time.Duration(cfg.AlertMinIntervalSec)*time.Second,
+		)
+	})
+	return alerter
+}
+
+// alertOrderFailure 致命错误（密钥失效、权限不足等）需要人工处理，异步推送告警
+func (e *ExecutionEngine) alertOrderFailure(symbol, event string, err error) {
+	al := getAlerter()
+	if al == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := utils.WithShortTimeout(context.Background())
+		defer cancel()
+		message := fmt.Sprintf("%s %s: %v", symbol, event, err)
+		if _, sendErr := al.Send(ctx, event+":"+symbol, message, map[string]interface{}{
+			"symbol": symbol,
+			"event":  event,
+			"error":  err.Error(),
+		}); sendErr != nil {
+			utils.GetLogger("execution").Warnw("告警推送失败", "event", event, "error", sendErr)
+		}
+	}()
+}
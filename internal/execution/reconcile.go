@@ -0,0 +1,631 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/indicators"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 对账发现的问题类型
+const (
+	ReconcileMissingProtection = "missing_protection" // 系统开的仓（有生命周期或入场单记录）缺少保护信息
+	ReconcileUnknownPosition   = "unknown_position"   // 系统没有任何记录的持仓（如在交易所手动开仓）
+	ReconcileOrphanOrder       = "orphan_order"       // 对应方向已无持仓的止损止盈单
+	ReconcileQuantityMismatch  = "quantity_mismatch"  // 止损单数量与持仓不符，或止盈单总量超过持仓
+)
+
+// 对账修复动作
+const (
+	ReconcileActionAdopt  = "adopt"  // 按ATR补设保护信息并挂止损止盈
+	ReconcileActionCancel = "cancel" // 撤销订单（数量不符时随后按持仓数量重挂）
+	ReconcileActionAlert  = "alert"  // 只告警，人工处理
+)
+
+// 对账触发来源
+const (
+	ReconcileTriggerStartup  = "startup"
+	ReconcileTriggerSchedule = "schedule"
+	ReconcileTriggerAPI      = "api"
+)
+
+// errReconcileDeferred 持仓正在下单或已有保护信息，本次不接管，下次对账重新检查
+var errReconcileDeferred = errors.New("持仓正在处理中，下次对账重新检查")
+
+// reconcileQtyTolerance 数量比较的相对误差（交易所按步长量化后的差异）
+const reconcileQtyTolerance = 0.001
+
+// ReconcileState 对账输入：交易所持仓和挂单，以及Redis中的保护信息和系统记录
+type ReconcileState struct {
+	Positions   []*types.Position
+	OpenOrders  map[string][]*types.Order // 按交易对
+	Protections map[string]string         // "SYMBOL:SIDE" -> 保护信息的信号ID
+	Known       map[string]bool           // 系统有记录（未结束的生命周期或未成交入场单）的"SYMBOL:SIDE"
+}
+
+// ReconcileIssue 对账发现的一个问题及其处理结果
+type ReconcileIssue struct {
+	Type         string   `json:"type"`
+	Symbol       string   `json:"symbol"`
+	PositionSide string   `json:"position_side"`
+	PositionSize float64  `json:"position_size"`
+	OrderQty     float64  `json:"order_qty,omitempty"`
+	OrderIDs     []string `json:"order_ids,omitempty"`
+	SignalID     string   `json:"signal_id,omitempty"`
+	Detail       string   `json:"detail"`
+	Action       string   `json:"action"`  // 按策略确定的修复动作（dry-run时为计划动作）
+	Applied      bool     `json:"applied"` // 修复是否已执行成功
+	StopLoss     float64  `json:"stop_loss,omitempty"`
+	TakeProfit   float64  `json:"take_profit,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// ReconcileReport 一次对账的报告
+type ReconcileReport struct {
+	TS         int64            `json:"ts"`
+	Trigger    string           `json:"trigger"`
+	DryRun     bool             `json:"dry_run"`
+	Positions  int              `json:"positions"`
+	OpenOrders int              `json:"open_orders"`
+	Issues     []ReconcileIssue `json:"issues"`
+}
+
+// reconcileSideKey 交易对和持仓方向的组合键
+func reconcileSideKey(symbol, positionSide string) string {
+	return strings.ToUpper(symbol) + ":" + strings.ToUpper(positionSide)
+}
+
+// qtyMatches 数量在相对误差内相等
+func qtyMatches(a, b float64) bool {
+	return math.Abs(a-b) <= math.Max(a, b)*reconcileQtyTolerance
+}
+
+// DetectReconcileIssues 对比交易所持仓、挂单与Redis保护信息，返回发现的问题（按交易对和方向排序，不含修复动作）
+// 只检查平仓方向的止损止盈单（STOP/TAKE_PROFIT类型），入场限价单由入场单生命周期管理，手动挂的突破入场单不参与；
+// 数量只在持仓有保护信息时检查，否则撤单后没有可用于重挂的价格
+func DetectReconcileIssues(state *ReconcileState) []ReconcileIssue {
+	issues := make([]ReconcileIssue, 0)
+	if state == nil {
+		return issues
+	}
+
+	sizes := make(map[string]float64)
+	for _, pos := range state.Positions {
+		if pos == nil || pos.Size <= 0 {
+			continue
+		}
+		sizes[reconcileSideKey(pos.Symbol, pos.Side)] += pos.Size
+	}
+
+	// 按方向归集止损止盈单
+	stops := make(map[string][]*types.Order)
+	takeProfits := make(map[string][]*types.Order)
+	for symbol, orders := range state.OpenOrders {
+		for _, o := range orders {
+			if o == nil || !isReduceOnly(o) || !isClosingOrder(o) {
+				continue
+			}
+			key := reconcileSideKey(symbol, getOrderPositionSide(o))
+			if isStopLossOrder(o) {
+				stops[key] = append(stops[key], o)
+			} else {
+				takeProfits[key] = append(takeProfits[key], o)
+			}
+		}
+	}
+
+	for key, size := range sizes {
+		symbol, side := splitReconcileSideKey(key)
+		signalID, protected := state.Protections[key]
+		if !protected {
+			issue := ReconcileIssue{
+				Type:         ReconcileUnknownPosition,
+				Symbol:       symbol,
+				PositionSide: side,
+				PositionSize: size,
+				Detail:       "系统没有该持仓的记录",
+			}
+			if state.Known[key] {
+				issue.Type = ReconcileMissingProtection
+				issue.Detail = "持仓缺少保护信息"
+			}
+			issue.OrderQty = sumOrderQty(stops[key])
+			if issue.OrderQty > 0 {
+				issue.Detail += fmt.Sprintf("（已有止损单%s）", formatLifecycleFloat(issue.OrderQty))
+			}
+			issues = append(issues, issue)
+			continue
+		}
+
+		if qty := sumOrderQty(stops[key]); qty > 0 && !qtyMatches(qty, size) {
+			issues = append(issues, ReconcileIssue{
+				Type:         ReconcileQuantityMismatch,
+				Symbol:       symbol,
+				PositionSide: side,
+				PositionSize: size,
+				OrderQty:     qty,
+				OrderIDs:     orderIDs(stops[key]),
+				SignalID:     signalID,
+				Detail:       fmt.Sprintf("止损单数量%s与持仓%s不符", formatLifecycleFloat(qty), formatLifecycleFloat(size)),
+			})
+		}
+		if qty := sumOrderQty(takeProfits[key]); qty > size && !qtyMatches(qty, size) {
+			issues = append(issues, ReconcileIssue{
+				Type:         ReconcileQuantityMismatch,
+				Symbol:       symbol,
+				PositionSide: side,
+				PositionSize: size,
+				OrderQty:     qty,
+				OrderIDs:     orderIDs(takeProfits[key]),
+				SignalID:     signalID,
+				Detail:       fmt.Sprintf("止盈单总量%s超过持仓%s", formatLifecycleFloat(qty), formatLifecycleFloat(size)),
+			})
+		}
+	}
+
+	// 无持仓方向上残留的止损止盈单
+	for _, group := range []map[string][]*types.Order{stops, takeProfits} {
+		for key, orders := range group {
+			if sizes[key] > 0 {
+				continue
+			}
+			symbol, side := splitReconcileSideKey(key)
+			issues = append(issues, ReconcileIssue{
+				Type:         ReconcileOrphanOrder,
+				Symbol:       symbol,
+				PositionSide: side,
+				OrderQty:     sumOrderQty(orders),
+				OrderIDs:     orderIDs(orders),
+				SignalID:     state.Protections[key],
+				Detail:       fmt.Sprintf("无持仓但有%d个止损止盈单", len(orders)),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Symbol != issues[j].Symbol {
+			return issues[i].Symbol < issues[j].Symbol
+		}
+		if issues[i].PositionSide != issues[j].PositionSide {
+			return issues[i].PositionSide < issues[j].PositionSide
+		}
+		return issues[i].Type < issues[j].Type
+	})
+	return issues
+}
+
+// splitReconcileSideKey 拆分"SYMBOL:SIDE"
+func splitReconcileSideKey(key string) (string, string) {
+	idx := strings.LastIndex(key, ":")
+	if idx < 0 {
+		return key, ""
+	}
+	return key[:idx], key[idx+1:]
+}
+
+// sumOrderQty 订单未成交数量合计
+func sumOrderQty(orders []*types.Order) float64 {
+	total := 0.0
+	for _, o := range orders {
+		total += o.Quantity - o.FilledQty
+	}
+	return math.Round(total*1e8) / 1e8
+}
+
+// orderIDs 订单ID列表
+func orderIDs(orders []*types.Order) []string {
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ReconcileAction 按配置的策略确定问题的修复动作（策略无效时只告警）
+func ReconcileAction(issueType string, cfg *config.Config) string {
+	var policy, repair string
+	switch issueType {
+	case ReconcileMissingProtection:
+		policy, repair = cfg.ReconcileUnprotectedPolicy, ReconcileActionAdopt
+	case ReconcileUnknownPosition:
+		policy, repair = cfg.ReconcileUnknownPolicy, ReconcileActionAdopt
+	case ReconcileOrphanOrder, ReconcileQuantityMismatch:
+		policy, repair = cfg.ReconcileOrphanPolicy, ReconcileActionCancel
+	}
+	if repair != "" && strings.ToLower(policy) == repair {
+		return repair
+	}
+	return ReconcileActionAlert
+}
+
+// ATRProtection 按ATR倍数计算接管持仓的止损和止盈价格（以当前价格为基准，无法计算时返回0）
+func ATRProtection(positionSide string, price, atr, stopMult, takeProfitMult float64) (float64, float64) {
+	if price <= 0 || atr <= 0 || stopMult <= 0 || takeProfitMult <= 0 {
+		return 0, 0
+	}
+	var stopLoss, takeProfit float64
+	switch strings.ToUpper(positionSide) {
+	case "LONG":
+		stopLoss, takeProfit = price-stopMult*atr, price+takeProfitMult*atr
+	case "SHORT":
+		stopLoss, takeProfit = price+stopMult*atr, price-takeProfitMult*atr
+	default:
+		return 0, 0
+	}
+	if stopLoss <= 0 || takeProfit <= 0 {
+		return 0, 0
+	}
+	return stopLoss, takeProfit
+}
+
+// reconcileReportKey 最近一次对账报告
+func reconcileReportKey() string {
+	return config.GetRedisKey("reconcile:last")
+}
+
+// LoadReconcileReport 读取最近一次对账报告（尚未对账时返回nil）
+func LoadReconcileReport(ctx context.Context, rdb utils.RedisClient) (*ReconcileReport, error) {
+	data, err := rdb.Get(ctx, reconcileReportKey()).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report ReconcileReport
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ReconcileOnce 交易所与Redis状态对账（单次执行）：检测缺少保护的持仓、未知持仓、孤立和数量不符的止损止盈单，
+// 按策略接管、撤单或告警；dryRun时只生成报告。启动和定时对账的报告保存到Redis供Web查看，API触发的报告只返回
+func (e *ExecutionEngine) ReconcileOnce(ctx context.Context, trigger string, dryRun bool) (*ReconcileReport, error) {
+	logger := utils.GetLogger("execution_reconcile")
+	cfg := config.Get()
+
+	// 多实例部署时只有一个实例执行
+	lockKey := "reconcile:lock"
+	lockToken, err := e.acquireLock(ctx, lockKey, 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	defer e.releaseLock(ctx, lockKey, lockToken)
+
+	state, err := e.loadReconcileState(ctx)
+	if err != nil {
+		logger.Warnw("对账读取状态失败", "trigger", trigger, "error", err)
+		return nil, err
+	}
+
+	report := &ReconcileReport{
+		TS:      time.Now().Unix(),
+		Trigger: trigger,
+		DryRun:  dryRun,
+		Issues:  DetectReconcileIssues(state),
+	}
+	for _, pos := range state.Positions {
+		if pos != nil && pos.Size > 0 {
+			report.Positions++
+		}
+	}
+	for _, orders := range state.OpenOrders {
+		report.OpenOrders += len(orders)
+	}
+
+	positions := make(map[string]*types.Position)
+	for _, pos := range state.Positions {
+		if pos != nil && pos.Size > 0 {
+			positions[reconcileSideKey(pos.Symbol, pos.Side)] = pos
+		}
+	}
+
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		issue.Action = ReconcileAction(issue.Type, cfg)
+		if dryRun {
+			continue
+		}
+
+		var repairErr error
+		switch issue.Action {
+		case ReconcileActionAdopt:
+			repairErr = e.adoptPosition(ctx, issue, positions[reconcileSideKey(issue.Symbol, issue.PositionSide)])
+		case ReconcileActionCancel:
+			repairErr = e.cancelReconcileOrders(ctx, issue)
+		}
+		issue.Applied = repairErr == nil && issue.Action != ReconcileActionAlert
+		if repairErr != nil {
+			issue.Error = repairErr.Error()
+		}
+		// 只告警或修复失败时需要人工处理
+		if issue.Action == ReconcileActionAlert || (repairErr != nil && !errors.Is(repairErr, errReconcileDeferred)) {
+			e.alertReconcileIssue(ctx, issue)
+		}
+
+		e.saveAudit(ctx, map[string]interface{}{
+			"ts":            time.Now().Unix(),
+			"event":         "reconcile_" + issue.Type,
+			"trigger":       trigger,
+			"symbol":        issue.Symbol,
+			"position_side": issue.PositionSide,
+			"position_size": issue.PositionSize,
+			"order_qty":     issue.OrderQty,
+			"order_ids":     issue.OrderIDs,
+			"signal_id":     issue.SignalID,
+			"action":        issue.Action,
+			"applied":       issue.Applied,
+			"detail":        issue.Detail,
+			"error":         issue.Error,
+		})
+	}
+
+	// API触发的只读对账不覆盖最近一次报告
+	if trigger != ReconcileTriggerAPI {
+		if data, err := json.Marshal(report); err == nil {
+			e.redis.Set(ctx, reconcileReportKey(), string(data), 0)
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		logger.Warnw("对账发现不一致",
+			"trigger", trigger,
+			"dry_run", dryRun,
+			"issues", len(report.Issues),
+			"positions", report.Positions,
+			"open_orders", report.OpenOrders,
+		)
+	} else {
+		logger.Debugw("对账完成", "trigger", trigger, "positions", report.Positions, "open_orders", report.OpenOrders)
+	}
+	return report, nil
+}
+
+// loadReconcileState 读取持仓、保护信息、系统记录和相关交易对的挂单
+// 挂单按交易对查询，覆盖有持仓、有保护信息、有未成交入场单或未结束生命周期的交易对
+func (e *ExecutionEngine) loadReconcileState(ctx context.Context) (*ReconcileState, error) {
+	logger := utils.GetLogger("execution_reconcile")
+	cfg := config.Get()
+
+	positions, err := e.exchange.GetPositionsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	protections, err := e.loadProtectionSignals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取保护信息失败: %w", err)
+	}
+
+	state := &ReconcileState{
+		Positions:   positions,
+		OpenOrders:  make(map[string][]*types.Order),
+		Protections: protections,
+		Known:       make(map[string]bool),
+	}
+
+	symbols := make(map[string]bool)
+	for _, pos := range positions {
+		if pos != nil && pos.Size > 0 {
+			symbols[strings.ToUpper(pos.Symbol)] = true
+		}
+	}
+	for key := range protections {
+		symbol, _ := splitReconcileSideKey(key)
+		symbols[symbol] = true
+	}
+
+	entries, err := e.PendingEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取未成交入场单失败: %w", err)
+	}
+	for symbol, list := range entries {
+		symbols[strings.ToUpper(symbol)] = true
+		for _, entry := range list {
+			state.Known[reconcileSideKey(entry.Symbol, entry.PositionSide)] = true
+		}
+	}
+
+	maxLen := cfg.OrderLifecycleMaxLen
+	if maxLen <= 0 {
+		maxLen = 500
+	}
+	lifecycles, err := e.Lifecycle().Recent(ctx, maxLen)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单生命周期失败: %w", err)
+	}
+	for _, lc := range lifecycles {
+		if lc.State.IsTerminal() || lc.Symbol == "" || lc.PositionSide == "" {
+			continue
+		}
+		symbols[strings.ToUpper(lc.Symbol)] = true
+		state.Known[reconcileSideKey(lc.Symbol, lc.PositionSide)] = true
+	}
+
+	// 个别交易对查询失败时跳过：缺少挂单只会少报孤立单和数量不符，不会误撤
+	for symbol := range symbols {
+		orders, err := e.exchange.GetOpenOrdersContext(ctx, symbol)
+		if err != nil {
+			logger.Warnw("对账获取挂单失败", "symbol", symbol, "error", err)
+			continue
+		}
+		state.OpenOrders[symbol] = orders
+	}
+	return state, nil
+}
+
+// loadProtectionSignals 读取全部保护信息（"SYMBOL:SIDE" -> 信号ID）
+func (e *ExecutionEngine) loadProtectionSignals(ctx context.Context) (map[string]string, error) {
+	pattern := config.GetRedisKey("protection:*")
+
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := e.redis.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		// 解析key: nofx:protection:{SYMBOL}:{LONG/SHORT}
+		parts := strings.Split(key, ":")
+		if len(parts) < 4 {
+			continue
+		}
+		symbol := strings.ToUpper(parts[len(parts)-2])
+		positionSide := strings.ToUpper(parts[len(parts)-1])
+		result[reconcileSideKey(symbol, positionSide)] = e.protectionSignalID(ctx, symbol, positionSide)
+	}
+	return result, nil
+}
+
+// adoptPosition 接管持仓：按ATR计算止损止盈写入保护信息，并立即补挂保护单
+// 与下单共用锁：下单成交后才写入保护信息，锁被占用或期间已写入时不接管，避免覆盖信号的止损止盈
+func (e *ExecutionEngine) adoptPosition(ctx context.Context, issue *ReconcileIssue, pos *types.Position) error {
+	cfg := config.Get()
+	if pos == nil {
+		return fmt.Errorf("持仓不存在")
+	}
+
+	lockKey := fmt.Sprintf("execution:lock:%s", issue.Symbol)
+	lockToken, err := e.acquireLock(ctx, lockKey, 30*time.Second)
+	if err != nil {
+		return errReconcileDeferred
+	}
+	defer e.releaseLock(ctx, lockKey, lockToken)
+	if e.protectionSignalID(ctx, issue.Symbol, issue.PositionSide) != "" {
+		return errReconcileDeferred
+	}
+
+	period := cfg.ReconcileATRPeriod
+	if period <= 0 {
+		period = 14
+	}
+	candles, err := e.exchange.GetOHLCVContext(ctx, issue.Symbol, cfg.ReconcileATRTimeframe, period*3)
+	if err != nil {
+		return fmt.Errorf("获取K线失败: %w", err)
+	}
+	highs := make([]float64, 0, len(candles))
+	lows := make([]float64, 0, len(candles))
+	closes := make([]float64, 0, len(candles))
+	for _, c := range candles {
+		highs = append(highs, c.High)
+		lows = append(lows, c.Low)
+		closes = append(closes, c.Close)
+	}
+	atr := indicators.CalculateATR(highs, lows, closes, period)
+
+	price := pos.MarkPrice
+	if price <= 0 && len(closes) > 0 {
+		price = closes[len(closes)-1]
+	}
+	stopLoss, takeProfit := ATRProtection(issue.PositionSide, price, atr, cfg.ReconcileATRStopMult, cfg.ReconcileATRTakeProfitMult)
+	if stopLoss <= 0 {
+		return fmt.Errorf("无法计算ATR止损（price=%v, atr=%v）", price, atr)
+	}
+
+	signalID := fmt.Sprintf("reconcile_%s_%s_%d", issue.Symbol, issue.PositionSide, time.Now().Unix())
+	e.SaveProtection(ctx, issue.Symbol, issue.PositionSide, stopLoss, takeProfit, 0, signalID)
+	e.ensureProtection(ctx, issue.Symbol, issue.PositionSide, issue.PositionSize, "reconcile")
+
+	issue.SignalID = signalID
+	issue.StopLoss = stopLoss
+	issue.TakeProfit = takeProfit
+	return nil
+}
+
+// cancelReconcileOrders 撤销孤立或数量不符的止损止盈单，数量不符时随后按持仓数量重挂；孤立单在撤单前确认持仓仍不存在
+func (e *ExecutionEngine) cancelReconcileOrders(ctx context.Context, issue *ReconcileIssue) error {
+	// 与守护进程共用锁，避免撤单与补挂交错
+	lockKey := fmt.Sprintf("guard:lock:%s:%s", issue.Symbol, issue.PositionSide)
+	lockToken, err := e.acquireLock(ctx, lockKey, 60*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// 持仓先于挂单读取，期间入场单成交时新挂的保护单会被误判为孤立单，撤单前重新确认该方向仍无持仓
+	if issue.Type == ReconcileOrphanOrder {
+		size, err := e.positionSize(ctx, issue.Symbol, issue.PositionSide)
+		if err != nil || size > 0 {
+			e.releaseLock(ctx, lockKey, lockToken)
+			if err != nil {
+				return fmt.Errorf("确认持仓失败: %w", err)
+			}
+			return errReconcileDeferred
+		}
+	}
+
+	var failed []string
+	for _, orderID := range issue.OrderIDs {
+		if err := e.exchange.CancelOrderContext(ctx, issue.Symbol, orderID); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", orderID, err))
+		}
+	}
+	e.releaseLock(ctx, lockKey, lockToken)
+
+	if issue.Type == ReconcileQuantityMismatch && issue.PositionSize > 0 {
+		e.ensureProtection(ctx, issue.Symbol, issue.PositionSide, issue.PositionSize, "reconcile")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("撤单失败: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// positionSize 交易所当前的持仓数量（无持仓时为0）
+func (e *ExecutionEngine) positionSize(ctx context.Context, symbol, positionSide string) (float64, error) {
+	positions, err := e.exchange.GetPositionsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, pos := range positions {
+		if pos != nil && strings.EqualFold(pos.Symbol, symbol) && strings.EqualFold(pos.Side, positionSide) && pos.Size > 0 {
+			return pos.Size, nil
+		}
+	}
+	return 0, nil
+}
+
+// alertReconcileIssue 推送需要人工处理的对账问题
+func (e *ExecutionEngine) alertReconcileIssue(ctx context.Context, issue *ReconcileIssue) {
+	al := getAlerter()
+	if al == nil {
+		return
+	}
+
+	message := fmt.Sprintf("%s %s %s: %s", issue.Symbol, issue.PositionSide, issue.Type, issue.Detail)
+	if issue.Error != "" {
+		message += fmt.Sprintf("（修复失败: %s）", issue.Error)
+	}
+	key := fmt.Sprintf("reconcile:%s:%s:%s", issue.Type, issue.Symbol, issue.PositionSide)
+	if _, err := al.Send(ctx, key, message, map[string]interface{}{
+		"symbol":        issue.Symbol,
+		"position_side": issue.PositionSide,
+		"type":          issue.Type,
+		"position_size": issue.PositionSize,
+		"order_qty":     issue.OrderQty,
+		"action":        issue.Action,
+		"error":         issue.Error,
+	}); err != nil {
+		utils.GetLogger("execution_reconcile").Warnw("告警推送失败", "type", issue.Type, "error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// 风控拒绝原因码（写入order_audit的reason_code字段，供程序解析）
+const (
+	RiskReasonInvalidNotional = "invalid_notional"
+	RiskReasonMaxPositions    = "max_concurrent_positions"
+	RiskReasonSymbolCooldown  = "symbol_cooldown"
+	RiskReasonDataUnavailable = "risk_data_unavailable"
+	RiskReasonFundingAdverse  = "funding_adverse"
+)
+
+// 风控调整码（信号被缩减而非拒绝时记录）
+const (
+	RiskAdjustNotionalScaled = "notional_scaled_to_max"
+	RiskAdjustLeverageScaled = "leverage_scaled_to_max"
+)
+
+// RiskLimits 风控限制参数
+type RiskLimits struct {
+	MaxNotionalPerTrade    float64
+	MaxLeverage            float64
+	MaxConcurrentPositions int
+
+	// 资金费率风控（任一为0时不检查）
+	FundingGuardThreshold float64
+	FundingGuardWindowMin int
+}
+
+// RiskInput 风控检查输入
+type RiskInput struct {
+	Symbol               string
+	Notional             float64 // 本次下单名义价值（USDT）
+	Leverage             int     // 信号请求的杠杆（0表示未指定）
+	OpenSymbols          int     // 当前有持仓的币种数
+	HasPosition          bool    // 该币种是否已有持仓（加仓不占用新的持仓名额）
+	CooldownRemainingSec int     // 该币种剩余冷却时间
+
+	PositionSide     string  // 开仓方向（LONG/SHORT）
+	FundingRate      float64 // 下次结算的预测资金费率
+	MinutesToFunding float64 // 距下次结算的分钟数（0表示未知）
+}
+
+// RiskDecision 风控检查结果
+type RiskDecision struct {
+	Allowed     bool     `json:"allowed"`
+	ReasonCode  string   `json:"reason_code,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	Notional    float64  `json:"notional"`
+	Leverage    int      `json:"leverage"`
+	Adjustments []string `json:"adjustments,omitempty"`
+}
+
+// RiskLimitsFromConfig 从配置构建风控限制
+func RiskLimitsFromConfig(cfg *config.Config) RiskLimits {
+	limits := RiskLimits{
+		MaxNotionalPerTrade:    cfg.MaxNotionalPerTrade,
+		MaxLeverage:            cfg.MaxLeverage,
+		MaxConcurrentPositions: cfg.MaxConcurrentPositions,
+	}
+	if cfg.FundingGuardEnabled {
+		limits.FundingGuardThreshold = cfg.FundingGuardThreshold
+		limits.FundingGuardWindowMin = cfg.FundingGuardWindowMin
+	}
+	return limits
+}
+
+// EvaluateRisk 评估风控规则（纯函数，不访问交易所和Redis）
+// 冷却期、持仓数超限和临近不利的资金费率结算直接拒绝；名义价值和杠杆超限则缩减到上限
+func EvaluateRisk(in RiskInput, limits RiskLimits) RiskDecision {
+	decision := RiskDecision{
+		Allowed:  true,
+		Notional: in.Notional,
+		Leverage: in.Leverage,
+	}
+
+	if in.Notional <= 0 {
+		return rejectRisk(decision, RiskReasonInvalidNotional, "下单名义价值无效")
+	}
+
+	if in.CooldownRemainingSec > 0 {
+		return rejectRisk(decision, RiskReasonSymbolCooldown,
+			fmt.Sprintf("币种冷却中（剩余%d秒）", in.CooldownRemainingSec))
+	}
+
+	if limits.MaxConcurrentPositions > 0 && !in.HasPosition && in.OpenSymbols >= limits.MaxConcurrentPositions {
+		return rejectRisk(decision, RiskReasonMaxPositions,
+			fmt.Sprintf("持仓数已达上限（%d/%d）", in.OpenSymbols, limits.MaxConcurrentPositions))
+	}
+
+	if FundingGuardHit(in.PositionSide, in.FundingRate, in.MinutesToFunding, limits.FundingGuardThreshold, limits.FundingGuardWindowMin) {
+		return rejectRisk(decision, RiskReasonFundingAdverse,
+			fmt.Sprintf("资金费率%.4f%%将于%.0f分钟后结算，开仓方向需支付", in.FundingRate*100, in.MinutesToFunding))
+	}
+
+	if limits.MaxNotionalPerTrade > 0 && in.Notional > limits.MaxNotionalPerTrade {
+		decision.Notional = limits.MaxNotionalPerTrade
+		decision.Adjustments = append(decision.Adjustments, RiskAdjustNotionalScaled)
+	}
+
+	if limits.MaxLeverage > 0 && float64(in.Leverage) > limits.MaxLeverage {
+		decision.Leverage = int(limits.MaxLeverage)
+		decision.Adjustments = append(decision.Adjustments, RiskAdjustLeverageScaled)
+	}
+
+	return decision
+}
+
+// rejectRisk 构建拒绝结果
+func rejectRisk(decision RiskDecision, code, reason string) RiskDecision {
+	decision.Allowed = false
+	decision.ReasonCode = code
+	decision.Reason = reason
+	return decision
+}
+
+// checkRisk 下单前风控检查（收集持仓和冷却状态后调用EvaluateRisk）
+func (e *ExecutionEngine) checkRisk(ctx context.Context, signal *types.Signal, notional float64) RiskDecision {
+	cfg := config.Get()
+	symbol := signal.Symbol
+
+	positions, err := e.exchange.GetPositionsContext(ctx)
+	if err != nil {
+		// 无法确认持仓时拒绝下单（风控失败即关闭）
+		return rejectRisk(RiskDecision{Notional: notional, Leverage: signal.Leverage},
+			RiskReasonDataUnavailable, fmt.Sprintf("获取持仓失败: %v", err))
+	}
+
+	openSymbols := make(map[string]bool)
+	for _, pos := range positions {
+		if pos.Size > 0 {
+			openSymbols[strings.ToUpper(pos.Symbol)] = true
+		}
+	}
+
+	input := RiskInput{
+		Symbol:               symbol,
+		Notional:             notional,
+		Leverage:             signal.Leverage,
+		OpenSymbols:          len(openSymbols),
+		HasPosition:          openSymbols[strings.ToUpper(symbol)],
+		CooldownRemainingSec: e.getCooldownRemaining(ctx, symbol),
+		PositionSide:         strings.ToUpper(signal.Side),
+	}
+
+	// 资金费率获取失败时不拦截（附加保护，不影响其他风控）
+	if cfg.FundingGuardEnabled {
+		if premium, err := e.exchange.GetPremiumIndexContext(ctx, symbol); err == nil && premium != nil {
+			input.FundingRate = premium.PredictedFundingRate
+			input.MinutesToFunding = minutesToFunding(premium.NextFundingTime, time.Now())
+		}
+	}
+
+	return EvaluateRisk(input, RiskLimitsFromConfig(cfg))
+}
+
+// getCooldownRemaining 获取币种剩余冷却时间（秒）
+func (e *ExecutionEngine) getCooldownRemaining(ctx context.Context, symbol string) int {
+	key := config.GetRedisKey(fmt.Sprintf("cooldown:%s", strings.ToUpper(symbol)))
+	ttl, err := e.redis.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return 0
+	}
+	return int(ttl.Seconds())
+}
+
+// setCooldown 下单成功后设置币种冷却
+func (e *ExecutionEngine) setCooldown(ctx context.Context, symbol string) {
+	cfg := config.Get()
+	if cfg.SymbolCooldownSec <= 0 {
+		return
+	}
+	key := config.GetRedisKey(fmt.Sprintf("cooldown:%s", strings.ToUpper(symbol)))
+	ttl := time.Duration(cfg.SymbolCooldownSec) * time.Second
+	e.redis.Set(ctx, key, time.Now().Unix(), ttl)
+}
@@ -0,0 +1,134 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+)
+
+// StartUserStream 启动用户数据流（Binance实盘且配置了API密钥时），返回是否已启用
+func (e *ExecutionEngine) StartUserStream(ctx context.Context) bool {
+	cfg := config.Get()
+	if !cfg.UserStreamEnabled || cfg.DryRun || cfg.BinanceAPIKey == "" || !exchange.BinanceAccount() {
+		return false
+	}
+
+	stream := exchange.GetUserDataStream()
+	e.AttachUserStream(stream)
+	go stream.Run(ctx)
+	return true
+}
+
+// AttachUserStream 订阅用户数据流的订单和账户事件
+func (e *ExecutionEngine) AttachUserStream(stream *exchange.UserDataStream) {
+	e.stream = stream
+	stream.OnOrderUpdate(e.handleOrderUpdate)
+	stream.OnAccountUpdate(e.handleAccountUpdate)
+}
+
+// UserStreamConnected 用户数据流是否在线（在线时守护进程和订单确认不再依赖轮询）
+func (e *ExecutionEngine) UserStreamConnected() bool {
+	return e.stream != nil && e.stream.IsConnected()
+}
+
+// waitOrderUpdate 注册订单事件等待，返回事件通道和注销函数
+func (e *ExecutionEngine) waitOrderUpdate(orderID string) (<-chan *exchange.OrderUpdateEvent, func()) {
+	ch := make(chan *exchange.OrderUpdateEvent, 8)
+
+	e.waitersMu.Lock()
+	if e.waiters == nil {
+		e.waiters = make(map[string]chan *exchange.OrderUpdateEvent)
+	}
+	e.waiters[orderID] = ch
+	e.waitersMu.Unlock()
+
+	return ch, func() {
+		e.waitersMu.Lock()
+		delete(e.waiters, orderID)
+		e.waitersMu.Unlock()
+	}
+}
+
+// handleOrderUpdate 处理订单更新事件：唤醒等待确认的订单并记录成交
+func (e *ExecutionEngine) handleOrderUpdate(event *exchange.OrderUpdateEvent) {
+	e.waitersMu.Lock()
+	if ch, ok := e.waiters[event.OrderID]; ok {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	e.waitersMu.Unlock()
+
+	ctx, cancel := utils.WithRedisTimeout(context.Background())
+	defer cancel()
+
+	// 入场单事件推进订单生命周期
+	if !event.ReduceOnly {
+		e.recordOrderEvent(ctx, event)
+	}
+
+	if event.ExecutionType != "TRADE" {
+		return
+	}
+
+	e.saveAudit(ctx, map[string]interface{}{
+		"ts":              time.Now().Unix(),
+		"event":           "stream_order_trade",
+		"symbol":          event.Symbol,
+		"order_id":        event.OrderID,
+		"order_type":      event.OrderType,
+		"side":            event.Side,
+		"position_side":   event.PositionSide,
+		"status":          event.Status,
+		"last_filled_qty": event.LastFilledQty,
+		"last_price":      event.LastFilledPrice,
+		"filled_qty":      event.FilledQty,
+		"reduce_only":     event.ReduceOnly,
+		"realized_pnl":    event.RealizedProfit,
+	})
+}
+
+// handleAccountUpdate 处理账户更新事件：持仓变化时立即补挂保护单，平仓时清理残留订单
+func (e *ExecutionEngine) handleAccountUpdate(event *exchange.AccountUpdateEvent) {
+	for _, pos := range event.Positions {
+		// 单向持仓模式下的空仓无法判断方向，交给定期对账处理
+		if strings.ToUpper(pos.PositionSide) == "BOTH" && pos.Amount == 0 {
+			continue
+		}
+
+		// 在独立goroutine中处理，避免阻塞数据流读取
+		go e.onPositionChanged(pos.Symbol, pos.Side(), pos.Size())
+	}
+}
+
+// onPositionChanged 持仓变化处理
+func (e *ExecutionEngine) onPositionChanged(symbol, positionSide string, size float64) {
+	logger := utils.GetLogger("execution_guard")
+
+	ctx, cancel := utils.WithMediumTimeout(context.Background())
+	defer cancel()
+
+	if size > 0 {
+		e.ensureProtection(ctx, symbol, positionSide, size, "stream")
+		return
+	}
+
+	// 仅在存在保护信息时清理，避免无关持仓触发额外请求
+	key := config.GetRedisKey(fmt.Sprintf("protection:%s:%s", symbol, positionSide))
+	if n, err := e.redis.Exists(ctx, key).Result(); err != nil || n == 0 {
+		return
+	}
+
+	cancelled, _ := e.cleanupFlatPosition(ctx, symbol, positionSide)
+	logger.Debugw("持仓已平，清理保护信息",
+		"symbol", symbol,
+		"position_side", positionSide,
+		"cancelled_orders", cancelled,
+	)
+}
@@ -116,6 +116,32 @@ func IsBollingerSqueeze(upper, middle, lower float64, bandwidthThreshold float64
 	return bandwidth < bandwidthThreshold
 }
 
+// CalculateATR 计算平均真实波幅（Wilder平滑）
+func CalculateATR(highs, lows, closes []float64, period int) float64 {
+	n := len(closes)
+	if period <= 0 || len(highs) != n || len(lows) != n || n < period+1 {
+		return 0
+	}
+
+	// 真实波幅：当根高低差与前收盘价跳空的最大值
+	trs := make([]float64, 0, n-1)
+	for i := 1; i < n; i++ {
+		tr := math.Max(highs[i]-lows[i], math.Max(math.Abs(highs[i]-closes[i-1]), math.Abs(lows[i]-closes[i-1])))
+		trs = append(trs, tr)
+	}
+
+	atr := 0.0
+	for i := 0; i < period; i++ {
+		atr += trs[i]
+	}
+	atr /= float64(period)
+	for i := period; i < len(trs); i++ {
+		atr = (atr*float64(period-1) + trs[i]) / float64(period)
+	}
+
+	return atr
+}
+
 // CalculateCVD 计算累计成交量差
 func CalculateCVD(ohlcv []struct {
 	Open   float64
@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// CandleStore 内存K线存储（按交易对和周期滚动保存）
+type CandleStore struct {
+	mu       sync.RWMutex
+	capacity map[string]int           // 各周期保留的K线数量
+	series   map[string][]types.OHLCV // key: SYMBOL:timeframe
+}
+
+// NewCandleStore 创建K线存储，limits 与 timeframes 一一对应
+func NewCandleStore(timeframes []string, limits []int) *CandleStore {
+	capacity := make(map[string]int, len(timeframes))
+	for i, tf := range timeframes {
+		if i < len(limits) {
+			capacity[tf] = limits[i]
+		}
+	}
+	return &CandleStore{
+		capacity: capacity,
+		series:   make(map[string][]types.OHLCV),
+	}
+}
+
+// seriesKey 存储键
+func seriesKey(symbol, timeframe string) string {
+	return fmt.Sprintf("%s:%s", symbol, timeframe)
+}
+
+// Seed 用REST获取的K线初始化（或覆盖）一个序列
+func (cs *CandleStore) Seed(symbol, timeframe string, candles []types.OHLCV) {
+	series := make([]types.OHLCV, len(candles))
+	copy(series, candles)
+	if limit := cs.capacity[timeframe]; limit > 0 && len(series) > limit {
+		series = series[len(series)-limit:]
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.series[seriesKey(symbol, timeframe)] = series
+}
+
+// Update 合并一根推送的K线
+// 返回false表示序列未初始化或出现缺口，需要通过REST回补
+func (cs *CandleStore) Update(symbol, timeframe string, candle types.OHLCV) bool {
+	duration, err := TimeframeDuration(timeframe)
+	if err != nil {
+		return true
+	}
+	step := duration.Milliseconds()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	key := seriesKey(symbol, timeframe)
+	series := cs.series[key]
+	if len(series) == 0 {
+		return false
+	}
+
+	last := series[len(series)-1]
+	switch {
+	case candle.Time < last.Time:
+		// 过期推送，忽略
+	case candle.Time == last.Time:
+		series[len(series)-1] = candle
+	case candle.Time == last.Time+step:
+		series = append(series, candle)
+		if limit := cs.capacity[timeframe]; limit > 0 && len(series) > limit {
+			series = series[len(series)-limit:]
+		}
+		cs.series[key] = series
+	default:
+		return false
+	}
+	return true
+}
+
+// Get 获取最近limit根K线（副本），序列未初始化时返回false
+func (cs *CandleStore) Get(symbol, timeframe string, limit int) ([]types.OHLCV, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	series, ok := cs.series[seriesKey(symbol, timeframe)]
+	if !ok || len(series) == 0 {
+		return nil, false
+	}
+	if limit > 0 && len(series) > limit {
+		series = series[len(series)-limit:]
+	}
+
+	result := make([]types.OHLCV, len(series))
+	copy(result, series)
+	return result, true
+}
+
+// Remove 删除交易对的所有周期
+func (cs *CandleStore) Remove(symbol string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for tf := range cs.capacity {
+		delete(cs.series, seriesKey(symbol, tf))
+	}
+}
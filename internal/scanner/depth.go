@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// BuildDepthMetrics 根据深度快照计算盘口微观结构指标
+// levels: 买卖量失衡统计的前N档；bandPct: 深度统计带宽（0.005即中间价±0.5%）；notional: 估算滑点的名义价值（USDT）
+// 任一侧为空时返回nil
+func BuildDepthMetrics(book *types.OrderBook, levels int, bandPct, notional float64) *types.DepthMetrics {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil
+	}
+
+	bestBid := book.Bids[0].Price
+	bestAsk := book.Asks[0].Price
+	mid := (bestBid + bestAsk) / 2
+	if mid <= 0 {
+		return nil
+	}
+
+	m := &types.DepthMetrics{
+		BestBid:      bestBid,
+		BestAsk:      bestAsk,
+		SpreadBps:    (bestAsk - bestBid) / mid * 10000,
+		Imbalance:    depthImbalance(book, levels),
+		BidDepthUSDT: bandNotional(book.Bids, func(p float64) bool { return p >= mid*(1-bandPct) }),
+		AskDepthUSDT: bandNotional(book.Asks, func(p float64) bool { return p <= mid*(1+bandPct) }),
+		NotionalUSDT: notional,
+	}
+
+	if notional > 0 {
+		buyAvg, buyFilled := sweepAvgPrice(book.Asks, notional)
+		sellAvg, sellFilled := sweepAvgPrice(book.Bids, notional)
+		m.BuySlippageBps = (buyAvg - mid) / mid * 10000
+		m.SellSlippageBps = (mid - sellAvg) / mid * 10000
+		m.Thin = !buyFilled || !sellFilled
+	}
+	return m
+}
+
+// depthImbalance 前N档买卖量失衡 (bid-ask)/(bid+ask)
+func depthImbalance(book *types.OrderBook, levels int) float64 {
+	if levels <= 0 {
+		levels = 10
+	}
+	var bidQty, askQty float64
+	for i := 0; i < levels && i < len(book.Bids); i++ {
+		bidQty += book.Bids[i].Quantity
+	}
+	for i := 0; i < levels && i < len(book.Asks); i++ {
+		askQty += book.Asks[i].Quantity
+	}
+	if bidQty+askQty == 0 {
+		return 0
+	}
+	return (bidQty - askQty) / (bidQty + askQty)
+}
+
+// bandNotional 统计价格在带宽内的档位名义价值（档位按距中间价由近到远排列）
+func bandNotional(side []types.OrderBookLevel, inBand func(price float64) bool) float64 {
+	total := 0.0
+	for _, lvl := range side {
+		if !inBand(lvl.Price) {
+			break
+		}
+		total += lvl.Price * lvl.Quantity
+	}
+	return total
+}
+
+// sweepAvgPrice 模拟市价单逐档吃单直到成交notional，返回成交均价及可见深度是否足够
+// 深度不足时按全部可见档位计算均价
+func sweepAvgPrice(side []types.OrderBookLevel, notional float64) (float64, bool) {
+	var filledNotional, filledQty float64
+	for _, lvl := range side {
+		levelNotional := lvl.Price * lvl.Quantity
+		if filledNotional+levelNotional >= notional {
+			remaining := notional - filledNotional
+			filledQty += remaining / lvl.Price
+			filledNotional = notional
+			return filledNotional / filledQty, true
+		}
+		filledNotional += levelNotional
+		filledQty += lvl.Quantity
+	}
+	if filledQty == 0 {
+		return side[0].Price, false
+	}
+	return filledNotional / filledQty, false
+}
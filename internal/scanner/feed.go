@@ -0,0 +1,239 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// MarketFeed WebSocket行情源：维护内存K线和标记价格，REST只用于回补和缺口修复
+type MarketFeed struct {
+	exchange types.Exchange
+	stream   *exchange.MarketStream
+	store    *CandleStore
+
+	trackedMu sync.RWMutex
+	tracked   map[string]bool
+
+	marksMu sync.RWMutex
+	marks   map[string]exchange.MarkPriceUpdate
+
+	repairMu  sync.Mutex
+	repairing map[string]bool
+
+	liquidations *LiquidationTracker // 非空时订阅全市场强平订单流并写入
+}
+
+var (
+	globalMarketFeed     *MarketFeed
+	globalMarketFeedErr  error
+	globalMarketFeedOnce sync.Once
+)
+
+// GetMarketFeed 获取行情源实例（单例）
+func GetMarketFeed() (*MarketFeed, error) {
+	globalMarketFeedOnce.Do(func() {
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			globalMarketFeedErr = err
+			return
+		}
+
+		cfg := config.Get()
+		globalMarketFeed = NewMarketFeed(
+			ex,
+			exchange.NewMarketStream(cfg.BinanceWSBaseURL, ScanTimeframes),
+		)
+		if cfg.LiquidationEnabled {
+			globalMarketFeed.TrackLiquidations(GetLiquidationTracker())
+		}
+	})
+	return globalMarketFeed, globalMarketFeedErr
+}
+
+// NewMarketFeed 创建行情源
+func NewMarketFeed(ex types.Exchange, stream *exchange.MarketStream) *MarketFeed {
+	return &MarketFeed{
+		exchange:  ex,
+		stream:    stream,
+		store:     NewCandleStore(ScanTimeframes, ScanLimits),
+		tracked:   make(map[string]bool),
+		marks:     make(map[string]exchange.MarkPriceUpdate),
+		repairing: make(map[string]bool),
+	}
+}
+
+// Start 订阅行情流事件并在后台运行（ctx取消时停止，进行中的回补随之中止）
+func (f *MarketFeed) Start(ctx context.Context) {
+	f.stream.OnKline(func(event *exchange.KlineEvent) {
+		f.handleKline(ctx, event)
+	})
+	f.stream.OnMarkPrice(f.handleMarkPrice)
+	if f.liquidations != nil {
+		f.stream.OnLiquidation(f.handleLiquidation)
+	}
+	go f.stream.Run(ctx)
+}
+
+// TrackLiquidations 订阅全市场强平订单流并写入tracker（需在Start之前调用）
+func (f *MarketFeed) TrackLiquidations(tracker *LiquidationTracker) {
+	f.liquidations = tracker
+}
+
+// Track 更新订阅的交易对，移除的交易对同时清理内存K线
+func (f *MarketFeed) Track(symbols []string) {
+	subscribed := f.stream.SetSymbols(symbols)
+
+	tracked := make(map[string]bool, len(subscribed))
+	for _, symbol := range subscribed {
+		tracked[symbol] = true
+	}
+
+	f.trackedMu.Lock()
+	for symbol := range f.tracked {
+		if !tracked[symbol] {
+			f.store.Remove(symbol)
+		}
+	}
+	f.tracked = tracked
+	f.trackedMu.Unlock()
+}
+
+// Streaming 交易对是否由行情流实时更新
+func (f *MarketFeed) Streaming(symbol string) bool {
+	if !f.stream.IsConnected() {
+		return false
+	}
+	f.trackedMu.RLock()
+	defer f.trackedMu.RUnlock()
+	return f.tracked[symbol]
+}
+
+// Candles 读取内存K线，仅在行情流在线且最新K线未过期时返回true
+func (f *MarketFeed) Candles(symbol, timeframe string, limit int) ([]types.OHLCV, bool) {
+	if !f.Streaming(symbol) {
+		return nil, false
+	}
+
+	candles, ok := f.store.Get(symbol, timeframe, limit)
+	if !ok {
+		return nil, false
+	}
+
+	// 最新K线落后超过一个周期视为缺口（如断线期间），交给REST回补
+	duration, err := TimeframeDuration(timeframe)
+	if err != nil {
+		return nil, false
+	}
+	last := time.UnixMilli(candles[len(candles)-1].Time)
+	if time.Since(last) > 2*duration {
+		return nil, false
+	}
+	return candles, true
+}
+
+// Backfill 通过REST获取K线并写入内存存储
+func (f *MarketFeed) Backfill(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	candles, err := f.exchange.GetOHLCVContext(ctx, symbol, timeframe, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	f.trackedMu.RLock()
+	tracked := f.tracked[symbol]
+	f.trackedMu.RUnlock()
+	if tracked && len(candles) > 0 {
+		f.store.Seed(symbol, timeframe, candles)
+	}
+	return candles, nil
+}
+
+// MarkPrice 获取最新标记价格和资金费率
+func (f *MarketFeed) MarkPrice(symbol string) (exchange.MarkPriceUpdate, bool) {
+	if !f.stream.IsConnected() {
+		return exchange.MarkPriceUpdate{}, false
+	}
+	f.marksMu.RLock()
+	defer f.marksMu.RUnlock()
+	mark, ok := f.marks[symbol]
+	return mark, ok
+}
+
+// handleKline 合并K线推送，未初始化或出现缺口时异步回补
+func (f *MarketFeed) handleKline(ctx context.Context, event *exchange.KlineEvent) {
+	f.trackedMu.RLock()
+	tracked := f.tracked[event.Symbol]
+	f.trackedMu.RUnlock()
+	if !tracked {
+		return
+	}
+
+	if !f.store.Update(event.Symbol, event.Interval, event.Candle) {
+		go f.repair(ctx, event.Symbol, event.Interval)
+	}
+}
+
+// handleMarkPrice 更新标记价格
+func (f *MarketFeed) handleMarkPrice(updates []exchange.MarkPriceUpdate) {
+	f.marksMu.Lock()
+	defer f.marksMu.Unlock()
+	for _, update := range updates {
+		f.marks[update.Symbol] = update
+	}
+}
+
+// handleLiquidation 异步写入强平订单，避免Redis延迟阻塞行情流读取
+func (f *MarketFeed) handleLiquidation(event *exchange.LiquidationEvent) {
+	go func() {
+		ctx, cancel := utils.WithShortTimeout(context.Background())
+		defer cancel()
+		if err := f.liquidations.Record(ctx, *event); err != nil {
+			utils.GetLogger("scanner").Debugw("写入强平订单失败",
+				"symbol", event.Symbol,
+				"error", err,
+			)
+		}
+	}()
+}
+
+// repair 通过REST回补一个序列（同一序列同时只回补一次）
+func (f *MarketFeed) repair(ctx context.Context, symbol, timeframe string) {
+	key := seriesKey(symbol, timeframe)
+
+	f.repairMu.Lock()
+	if f.repairing[key] {
+		f.repairMu.Unlock()
+		return
+	}
+	f.repairing[key] = true
+	f.repairMu.Unlock()
+
+	defer func() {
+		f.repairMu.Lock()
+		delete(f.repairing, key)
+		f.repairMu.Unlock()
+	}()
+
+	limit := 0
+	for i, tf := range ScanTimeframes {
+		if tf == timeframe && i < len(ScanLimits) {
+			limit = ScanLimits[i]
+		}
+	}
+	if limit == 0 {
+		return
+	}
+
+	if _, err := f.Backfill(ctx, symbol, timeframe, limit); err != nil {
+		utils.GetLogger("scanner").Debugw("K线回补失败",
+			"symbol", symbol,
+			"timeframe", timeframe,
+			"error", err,
+		)
+	}
+}
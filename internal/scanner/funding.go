@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// BuildFundingStats 根据标记价格数据和历史结算计算资金费率统计
+// premium为空时只统计历史结算，两者都为空时返回nil
+func BuildFundingStats(premium *types.PremiumIndex, history []types.FundingRateRecord, now time.Time) *types.FundingStats {
+	if premium == nil && len(history) == 0 {
+		return nil
+	}
+
+	stats := &types.FundingStats{}
+	if premium != nil {
+		stats.PredictedRate = premium.PredictedFundingRate
+		stats.NextFundingTime = premium.NextFundingTime
+		if premium.NextFundingTime > 0 {
+			minutes := float64(premium.NextFundingTime-now.UnixMilli()) / float64(time.Minute/time.Millisecond)
+			if minutes > 0 {
+				stats.MinutesToFunding = minutes
+			}
+		}
+		if premium.IndexPrice > 0 && premium.MarkPrice > 0 {
+			stats.BasisBps = (premium.MarkPrice - premium.IndexPrice) / premium.IndexPrice * 10000
+		}
+	}
+
+	if len(history) == 0 {
+		return stats
+	}
+
+	positive := 0
+	stats.MaxRate = history[0].FundingRate
+	stats.MinRate = history[0].FundingRate
+	for _, record := range history {
+		stats.SumRate += record.FundingRate
+		if record.FundingRate > stats.MaxRate {
+			stats.MaxRate = record.FundingRate
+		}
+		if record.FundingRate < stats.MinRate {
+			stats.MinRate = record.FundingRate
+		}
+		if record.FundingRate > 0 {
+			positive++
+		}
+	}
+	stats.Settlements = len(history)
+	stats.AvgRate = stats.SumRate / float64(len(history))
+	stats.PositiveRatio = float64(positive) / float64(len(history))
+	stats.Streak = fundingStreak(history)
+	return stats
+}
+
+// fundingStreak 从最近一次结算往前统计连续同号的次数（正费率为正数，负费率为负数，费率为0时中断）
+func fundingStreak(history []types.FundingRateRecord) int {
+	streak := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		rate := history[i].FundingRate
+		switch {
+		case rate > 0 && streak >= 0:
+			streak++
+		case rate < 0 && streak <= 0:
+			streak--
+		default:
+			return streak
+		}
+	}
+	return streak
+}
@@ -0,0 +1,215 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// LiquidationTracker 强平数据：按交易对写入Redis有序集合（score为成交时间），读取时按滚动窗口聚合
+// 数据只来自强平订单流（Binance已下线公开的强平订单REST接口），行情流断开期间没有新数据
+type LiquidationTracker struct {
+	redis   utils.RedisClient
+	windows []string
+	maxAge  time.Duration // 最长窗口，超出的数据被清理
+}
+
+// LiquidationSummary 交易对最近的强平统计
+type LiquidationSummary struct {
+	Symbol   string                    `json:"symbol"`
+	LastTime int64                     `json:"last_time"` // 最近一笔强平时间（毫秒）
+	Windows  []types.LiquidationWindow `json:"windows"`
+}
+
+var (
+	globalLiquidationTracker     *LiquidationTracker
+	globalLiquidationTrackerOnce sync.Once
+)
+
+// GetLiquidationTracker 获取强平数据实例（单例）
+func GetLiquidationTracker() *LiquidationTracker {
+	globalLiquidationTrackerOnce.Do(func() {
+		globalLiquidationTracker = NewLiquidationTracker(utils.GetRedisClient(), config.Get().LiquidationWindows)
+	})
+	return globalLiquidationTracker
+}
+
+// NewLiquidationTracker 创建强平数据，windows为逗号分隔的窗口（如"5m,15m,1h"）
+func NewLiquidationTracker(redis utils.RedisClient, windows string) *LiquidationTracker {
+	t := &LiquidationTracker{redis: redis}
+	for _, w := range strings.Split(windows, ",") {
+		w = strings.TrimSpace(w)
+		d, err := TimeframeDuration(w)
+		if err != nil {
+			continue
+		}
+		t.windows = append(t.windows, w)
+		if d > t.maxAge {
+			t.maxAge = d
+		}
+	}
+	if len(t.windows) == 0 {
+		t.windows = []string{"1h"}
+		t.maxAge = time.Hour
+	}
+	sort.SliceStable(t.windows, func(i, j int) bool {
+		di, _ := TimeframeDuration(t.windows[i])
+		dj, _ := TimeframeDuration(t.windows[j])
+		return di < dj
+	})
+	return t
+}
+
+// liquidationKey 交易对强平订单的Redis key
+func liquidationKey(symbol string) string {
+	return config.GetRedisKey(fmt.Sprintf("liquidations:%s", symbol))
+}
+
+// liquidationSymbolsKey 最近有强平的交易对索引（score为最近强平时间）
+func liquidationSymbolsKey() string {
+	return config.GetRedisKey("liquidations:symbols")
+}
+
+// Record 写入强平订单并清理超出最长窗口的数据
+// 成员为订单JSON，重复推送的同一笔订单只记录一次
+func (t *LiquidationTracker) Record(ctx context.Context, events ...exchange.LiquidationEvent) error {
+	cutoff := time.Now().Add(-t.maxAge).UnixMilli()
+
+	pipe := t.redis.Pipeline()
+	latest := make(map[string]int64)
+	for _, event := range events {
+		if event.Symbol == "" || event.Time <= cutoff || event.Notional() <= 0 {
+			continue
+		}
+		member, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		pipe.ZAdd(ctx, liquidationKey(event.Symbol), redis.Z{Score: float64(event.Time), Member: string(member)})
+		if event.Time > latest[event.Symbol] {
+			latest[event.Symbol] = event.Time
+		}
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+
+	expired := "(" + strconv.FormatInt(cutoff, 10)
+	for symbol, ts := range latest {
+		key := liquidationKey(symbol)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", expired)
+		pipe.Expire(ctx, key, t.maxAge)
+		pipe.ZAddGT(ctx, liquidationSymbolsKey(), redis.Z{Score: float64(ts), Member: symbol})
+	}
+	pipe.ZRemRangeByScore(ctx, liquidationSymbolsKey(), "-inf", expired)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Windows 按滚动窗口统计交易对最近的强平，没有强平记录时返回nil
+func (t *LiquidationTracker) Windows(ctx context.Context, symbol string) ([]types.LiquidationWindow, error) {
+	now := time.Now()
+	members, err := t.redis.ZRangeByScore(ctx, liquidationKey(symbol), &redis.ZRangeBy{
+		Min: strconv.FormatInt(now.Add(-t.maxAge).UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	events := make([]exchange.LiquidationEvent, 0, len(members))
+	for _, member := range members {
+		var event exchange.LiquidationEvent
+		if json.Unmarshal([]byte(member), &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return AggregateLiquidations(events, now, t.windows), nil
+}
+
+// Recent 最近有强平的交易对及窗口统计（按最近强平时间倒序）
+func (t *LiquidationTracker) Recent(ctx context.Context, limit int) ([]LiquidationSummary, error) {
+	items, err := t.redis.ZRevRangeByScoreWithScores(ctx, liquidationSymbolsKey(), &redis.ZRangeBy{
+		Min:   strconv.FormatInt(time.Now().Add(-t.maxAge).UnixMilli(), 10),
+		Max:   "+inf",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]LiquidationSummary, 0, len(items))
+	for _, item := range items {
+		symbol, _ := item.Member.(string)
+		windows, err := t.Windows(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if windows == nil {
+			continue
+		}
+		summaries = append(summaries, LiquidationSummary{
+			Symbol:   symbol,
+			LastTime: int64(item.Score),
+			Windows:  windows,
+		})
+	}
+	return summaries, nil
+}
+
+// AggregateLiquidations 按窗口汇总强平订单（SELL为多头被强平，BUY为空头被强平）
+// 窗口内强平名义价值达到LiquidationSqueezeMinUSDT且单边占比达到LiquidationSqueezeRatio时标记挤压方向
+func AggregateLiquidations(events []exchange.LiquidationEvent, now time.Time, windows []string) []types.LiquidationWindow {
+	cfg := config.Get()
+
+	result := make([]types.LiquidationWindow, 0, len(windows))
+	for _, window := range windows {
+		d, err := TimeframeDuration(window)
+		if err != nil {
+			continue
+		}
+		since := now.Add(-d).UnixMilli()
+
+		w := types.LiquidationWindow{Window: window}
+		for _, event := range events {
+			if event.Time < since {
+				continue
+			}
+			switch strings.ToUpper(event.Side) {
+			case "SELL":
+				w.LongNotional += event.Notional()
+				w.LongCount++
+			case "BUY":
+				w.ShortNotional += event.Notional()
+				w.ShortCount++
+			}
+		}
+
+		total := w.LongNotional + w.ShortNotional
+		if total > 0 && total >= cfg.LiquidationSqueezeMinUSDT {
+			switch {
+			case w.LongNotional/total >= cfg.LiquidationSqueezeRatio:
+				w.Squeeze = "long_squeeze"
+			case w.ShortNotional/total >= cfg.LiquidationSqueezeRatio:
+				w.Squeeze = "short_squeeze"
+			}
+		}
+		result = append(result, w)
+	}
+	return result
+}
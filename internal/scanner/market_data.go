@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/indicators"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// ScanTimeframes 扫描使用的K线周期
+var ScanTimeframes = []string{"1m", "3m", "5m", "15m", "30m", "1h", "4h", "1d"}
+
+// ScanLimits 各周期获取的K线数量（与ScanTimeframes一一对应）
+var ScanLimits = []int{50, 50, 50, 200, 100, 200, 200, 200}
+
+// TimeframeDuration 解析K线周期（1m, 3m, 1h, 4h, 1d等）
+func TimeframeDuration(timeframe string) (time.Duration, error) {
+	if len(timeframe) < 2 {
+		return 0, fmt.Errorf("invalid timeframe: %s", timeframe)
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(timeframe[:len(timeframe)-1], "%d", &n); err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid timeframe: %s", timeframe)
+	}
+
+	switch timeframe[len(timeframe)-1] {
+	case 'm':
+		return time.Duration(n) * time.Minute, nil
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid timeframe: %s", timeframe)
+}
+
+// MarketInputs 构建市场数据所需的原始输入
+type MarketInputs struct {
+	Symbol       string
+	OHLCV        map[string][]types.OHLCV // 按周期索引的K线
+	TickerPrice  float64                  // 为0时使用最新1m收盘价
+	FundingRate  float64
+	OpenInterest float64
+	OIChange     float64
+	OrderBook    *types.OrderBook          // 深度快照（可选，为空时不计算盘口指标）
+	Derivatives  *types.DerivativesData    // 衍生品情绪数据（可选）
+	Liquidations []types.LiquidationWindow // 强平统计（可选）
+
+	PremiumIndex   *types.PremiumIndex       // 标记价格与预测资金费率（可选）
+	FundingHistory []types.FundingRateRecord // 最近的资金费率结算（可选，按时间正序）
+	Timestamp      int64
+}
+
+// BuildMarketData 根据K线和衍生品数据计算技术指标并构建市场数据
+// 实盘扫描和回测共用此函数，保证指标计算一致
+func BuildMarketData(in MarketInputs) (*types.MarketData, error) {
+	cfg := config.Get()
+
+	// 检查核心周期
+	for _, tf := range []string{"1m", "3m", "15m"} {
+		if len(in.OHLCV[tf]) == 0 {
+			return nil, fmt.Errorf("failed to get %s OHLCV", tf)
+		}
+	}
+
+	// 提取收盘价和成交量
+	prices := make(map[string][]float64)
+	volumes := make(map[string][]float64)
+	for tf, candles := range in.OHLCV {
+		prices[tf] = make([]float64, 0, len(candles))
+		volumes[tf] = make([]float64, 0, len(candles))
+		for _, candle := range candles {
+			prices[tf] = append(prices[tf], candle.Close)
+			volumes[tf] = append(volumes[tf], candle.Volume)
+		}
+	}
+
+	// 获取当前价格
+	currentPrice := in.TickerPrice
+	if currentPrice == 0 {
+		currentPrice = prices["1m"][len(prices["1m"])-1]
+	}
+
+	// 计算技术指标
+	ema20_3m := indicators.CalculateEMA(prices["3m"], cfg.IndEMAPeriod20)
+	ema50_3m := indicators.CalculateEMA(prices["3m"], cfg.IndEMAPeriod50)
+	ema200_1h := indicators.CalculateEMA(prices["1h"], cfg.IndEMAPeriod200)
+
+	// 计算RSI
+	rsi1h := indicators.CalculateRSI(prices["1h"], cfg.IndRSIPeriod)
+
+	// 计算布林带
+	bbUpper1h, bbMiddle1h, bbLower1h := indicators.CalculateBollingerBands(
+		prices["1h"], cfg.IndBBPeriod, cfg.IndBBStdDev)
+
+	bb1h := &types.BollingerBands{
+		Upper:   bbUpper1h,
+		Middle:  bbMiddle1h,
+		Lower:   bbLower1h,
+		Squeeze: indicators.IsBollingerSqueeze(bbUpper1h, bbMiddle1h, bbLower1h, cfg.BBSqueezeBandwidth),
+	}
+
+	// 计算CVD和OBV
+	cvd1h := calculateCVD(in.OHLCV["1h"])
+	cvdByTF := make(map[string]float64, len(in.OHLCV))
+	for tf, candles := range in.OHLCV {
+		cvdByTF[tf] = calculateCVD(candles)
+	}
+	obv1h := calculateOBV(in.OHLCV["1h"])
+
+	// 盘口微观结构
+	depth := BuildDepthMetrics(in.OrderBook, cfg.DepthImbalanceLevels, cfg.DepthBandPct, cfg.StratDefaultNotionalUSDT)
+
+	// 资金费率统计（距结算时间按数据时间计算，回测与实盘一致）
+	now := time.Now()
+	if in.Timestamp > 0 {
+		now = time.Unix(in.Timestamp, 0)
+	}
+	funding := BuildFundingStats(in.PremiumIndex, in.FundingHistory, now)
+
+	return &types.MarketData{
+		Symbol:             in.Symbol,
+		CurrentPrice:       currentPrice,
+		PriceChangePct24h:  0, // 需要从ticker获取
+		OpenInterest:       in.OpenInterest,
+		OpenInterestChange: in.OIChange,
+		FundingRate:        in.FundingRate,
+		Volume:             volumes["1m"][len(volumes["1m"])-1],
+		Volume24h:          0, // 需要从ticker获取
+		Timestamp:          in.Timestamp,
+		OHLCV1m:            in.OHLCV["1m"],
+		OHLCV3m:            in.OHLCV["3m"],
+		OHLCV5m:            in.OHLCV["5m"],
+		OHLCV15m:           in.OHLCV["15m"],
+		OHLCV30m:           in.OHLCV["30m"],
+		OHLCV1h:            in.OHLCV["1h"],
+		OHLCV4h:            in.OHLCV["4h"],
+		OHLCV1d:            in.OHLCV["1d"],
+		EMA20:              ema20_3m,
+		EMA50:              ema50_3m,
+		EMA200:             ema200_1h,
+		RSI:                rsi1h,
+		BB:                 bb1h,
+		CVD:                cvd1h,
+		CVDByTimeframe:     cvdByTF,
+		OBV:                obv1h,
+		Depth:              depth,
+		Derivatives:        in.Derivatives,
+		Liquidations:       in.Liquidations,
+		Funding:            funding,
+	}, nil
+}
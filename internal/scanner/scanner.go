@@ -10,7 +10,6 @@ import (
 
 	"github.com/yuechangmingzou/nofx-go/internal/config"
 	"github.com/yuechangmingzou/nofx-go/internal/exchange"
-	"github.com/yuechangmingzou/nofx-go/internal/indicators"
 	"github.com/yuechangmingzou/nofx-go/internal/utils"
 	"github.com/yuechangmingzou/nofx-go/pkg/types"
 )
@@ -19,25 +18,50 @@ import (
 type Scanner struct {
 	exchange types.Exchange
 	redis    utils.RedisClient
+	feed     *MarketFeed // 行情流（可选），为nil时全部通过REST获取
 }
 
 var globalScanner *Scanner
 
 // GetScanner 获取扫描器实例（单例）
-func GetScanner() *Scanner {
+func GetScanner() (*Scanner, error) {
 	if globalScanner == nil {
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			return nil, err
+		}
 		globalScanner = &Scanner{
-			exchange: exchange.GetBinanceExchange(),
+			exchange: ex,
 			redis:    utils.GetRedisClient(),
 		}
 	}
-	return globalScanner
+	return globalScanner, nil
+}
+
+// StartMarketFeed 启动WebSocket行情源（配置启用且行情来自Binance时），返回是否已启用
+func (s *Scanner) StartMarketFeed(ctx context.Context) bool {
+	if !config.Get().MarketStreamEnabled || !exchange.BinanceMarketData() {
+		return false
+	}
+
+	feed, err := GetMarketFeed()
+	if err != nil {
+		utils.GetLogger("scanner").Warnw("行情流初始化失败，使用REST获取行情", "error", err)
+		return false
+	}
+	feed.Start(ctx)
+	s.AttachFeed(feed)
+	return true
+}
+
+// AttachFeed 绑定行情源，ScanSymbol优先读取其内存K线
+func (s *Scanner) AttachFeed(feed *MarketFeed) {
+	s.feed = feed
 }
 
 // ScanSymbol 扫描单个交易对
 func (s *Scanner) ScanSymbol(ctx context.Context, symbol string) (*types.MarketData, error) {
 	logger := utils.GetLogger("scanner")
-	cfg := config.Get()
 
 	// 规范化symbol
 	symbol = utils.NormalizeSymbol(symbol)
@@ -52,8 +76,8 @@ func (s *Scanner) ScanSymbol(ctx context.Context, symbol string) (*types.MarketD
 		index int
 	}
 
-	timeframes := []string{"1m", "3m", "5m", "15m", "30m", "1h", "4h", "1d"}
-	limits := []int{50, 50, 50, 200, 100, 200, 200, 200}
+	timeframes := ScanTimeframes
+	limits := ScanLimits
 
 	ohlcvResults := make([]ohlcvResult, len(timeframes))
 	var wg sync.WaitGroup
@@ -62,7 +86,7 @@ func (s *Scanner) ScanSymbol(ctx context.Context, symbol string) (*types.MarketD
 		wg.Add(1)
 		go func(idx int, timeframe string, limit int) {
 			defer wg.Done()
-			data, err := s.exchange.GetOHLCV(symbol, timeframe, limit)
+			data, err := s.loadOHLCV(ctx, symbol, timeframe, limit)
 			ohlcvResults[idx] = ohlcvResult{data: data, err: err, index: idx}
 		}(i, tf, limits[i])
 	}
@@ -71,118 +95,144 @@ func (s *Scanner) ScanSymbol(ctx context.Context, symbol string) (*types.MarketD
 	var tickerPrice float64
 	var fundingRate float64
 	var openInterest float64
-	var tickerErr error
 
-	wg.Add(3)
-	go func() {
-		defer wg.Done()
-		tickerPrice, tickerErr = s.exchange.GetTickerPrice(symbol)
-	}()
-	go func() {
-		defer wg.Done()
-		fundingRate, _ = s.exchange.GetFundingRate(symbol)
-	}()
-	go func() {
-		defer wg.Done()
-		openInterest, _ = s.exchange.GetOpenInterest(symbol)
-	}()
-
-	wg.Wait()
+	// 行情流在线时最新价取1m K线收盘价、资金费率取标记价格推送，不再请求REST
+	streaming := s.feed != nil && s.feed.Streaming(symbol)
+	mark, hasMark := exchange.MarkPriceUpdate{}, false
+	if s.feed != nil {
+		mark, hasMark = s.feed.MarkPrice(symbol)
+	}
 
-	// 检查核心周期是否成功
-	if ohlcvResults[0].err != nil || len(ohlcvResults[0].data) == 0 { // 1m
-		return nil, fmt.Errorf("failed to get 1m OHLCV")
+	if !streaming {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tickerPrice, _ = s.exchange.GetTickerPriceContext(ctx, symbol)
+		}()
 	}
-	if ohlcvResults[1].err != nil || len(ohlcvResults[1].data) == 0 { // 3m
-		return nil, fmt.Errorf("failed to get 3m OHLCV")
+	// 资金费率统计开启时预测费率取标记价格数据，不再单独请求资金费率
+	fundingStats := config.Get().FundingStatsEnabled
+	var premium *types.PremiumIndex
+	var fundingHistory []types.FundingRateRecord
+	switch {
+	case hasMark:
+		fundingRate = mark.FundingRate
+		if fundingStats {
+			premium = mark.PremiumIndex()
+		}
+	case fundingStats:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := s.exchange.GetPremiumIndexContext(ctx, symbol)
+			if err != nil {
+				logger.Debugw("获取标记价格失败", "symbol", symbol, "error", err)
+				return
+			}
+			premium = data
+			fundingRate = data.PredictedFundingRate
+		}()
+	default:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fundingRate, _ = s.exchange.GetFundingRateContext(ctx, symbol)
+		}()
 	}
-	if ohlcvResults[3].err != nil || len(ohlcvResults[3].data) == 0 { // 15m
-		return nil, fmt.Errorf("failed to get 15m OHLCV")
+	if fundingStats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records, err := s.exchange.GetFundingHistoryContext(ctx, symbol, config.Get().FundingHistoryLimit)
+			if err != nil {
+				logger.Debugw("获取资金费率历史失败", "symbol", symbol, "error", err)
+				return
+			}
+			fundingHistory = records
+		}()
 	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		openInterest, _ = s.exchange.GetOpenInterestContext(ctx, symbol)
+	}()
 
-	// 提取价格和成交量
-	prices := make(map[string][]float64)
-	volumes := make(map[string][]float64)
-	ohlcvMap := make(map[string][]types.OHLCV)
-
-	for i, result := range ohlcvResults {
-		if result.err == nil && len(result.data) > 0 {
-			tf := timeframes[i]
-			ohlcvMap[tf] = result.data
-			prices[tf] = make([]float64, 0, len(result.data))
-			volumes[tf] = make([]float64, 0, len(result.data))
-			for _, candle := range result.data {
-				prices[tf] = append(prices[tf], candle.Close)
-				volumes[tf] = append(volumes[tf], candle.Volume)
+	// 深度快照（失败时不计算盘口指标，不影响扫描）
+	var orderBook *types.OrderBook
+	if cfg := config.Get(); cfg.DepthEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			book, err := s.exchange.GetOrderBookContext(ctx, symbol, cfg.DepthLimit)
+			if err != nil {
+				logger.Debugw("获取深度快照失败", "symbol", symbol, "error", err)
+				return
 			}
-		}
+			orderBook = book
+		}()
 	}
 
-	// 获取当前价格
-	currentPrice := tickerPrice
-	if currentPrice == 0 && len(prices["1m"]) > 0 {
-		currentPrice = prices["1m"][len(prices["1m"])-1]
+	// 衍生品情绪数据（仅支持的交易所）
+	var derivatives *types.DerivativesData
+	provider, hasDerivatives := s.exchange.(exchange.DerivativesProvider)
+	if cfg := config.Get(); cfg.DerivativesEnabled && hasDerivatives {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := provider.GetDerivativesDataContext(ctx, symbol, cfg.DerivativesPeriod, cfg.DerivativesLimit)
+			if err != nil {
+				logger.Debugw("获取衍生品数据失败", "symbol", symbol, "error", err)
+				return
+			}
+			derivatives = data
+		}()
 	}
 
-	// 计算24h涨跌幅（从ticker获取，如果失败则计算）
-	priceChangePct24h := 0.0
-	if tickerErr == nil {
-		// 尝试从ticker获取24h涨跌幅
-		// 这里需要调用GetTicker24h，暂时用0
+	// 强平统计（只来自强平订单流，不消耗REST权重）
+	var liquidations []types.LiquidationWindow
+	if config.Get().LiquidationEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			windows, err := GetLiquidationTracker().Windows(ctx, symbol)
+			if err != nil {
+				logger.Debugw("读取强平统计失败", "symbol", symbol, "error", err)
+				return
+			}
+			liquidations = windows
+		}()
 	}
 
-	// 计算技术指标
-	ema20_3m := indicators.CalculateEMA(prices["3m"], cfg.IndEMAPeriod20)
-	ema50_3m := indicators.CalculateEMA(prices["3m"], cfg.IndEMAPeriod50)
-	ema200_1h := indicators.CalculateEMA(prices["1h"], cfg.IndEMAPeriod200)
-
-	// 计算RSI
-	rsi1h := indicators.CalculateRSI(prices["1h"], cfg.IndRSIPeriod)
-
-	// 计算布林带
-	bbUpper1h, bbMiddle1h, bbLower1h := indicators.CalculateBollingerBands(
-		prices["1h"], cfg.IndBBPeriod, cfg.IndBBStdDev)
+	wg.Wait()
 
-	bb1h := &types.BollingerBands{
-		Upper:   bbUpper1h,
-		Middle:  bbMiddle1h,
-		Lower:   bbLower1h,
-		Squeeze: indicators.IsBollingerSqueeze(bbUpper1h, bbMiddle1h, bbLower1h, cfg.BBSqueezeBandwidth),
+	ohlcvMap := make(map[string][]types.OHLCV)
+	for i, result := range ohlcvResults {
+		if result.err == nil && len(result.data) > 0 {
+			ohlcvMap[timeframes[i]] = result.data
+		}
 	}
 
-	// 计算CVD和OBV
-	cvd1h := calculateCVD(ohlcvMap["1h"])
-	obv1h := calculateOBV(ohlcvMap["1h"])
-
 	// 计算持仓量变化
 	oiChange := s.calculateOIChange(symbol, openInterest)
 
-	// 构建市场数据
-	marketData := &types.MarketData{
-		Symbol:             symbol,
-		CurrentPrice:       currentPrice,
-		PriceChangePct24h:  priceChangePct24h,
-		OpenInterest:       openInterest,
-		OpenInterestChange: oiChange,
-		FundingRate:        fundingRate,
-		Volume:             volumes["1m"][len(volumes["1m"])-1],
-		Volume24h:          0, // 需要从ticker获取
-		Timestamp:          time.Now().Unix(),
-		OHLCV1m:            ohlcvMap["1m"],
-		OHLCV3m:            ohlcvMap["3m"],
-		OHLCV5m:            ohlcvMap["5m"],
-		OHLCV15m:           ohlcvMap["15m"],
-		OHLCV30m:           ohlcvMap["30m"],
-		OHLCV1h:            ohlcvMap["1h"],
-		OHLCV4h:            ohlcvMap["4h"],
-		OHLCV1d:            ohlcvMap["1d"],
-		EMA20:              ema20_3m,
-		EMA50:              ema50_3m,
-		EMA200:             ema200_1h,
-		RSI:                rsi1h,
-		BB:                 bb1h,
-		CVD:                cvd1h,
-		OBV:                obv1h,
+	// 计算技术指标并构建市场数据
+	marketData, err := BuildMarketData(MarketInputs{
+		Symbol:       symbol,
+		OHLCV:        ohlcvMap,
+		TickerPrice:  tickerPrice,
+		FundingRate:  fundingRate,
+		OpenInterest: openInterest,
+		OIChange:     oiChange,
+		OrderBook:    orderBook,
+		Derivatives:  derivatives,
+		Liquidations: liquidations,
+
+		PremiumIndex:   premium,
+		FundingHistory: fundingHistory,
+		Timestamp:      time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 保存市场快照到Redis
@@ -190,16 +240,37 @@ func (s *Scanner) ScanSymbol(ctx context.Context, symbol string) (*types.MarketD
 
 	logger.Debugw("Symbol scanned",
 		"symbol", symbol,
-		"price", currentPrice,
-		"ema20", ema20_3m,
-		"rsi", rsi1h,
+		"price", marketData.CurrentPrice,
+		"ema20", marketData.EMA20,
+		"rsi", marketData.RSI,
 	)
 
 	return marketData, nil
 }
 
-// calculateCVD 计算累计成交量差
+// loadOHLCV 获取K线：行情流有最新数据时读取内存，否则通过REST获取并回补到内存
+func (s *Scanner) loadOHLCV(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	if s.feed == nil {
+		return s.exchange.GetOHLCVContext(ctx, symbol, timeframe, limit)
+	}
+	if candles, ok := s.feed.Candles(symbol, timeframe, limit); ok {
+		return candles, nil
+	}
+	return s.feed.Backfill(ctx, symbol, timeframe, limit)
+}
+
+// calculateCVD 计算累计成交量差（主动买入量 - 主动卖出量）
+// K线带主动买入量时按真实订单流计算；交易所不提供时（OKX/Bybit）退化为按K线涨跌给成交量加符号
 func calculateCVD(ohlcv []types.OHLCV) float64 {
+	if hasTakerVolume(ohlcv) {
+		cvd := 0.0
+		for _, candle := range ohlcv {
+			// 主动卖出量 = 总成交量 - 主动买入量
+			cvd += 2*candle.TakerBuyVolume - candle.Volume
+		}
+		return cvd
+	}
+
 	cvd := 0.0
 	for _, candle := range ohlcv {
 		if candle.Close > candle.Open {
@@ -211,6 +282,16 @@ func calculateCVD(ohlcv []types.OHLCV) float64 {
 	return cvd
 }
 
+// hasTakerVolume K线序列是否带主动买入量
+func hasTakerVolume(ohlcv []types.OHLCV) bool {
+	for _, candle := range ohlcv {
+		if candle.TakerBuyVolume > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // calculateOBV 计算能量潮指标
 func calculateOBV(ohlcv []types.OHLCV) float64 {
 	if len(ohlcv) < 2 {
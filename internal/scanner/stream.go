@@ -26,6 +26,11 @@ func (s *Scanner) ScanMarketStream(ctx context.Context, forceFull bool) (<-chan
 		return nil, fmt.Errorf("no symbols to scan")
 	}
 
+	// 同步行情流订阅
+	if s.feed != nil {
+		s.feed.Track(symbols)
+	}
+
 	snapTTL := cfg.MarketSnapshotTTLSec
 	scanConc := cfg.ScanConcurrency
 
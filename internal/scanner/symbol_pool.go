@@ -68,14 +68,19 @@ func (s *Scanner) filterSymbolsByOnlineDays(symbols []string, minDays int) []str
 		return symbols
 	}
 
-	exchange := exchange.GetBinanceExchange()
+	// 交易所不提供上市时间时不过滤
+	provider, ok := s.exchange.(exchange.MarketInfoProvider)
+	if !ok {
+		return symbols
+	}
+
 	now := time.Now().Unix() * 1000 // 毫秒时间戳
 	minOnlineMs := int64(minDays) * 24 * 60 * 60 * 1000
 
 	filtered := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		// 从交易所获取市场信息
-		marketInfo, err := exchange.GetMarketInfo(symbol)
+		marketInfo, err := provider.GetMarketInfo(symbol)
 		if err != nil {
 			// 如果获取失败，默认保留（保守策略）
 			filtered = append(filtered, symbol)
@@ -147,7 +152,12 @@ func (s *Scanner) UpdateSymbolPool(activeSymbols []string) error {
 
 // CalculateVolatility 计算币种的波动率（基于24h涨跌幅的绝对值）
 func (s *Scanner) CalculateVolatility(symbol string) float64 {
-	ticker, err := exchange.GetBinanceExchange().GetTicker24h(symbol)
+	provider, ok := s.exchange.(exchange.Ticker24hProvider)
+	if !ok {
+		return 0.0
+	}
+
+	ticker, err := provider.GetTicker24h(symbol)
 	if err != nil {
 		return 0.0
 	}
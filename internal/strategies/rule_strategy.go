@@ -10,10 +10,17 @@ type RuleStrategy interface {
 }
 
 // DefaultRuleStrategy 默认规则策略（简单示例）
-type DefaultRuleStrategy struct{}
+type DefaultRuleStrategy struct {
+	MaxSlippageBps float64 // 预估滑点上限（基点），不大于0时不检查盘口
+}
 
 // MakeDecision 做出决策
 func (s *DefaultRuleStrategy) MakeDecision(marketData *types.MarketData) (string, *types.Signal, string, map[string]interface{}) {
+	// 盘口流动性不足时不开仓
+	if ok, reason, details := CheckLiquidity(marketData, s.MaxSlippageBps); !ok {
+		return "wait", nil, reason, details
+	}
+
 	// 简单规则：如果RSI < 30，做多；如果RSI > 70，做空
 	if marketData.RSI > 0 {
 		if marketData.RSI < 30 {
@@ -47,9 +54,32 @@ func (s *DefaultRuleStrategy) MakeDecision(marketData *types.MarketData) (string
 	return "wait", nil, "无交易信号", map[string]interface{}{}
 }
 
-// GetRuleStrategy 获取规则策略实例
-func GetRuleStrategy() RuleStrategy {
+// CheckLiquidity 检查盘口流动性：可见深度不足以成交默认名义价值、或预估滑点超过上限时返回false
+// 没有深度数据（如回测）或maxSlippageBps不大于0时不做限制
+func CheckLiquidity(marketData *types.MarketData, maxSlippageBps float64) (bool, string, map[string]interface{}) {
+	depth := marketData.Depth
+	if depth == nil || maxSlippageBps <= 0 {
+		return true, "", nil
+	}
+
+	details := map[string]interface{}{
+		"spread_bps":        depth.SpreadBps,
+		"buy_slippage_bps":  depth.BuySlippageBps,
+		"sell_slippage_bps": depth.SellSlippageBps,
+		"notional_usdt":     depth.NotionalUSDT,
+	}
+	if depth.Thin {
+		return false, "盘口深度不足", details
+	}
+	if depth.BuySlippageBps > maxSlippageBps || depth.SellSlippageBps > maxSlippageBps {
+		return false, "预估滑点过大", details
+	}
+	return true, "", nil
+}
+
+// GetRuleStrategy 获取规则策略实例，maxSlippageBps为开仓允许的预估滑点上限（基点）
+func GetRuleStrategy(maxSlippageBps float64) RuleStrategy {
 	// 可以根据配置选择不同的策略
-	return &DefaultRuleStrategy{}
+	return &DefaultRuleStrategy{MaxSlippageBps: maxSlippageBps}
 }
 
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Alerter 告警推送（Webhook），按key去重并限制最小发送间隔
+type Alerter struct {
+	webhookURL  string
+	dedupeTTL   time.Duration
+	minInterval time.Duration
+	client      *http.Client
+
+	mu       sync.Mutex
+	sentAt   map[string]time.Time // key -> 最近发送时间
+	lastSent time.Time
+}
+
+// NewAlerter 创建告警推送
+func NewAlerter(webhookURL string, dedupeTTL, minInterval time.Duration) *Alerter {
+	return &Alerter{
+		webhookURL:  webhookURL,
+		dedupeTTL:   dedupeTTL,
+		minInterval: minInterval,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		sentAt:      make(map[string]time.Time),
+	}
+}
+
+// Send 发送告警；相同key在去重窗口内、或距上次发送不足最小间隔时丢弃，返回是否已发送
+func (a *Alerter) Send(ctx context.Context, key, message string, fields map[string]interface{}) (bool, error) {
+	if a == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	if last, ok := a.sentAt[key]; ok && now.Sub(last) < a.dedupeTTL {
+		a.mu.Unlock()
+		return false, nil
+	}
+	if now.Sub(a.lastSent) < a.minInterval {
+		a.mu.Unlock()
+		return false, nil
+	}
+	a.sentAt[key] = now
+	a.lastSent = now
+	for k, t := range a.sentAt {
+		if now.Sub(t) >= a.dedupeTTL {
+			delete(a.sentAt, k)
+		}
+	}
+	a.mu.Unlock()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"key":     key,
+		"message": message,
+		"fields":  fields,
+		"ts":      now.Unix(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("encode alert failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("send alert failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false, fmt.Errorf("send alert failed: HTTP %d", resp.StatusCode)
+	}
+	return true, nil
+}
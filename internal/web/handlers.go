@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
 	"github.com/yuechangmingzou/nofx-go/internal/utils"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
 )
 
 // handleStatus 获取系统状态（带缓存）
@@ -21,7 +25,7 @@ func (s *Server) handleStatus(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	status := map[string]interface{}{
@@ -57,7 +61,7 @@ func (s *Server) handleStatus(c *gin.Context) {
 
 // handleMarketData 获取市场数据（带超时和错误处理）
 func (s *Server) handleMarketData(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	// 从Redis读取最近扫描的市场数据
@@ -115,7 +119,7 @@ func (s *Server) handleSetAIMode(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("ai_mode")
@@ -131,7 +135,7 @@ func (s *Server) handleSetAIMode(c *gin.Context) {
 
 // handleGetAIPrompt 获取AI提示词
 func (s *Server) handleGetAIPrompt(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("ai_prompt")
@@ -167,7 +171,7 @@ func (s *Server) handleSetAIPrompt(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("ai_prompt")
@@ -186,7 +190,7 @@ func (s *Server) handleSetAIPrompt(c *gin.Context) {
 
 // handleDeleteAIPrompt 删除AI提示词（恢复默认）
 func (s *Server) handleDeleteAIPrompt(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("ai_prompt")
@@ -200,7 +204,7 @@ func (s *Server) handleDeleteAIPrompt(c *gin.Context) {
 
 // handleGetRuntimeConfig 获取运行时配置
 func (s *Server) handleGetRuntimeConfig(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("runtime_config")
@@ -236,7 +240,7 @@ func (s *Server) handleSetRuntimeConfig(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	// 读取现有配置
@@ -275,7 +279,7 @@ func (s *Server) handleDeleteRuntimeConfig(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	// 读取现有配置
@@ -316,7 +320,7 @@ func (s *Server) handleRuntimeConfigAudit(c *gin.Context) {
 		}
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("runtime_config_audit")
@@ -351,7 +355,7 @@ func (s *Server) handleWSToken(c *gin.Context) {
 	}
 	payloadJSON, _ := json.Marshal(payload)
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	if err := s.redis.Set(ctx, key, payloadJSON, time.Duration(ttl)*time.Second).Err(); err != nil {
@@ -367,7 +371,10 @@ func (s *Server) handleWSToken(c *gin.Context) {
 
 // handleBalance 获取余额
 func (s *Server) handleBalance(c *gin.Context) {
-	balance, err := s.exchange.GetBalance()
+	ctx, cancel := utils.WithMediumTimeout(c.Request.Context())
+	defer cancel()
+
+	balance, err := s.exchange.GetBalanceContext(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		return
@@ -378,10 +385,10 @@ func (s *Server) handleBalance(c *gin.Context) {
 
 // handlePositions 获取持仓
 func (s *Server) handlePositions(c *gin.Context) {
-	_, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(c.Request.Context())
 	defer cancel()
 
-	positions, err := s.exchange.GetPositions()
+	positions, err := s.exchange.GetPositionsContext(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		return
@@ -408,22 +415,22 @@ func (s *Server) handlePositions(c *gin.Context) {
 
 // handleEquity 获取权益
 func (s *Server) handleEquity(c *gin.Context) {
-	_, cancel := utils.WithMediumTimeout(context.Background())
+	ctx, cancel := utils.WithMediumTimeout(c.Request.Context())
 	defer cancel()
 
-	balanceMap, err := s.exchange.GetBalance()
+	balanceMap, err := s.exchange.GetBalanceContext(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 提取USDT余额
+	// 提取USDT钱包余额（GetBalance返回total/free/used）
 	balance := 0.0
-	if usdt, ok := balanceMap["USDT"]; ok {
-		balance = usdt
+	if total, ok := balanceMap["total"]; ok {
+		balance = total
 	}
 
-	positions, err := s.exchange.GetPositions()
+	positions, err := s.exchange.GetPositionsContext(ctx)
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		return
@@ -452,7 +459,7 @@ func (s *Server) handleHistory(c *gin.Context) {
 		}
 	}
 
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("signal_history")
@@ -475,7 +482,7 @@ func (s *Server) handleHistory(c *gin.Context) {
 
 // handleLatestAIDecision 获取最新AI决策
 func (s *Server) handleLatestAIDecision(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("deepseek_analysis_response_history")
@@ -496,7 +503,7 @@ func (s *Server) handleLatestAIDecision(c *gin.Context) {
 
 // handleScannedSymbols 获取扫描的币种
 func (s *Server) handleScannedSymbols(c *gin.Context) {
-	ctx, cancel := utils.WithDefaultTimeout(context.Background())
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
 	defer cancel()
 
 	key := config.GetRedisKey("scanner_last_scan")
@@ -524,6 +531,115 @@ func (s *Server) handleScannedSymbols(c *gin.Context) {
 	})
 }
 
+// handleLiquidations 获取强平统计：指定symbol时返回该交易对的窗口统计，否则返回最近有强平的交易对
+func (s *Server) handleLiquidations(c *gin.Context) {
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
+	defer cancel()
+
+	tracker := scanner.GetLiquidationTracker()
+
+	if symbol := utils.NormalizeSymbol(c.Query("symbol")); symbol != "" {
+		windows, err := tracker.Windows(ctx, symbol)
+		if err != nil {
+			s.logger.Warnw("读取强平统计失败", "symbol", symbol, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "redis_error"})
+			return
+		}
+		if windows == nil {
+			windows = []types.LiquidationWindow{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"symbol":  symbol,
+			"windows": windows,
+		})
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	items, err := tracker.Recent(ctx, limit)
+	if err != nil {
+		s.logger.Warnw("读取强平统计失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "redis_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// handleOrderLifecycle 订单生命周期：带signal_id时返回该信号的状态和完整时间线，否则返回最近的信号
+func (s *Server) handleOrderLifecycle(c *gin.Context) {
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
+	defer cancel()
+
+	store := execution.NewLifecycleStore(s.redis)
+
+	if signalID := strings.TrimSpace(c.Query("signal_id")); signalID != "" {
+		lifecycle, err := store.Get(ctx, signalID)
+		if err != nil {
+			s.logger.Warnw("读取订单生命周期失败", "signal_id", signalID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "redis_error"})
+			return
+		}
+		if lifecycle == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+			return
+		}
+		c.JSON(http.StatusOK, lifecycle)
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	items, err := store.Recent(ctx, limit)
+	if err != nil {
+		s.logger.Warnw("读取订单生命周期失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "redis_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// handleReconcile 最近一次对账报告（启动或定时对账生成）
+func (s *Server) handleReconcile(c *gin.Context) {
+	ctx, cancel := utils.WithDefaultTimeout(c.Request.Context())
+	defer cancel()
+
+	report, err := execution.LoadReconcileReport(ctx, s.redis)
+	if err != nil {
+		s.logger.Warnw("读取对账报告失败", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "redis_error"})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// handleRunReconcile 立即执行一次只读对账（dry-run，不做修复），报告只返回不保存
+func (s *Server) handleRunReconcile(c *gin.Context) {
+	ctx, cancel := utils.WithMediumTimeout(c.Request.Context())
+	defer cancel()
+
+	report, err := execution.NewExecutionEngine(s.exchange, s.redis).ReconcileOnce(ctx, execution.ReconcileTriggerAPI, true)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // getAIMode 获取AI模式
 func (s *Server) getAIMode() map[string]string {
 	ctx, cancel := utils.WithShortTimeout(context.Background())
@@ -572,7 +688,7 @@ func (s *Server) probeBinance(ctx context.Context) map[string]interface{} {
 	}
 
 	// 尝试获取余额（简单测试）
-	_, err := s.exchange.GetBalance()
+	_, err := s.exchange.GetBalanceContext(ctx)
 	if err != nil {
 		return map[string]interface{}{
 			"configured": true,
@@ -30,18 +30,22 @@ type Server struct {
 var globalServer *Server
 
 // GetServer 获取Web服务器实例（单例）
-func GetServer() *Server {
+func GetServer() (*Server, error) {
 	if globalServer == nil {
+		ex, err := exchange.GetExchange()
+		if err != nil {
+			return nil, err
+		}
 		globalServer = &Server{
 			engine:   gin.Default(),
 			config:   config.Get(),
 			logger:   utils.GetLogger("web"),
-			exchange: exchange.GetBinanceExchange(),
+			exchange: ex,
 			redis:    utils.GetRedisClient(),
 		}
 		globalServer.setupRoutes()
 	}
-	return globalServer
+	return globalServer, nil
 }
 
 // setupRoutes 设置路由
@@ -98,6 +102,16 @@ func (s *Server) setupRoutes() {
 
 		// 扫描的币种
 		api.GET("/scanned-symbols", s.handleScannedSymbols)
+
+		// 强平统计
+		api.GET("/liquidations", s.handleLiquidations)
+
+		// 订单生命周期
+		api.GET("/order-lifecycle", s.handleOrderLifecycle)
+
+		// 交易所与Redis状态对账
+		api.GET("/reconcile", s.handleReconcile)
+		api.POST("/reconcile/run", s.handleRunReconcile)
 	}
 
 	// WebSocket
@@ -221,7 +235,7 @@ func (s *Server) handleHealthz(c *gin.Context) {
 // handleReadyz 就绪检查
 func (s *Server) handleReadyz(c *gin.Context) {
 	// 检查Redis连接
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 	defer cancel()
 
 	if err := s.redis.Ping(ctx).Err(); err != nil {
@@ -243,7 +243,7 @@ func (s *Server) getStatusForWS(ctx context.Context) map[string]interface{} {
 
 // getPositionsForWS 获取WebSocket用的持仓数据
 func (s *Server) getPositionsForWS(ctx context.Context) []map[string]interface{} {
-	positions, err := s.exchange.GetPositions()
+	positions, err := s.exchange.GetPositionsContext(ctx)
 	if err != nil {
 		return nil
 	}
@@ -269,7 +269,7 @@ func (s *Server) getPositionsForWS(ctx context.Context) []map[string]interface{}
 
 // getBalanceForWS 获取WebSocket用的余额数据
 func (s *Server) getBalanceForWS(ctx context.Context) float64 {
-	balanceMap, err := s.exchange.GetBalance()
+	balanceMap, err := s.exchange.GetBalanceContext(ctx)
 	if err != nil {
 		return 0
 	}
@@ -1,5 +1,7 @@
 package types
 
+import "context"
+
 // MarketData 市场数据
 type MarketData struct {
 	Symbol            string  `json:"symbol"`
@@ -29,11 +31,24 @@ type MarketData struct {
 	RSI      float64 `json:"rsi,omitempty"`
 	BB       *BollingerBands `json:"bb,omitempty"`
 	CVD      float64 `json:"cvd,omitempty"`
+	CVDByTimeframe map[string]float64 `json:"cvd_by_timeframe,omitempty"` // 各周期CVD
 	OBV      float64 `json:"obv,omitempty"`
 
 	// 预过滤字段
 	VolumePeakRatio  float64 `json:"volume_peak_ratio,omitempty"`
 	ConsecutiveCount int     `json:"consecutive_count,omitempty"`
+
+	// 盘口微观结构（深度快照获取失败时为空）
+	Depth *DepthMetrics `json:"depth,omitempty"`
+
+	// 衍生品情绪数据（多空比、主动买卖量、持仓量历史，仅Binance行情）
+	Derivatives *DerivativesData `json:"derivatives,omitempty"`
+
+	// 最近强平统计（按滚动窗口，由短到长）
+	Liquidations []LiquidationWindow `json:"liquidations,omitempty"`
+
+	// 资金费率统计（预测费率、距下次结算时间、基差和历史结算）
+	Funding *FundingStats `json:"funding,omitempty"`
 	
 	// 账户信息（可选，用于AI决策）
 	Account *AccountInfo `json:"account,omitempty"`
@@ -53,6 +68,9 @@ type OHLCV struct {
 	Close  float64 `json:"close"`
 	Volume float64 `json:"volume"`
 	Time   int64   `json:"time"`
+
+	// 主动买入成交量（币数量），交易所K线不提供时为0
+	TakerBuyVolume float64 `json:"taker_buy_volume,omitempty"`
 }
 
 // BollingerBands 布林带
@@ -63,6 +81,90 @@ type BollingerBands struct {
 	Squeeze bool   `json:"squeeze"`
 }
 
+// OrderBookLevel 盘口档位
+type OrderBookLevel struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"` // 币数量
+}
+
+// OrderBook 深度快照（买盘价格降序，卖盘价格升序）
+type OrderBook struct {
+	Symbol    string           `json:"symbol"`
+	Bids      []OrderBookLevel `json:"bids"`
+	Asks      []OrderBookLevel `json:"asks"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+// DepthMetrics 盘口微观结构指标
+type DepthMetrics struct {
+	BestBid         float64 `json:"best_bid"`
+	BestAsk         float64 `json:"best_ask"`
+	SpreadBps       float64 `json:"spread_bps"`        // 买卖价差（基点，相对中间价）
+	Imbalance       float64 `json:"imbalance"`         // 前N档买卖量失衡 (bid-ask)/(bid+ask)，范围[-1,1]
+	BidDepthUSDT    float64 `json:"bid_depth_usdt"`    // 中间价下方±带宽内的买盘名义价值
+	AskDepthUSDT    float64 `json:"ask_depth_usdt"`    // 中间价上方±带宽内的卖盘名义价值
+	NotionalUSDT    float64 `json:"notional_usdt"`     // 估算滑点使用的名义价值
+	BuySlippageBps  float64 `json:"buy_slippage_bps"`  // 市价买入的预估滑点（基点，相对中间价）
+	SellSlippageBps float64 `json:"sell_slippage_bps"` // 市价卖出的预估滑点（基点，相对中间价）
+	Thin            bool    `json:"thin,omitempty"`    // 可见深度不足以成交NotionalUSDT
+}
+
+// FundingRateRecord 单次资金费率结算记录
+type FundingRateRecord struct {
+	Symbol      string  `json:"symbol"`
+	FundingRate float64 `json:"funding_rate"`
+	FundingTime int64   `json:"funding_time"` // 结算时间（毫秒）
+}
+
+// PremiumIndex 标记价格、指数价格与下次结算的资金费率
+type PremiumIndex struct {
+	Symbol               string  `json:"symbol"`
+	MarkPrice            float64 `json:"mark_price"`
+	IndexPrice           float64 `json:"index_price"`
+	PredictedFundingRate float64 `json:"predicted_funding_rate"` // 下次结算的预测资金费率
+	NextFundingTime      int64   `json:"next_funding_time"`      // 下次结算时间（毫秒）
+	Timestamp            int64   `json:"timestamp"`
+}
+
+// FundingStats 资金费率统计
+type FundingStats struct {
+	PredictedRate    float64 `json:"predicted_rate"`     // 下次结算的预测资金费率
+	NextFundingTime  int64   `json:"next_funding_time"`  // 下次结算时间（毫秒）
+	MinutesToFunding float64 `json:"minutes_to_funding"` // 距下次结算的分钟数
+	BasisBps         float64 `json:"basis_bps"`          // 标记价格相对指数价格的基差（基点）
+	Settlements      int     `json:"settlements"`        // 参与统计的历史结算次数
+	AvgRate          float64 `json:"avg_rate"`           // 历史结算平均费率
+	SumRate          float64 `json:"sum_rate"`           // 历史结算累计费率
+	MaxRate          float64 `json:"max_rate"`
+	MinRate          float64 `json:"min_rate"`
+	PositiveRatio    float64 `json:"positive_ratio"` // 正费率结算占比
+	Streak           int     `json:"streak"`         // 最近连续同号结算次数（正数为连续正费率，负数为连续负费率）
+}
+
+// DerivativesData 衍生品情绪数据（取最近一个统计周期）
+type DerivativesData struct {
+	Period                 string  `json:"period"`                    // 统计周期，如5m、1h
+	LongShortAccountRatio  float64 `json:"long_short_account_ratio"`  // 全市场多空账户数比
+	LongAccountPct         float64 `json:"long_account_pct"`          // 全市场多头账户占比
+	TopTraderPositionRatio float64 `json:"top_trader_position_ratio"` // 大户持仓多空比
+	TakerBuySellRatio      float64 `json:"taker_buy_sell_ratio"`      // 主动买卖量比
+	TakerBuyVolume         float64 `json:"taker_buy_volume"`
+	TakerSellVolume        float64 `json:"taker_sell_volume"`
+	OpenInterestValue      float64 `json:"open_interest_value"`      // 持仓价值（USDT）
+	OpenInterestChangePct  float64 `json:"open_interest_change_pct"` // 统计区间内持仓量变化（%）
+	Timestamp              int64   `json:"timestamp"`
+}
+
+// LiquidationWindow 滚动窗口内的强平统计
+type LiquidationWindow struct {
+	Window        string  `json:"window"`         // 窗口，如5m、1h
+	LongNotional  float64 `json:"long_notional"`  // 多头被强平的名义价值（USDT）
+	ShortNotional float64 `json:"short_notional"` // 空头被强平的名义价值（USDT）
+	LongCount     int     `json:"long_count"`
+	ShortCount    int     `json:"short_count"`
+	Squeeze       string  `json:"squeeze,omitempty"` // long_squeeze（多头被集中强平）或short_squeeze
+}
+
 // Signal 交易信号
 type Signal struct {
 	Symbol       string  `json:"symbol"`
@@ -93,6 +195,8 @@ type Order struct {
 	FilledQty     float64 `json:"filled_qty"`
 	AvgPrice      float64 `json:"avg_price,omitempty"`
 	ReduceOnly    bool    `json:"reduce_only,omitempty"`
+	ClosePosition bool    `json:"close_position,omitempty"` // 条件单触发后平掉整个仓位
+	ClientOrderID string  `json:"client_order_id,omitempty"`
 	Timestamp     int64   `json:"timestamp"`
 }
 
@@ -141,6 +245,58 @@ type Exchange interface {
 	
 	// 获取当前挂单
 	GetOpenOrders(symbol string) ([]*Order, error)
+	
+	// 设置杠杆倍数
+	SetLeverage(symbol string, leverage int) error
+	
+	// 设置保证金模式（ISOLATED逐仓, CROSSED全仓）
+	SetMarginType(symbol string, marginType string) error
+	
+	// 查询持仓模式（true为双向持仓Hedge Mode）
+	GetPositionMode() (bool, error)
+	
+	// 批量下单（结果与请求一一对应，单笔失败不影响其他订单）
+	PlaceBatchOrders(orders []OrderRequest) ([]BatchOrderResult, error)
+
+	// 获取深度快照（limit为每侧档位数）
+	GetOrderBook(symbol string, limit int) (*OrderBook, error)
+
+	// 获取最近limit次资金费率结算（按时间正序）
+	GetFundingHistory(symbol string, limit int) ([]FundingRateRecord, error)
+
+	// 获取标记价格、指数价格和预测资金费率
+	GetPremiumIndex(symbol string) (*PremiumIndex, error)
+
+	ContextExchange
+}
+
+// ContextExchange 支持context的交易所接口，ctx取消或超时时中止进行中的请求
+// 不带ctx的方法等价于传入context.Background()
+type ContextExchange interface {
+	GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]OHLCV, error)
+	PlaceOrderContext(ctx context.Context, order OrderRequest) (*Order, error)
+	CancelOrderContext(ctx context.Context, symbol, orderID string) error
+	GetOrderContext(ctx context.Context, symbol, orderID string) (*Order, error)
+	GetPositionContext(ctx context.Context, symbol string) (*Position, error)
+	GetPositionsContext(ctx context.Context) ([]*Position, error)
+	GetTickerPriceContext(ctx context.Context, symbol string) (float64, error)
+	GetFundingRateContext(ctx context.Context, symbol string) (float64, error)
+	GetOpenInterestContext(ctx context.Context, symbol string) (float64, error)
+	GetBalanceContext(ctx context.Context) (map[string]float64, error)
+	GetOpenOrdersContext(ctx context.Context, symbol string) ([]*Order, error)
+	SetLeverageContext(ctx context.Context, symbol string, leverage int) error
+	SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error
+	GetPositionModeContext(ctx context.Context) (bool, error)
+	PlaceBatchOrdersContext(ctx context.Context, orders []OrderRequest) ([]BatchOrderResult, error)
+	GetOrderBookContext(ctx context.Context, symbol string, limit int) (*OrderBook, error)
+	GetFundingHistoryContext(ctx context.Context, symbol string, limit int) ([]FundingRateRecord, error)
+	GetPremiumIndexContext(ctx context.Context, symbol string) (*PremiumIndex, error)
+}
+
+// BatchOrderResult 批量下单中单笔订单的结果
+type BatchOrderResult struct {
+	Order *Order `json:"order,omitempty"`
+	Err   error  `json:"-"`
 }
 
 // OrderRequest 订单请求
@@ -154,5 +310,12 @@ type OrderRequest struct {
 	StopPrice    *float64 `json:"stop_price,omitempty"`
 	ReduceOnly   bool    `json:"reduce_only,omitempty"`
 	TimeInForce  string  `json:"time_in_force,omitempty"` // GTC, IOC, FOK
+
+	// 客户端订单ID（相同ID重复提交时交易所拒绝，用于幂等下单）
+	ClientOrderID string `json:"client_order_id,omitempty"`
+
+	// 随单附带的止盈止损（仅支持的交易所生效，如Bybit）
+	TakeProfit *float64 `json:"take_profit,omitempty"`
+	StopLoss   *float64 `json:"stop_loss,omitempty"`
 }
 
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/backtest"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// onceDecider 第一次决策返回给定信号，之后观望
+type onceDecider struct {
+	signal *types.Signal
+	used   bool
+}
+
+func (d *onceDecider) Decide(ctx context.Context, marketData *types.MarketData) (*types.Signal, error) {
+	if d.used {
+		return nil, nil
+	}
+	d.used = true
+	return d.signal, nil
+}
+
+// buildCandles 按收盘价序列生成1m K线，并聚合出扫描所需的其他周期
+func buildCandles(t *testing.T, closes []float64) map[string][]types.OHLCV {
+	start := int64(19700) * 24 * time.Hour.Milliseconds()
+	oneMinute := make([]types.OHLCV, len(closes))
+	prev := closes[0]
+	for i, c := range closes {
+		high, low := c, c
+		if prev > high {
+			high = prev
+		}
+		if prev < low {
+			low = prev
+		}
+		oneMinute[i] = types.OHLCV{Open: prev, High: high, Low: low, Close: c, Volume: 1, Time: start + int64(i)*time.Minute.Milliseconds()}
+		prev = c
+	}
+
+	candles := map[string][]types.OHLCV{"1m": oneMinute}
+	for _, tf := range []string{"3m", "5m", "15m", "30m", "1h", "4h", "1d"} {
+		series, err := backtest.Resample(oneMinute, "1m", tf)
+		if err != nil {
+			t.Fatalf("Resample %s failed: %v", tf, err)
+		}
+		candles[tf] = series
+	}
+	return candles
+}
+
+func flatThen(n int, base float64, tail ...float64) []float64 {
+	closes := make([]float64, 0, n+len(tail))
+	for i := 0; i < n; i++ {
+		closes = append(closes, base)
+	}
+	return append(closes, tail...)
+}
+
+func testBacktestConfig() backtest.Config {
+	return backtest.Config{
+		InitialBalance:   1000,
+		DefaultNotional:  100,
+		TP1Ratio:         0.5,
+		DecisionInterval: 3 * time.Minute,
+	}
+}
+
+func TestResample(t *testing.T) {
+	candles := buildCandles(t, []float64{1, 2, 3, 4, 5, 6, 7})
+	threeMin := candles["3m"]
+	if len(threeMin) != 2 {
+		t.Fatalf("Expected 2 complete 3m candles, got %d", len(threeMin))
+	}
+	if threeMin[0].Open != 1 || threeMin[0].Close != 3 || threeMin[0].High != 3 || threeMin[0].Volume != 3 {
+		t.Errorf("Unexpected 3m candle: %+v", threeMin[0])
+	}
+}
+
+func TestBacktest_TakeProfitSplit(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	candles := buildCandles(t, flatThen(30, 100, 103, 106, 108, 111, 111))
+	decider := &onceDecider{signal: &types.Signal{
+		Symbol: "BTCUSDT", Action: "open_long", EntryPrice: 100,
+		StopLoss: 95, TakeProfit: 105, TakeProfit2: 110,
+	}}
+
+	result, err := backtest.NewBacktester(testBacktestConfig(), decider).Run(context.Background(), "BTCUSDT", candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(result.Trades) != 1 {
+		t.Fatalf("Expected 1 trade, got %d", len(result.Trades))
+	}
+	trade := result.Trades[0]
+	if len(trade.Exits) != 2 || trade.Exits[0].Reason != backtest.ExitTakeProfit1 || trade.Exits[1].Reason != backtest.ExitTakeProfit2 {
+		t.Fatalf("Expected TP1 then TP2 exits, got %+v", trade.Exits)
+	}
+	// 1个单位：一半在105止盈，一半在110止盈
+	if !almostEqual(trade.PnL, 7.5) {
+		t.Errorf("Expected PnL 7.5, got %f", trade.PnL)
+	}
+	if result.Stats.WinRate != 1 || !almostEqual(result.Stats.FinalEquity, 1007.5) {
+		t.Errorf("Unexpected stats: %+v", result.Stats)
+	}
+}
+
+func TestBacktest_StopLossShort(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	candles := buildCandles(t, flatThen(30, 100, 101, 103, 104))
+	decider := &onceDecider{signal: &types.Signal{
+		Symbol: "BTCUSDT", Action: "open_short", EntryPrice: 100,
+		StopLoss: 102, TakeProfit: 95,
+	}}
+
+	result, err := backtest.NewBacktester(testBacktestConfig(), decider).Run(context.Background(), "BTCUSDT", candles)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(result.Trades) != 1 {
+		t.Fatalf("Expected 1 trade, got %d", len(result.Trades))
+	}
+	trade := result.Trades[0]
+	if trade.Exits[0].Reason != backtest.ExitStopLoss || !almostEqual(trade.PnL, -2) {
+		t.Errorf("Expected stop loss at 102 for -2, got %+v", trade)
+	}
+	if result.Stats.Losses != 1 || result.Stats.MaxDrawdown <= 0 {
+		t.Errorf("Unexpected stats: %+v", result.Stats)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	trades := []backtest.Trade{{PnL: 30}, {PnL: -10}, {PnL: 20}, {PnL: -20}}
+	equity := []backtest.EquityPoint{
+		{Equity: 1030}, {Equity: 1020}, {Equity: 1040}, {Equity: 1020},
+	}
+
+	stats := backtest.ComputeStats(trades, equity, 1000, time.Hour)
+	if stats.WinRate != 0.5 {
+		t.Errorf("Expected win rate 0.5, got %f", stats.WinRate)
+	}
+	if !almostEqual(stats.ProfitFactor, 50.0/30.0) {
+		t.Errorf("Expected profit factor 1.667, got %f", stats.ProfitFactor)
+	}
+	if !almostEqual(stats.MaxDrawdown, 20) {
+		t.Errorf("Expected max drawdown 20, got %f", stats.MaxDrawdown)
+	}
+	if !almostEqual(stats.NetProfit, 20) || !almostEqual(stats.ReturnPct, 2) {
+		t.Errorf("Unexpected net profit/return: %+v", stats)
+	}
+}
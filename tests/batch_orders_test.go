@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBinanceExchange_PlaceBatchOrders(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("BINANCE_API_KEY", "test-key")
+	t.Setenv("BINANCE_SECRET_KEY", "test-secret")
+
+	var mu sync.Mutex
+	var batches [][]map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/time":
+			w.Write([]byte(`{"serverTime":` + strconv.FormatInt(time.Now().UnixMilli(), 10) + `}`))
+		case "/fapi/v1/exchangeInfo":
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","filters":[
+				{"filterType":"PRICE_FILTER","tickSize":"0.10","minPrice":"100","maxPrice":"1000000"},
+				{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001","maxQty":"1000"},
+				{"filterType":"MIN_NOTIONAL","notional":"5"}]}]}`))
+		case "/fapi/v1/batchOrders":
+			var batch []map[string]string
+			if err := json.Unmarshal([]byte(r.URL.Query().Get("batchOrders")), &batch); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			batches = append(batches, batch)
+			mu.Unlock()
+
+			w.Write([]byte(`[
+				{"orderId":101,"status":"NEW","executedQty":"0","avgPrice":"0"},
+				{"code":-2021,"msg":"Order would immediately trigger."}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BINANCE_FAPI_BASE_URL", server.URL)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	results, err := be.PlaceBatchOrders([]types.OrderRequest{
+		{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "STOP_MARKET", Quantity: 0.0128, StopPrice: floatPtr(49000.04), ReduceOnly: true},
+		{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "TAKE_PROFIT_MARKET", Quantity: 0.0064, StopPrice: floatPtr(51000), ReduceOnly: true},
+		{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "TAKE_PROFIT_MARKET", Quantity: 0.0004, StopPrice: floatPtr(52000), ReduceOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("PlaceBatchOrders failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	// 第一笔成功，按规则量化
+	if results[0].Err != nil || results[0].Order == nil || results[0].Order.ID != "101" {
+		t.Fatalf("Expected first order placed, got %+v", results[0])
+	}
+	if results[0].Order.Quantity != 0.012 || results[0].Order.StopPrice != 49000 {
+		t.Errorf("Expected quantized order, got qty=%v stop=%v", results[0].Order.Quantity, results[0].Order.StopPrice)
+	}
+	// 第二笔被交易所拒绝
+	if results[1].Err == nil || results[1].Order != nil {
+		t.Errorf("Expected second order rejected by exchange, got %+v", results[1])
+	}
+	// 第三笔低于minQty，本地拦截不发送
+	if !errors.Is(results[2].Err, exchange.ErrOrderFilter) {
+		t.Errorf("Expected third order rejected by filters, got %v", results[2].Err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("Expected one batch request with 2 orders, got %v", batches)
+	}
+	if got := batches[0][0]; got["type"] != "STOP_MARKET" || got["quantity"] != "0.012" || got["reduceOnly"] != "true" {
+		t.Errorf("Unexpected batch order params: %v", got)
+	}
+}
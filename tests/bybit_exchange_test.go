@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const bybitTestSecret = "bybit-test-secret"
+
+// bybitRequest 模拟服务器记录的请求
+type bybitRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// newBybitFakeServer 按 "METHOD path" 返回预置的result，并校验签名
+func newBybitFakeServer(t *testing.T, routes map[string]func(query string) string) (*httptest.Server, func() []bybitRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []bybitRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, bybitRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: string(body)})
+		mu.Unlock()
+
+		if sign := r.Header.Get("X-BAPI-SIGN"); sign != "" {
+			payload := r.URL.RawQuery
+			if r.Method != http.MethodGet {
+				payload = string(body)
+			}
+			mac := hmac.New(sha256.New, []byte(bybitTestSecret))
+			mac.Write([]byte(r.Header.Get("X-BAPI-TIMESTAMP") + r.Header.Get("X-BAPI-API-KEY") + r.Header.Get("X-BAPI-RECV-WINDOW") + payload))
+			if sign != hex.EncodeToString(mac.Sum(nil)) {
+				w.Write([]byte(`{"retCode":10004,"retMsg":"error sign!","result":{}}`))
+				return
+			}
+		}
+
+		handler, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		result := handler(r.URL.RawQuery)
+		if strings.HasPrefix(result, `{"retCode"`) {
+			w.Write([]byte(result))
+			return
+		}
+		w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":` + result + `,"time":1700000000000}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []bybitRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]bybitRequest(nil), requests...)
+	}
+}
+
+// bybitStatic 固定返回同一result
+func bybitStatic(result string) func(string) string {
+	return func(string) string { return result }
+}
+
+func TestBybitExchange_MarketData(t *testing.T) {
+	config.Load()
+
+	server, requests := newBybitFakeServer(t, map[string]func(string) string{
+		"GET /v5/market/kline": bybitStatic(`{"category":"linear","symbol":"BTCUSDT","list":[
+			["1700007200000","37040","37050","37030","37045.5","3.124","115700.1"],
+			["1700003600000","37020","37045","37010","37040","2.5","92575"],
+			["1700000000000","37000","37025","36990","37020","1.75","64785"]]}`),
+		"GET /v5/market/tickers":       bybitStatic(`{"category":"linear","list":[{"symbol":"BTCUSDT","lastPrice":"37045.5","markPrice":"37046.1","fundingRate":"0.0001235","nextFundingTime":"1700006400000"}]}`),
+		"GET /v5/market/open-interest": bybitStatic(`{"category":"linear","symbol":"BTCUSDT","list":[{"openInterest":"51234.567","timestamp":"1700007000000"}],"nextPageCursor":""}`),
+	})
+	bb := exchange.NewBybitExchange(server.URL, "", "")
+
+	candles, err := bb.GetOHLCV("BTC/USDT", "1h", 3)
+	if err != nil {
+		t.Fatalf("GetOHLCV failed: %v", err)
+	}
+	if len(candles) != 3 || candles[0].Time != 1700000000000 || candles[2].Time != 1700007200000 {
+		t.Fatalf("Expected ascending candles, got %+v", candles)
+	}
+	if candles[2].Close != 37045.5 || candles[2].Volume != 3.124 {
+		t.Errorf("Unexpected last candle: %+v", candles[2])
+	}
+	q := requests()[0].Query
+	if !strings.Contains(q, "interval=60") || !strings.Contains(q, "symbol=BTCUSDT") || !strings.Contains(q, "category=linear") {
+		t.Errorf("Unexpected kline query: %s", q)
+	}
+
+	price, err := bb.GetTickerPrice("BTCUSDT")
+	if err != nil || price != 37045.5 {
+		t.Errorf("GetTickerPrice = %f, %v", price, err)
+	}
+	rate, err := bb.GetFundingRate("BTCUSDT")
+	if err != nil || rate != 0.0001235 {
+		t.Errorf("GetFundingRate = %f, %v", rate, err)
+	}
+	oi, err := bb.GetOpenInterest("BTCUSDT")
+	if err != nil || oi != 51234.567 {
+		t.Errorf("GetOpenInterest = %f, %v", oi, err)
+	}
+
+	if _, err := bb.GetOHLCV("BTCUSDT", "7m", 10); err == nil {
+		t.Error("Expected unsupported timeframe error")
+	}
+}
+
+func TestBybitExchange_PlaceOrders(t *testing.T) {
+	useLiveTrading(t)
+
+	server, requests := newBybitFakeServer(t, map[string]func(string) string{
+		"POST /v5/order/create": bybitStatic(`{"orderId":"1321003749386327552","orderLinkId":""}`),
+		"POST /v5/order/cancel": bybitStatic(`{"orderId":"1321003749386327553","orderLinkId":""}`),
+	})
+	bb := exchange.NewBybitExchange(server.URL, "key", bybitTestSecret)
+
+	// 双向持仓开多，附带止盈止损
+	price, tp, sl := 37001.3, 38500.0, 36000.0
+	order, err := bb.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "BUY",
+		PositionSide: "LONG",
+		OrderType:    "LIMIT",
+		Quantity:     0.015,
+		Price:        &price,
+		TimeInForce:  "GTX",
+		TakeProfit:   &tp,
+		StopLoss:     &sl,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if order.ID != "1321003749386327552" || order.Symbol != "BTCUSDT" || order.Quantity != 0.015 {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+
+	var body map[string]interface{}
+	reqs := requests()
+	json.Unmarshal([]byte(reqs[len(reqs)-1].Body), &body)
+	if body["side"] != "Buy" || body["orderType"] != "Limit" || body["qty"] != "0.015" || body["price"] != "37001.3" || body["timeInForce"] != "PostOnly" {
+		t.Errorf("Unexpected order body: %v", body)
+	}
+	if body["positionIdx"] != float64(1) || body["takeProfit"] != "38500" || body["stopLoss"] != "36000" || body["tpslMode"] != "Full" {
+		t.Errorf("Unexpected hedge/tpsl fields: %v", body)
+	}
+
+	// 平多止损：卖出，价格下跌触发
+	stop := 36000.0
+	if _, err := bb.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "SELL",
+		PositionSide: "LONG",
+		OrderType:    "STOP_MARKET",
+		Quantity:     0.015,
+		StopPrice:    &stop,
+		ReduceOnly:   true,
+	}); err != nil {
+		t.Fatalf("Place stop order failed: %v", err)
+	}
+	reqs = requests()
+	body = nil
+	json.Unmarshal([]byte(reqs[len(reqs)-1].Body), &body)
+	if body["orderType"] != "Market" || body["triggerPrice"] != "36000" || body["triggerDirection"] != float64(2) || body["reduceOnly"] != true {
+		t.Errorf("Unexpected stop order body: %v", body)
+	}
+	if _, ok := body["tpslMode"]; ok {
+		t.Errorf("Expected no tpsl fields on stop order: %v", body)
+	}
+
+	if err := bb.CancelOrder("BTCUSDT", "1321003749386327553"); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	reqs = requests()
+	if last := reqs[len(reqs)-1]; last.Path != "/v5/order/cancel" || !strings.Contains(last.Body, `"orderId":"1321003749386327553"`) {
+		t.Errorf("Unexpected cancel request: %+v", last)
+	}
+}
+
+func TestBybitExchange_PositionsAndOpenOrders(t *testing.T) {
+	useLiveTrading(t)
+
+	server, requests := newBybitFakeServer(t, map[string]func(string) string{
+		"GET /v5/position/list": bybitStatic(`{"category":"linear","list":[
+			{"symbol":"BTCUSDT","positionIdx":1,"side":"Buy","size":"0.02","avgPrice":"37000","markPrice":"37100","unrealisedPnl":"2","leverage":"10"},
+			{"symbol":"BTCUSDT","positionIdx":2,"side":"Sell","size":"0.01","avgPrice":"37200","markPrice":"37100","unrealisedPnl":"1","leverage":"10"},
+			{"symbol":"ETHUSDT","positionIdx":1,"side":"","size":"0","avgPrice":"0","markPrice":"2000","unrealisedPnl":"0","leverage":"10"}],"nextPageCursor":""}`),
+		"GET /v5/order/realtime": func(query string) string {
+			// 第二页通过cursor获取
+			if strings.Contains(query, "cursor=page2") {
+				return `{"category":"linear","list":[
+					{"orderId":"3","symbol":"BTCUSDT","side":"Buy","orderType":"Market","stopOrderType":"Stop","triggerDirection":2,"triggerPrice":"35000","qty":"0.01","price":"0","cumExecQty":"0","avgPrice":"","orderStatus":"Untriggered","positionIdx":2,"reduceOnly":true,"createdTime":"1700000002000"}],"nextPageCursor":""}`
+			}
+			return `{"category":"linear","list":[
+				{"orderId":"1","symbol":"BTCUSDT","side":"Buy","orderType":"Limit","stopOrderType":"","qty":"0.02","price":"36500","cumExecQty":"0.005","avgPrice":"36500","orderStatus":"PartiallyFilled","positionIdx":1,"reduceOnly":false,"createdTime":"1700000000000"},
+				{"orderId":"2","symbol":"BTCUSDT","side":"Sell","orderType":"Market","stopOrderType":"StopLoss","triggerPrice":"36000","qty":"0.02","price":"0","cumExecQty":"0","avgPrice":"","orderStatus":"Untriggered","positionIdx":1,"reduceOnly":true,"createdTime":"1700000001000"}],"nextPageCursor":"page2"}`
+		},
+		"GET /v5/account/wallet-balance": bybitStatic(`{"list":[{"accountType":"UNIFIED","totalEquity":"1050","coin":[{"coin":"USDT","equity":"1000.5","walletBalance":"998.5","totalPositionIM":"74.5","totalOrderIM":"26"}]}]}`),
+	})
+	bb := exchange.NewBybitExchange(server.URL, "key", bybitTestSecret)
+
+	positions, err := bb.GetPositions()
+	if err != nil {
+		t.Fatalf("GetPositions failed: %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("Expected 2 hedge positions, got %d", len(positions))
+	}
+	if positions[0].Side != "LONG" || positions[0].Size != 0.02 || positions[0].Leverage != 10 {
+		t.Errorf("Unexpected long position: %+v", positions[0])
+	}
+	if positions[1].Side != "SHORT" || positions[1].Size != 0.01 || positions[1].EntryPrice != 37200 {
+		t.Errorf("Unexpected short position: %+v", positions[1])
+	}
+
+	orders, err := bb.GetOpenOrders("")
+	if err != nil {
+		t.Fatalf("GetOpenOrders failed: %v", err)
+	}
+	if len(orders) != 3 {
+		t.Fatalf("Expected 3 open orders across pages, got %d", len(orders))
+	}
+	if orders[0].OrderType != "LIMIT" || orders[0].Status != "PARTIALLY_FILLED" || orders[0].FilledQty != 0.005 || orders[0].PositionSide != "LONG" {
+		t.Errorf("Unexpected limit order: %+v", orders[0])
+	}
+	if orders[1].OrderType != "STOP_MARKET" || orders[1].Status != "NEW" || orders[1].StopPrice != 36000 || !orders[1].ReduceOnly {
+		t.Errorf("Unexpected stop loss order: %+v", orders[1])
+	}
+	// 买入平空、下跌触发的普通条件单视为止盈
+	if orders[2].OrderType != "TAKE_PROFIT_MARKET" || orders[2].PositionSide != "SHORT" {
+		t.Errorf("Unexpected conditional order: %+v", orders[2])
+	}
+	for _, req := range requests() {
+		if req.Path == "/v5/order/realtime" && !strings.Contains(req.Query, "settleCoin=USDT") {
+			t.Errorf("Expected settleCoin for all-symbol query: %s", req.Query)
+		}
+	}
+
+	balance, err := bb.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance["total"] != 1000.5 || !almostEqual(balance["used"], 100.5) || !almostEqual(balance["free"], 900) {
+		t.Errorf("Unexpected balance: %v", balance)
+	}
+}
+
+func TestBybitExchange_ErrorResponse(t *testing.T) {
+	useLiveTrading(t)
+
+	server, _ := newBybitFakeServer(t, map[string]func(string) string{
+		"POST /v5/order/create": bybitStatic(`{"retCode":110007,"retMsg":"ab not enough for new order","result":{}}`),
+	})
+
+	// 签名错误
+	bad := exchange.NewBybitExchange(server.URL, "key", "wrong-secret")
+	if _, err := bad.GetBalance(); err == nil || !strings.Contains(err.Error(), "10004") {
+		t.Errorf("Expected sign error, got %v", err)
+	}
+
+	bb := exchange.NewBybitExchange(server.URL, "key", bybitTestSecret)
+	_, err := bb.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: 1})
+	if err == nil || !strings.Contains(err.Error(), "bybit error 110007") {
+		t.Errorf("Expected bybit error 110007, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestClientOrderID_Deterministic(t *testing.T) {
+	entry := execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleEntry)
+	if entry != execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleEntry) {
+		t.Error("Expected same signal and role to produce the same ID")
+	}
+
+	seen := map[string]bool{entry: true}
+	for _, id := range []string{
+		execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleSL),
+		execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleTP1),
+		execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleTP2),
+		execution.ClientOrderID("BTCUSDT_sig_2", execution.OrderRoleEntry),
+	} {
+		if seen[id] {
+			t.Errorf("Duplicate client order ID %s", id)
+		}
+		seen[id] = true
+	}
+
+	// Binance/OKX/Bybit共同允许的格式
+	valid := regexp.MustCompile(`^[A-Za-z0-9]{1,32}$`)
+	for id := range seen {
+		if !valid.MatchString(id) {
+			t.Errorf("Client order ID %q not accepted by all exchanges", id)
+		}
+	}
+
+	if id := execution.ClientOrderID("", execution.OrderRoleEntry); id != "" {
+		t.Errorf("Expected empty ID without signal ID, got %q", id)
+	}
+}
+
+func TestBinanceExchange_PlaceOrderRecoversByClientOrderID(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("BINANCE_API_KEY", "test-key")
+	t.Setenv("BINANCE_SECRET_KEY", "test-secret")
+
+	var mu sync.Mutex
+	placeCalls := 0
+	var sentClientIDs []string
+	var lookups []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/fapi/v1/time":
+			w.Write([]byte(`{"serverTime":` + strconv.FormatInt(time.Now().UnixMilli(), 10) + `}`))
+		case r.URL.Path == "/fapi/v1/exchangeInfo":
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","filters":[
+				{"filterType":"PRICE_FILTER","tickSize":"0.10"},
+				{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001"}]}]}`))
+		case r.URL.Path == "/fapi/v1/order" && r.Method == http.MethodPost:
+			placeCalls++
+			sentClientIDs = append(sentClientIDs, r.URL.Query().Get("newClientOrderId"))
+			if placeCalls == 1 {
+				// 交易所已受理但响应失败
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"code":-1001,"msg":"Internal error; unable to process your request. Please try again."}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-4116,"msg":"ClientOrderId is duplicated."}`))
+		case r.URL.Path == "/fapi/v1/order" && r.Method == http.MethodGet:
+			clientID := r.URL.Query().Get("origClientOrderId")
+			lookups = append(lookups, clientID)
+			w.Write([]byte(`{"orderId":777,"clientOrderId":"` + clientID + `","status":"NEW","side":"BUY","positionSide":"LONG",
+				"type":"LIMIT","origQty":"0.01","price":"50000","executedQty":"0","time":` + strconv.FormatInt(time.Now().UnixMilli(), 10) + `}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BINANCE_FAPI_BASE_URL", server.URL)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	clientID := execution.ClientOrderID("BTCUSDT_sig_1", execution.OrderRoleEntry)
+	req := types.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          "BUY",
+		PositionSide:  "LONG",
+		OrderType:     "LIMIT",
+		Quantity:      0.01,
+		Price:         floatPtr(50000),
+		ClientOrderID: clientID,
+	}
+
+	// 5xx：结果不确定，按客户端订单ID找回
+	order, err := be.PlaceOrder(req)
+	if err != nil {
+		t.Fatalf("Expected order recovered after 5xx, got %v", err)
+	}
+	if order.ID != "777" || order.ClientOrderID != clientID {
+		t.Errorf("Unexpected recovered order: %+v", order)
+	}
+
+	// 重试：客户端订单ID重复，返回已存在的订单
+	order, err = be.PlaceOrder(req)
+	if err != nil || order.ID != "777" {
+		t.Fatalf("Expected duplicate submission to return existing order, got %+v, %v", order, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range append(sentClientIDs, lookups...) {
+		if id != clientID {
+			t.Errorf("Expected client order ID %s, got %s", clientID, id)
+		}
+	}
+	if placeCalls != 2 || len(lookups) != 2 {
+		t.Errorf("Expected 2 submissions and 2 lookups, got %d and %d", placeCalls, len(lookups))
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBinanceExchange_GetOHLCVTakerBuyVolume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// [开盘时间, 开, 高, 低, 收, 成交量, 收盘时间, 成交额, 笔数, 主动买入量, 主动买入额, 忽略]
+		w.Write([]byte(`[
+			[1700000000000,"100","101","99","100.5","10",1700000059999,"1000",50,"7","700","0"],
+			[1700000060000,"100.5","101","99","100.8","4",1700000119999,"400",20,"1","100","0"]]`))
+	}))
+	defer server.Close()
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	candles, err := be.GetOHLCV("BTCUSDT", "1m", 2)
+	if err != nil {
+		t.Fatalf("GetOHLCV failed: %v", err)
+	}
+	if len(candles) != 2 || candles[0].TakerBuyVolume != 7 || candles[1].TakerBuyVolume != 1 {
+		t.Errorf("Expected taker buy volume 7 and 1, got %+v", candles)
+	}
+}
+
+func TestBuildMarketData_CVDFromTakerVolume(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 两根阳线，但主动卖出占优：旧算法得+14，真实订单流为(7-3)+(1-3)=+2
+	taker := []types.OHLCV{
+		{Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 10, TakerBuyVolume: 7, Time: 0},
+		{Open: 100.5, High: 101, Low: 99, Close: 100.8, Volume: 4, TakerBuyVolume: 1, Time: 60000},
+	}
+	// 不带主动买入量的交易所（OKX/Bybit）退化为按涨跌加符号
+	plain := []types.OHLCV{
+		{Open: 100, High: 101, Low: 99, Close: 100.5, Volume: 10, Time: 0},
+		{Open: 100.5, High: 101, Low: 99, Close: 100.2, Volume: 4, Time: 60000},
+	}
+
+	md, err := scanner.BuildMarketData(scanner.MarketInputs{
+		Symbol: "BTCUSDT",
+		OHLCV: map[string][]types.OHLCV{
+			"1m":  taker,
+			"3m":  plain,
+			"15m": taker,
+			"1h":  taker,
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildMarketData failed: %v", err)
+	}
+
+	if !almostEqual(md.CVD, 2) {
+		t.Errorf("Expected 1h CVD 2, got %v", md.CVD)
+	}
+	if !almostEqual(md.CVDByTimeframe["15m"], 2) || !almostEqual(md.CVDByTimeframe["3m"], 6) {
+		t.Errorf("Expected CVD 15m=2 3m=6, got %v", md.CVDByTimeframe)
+	}
+}
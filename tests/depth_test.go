@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/internal/strategies"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBuildDepthMetrics(t *testing.T) {
+	book := &types.OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   []types.OrderBookLevel{{Price: 100, Quantity: 1}, {Price: 99.9, Quantity: 2}, {Price: 99, Quantity: 10}},
+		Asks:   []types.OrderBookLevel{{Price: 100.1, Quantity: 0.5}, {Price: 100.2, Quantity: 2}, {Price: 101, Quantity: 10}},
+	}
+
+	m := scanner.BuildDepthMetrics(book, 2, 0.005, 200)
+	if m == nil {
+		t.Fatal("Expected depth metrics")
+	}
+	if m.BestBid != 100 || m.BestAsk != 100.1 {
+		t.Errorf("Expected best bid/ask 100/100.1, got %v/%v", m.BestBid, m.BestAsk)
+	}
+	mid := 100.05
+	if !almostEqual(m.SpreadBps, 0.1/mid*10000) {
+		t.Errorf("Expected spread ~9.995bps, got %v", m.SpreadBps)
+	}
+	// 前2档：买3，卖2.5
+	if !almostEqual(m.Imbalance, 0.5/5.5) {
+		t.Errorf("Expected imbalance %v, got %v", 0.5/5.5, m.Imbalance)
+	}
+	// ±0.5%内不含99和101两档
+	if !almostEqual(m.BidDepthUSDT, 299.8) || !almostEqual(m.AskDepthUSDT, 250.45) {
+		t.Errorf("Expected band depth 299.8/250.45, got %v/%v", m.BidDepthUSDT, m.AskDepthUSDT)
+	}
+	// 买入吃掉卖1全部和卖2部分，卖出吃掉买1全部和买2部分
+	buyAvg := 200 / (0.5 + (200-50.05)/100.2)
+	sellAvg := 200 / (1 + (200-100)/99.9)
+	if !almostEqual(m.BuySlippageBps, (buyAvg-mid)/mid*10000) || !almostEqual(m.SellSlippageBps, (mid-sellAvg)/mid*10000) {
+		t.Errorf("Expected slippage ~12.489/9.9975bps, got %v/%v", m.BuySlippageBps, m.SellSlippageBps)
+	}
+	if m.Thin {
+		t.Error("Expected book deep enough for 200 USDT")
+	}
+
+	// 名义价值超过可见深度
+	if m := scanner.BuildDepthMetrics(book, 2, 0.005, 100000); m == nil || !m.Thin {
+		t.Errorf("Expected thin book for 100000 USDT, got %+v", m)
+	}
+
+	// 单侧为空
+	if m := scanner.BuildDepthMetrics(&types.OrderBook{Bids: book.Bids}, 2, 0.005, 200); m != nil {
+		t.Errorf("Expected nil metrics for one-sided book, got %+v", m)
+	}
+}
+
+func TestCheckLiquidity(t *testing.T) {
+	data := &types.MarketData{Symbol: "BTCUSDT", Depth: &types.DepthMetrics{BuySlippageBps: 5, SellSlippageBps: 30}}
+	if ok, reason, _ := strategies.CheckLiquidity(data, 20); ok || reason != "预估滑点过大" {
+		t.Errorf("Expected slippage rejection, got %v %q", ok, reason)
+	}
+	if ok, _, _ := strategies.CheckLiquidity(data, 50); !ok {
+		t.Error("Expected slippage within limit to pass")
+	}
+	if ok, _, _ := strategies.CheckLiquidity(data, 0); !ok {
+		t.Error("Expected no check without slippage limit")
+	}
+	data.Depth.Thin = true
+	if ok, reason, _ := strategies.CheckLiquidity(data, 50); ok || reason != "盘口深度不足" {
+		t.Errorf("Expected thin book rejection, got %v %q", ok, reason)
+	}
+	if ok, _, _ := strategies.CheckLiquidity(&types.MarketData{Symbol: "BTCUSDT"}, 20); !ok {
+		t.Error("Expected no check without depth data")
+	}
+}
+
+func TestBinanceExchange_GetOrderBook(t *testing.T) {
+	var gotLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/depth" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gotLimit = r.URL.Query().Get("limit")
+		w.Write([]byte(`{"lastUpdateId":1,"E":1700000000100,"T":1700000000000,
+			"bids":[["50000.0","1.5"],["49999.9","2"]],
+			"asks":[["50000.1","0.8"],["50000.2","3"]]}`))
+	}))
+	defer server.Close()
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	book, err := be.GetOrderBook("BTC/USDT", 30)
+	if err != nil {
+		t.Fatalf("GetOrderBook failed: %v", err)
+	}
+
+	// limit取不小于请求值的合法档位
+	if gotLimit != "50" {
+		t.Errorf("Expected limit 50, got %s", gotLimit)
+	}
+	if book.Symbol != "BTCUSDT" || book.Timestamp != 1700000000000 {
+		t.Errorf("Unexpected book header: %+v", book)
+	}
+	if len(book.Bids) != 2 || len(book.Asks) != 2 {
+		t.Fatalf("Expected 2 levels per side, got %d/%d", len(book.Bids), len(book.Asks))
+	}
+	if book.Bids[0] != (types.OrderBookLevel{Price: 50000, Quantity: 1.5}) ||
+		book.Asks[1] != (types.OrderBookLevel{Price: 50000.2, Quantity: 3}) {
+		t.Errorf("Unexpected levels: bids=%v asks=%v", book.Bids, book.Asks)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/ai"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBinanceExchange_GetDerivativesData(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+
+		if r.URL.Query().Get("period") != "5m" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/futures/data/globalLongShortAccountRatio":
+			w.Write([]byte(`[{"symbol":"BTCUSDT","longShortRatio":"1.5","longAccount":"0.6","shortAccount":"0.4","timestamp":1700000000000},
+				{"symbol":"BTCUSDT","longShortRatio":"1.8","longAccount":"0.6429","shortAccount":"0.3571","timestamp":1700000300000}]`))
+		case "/futures/data/topLongShortPositionRatio":
+			w.Write([]byte(`[{"symbol":"BTCUSDT","longShortRatio":"0.9","longAccount":"0.4737","shortAccount":"0.5263","timestamp":1700000300000}]`))
+		case "/futures/data/takerlongshortRatio":
+			// 模拟接口报错：其余数据仍然返回
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+		case "/futures/data/openInterestHist":
+			w.Write([]byte(`[{"symbol":"BTCUSDT","sumOpenInterest":"1000","sumOpenInterestValue":"50000000","timestamp":1700000000000},
+				{"symbol":"BTCUSDT","sumOpenInterest":"1100","sumOpenInterestValue":"55000000","timestamp":1700000300000}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	data, err := be.GetDerivativesData("BTCUSDT", "5m", 2)
+	if err != nil {
+		t.Fatalf("GetDerivativesData failed: %v", err)
+	}
+	if data.LongShortAccountRatio != 1.8 || data.TopTraderPositionRatio != 0.9 || data.TakerBuySellRatio != 0 {
+		t.Errorf("Unexpected ratios: %+v", data)
+	}
+	if data.OpenInterestValue != 55000000 || !almostEqual(data.OpenInterestChangePct, 10) || data.Timestamp != 1700000300000 {
+		t.Errorf("Unexpected open interest: %+v", data)
+	}
+
+	// 同一周期内读取缓存，失败的接口下次重新请求
+	if _, err := be.GetDerivativesData("BTCUSDT", "5m", 2); err != nil {
+		t.Fatalf("GetDerivativesData failed: %v", err)
+	}
+	mu.Lock()
+	if calls["/futures/data/globalLongShortAccountRatio"] != 1 || calls["/futures/data/openInterestHist"] != 1 {
+		t.Errorf("Expected cached futures data within period, got %v", calls)
+	}
+	mu.Unlock()
+
+	// 旧接口使用正确的路径
+	rows, err := be.GetOpenInterestHistChange("BTCUSDT", "5m", 2)
+	if err != nil || len(rows) != 2 {
+		t.Errorf("Expected 2 open interest hist rows, got %v, %v", rows, err)
+	}
+}
+
+func TestAITrader_FormatMarketDataIncludesDerivatives(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("AI_TRADER_SYSTEM_PROMPT", "根据衍生品与资金数据做决策")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	trader := &ai.AITrader{}
+	raw, err := trader.FormatMarketData(&types.MarketData{
+		Symbol:      "BTCUSDT",
+		FundingRate: 0.0001,
+		Derivatives: &types.DerivativesData{Period: "5m", LongShortAccountRatio: 1.8},
+	})
+	if err != nil {
+		t.Fatalf("FormatMarketData failed: %v", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	derivatives, ok := data["derivatives"].(map[string]interface{})
+	if !ok || derivatives["long_short_account_ratio"] != 1.8 {
+		t.Errorf("Expected derivatives block in market data, got %v", data)
+	}
+	if _, ok := data["ema_20"]; ok {
+		t.Error("Expected technical indicators to be filtered out")
+	}
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+)
+
+func TestEntryExpiryReason(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	long := &execution.PendingEntry{Side: "BUY", PositionSide: "LONG", EntryPrice: 100, PlacedAt: now.Unix() - 60}
+	short := &execution.PendingEntry{Side: "SELL", PositionSide: "SHORT", EntryPrice: 100, PlacedAt: now.Unix() - 60}
+
+	tests := []struct {
+		name     string
+		entry    *execution.PendingEntry
+		price    float64
+		timeout  int
+		drift    float64
+		expected string
+	}{
+		{"within timeout and drift", long, 100.3, 120, 0.005, ""},
+		{"timeout", long, 100, 60, 0.005, execution.EntryExpiryTimeout},
+		{"timeout disabled", long, 100, 0, 0.005, ""},
+		{"long price ran away", long, 100.6, 120, 0.005, execution.EntryExpiryDrift},
+		{"long price moved toward entry", long, 99, 120, 0.005, ""},
+		{"short price ran away", short, 99.4, 120, 0.005, execution.EntryExpiryDrift},
+		{"short price moved toward entry", short, 101, 120, 0.005, ""},
+		{"drift disabled", long, 110, 120, 0, ""},
+		{"price unavailable", long, 0, 120, 0.005, ""},
+		{"nil entry", nil, 100, 1, 0.005, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := execution.EntryExpiryReason(tt.entry, tt.price, now, tt.timeout, tt.drift)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+)
+
+func TestBinanceExchange_ContextCancelAbortsRequest(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("BINANCE_API_KEY", "test-key")
+	t.Setenv("BINANCE_SECRET_KEY", "test-secret")
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 模拟交易所无响应，直到请求被取消
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	t.Setenv("BINANCE_FAPI_BASE_URL", server.URL)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 30*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := be.GetPositionsContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected request aborted on cancel, took %v", elapsed)
+	}
+
+	// 公共接口同样随ctx中止
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+	if _, err := be.GetTickerPriceContext(ctx2, "BTCUSDT"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBinanceExchange_ErrorClassification(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("BINANCE_API_KEY", "test-key")
+	t.Setenv("BINANCE_SECRET_KEY", "test-secret")
+
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"insufficient": {http.StatusBadRequest, `{"code":-2019,"msg":"Margin is insufficient."}`},
+		"precision":    {http.StatusBadRequest, `{"code":-1111,"msg":"Precision is over the maximum defined for this asset."}`},
+		"badkey":       {http.StatusUnauthorized, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`},
+		"busy":         {http.StatusServiceUnavailable, `{"code":-1008,"msg":"Server is currently overloaded with other requests."}`},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/exchangeInfo":
+			w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","filters":[
+				{"filterType":"PRICE_FILTER","tickSize":"0.10"},
+				{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001"}]}]}`))
+		case "/fapi/v1/order":
+			if r.Method == http.MethodGet {
+				// 结果不确定时按客户端订单ID查询：订单不存在
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-2013,"msg":"Order does not exist."}`))
+				return
+			}
+			resp := responses[r.URL.Query().Get("newClientOrderId")]
+			w.WriteHeader(resp.status)
+			w.Write([]byte(resp.body))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BINANCE_FAPI_BASE_URL", server.URL)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	cases := []struct {
+		clientID string
+		code     int
+		class    exchange.ErrorClass
+	}{
+		{"insufficient", -2019, exchange.ErrorRejected},
+		{"precision", -1111, exchange.ErrorRejected},
+		{"badkey", -2015, exchange.ErrorFatal},
+		{"busy", -1008, exchange.ErrorRetryable},
+	}
+	for _, c := range cases {
+		_, err := be.PlaceOrder(types.OrderRequest{
+			Symbol:        "BTCUSDT",
+			Side:          "BUY",
+			OrderType:     "LIMIT",
+			Quantity:      0.01,
+			Price:         floatPtr(50000),
+			ClientOrderID: c.clientID,
+		})
+
+		var apiErr *exchange.APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: expected APIError, got %v", c.clientID, err)
+			continue
+		}
+		if apiErr.Code != c.code || apiErr.Class != c.class || exchange.ClassifyError(err) != c.class {
+			t.Errorf("%s: expected code %d class %s, got %+v", c.clientID, c.code, c.class, apiErr)
+		}
+	}
+
+	// 非交易所错误
+	if class := exchange.ClassifyError(fmt.Errorf("wrapped: %w", exchange.ErrOrderFilter)); class != exchange.ErrorRejected {
+		t.Errorf("Expected filter error to be rejected, got %s", class)
+	}
+	if !exchange.IsRetryable(fmt.Errorf("request failed: %w", context.DeadlineExceeded)) {
+		t.Error("Expected timeout to be retryable")
+	}
+	if class := exchange.ClassifyError(context.Canceled); class != exchange.ErrorCanceled {
+		t.Errorf("Expected cancellation to be canceled, got %s", class)
+	}
+	urlErr := &url.Error{Op: "Post", URL: "https://fapi.binance.com/fapi/v1/order", Err: context.Canceled}
+	if class := exchange.ClassifyError(fmt.Errorf("request failed: %w", urlErr)); class != exchange.ErrorCanceled {
+		t.Errorf("Expected canceled HTTP request to be canceled, got %s", class)
+	}
+	if exchange.IsRetryable(errors.New("API keys required")) {
+		t.Error("Expected unknown error not to be retryable")
+	}
+}
+
+func TestHTTPClient_FetchJSONRetriesTransientErrors(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("BINANCE_HTTP_MAX_RETRIES", "2")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/fapi/v1/time":
+			// 第一次返回503，重试后成功
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"code":-1001,"msg":"Internal error; unable to process your request."}`))
+				return
+			}
+			w.Write([]byte(`{"serverTime":1700000000000}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+		}
+	}))
+	defer server.Close()
+
+	client := exchange.NewHTTPClient(server.URL, 5*time.Second)
+	if _, err := client.FetchJSON(context.Background(), "/fapi/v1/time", nil); err != nil {
+		t.Fatalf("Expected request to succeed after retry, got %v", err)
+	}
+
+	// 业务错误不重试
+	_, err := client.FetchJSON(context.Background(), "/fapi/v1/klines", map[string]string{"symbol": "FOO"})
+	var apiErr *exchange.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != -1121 || apiErr.Class != exchange.ErrorRejected {
+		t.Errorf("Expected rejected APIError, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["/fapi/v1/time"] != 2 || calls["/fapi/v1/klines"] != 1 {
+		t.Errorf("Expected 2 time calls and 1 klines call, got %v", calls)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// useExchange 切换EXCHANGE配置，测试结束后恢复
+func useExchange(t *testing.T, name string) {
+	t.Helper()
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("EXCHANGE", name)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+}
+
+func TestExchangeRegistry_New(t *testing.T) {
+	config.Load()
+
+	ex, err := exchange.New("okx")
+	if err != nil {
+		t.Fatalf("New(okx) failed: %v", err)
+	}
+	if _, ok := ex.(*exchange.OKXExchange); !ok {
+		t.Errorf("Expected *OKXExchange, got %T", ex)
+	}
+
+	ex, err = exchange.New(" Bybit ")
+	if err != nil {
+		t.Fatalf("New(Bybit) failed: %v", err)
+	}
+	if _, ok := ex.(*exchange.BybitExchange); !ok {
+		t.Errorf("Expected *BybitExchange, got %T", ex)
+	}
+
+	if _, err := exchange.New("unknown"); err == nil {
+		t.Error("Expected error for unknown exchange")
+	}
+
+	names := map[string]bool{}
+	for _, name := range exchange.Names() {
+		names[name] = true
+	}
+	for _, name := range []string{"binance", "binance-testnet", "paper", "okx", "bybit"} {
+		if !names[name] {
+			t.Errorf("Expected %s to be registered, got %v", name, exchange.Names())
+		}
+	}
+}
+
+func TestExchangeRegistry_SelectedByConfig(t *testing.T) {
+	useExchange(t, "bybit")
+	ex, err := exchange.GetExchange()
+	if err != nil {
+		t.Fatalf("GetExchange failed: %v", err)
+	}
+	if _, ok := ex.(*exchange.BybitExchange); !ok {
+		t.Errorf("Expected Bybit exchange, got %T", ex)
+	}
+	if exchange.BinanceAccount() || exchange.BinanceMarketData() {
+		t.Error("Expected Binance streams to be disabled for bybit")
+	}
+
+	// 切换配置后重新创建
+	fake := &stubMarket{candles: []types.OHLCV{{Close: 1}}}
+	exchange.Register("fake", func() types.Exchange { return fake })
+	useExchange(t, "fake")
+	if ex, err := exchange.GetExchange(); err != nil || ex != fake {
+		t.Errorf("Expected registered fake exchange, got %T (err=%v)", ex, err)
+	}
+
+	// 未注册的名称返回错误，不回退到实盘交易所
+	useExchange(t, "unknown")
+	if ex, err := exchange.GetExchange(); err == nil {
+		t.Errorf("Expected error for unknown exchange, got %T", ex)
+	}
+}
+
+func TestExchangeRegistry_OptionalProviders(t *testing.T) {
+	var ex types.Exchange = (*exchange.BinanceExchange)(nil)
+	if _, ok := ex.(exchange.DerivativesProvider); !ok {
+		t.Error("Expected Binance to provide derivatives data")
+	}
+
+	// 模拟交易所委托行情来源，行情来源不支持时返回错误
+	pe := exchange.NewPaperExchange(&stubMarket{}, nil, 1000.0, 0)
+	if _, err := pe.GetTicker24h("BTCUSDT"); err == nil {
+		t.Error("Expected error when market source has no 24h ticker")
+	}
+	if _, err := pe.GetMarketInfo("BTCUSDT"); err == nil {
+		t.Error("Expected error when market source has no market info")
+	}
+
+	// OKX不提供衍生品情绪数据，扫描器跳过
+	okx, err := exchange.New("okx")
+	if err != nil {
+		t.Fatalf("New(okx) failed: %v", err)
+	}
+	if _, ok := okx.(exchange.DerivativesProvider); ok {
+		t.Error("Expected OKX to not provide derivatives data")
+	}
+}
+
+func TestConfigLoad_BinanceTestnetURLs(t *testing.T) {
+	t.Setenv("BINANCE_FAPI_BASE_URL", "")
+	t.Setenv("BINANCE_WS_BASE_URL", "")
+	useExchange(t, "binance-testnet")
+
+	cfg := config.Get()
+	if !cfg.BinanceTestnet || cfg.BinanceFAPIBaseURL != "https://testnet.binancefuture.com" || cfg.BinanceWSBaseURL != "wss://fstream.binancefuture.com" {
+		t.Errorf("Unexpected testnet config: testnet=%v fapi=%s ws=%s", cfg.BinanceTestnet, cfg.BinanceFAPIBaseURL, cfg.BinanceWSBaseURL)
+	}
+	if !exchange.BinanceAccount() || !exchange.BinanceMarketData() {
+		t.Error("Expected Binance streams to be enabled for binance-testnet")
+	}
+}
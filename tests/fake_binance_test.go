@@ -0,0 +1,487 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange/binancetest"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// newFakeBinance 启动模拟服务器并按其地址和密钥加载实盘配置
+func newFakeBinance(t *testing.T) (*binancetest.Server, *exchange.BinanceExchange) {
+	t.Helper()
+	srv := binancetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSymbol("BTCUSDT", 50000)
+
+	t.Cleanup(func() { config.Load() })
+	for k, v := range srv.Env() {
+		t.Setenv(k, v)
+	}
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return srv, exchange.NewBinanceExchange(exchange.NewHTTPClient(srv.URL, 5*time.Second))
+}
+
+func TestFakeBinance_OrderLifecycle(t *testing.T) {
+	srv, be := newFakeBinance(t)
+
+	price, err := be.GetTickerPrice("BTCUSDT")
+	if err != nil || price != 50000 {
+		t.Fatalf("Expected ticker 50000, got %v, %v", price, err)
+	}
+	candles, err := be.GetOHLCV("BTCUSDT", "15m", 50)
+	if err != nil || len(candles) != 50 || candles[len(candles)-1].Close != 50000 {
+		t.Fatalf("Expected 50 candles closing at 50000, got %d, %v", len(candles), err)
+	}
+
+	entry, err := be.PlaceOrder(types.OrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          "BUY",
+		PositionSide:  "LONG",
+		OrderType:     "MARKET",
+		Quantity:      0.01,
+		ClientOrderID: "entry-1",
+	})
+	if err != nil || entry.Status != "FILLED" || entry.AvgPrice != 50000 {
+		t.Fatalf("Expected filled market order, got %+v, %v", entry, err)
+	}
+
+	stop := 49000.0
+	sl, err := be.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "SELL",
+		PositionSide: "LONG",
+		OrderType:    "STOP_MARKET",
+		Quantity:     0.01,
+		StopPrice:    &stop,
+	})
+	if err != nil || sl.Status != "NEW" {
+		t.Fatalf("Expected resting stop order, got %+v, %v", sl, err)
+	}
+
+	positions, err := be.GetPositions()
+	if err != nil || len(positions) != 1 || positions[0].Side != "LONG" || positions[0].Size != 0.01 {
+		t.Fatalf("Expected 0.01 LONG position, got %v, %v", positions, err)
+	}
+	open, err := be.GetOpenOrders("BTCUSDT")
+	if err != nil || len(open) != 1 || open[0].ID != sl.ID {
+		t.Fatalf("Expected stop order open, got %v, %v", open, err)
+	}
+
+	// 价格跌破止损价触发平仓，亏损计入余额
+	srv.SetPrice("BTCUSDT", 48900)
+	if len(srv.Positions()) != 0 || len(srv.OpenOrders("BTCUSDT")) != 0 {
+		t.Errorf("Expected stop to close position, got %v, %v", srv.Positions(), srv.OpenOrders("BTCUSDT"))
+	}
+	if !almostEqual(srv.Balance(), 10000-11) {
+		t.Errorf("Expected realized loss of 11, got balance %v", srv.Balance())
+	}
+	order, err := be.GetOrder("BTCUSDT", sl.ID)
+	if err != nil || order.Status != "FILLED" || order.AvgPrice != 48900 {
+		t.Errorf("Expected stop order filled at 48900, got %+v, %v", order, err)
+	}
+	balance, err := be.GetBalance()
+	if err != nil || !almostEqual(balance["total"], 9989) {
+		t.Errorf("Expected balance 9989, got %v, %v", balance, err)
+	}
+}
+
+func TestFakeBinance_RejectsBadSignature(t *testing.T) {
+	srv, _ := newFakeBinance(t)
+	t.Setenv("BINANCE_SECRET_KEY", "wrong-secret")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(srv.URL, 5*time.Second))
+
+	_, err := be.GetPositions()
+	var apiErr *exchange.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != binancetest.CodeInvalidSignature || exchange.ClassifyError(err) != exchange.ErrorFatal {
+		t.Errorf("Expected fatal -1022, got %v", err)
+	}
+}
+
+func TestFakeBinance_FaultInjection(t *testing.T) {
+	srv, be := newFakeBinance(t)
+
+	// 保证金不足：业务拒绝，注入一次后恢复
+	srv.Inject(binancetest.MarginInsufficient("POST", "/fapi/v1/order", 1))
+	req := types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "MARKET", Quantity: 0.01}
+	if _, err := be.PlaceOrder(req); exchange.ClassifyError(err) != exchange.ErrorRejected {
+		t.Errorf("Expected rejected -2019, got %v", err)
+	}
+	if _, err := be.PlaceOrder(req); err != nil {
+		t.Errorf("Expected order after fault exhausted, got %v", err)
+	}
+
+	// 限流：可重试
+	srv.Inject(binancetest.RateLimited("GET", "/fapi/v2/positionRisk", 1))
+	if _, err := be.GetPositions(); exchange.ClassifyError(err) != exchange.ErrorRetryable {
+		t.Errorf("Expected retryable 429, got %v", err)
+	}
+
+	// 已受理但响应超时：按客户端订单ID找回，不重复下单
+	srv.Inject(binancetest.Timeout("POST", "/fapi/v1/order", 2*time.Second, true, 1))
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req.ClientOrderID = "entry-timeout"
+	order, err := be.PlaceOrderContext(ctx, req)
+	if err != nil || order.ClientOrderID != "entry-timeout" || order.Status != "FILLED" {
+		t.Fatalf("Expected order recovered by client order ID, got %+v, %v", order, err)
+	}
+	if n := len(srv.Orders("BTCUSDT")); n != 2 {
+		t.Errorf("Expected 2 orders on exchange, got %d", n)
+	}
+}
+
+func TestFakeBinance_EnsureLeverage(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	t.Setenv("MARGIN_TYPE", "ISOLATED")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	engine := execution.NewExecutionEngine(be, nil)
+	lev, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 5)
+	if err != nil || lev != 5 || srv.Leverage("BTCUSDT") != 5 {
+		t.Errorf("Expected leverage 5 on exchange, got %d (exchange %d), %v", lev, srv.Leverage("BTCUSDT"), err)
+	}
+
+	// 缓存命中时不重复请求
+	if _, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 5); err != nil {
+		t.Fatalf("EnsureLeverage failed: %v", err)
+	}
+	if n := srv.Calls("POST", "/fapi/v1/leverage"); n != 1 {
+		t.Errorf("Expected 1 leverage call, got %d", n)
+	}
+	if n := srv.Calls("POST", "/fapi/v1/marginType"); n != 1 {
+		t.Errorf("Expected 1 margin type call, got %d", n)
+	}
+}
+
+// 入场单挂单期间守护进程运行一轮不清除其保护信息，成交后照常挂出止损止盈
+func TestFakeBinance_GuardKeepsPendingEntryProtection(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	t.Setenv("MAX_NOTIONAL_PER_TRADE", "1000")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(be, rdb)
+	ctx := context.Background()
+
+	// 另一方向已有持仓，守护进程会检查全部保护信息
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "SHORT", OrderType: "MARKET", Quantity: 0.01}); err != nil {
+		t.Fatalf("Failed to open short: %v", err)
+	}
+
+	ok, reason, _ := engine.PlaceOrderFromSignal(ctx, &types.Signal{
+		Symbol:      "BTCUSDT",
+		Action:      "open_long",
+		Side:        "long",
+		EntryPrice:  49900,
+		StopLoss:    49000,
+		TakeProfit:  51000,
+		TakeProfit2: 52000,
+		Quantity:    0.01,
+		Leverage:    5,
+		SignalID:    "sig-pending",
+		Timestamp:   time.Now().Unix(),
+	})
+	if !ok {
+		t.Fatalf("PlaceOrderFromSignal failed: %s", reason)
+	}
+
+	engine.EnsureSLTPGuardOnce(ctx, "test")
+	protectionKey := config.GetRedisKey("protection:BTCUSDT:LONG")
+	if n, _ := rdb.Exists(ctx, protectionKey).Result(); n != 1 {
+		t.Fatal("Expected protection of pending entry to survive guard tick")
+	}
+
+	srv.SetPrice("BTCUSDT", 49900)
+	var longOrders []binancetest.Order
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		longOrders = longOrders[:0]
+		for _, o := range srv.OpenOrders("BTCUSDT") {
+			if o.PositionSide == "LONG" {
+				longOrders = append(longOrders, o)
+			}
+		}
+		if len(longOrders) == 3 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(longOrders) != 3 {
+		t.Fatalf("Expected SL, TP1 and TP2 after fill, got %+v", longOrders)
+	}
+}
+
+// filledQtyMissing 查询订单时不返回成交数量的交易所
+type filledQtyMissing struct {
+	types.Exchange
+}
+
+func (f *filledQtyMissing) GetOrderContext(ctx context.Context, symbol, orderID string) (*types.Order, error) {
+	order, err := f.Exchange.GetOrderContext(ctx, symbol, orderID)
+	if order != nil {
+		order.FilledQty = 0
+	}
+	return order, err
+}
+
+// 确认成交时交易所未返回成交数量，生命周期按下单数量记录
+func TestFakeBinance_EntryFilledQtyFallback(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	t.Setenv("MAX_NOTIONAL_PER_TRADE", "1000")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(&filledQtyMissing{Exchange: be}, rdb)
+	ctx := context.Background()
+
+	ok, reason, _ := engine.PlaceOrderFromSignal(ctx, &types.Signal{
+		Symbol:     "BTCUSDT",
+		Action:     "open_long",
+		Side:       "long",
+		EntryPrice: 49900,
+		StopLoss:   49000,
+		TakeProfit: 51000,
+		Quantity:   0.01,
+		Leverage:   5,
+		SignalID:   "sig-fill-qty",
+		Timestamp:  time.Now().Unix(),
+	})
+	if !ok {
+		t.Fatalf("PlaceOrderFromSignal failed: %s", reason)
+	}
+	srv.SetPrice("BTCUSDT", 49900)
+
+	var lc *execution.OrderLifecycle
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if lc, _ = engine.Lifecycle().Get(ctx, "sig-fill-qty"); lc != nil && lc.State == execution.StateProtected {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if lc == nil || lc.State != execution.StateProtected {
+		t.Fatalf("Expected lifecycle to reach protected, got %+v", lc)
+	}
+
+	var filled []execution.LifecycleEvent
+	for _, ev := range lc.Timeline {
+		if ev.To == execution.StateFilled {
+			filled = append(filled, ev)
+		}
+	}
+	if len(filled) != 1 || filled[0].FilledQty != 0.01 {
+		t.Errorf("Expected one filled event with qty 0.01, got %+v", filled)
+	}
+}
+
+// 最后一个仓位平掉后，守护进程撤销残留的止盈单并删除保护信息
+func TestFakeBinance_GuardCleansUpLastFlatPosition(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(be, rdb)
+	ctx := context.Background()
+
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "MARKET", Quantity: 0.01}); err != nil {
+		t.Fatalf("Failed to open long: %v", err)
+	}
+	tp := 52000.0
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "TAKE_PROFIT_MARKET", Quantity: 0.01, StopPrice: &tp}); err != nil {
+		t.Fatalf("Failed to place take profit: %v", err)
+	}
+	engine.SaveProtection(ctx, "BTCUSDT", "LONG", 49000, 52000, 0, "sig-flat")
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "MARKET", Quantity: 0.01}); err != nil {
+		t.Fatalf("Failed to close long: %v", err)
+	}
+
+	engine.EnsureSLTPGuardOnce(ctx, "test")
+
+	if open := srv.OpenOrders("BTCUSDT"); len(open) != 0 {
+		t.Errorf("Expected leftover take profit cancelled, got %+v", open)
+	}
+	if n, _ := rdb.Exists(ctx, config.GetRedisKey("protection:BTCUSDT:LONG")).Result(); n != 0 {
+		t.Error("Expected protection deleted after last position closed")
+	}
+}
+
+// openOrdersFailing 查询挂单失败的交易所
+type openOrdersFailing struct {
+	types.Exchange
+}
+
+func (f *openOrdersFailing) GetOpenOrdersContext(ctx context.Context, symbol string) ([]*types.Order, error) {
+	return nil, errors.New("open orders unavailable")
+}
+
+// 查询挂单失败时守护进程跳过该持仓，不重复挂出整组保护单
+func TestFakeBinance_GuardSkipsOnOpenOrdersError(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(&openOrdersFailing{Exchange: be}, rdb)
+	ctx := context.Background()
+
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "MARKET", Quantity: 0.01}); err != nil {
+		t.Fatalf("Failed to open long: %v", err)
+	}
+	sl := 49000.0
+	if _, err := be.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", OrderType: "STOP_MARKET", Quantity: 0.01, StopPrice: &sl}); err != nil {
+		t.Fatalf("Failed to place stop loss: %v", err)
+	}
+	engine.SaveProtection(ctx, "BTCUSDT", "LONG", 49000, 52000, 0, "sig-skip")
+
+	engine.EnsureSLTPGuardOnce(ctx, "test")
+
+	if open := srv.OpenOrders("BTCUSDT"); len(open) != 1 {
+		t.Errorf("Expected only the existing stop loss, got %+v", open)
+	}
+	if n, _ := rdb.Exists(ctx, config.GetRedisKey("protection:BTCUSDT:LONG")).Result(); n != 1 {
+		t.Error("Expected protection kept")
+	}
+}
+
+func TestFakeBinance_StreamAndDataEndpoints(t *testing.T) {
+	srv, be := newFakeBinance(t)
+
+	records, err := be.GetFundingHistory("BTCUSDT", 3)
+	if err != nil || len(records) != 3 || records[2].FundingRate != 0.0001 || records[0].FundingTime >= records[2].FundingTime {
+		t.Fatalf("Expected 3 funding records in ascending order, got %+v, %v", records, err)
+	}
+
+	// 合约数据接口没有统计数据
+	if _, err := be.GetDerivativesData("BTCUSDT", "5m", 30); err == nil {
+		t.Error("Expected error for empty derivatives data")
+	}
+	if n := srv.Calls("GET", "/futures/data/takerlongshortRatio"); n != 1 {
+		t.Errorf("Expected 1 taker ratio call, got %d", n)
+	}
+
+	// listenKey只校验API Key
+	listenKey := func(method, apiKey string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, srv.URL+"/fapi/v1/listenKey", nil)
+		req.Header.Set("X-MBX-APIKEY", apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s listenKey failed: %v", method, err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+	if status, _ := listenKey(http.MethodPost, "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for wrong API key, got %d", status)
+	}
+	status, body := listenKey(http.MethodPost, srv.APIKey)
+	if status != http.StatusOK || body["listenKey"] == "" {
+		t.Fatalf("Expected listenKey, got %d %v", status, body)
+	}
+	if status, again := listenKey(http.MethodPost, srv.APIKey); status != http.StatusOK || again["listenKey"] != body["listenKey"] {
+		t.Errorf("Expected same listenKey, got %d %v", status, again)
+	}
+	if status, _ := listenKey(http.MethodPut, srv.APIKey); status != http.StatusOK {
+		t.Errorf("Expected keepalive 200, got %d", status)
+	}
+	listenKey(http.MethodDelete, srv.APIKey)
+	if status, body := listenKey(http.MethodPut, srv.APIKey); status != http.StatusBadRequest || body["code"] != float64(binancetest.CodeListenKeyNotExist) {
+		t.Errorf("Expected -1125 after close, got %d %v", status, body)
+	}
+}
+
+// 信号下单 → 入场成交 → 批量挂止损止盈 → 止损触发 → 守护进程清理残留止盈单和保护信息
+func TestFakeBinance_SignalToStopLossCleanup(t *testing.T) {
+	srv, be := newFakeBinance(t)
+	t.Setenv("MAX_NOTIONAL_PER_TRADE", "1000")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(be, rdb)
+	ctx := context.Background()
+
+	signal := &types.Signal{
+		Symbol:      "BTCUSDT",
+		Action:      "open_long",
+		Side:        "long",
+		EntryPrice:  49900,
+		StopLoss:    49000,
+		TakeProfit:  51000,
+		TakeProfit2: 52000,
+		Quantity:    0.01,
+		Leverage:    5,
+		SignalID:    "sig-e2e",
+		Timestamp:   time.Now().Unix(),
+	}
+	ok, reason, entry := engine.PlaceOrderFromSignal(ctx, signal)
+	if !ok || entry == nil {
+		t.Fatalf("PlaceOrderFromSignal failed: %s", reason)
+	}
+	if open := srv.OpenOrders("BTCUSDT"); len(open) != 1 || open[0].Type != "LIMIT" {
+		t.Fatalf("Expected resting entry order, got %+v", open)
+	}
+
+	// 价格回落到入场价，限价单成交；确认后一次挂出止损、TP1、TP2
+	srv.SetPrice("BTCUSDT", 49900)
+	var brackets []binancetest.Order
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if brackets = srv.OpenOrders("BTCUSDT"); len(brackets) == 3 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(brackets) != 3 {
+		t.Fatalf("Expected 3 protection orders after fill, got %+v", brackets)
+	}
+	if n := srv.Calls("POST", "/fapi/v1/batchOrders"); n != 1 {
+		t.Errorf("Expected protection in 1 batch request, got %d", n)
+	}
+	for _, o := range brackets {
+		if o.Side != "SELL" || o.PositionSide != "LONG" {
+			t.Errorf("Expected SELL LONG protection order, got %+v", o)
+		}
+	}
+
+	// 止损触发，持仓平掉，止盈单残留
+	srv.SetPrice("BTCUSDT", 48900)
+	for _, pos := range srv.Positions() {
+		if pos.Symbol == "BTCUSDT" && pos.Amount != 0 {
+			t.Fatalf("Expected position closed by stop loss, got %+v", pos)
+		}
+	}
+	if n := len(srv.OpenOrders("BTCUSDT")); n != 2 {
+		t.Fatalf("Expected 2 take profit orders left, got %d", n)
+	}
+
+	engine.EnsureSLTPGuardOnce(ctx, "test")
+
+	if open := srv.OpenOrders("BTCUSDT"); len(open) != 0 {
+		t.Errorf("Expected leftover orders cancelled, got %+v", open)
+	}
+	if n, _ := rdb.Exists(ctx, config.GetRedisKey("protection:BTCUSDT:LONG")).Result(); n != 0 {
+		t.Error("Expected protection deleted after flat")
+	}
+	lc, err := engine.Lifecycle().Get(ctx, "sig-e2e")
+	if err != nil || lc == nil {
+		t.Fatalf("Expected lifecycle, got %v, %v", lc, err)
+	}
+	if lc.State != execution.StateClosed || lc.FilledQty != 0.01 || lc.AvgPrice != 49900 {
+		t.Errorf("Expected closed lifecycle filled 0.01 @ 49900, got %+v", lc)
+	}
+}
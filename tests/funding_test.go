@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestBuildFundingStats(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	premium := &types.PremiumIndex{
+		MarkPrice:            50050,
+		IndexPrice:           50000,
+		PredictedFundingRate: 0.0003,
+		NextFundingTime:      now.Add(30 * time.Minute).UnixMilli(),
+	}
+	history := []types.FundingRateRecord{
+		{FundingRate: -0.0001, FundingTime: 1},
+		{FundingRate: 0.0002, FundingTime: 2},
+		{FundingRate: 0.0001, FundingTime: 3},
+		{FundingRate: 0.0002, FundingTime: 4},
+	}
+
+	stats := scanner.BuildFundingStats(premium, history, now)
+	if stats == nil {
+		t.Fatal("Expected funding stats")
+	}
+	if stats.PredictedRate != 0.0003 || !almostEqual(stats.MinutesToFunding, 30) || !almostEqual(stats.BasisBps, 10) {
+		t.Errorf("Unexpected premium stats: %+v", stats)
+	}
+	if stats.Settlements != 4 || !almostEqual(stats.SumRate, 0.0004) || !almostEqual(stats.AvgRate, 0.0001) {
+		t.Errorf("Unexpected history stats: %+v", stats)
+	}
+	if stats.MaxRate != 0.0002 || stats.MinRate != -0.0001 || stats.PositiveRatio != 0.75 || stats.Streak != 3 {
+		t.Errorf("Unexpected range/streak: %+v", stats)
+	}
+
+	// 结算时间已过时不返回负数
+	stats = scanner.BuildFundingStats(premium, nil, now.Add(time.Hour))
+	if stats.MinutesToFunding != 0 || stats.Settlements != 0 {
+		t.Errorf("Expected no minutes/settlements, got %+v", stats)
+	}
+
+	if scanner.BuildFundingStats(nil, nil, now) != nil {
+		t.Error("Expected nil stats without data")
+	}
+}
+
+func TestEvaluateRisk_FundingGuard(t *testing.T) {
+	limits := defaultRiskLimits()
+	limits.FundingGuardThreshold = 0.001
+	limits.FundingGuardWindowMin = 15
+
+	tests := []struct {
+		name    string
+		side    string
+		rate    float64
+		minutes float64
+		allowed bool
+	}{
+		{"long pays positive funding", "LONG", 0.0015, 10, false},
+		{"short pays negative funding", "SHORT", -0.002, 5, false},
+		{"short receives positive funding", "SHORT", 0.0015, 10, true},
+		{"below threshold", "LONG", 0.0005, 10, true},
+		{"outside window", "LONG", 0.0015, 60, true},
+		{"unknown settlement time", "LONG", 0.0015, 0, true},
+	}
+
+	for _, tt := range tests {
+		decision := execution.EvaluateRisk(execution.RiskInput{
+			Symbol:           "BTCUSDT",
+			Notional:         20,
+			PositionSide:     tt.side,
+			FundingRate:      tt.rate,
+			MinutesToFunding: tt.minutes,
+		}, limits)
+		if decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got %v (%s)", tt.name, tt.allowed, decision.Allowed, decision.Reason)
+		}
+		if !tt.allowed && decision.ReasonCode != execution.RiskReasonFundingAdverse {
+			t.Errorf("%s: expected reason code %s, got %s", tt.name, execution.RiskReasonFundingAdverse, decision.ReasonCode)
+		}
+	}
+
+	// 未配置资金费率风控时不拦截
+	decision := execution.EvaluateRisk(execution.RiskInput{
+		Symbol: "BTCUSDT", Notional: 20, PositionSide: "LONG", FundingRate: 0.01, MinutesToFunding: 1,
+	}, defaultRiskLimits())
+	if !decision.Allowed {
+		t.Errorf("Expected funding guard disabled by default, got %s", decision.ReasonCode)
+	}
+}
+
+func TestBinanceExchange_FundingHistoryAndPremiumIndex(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/fapi/v1/fundingRate":
+			if r.URL.Query().Get("limit") != "2" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-1100,"msg":"Illegal characters found in parameter 'limit'."}`))
+				return
+			}
+			w.Write([]byte(`[{"symbol":"BTCUSDT","fundingRate":"0.00010000","fundingTime":1700000000000,"markPrice":"50000"},
+				{"symbol":"BTCUSDT","fundingRate":"-0.00005000","fundingTime":1700028800000,"markPrice":"50100"}]`))
+		case "/fapi/v1/premiumIndex":
+			w.Write([]byte(`{"symbol":"BTCUSDT","markPrice":"50050.00","indexPrice":"50000.00","estimatedSettlePrice":"50020.00",
+				"lastFundingRate":"0.00030000","interestRate":"0.00010000","nextFundingTime":1700057600000,"time":1700050000000}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	records, err := be.GetFundingHistory("BTCUSDT", 2)
+	if err != nil {
+		t.Fatalf("GetFundingHistory failed: %v", err)
+	}
+	if len(records) != 2 || records[0].FundingRate != 0.0001 || records[1].FundingRate != -0.00005 ||
+		records[1].FundingTime != 1700028800000 {
+		t.Errorf("Unexpected funding history: %+v", records)
+	}
+
+	// 下一个整点前读取缓存
+	if _, err := be.GetFundingHistory("BTCUSDT", 2); err != nil {
+		t.Fatalf("GetFundingHistory failed: %v", err)
+	}
+	mu.Lock()
+	if calls["/fapi/v1/fundingRate"] != 1 {
+		t.Errorf("Expected cached funding history, got %d calls", calls["/fapi/v1/fundingRate"])
+	}
+	mu.Unlock()
+
+	premium, err := be.GetPremiumIndex("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetPremiumIndex failed: %v", err)
+	}
+	if premium.MarkPrice != 50050 || premium.IndexPrice != 50000 || premium.PredictedFundingRate != 0.0003 ||
+		premium.NextFundingTime != 1700057600000 || premium.Timestamp != 1700050000000 {
+		t.Errorf("Unexpected premium index: %+v", premium)
+	}
+}
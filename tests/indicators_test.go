@@ -101,6 +101,26 @@ func TestIsBollingerSqueeze(t *testing.T) {
 	}
 }
 
+func TestCalculateATR(t *testing.T) {
+	// 每根K线高低差为2，无跳空
+	closes := []float64{100, 100, 100, 100, 100}
+	highs := []float64{101, 101, 101, 101, 101}
+	lows := []float64{99, 99, 99, 99, 99}
+	if atr := indicators.CalculateATR(highs, lows, closes, 3); math.Abs(atr-2) > 1e-9 {
+		t.Errorf("Expected ATR 2, got %f", atr)
+	}
+
+	// 最后一根向上跳空：真实波幅取与前收盘价的差（106-100=6），Wilder平滑 (2*2+6)/3
+	highs[4], lows[4], closes[4] = 106, 104, 105
+	if atr := indicators.CalculateATR(highs, lows, closes, 3); math.Abs(atr-10.0/3) > 1e-9 {
+		t.Errorf("Expected ATR %f, got %f", 10.0/3, atr)
+	}
+
+	if atr := indicators.CalculateATR(highs[:3], lows[:3], closes[:3], 3); atr != 0 {
+		t.Errorf("Expected ATR 0 for insufficient data, got %f", atr)
+	}
+}
+
 func TestCalculateCVD(t *testing.T) {
 	ohlcv := []struct {
 		Open   float64
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// leverageRecorder 记录杠杆和保证金模式设置的交易所
+type leverageRecorder struct {
+	types.Exchange
+	leverages   []int
+	marginTypes []string
+	fail        bool
+}
+
+func (r *leverageRecorder) SetLeverageContext(ctx context.Context, symbol string, leverage int) error {
+	if r.fail {
+		return errors.New("leverage rejected")
+	}
+	r.leverages = append(r.leverages, leverage)
+	return nil
+}
+
+func (r *leverageRecorder) SetMarginTypeContext(ctx context.Context, symbol string, marginType string) error {
+	r.marginTypes = append(r.marginTypes, marginType)
+	return nil
+}
+
+func TestEnsureLeverage_CapsAndCaches(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("MAX_LEVERAGE", "10")
+	t.Setenv("MARGIN_TYPE", "isolated")
+	config.Load()
+
+	ex := &leverageRecorder{}
+	engine := execution.NewExecutionEngine(ex, nil)
+
+	// 超过MaxLeverage时按上限设置
+	applied, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 25)
+	if err != nil || applied != 10 {
+		t.Fatalf("EnsureLeverage = %d, %v", applied, err)
+	}
+	// 相同值不重复发送
+	if _, err := engine.EnsureLeverage(context.Background(), "btcusdt", 10); err != nil {
+		t.Fatalf("EnsureLeverage failed: %v", err)
+	}
+	if _, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 5); err != nil {
+		t.Fatalf("EnsureLeverage failed: %v", err)
+	}
+	// 未指定杠杆时不修改
+	if applied, _ := engine.EnsureLeverage(context.Background(), "ETHUSDT", 0); applied != 0 {
+		t.Errorf("Expected unspecified leverage to be skipped, got %d", applied)
+	}
+
+	if len(ex.leverages) != 2 || ex.leverages[0] != 10 || ex.leverages[1] != 5 {
+		t.Errorf("Unexpected leverage calls: %v", ex.leverages)
+	}
+	if len(ex.marginTypes) != 2 || ex.marginTypes[0] != "ISOLATED" {
+		t.Errorf("Expected margin type once per symbol, got %v", ex.marginTypes)
+	}
+}
+
+func TestEnsureLeverage_FailureNotCached(t *testing.T) {
+	config.Load()
+
+	ex := &leverageRecorder{fail: true}
+	engine := execution.NewExecutionEngine(ex, nil)
+
+	if _, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 3); err == nil {
+		t.Fatal("Expected leverage error")
+	}
+	ex.fail = false
+	if applied, err := engine.EnsureLeverage(context.Background(), "BTCUSDT", 3); err != nil || applied != 3 || len(ex.leverages) != 1 {
+		t.Errorf("Expected retry after failure, got %d, %v, calls %v", applied, err, ex.leverages)
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+)
+
+func TestParseMarketStreamMessage_ForceOrder(t *testing.T) {
+	msg := `{"stream":"!forceOrder@arr","data":{"e":"forceOrder","E":1568014460893,"o":{"s":"BTCUSDT","S":"SELL","o":"LIMIT","f":"IOC","q":"0.014","p":"9910","ap":"9910.5","X":"FILLED","l":"0.014","z":"0.014","T":1568014460893}}}`
+	event, err := exchange.ParseMarketStreamMessage([]byte(msg))
+	if err != nil {
+		t.Fatalf("Parse force order failed: %v", err)
+	}
+	liq, ok := event.(*exchange.LiquidationEvent)
+	if !ok {
+		t.Fatalf("Expected *LiquidationEvent, got %T", event)
+	}
+	if liq.Symbol != "BTCUSDT" || liq.Side != "SELL" || liq.Price != 9910.5 || liq.Quantity != 0.014 || liq.Time != 1568014460893 {
+		t.Errorf("Unexpected liquidation event: %+v", liq)
+	}
+}
+
+func TestAggregateLiquidations(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("LIQUIDATION_SQUEEZE_MIN_USDT", "50000")
+	t.Setenv("LIQUIDATION_SQUEEZE_RATIO", "0.7")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	now := time.Now()
+	at := func(ago time.Duration) int64 { return now.Add(-ago).UnixMilli() }
+	events := []exchange.LiquidationEvent{
+		// 最近5分钟：多头集中被强平
+		{Symbol: "BTCUSDT", Side: "SELL", Price: 50000, Quantity: 1, Time: at(time.Minute)},
+		{Symbol: "BTCUSDT", Side: "SELL", Price: 50000, Quantity: 0.5, Time: at(2 * time.Minute)},
+		{Symbol: "BTCUSDT", Side: "BUY", Price: 50000, Quantity: 0.2, Time: at(3 * time.Minute)},
+		// 5分钟之前：空头被强平
+		{Symbol: "BTCUSDT", Side: "BUY", Price: 50000, Quantity: 2, Time: at(30 * time.Minute)},
+	}
+
+	windows := scanner.AggregateLiquidations(events, now, []string{"5m", "1h"})
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 windows, got %d", len(windows))
+	}
+
+	w5 := windows[0]
+	if w5.Window != "5m" || w5.LongCount != 2 || w5.ShortCount != 1 ||
+		!almostEqual(w5.LongNotional, 75000) || !almostEqual(w5.ShortNotional, 10000) {
+		t.Errorf("Unexpected 5m window: %+v", w5)
+	}
+	if w5.Squeeze != "long_squeeze" {
+		t.Errorf("Expected long_squeeze in 5m window, got %q", w5.Squeeze)
+	}
+
+	// 1小时内空头强平占比 110000/185000 不足70%
+	w1h := windows[1]
+	if w1h.LongCount != 2 || w1h.ShortCount != 2 || !almostEqual(w1h.ShortNotional, 110000) || w1h.Squeeze != "" {
+		t.Errorf("Unexpected 1h window: %+v", w1h)
+	}
+}
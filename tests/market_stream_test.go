@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/internal/scanner"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// stubMarket 只实现GetOHLCVContext的行情源，用于验证REST回补
+type stubMarket struct {
+	types.Exchange
+	candles []types.OHLCV
+}
+
+func (m *stubMarket) GetOHLCVContext(ctx context.Context, symbol, timeframe string, limit int) ([]types.OHLCV, error) {
+	return m.candles, nil
+}
+
+func TestParseMarketStreamMessage(t *testing.T) {
+	kline := `{"stream":"btcusdt@kline_1m","data":{"e":"kline","E":1638747660000,"s":"BTCUSDT","k":{"t":1638747660000,"T":1638747719999,"s":"BTCUSDT","i":"1m","o":"100.5","c":"101.0","h":"102.0","l":"99.0","v":"12.5","x":true}}}`
+	event, err := exchange.ParseMarketStreamMessage([]byte(kline))
+	if err != nil {
+		t.Fatalf("Parse kline failed: %v", err)
+	}
+	k, ok := event.(*exchange.KlineEvent)
+	if !ok {
+		t.Fatalf("Expected *KlineEvent, got %T", event)
+	}
+	if k.Symbol != "BTCUSDT" || k.Interval != "1m" || !k.Closed {
+		t.Errorf("Unexpected kline event: %+v", k)
+	}
+	if k.Candle.Time != 1638747660000 || k.Candle.Open != 100.5 || k.Candle.Close != 101.0 || k.Candle.Volume != 12.5 {
+		t.Errorf("Unexpected candle: %+v", k.Candle)
+	}
+
+	marks := `{"stream":"!markPrice@arr","data":[{"e":"markPriceUpdate","E":1562305380000,"s":"BTCUSDT","p":"11794.15","i":"11784.62","r":"0.00038167","T":1562306400000}]}`
+	event, err = exchange.ParseMarketStreamMessage([]byte(marks))
+	if err != nil {
+		t.Fatalf("Parse mark price failed: %v", err)
+	}
+	updates, ok := event.([]exchange.MarkPriceUpdate)
+	if !ok || len(updates) != 1 {
+		t.Fatalf("Expected 1 mark price update, got %T %v", event, event)
+	}
+	if updates[0].MarkPrice != 11794.15 || updates[0].FundingRate != 0.00038167 {
+		t.Errorf("Unexpected mark price update: %+v", updates[0])
+	}
+
+	// 订阅响应不产生事件
+	event, err = exchange.ParseMarketStreamMessage([]byte(`{"result":null,"id":1}`))
+	if err != nil || event != nil {
+		t.Errorf("Expected nil event for subscribe response, got %v, %v", event, err)
+	}
+}
+
+func TestCandleStore_UpdateAndGap(t *testing.T) {
+	store := scanner.NewCandleStore([]string{"1m"}, []int{3})
+
+	if store.Update("BTCUSDT", "1m", types.OHLCV{Time: 0, Close: 1}) {
+		t.Error("Expected update on unseeded series to request backfill")
+	}
+
+	store.Seed("BTCUSDT", "1m", []types.OHLCV{
+		{Time: 0, Close: 1},
+		{Time: 60000, Close: 2},
+		{Time: 120000, Close: 3},
+	})
+
+	// 同一根K线更新
+	if !store.Update("BTCUSDT", "1m", types.OHLCV{Time: 120000, Close: 3.5}) {
+		t.Error("Expected in-place update to succeed")
+	}
+	// 下一根K线追加，超出容量时丢弃最旧的
+	if !store.Update("BTCUSDT", "1m", types.OHLCV{Time: 180000, Close: 4}) {
+		t.Error("Expected append to succeed")
+	}
+	candles, ok := store.Get("BTCUSDT", "1m", 0)
+	if !ok || len(candles) != 3 {
+		t.Fatalf("Expected 3 candles, got %d", len(candles))
+	}
+	if candles[0].Time != 60000 || candles[1].Close != 3.5 || candles[2].Close != 4 {
+		t.Errorf("Unexpected series: %+v", candles)
+	}
+
+	// 缺口需要回补
+	if store.Update("BTCUSDT", "1m", types.OHLCV{Time: 300000, Close: 6}) {
+		t.Error("Expected gap to request backfill")
+	}
+
+	store.Remove("BTCUSDT")
+	if _, ok := store.Get("BTCUSDT", "1m", 0); ok {
+		t.Error("Expected series to be removed")
+	}
+}
+
+func TestMarketFeed_StreamUpdatesBackfilledCandles(t *testing.T) {
+	config.Load()
+
+	now := time.Now().Truncate(time.Minute)
+	seed := make([]types.OHLCV, 0, 50)
+	for i := 49; i >= 0; i-- {
+		ts := now.Add(-time.Duration(i) * time.Minute).UnixMilli()
+		seed = append(seed, types.OHLCV{Open: 100, High: 101, Low: 99, Close: 100, Volume: 1, Time: ts})
+	}
+
+	subscribed := make(chan []string, 1)
+	push := make(chan struct{})
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stream" || r.URL.Query().Get("streams") != exchange.MarkPriceStream {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+			ID     int64    `json:"id"`
+		}
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "SUBSCRIBE" {
+			return
+		}
+		conn.WriteJSON(map[string]interface{}{"result": nil, "id": req.ID})
+		subscribed <- req.Params
+
+		<-push
+		update := fmt.Sprintf(`{"stream":"btcusdt@kline_1m","data":{"e":"kline","s":"BTCUSDT","k":{"t":%d,"s":"BTCUSDT","i":"1m","o":"100","c":"105","h":"106","l":"99","v":"3","x":false}}}`, now.UnixMilli())
+		conn.WriteMessage(websocket.TextMessage, []byte(update))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"!markPrice@arr","data":[{"e":"markPriceUpdate","s":"BTCUSDT","p":"105.1","r":"0.0001"}]}`))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	feed := scanner.NewMarketFeed(&stubMarket{candles: seed}, exchange.NewMarketStream(wsURL, []string{"1m"}))
+	feed.Track([]string{"BTCUSDT"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed.Start(ctx)
+
+	select {
+	case params := <-subscribed:
+		if len(params) != 1 || params[0] != "btcusdt@kline_1m" {
+			t.Fatalf("Unexpected subscription: %v", params)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for subscription")
+	}
+
+	// 连接建立后首次读取需要REST回补
+	deadline := time.Now().Add(5 * time.Second)
+	for !feed.Streaming("BTCUSDT") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := feed.Candles("BTCUSDT", "1m", 50); ok {
+		t.Fatal("Expected no candles before backfill")
+	}
+	if _, err := feed.Backfill(context.Background(), "BTCUSDT", "1m", 50); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+	close(push)
+
+	var candles []types.OHLCV
+	for time.Now().Before(deadline) {
+		if c, ok := feed.Candles("BTCUSDT", "1m", 50); ok && c[len(c)-1].Close == 105 {
+			candles = c
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(candles) != 50 {
+		t.Fatalf("Expected streamed update on 50 candles, got %d", len(candles))
+	}
+
+	for time.Now().Before(deadline) {
+		if mark, ok := feed.MarkPrice("BTCUSDT"); ok {
+			if mark.FundingRate != 0.0001 {
+				t.Errorf("Unexpected funding rate %f", mark.FundingRate)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Timed out waiting for mark price")
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+const okxTestSecret = "okx-test-secret"
+
+// okxRequest 回放服务器记录的请求
+type okxRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// newOKXReplayServer 按 "METHOD path" 回放 testdata/okx 下录制的响应，并校验签名
+func newOKXReplayServer(t *testing.T, routes map[string]string) (*httptest.Server, func() []okxRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []okxRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, okxRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: string(body)})
+		mu.Unlock()
+
+		if sign := r.Header.Get("OK-ACCESS-SIGN"); sign != "" {
+			requestPath := r.URL.Path
+			if r.URL.RawQuery != "" {
+				requestPath += "?" + r.URL.RawQuery
+			}
+			mac := hmac.New(sha256.New, []byte(okxTestSecret))
+			mac.Write([]byte(r.Header.Get("OK-ACCESS-TIMESTAMP") + r.Method + requestPath + string(body)))
+			if sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) || r.Header.Get("OK-ACCESS-PASSPHRASE") != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":"50113","msg":"Invalid Sign","data":[]}`))
+				return
+			}
+		}
+
+		fixture, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"50000","msg":"not recorded","data":[]}`))
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "okx", fixture))
+		if err != nil {
+			t.Errorf("read fixture %s: %v", fixture, err)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []okxRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]okxRequest(nil), requests...)
+	}
+}
+
+// useLiveTrading 关闭DRY_RUN，测试结束后恢复配置
+func useLiveTrading(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+}
+
+func TestOKXInstIDMapping(t *testing.T) {
+	cases := map[string]string{
+		"BTCUSDT":       "BTC-USDT-SWAP",
+		"eth/usdt":      "ETH-USDT-SWAP",
+		"SOL":           "SOL-USDT-SWAP",
+		"BTC-USDT-SWAP": "BTC-USDT-SWAP",
+	}
+	for symbol, want := range cases {
+		if got := exchange.OKXInstID(symbol); got != want {
+			t.Errorf("OKXInstID(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+	if got := exchange.OKXSymbol("BTC-USDT-SWAP"); got != "BTCUSDT" {
+		t.Errorf("OKXSymbol = %q, want BTCUSDT", got)
+	}
+}
+
+func TestOKXExchange_MarketData(t *testing.T) {
+	config.Load()
+
+	server, requests := newOKXReplayServer(t, map[string]string{
+		"GET /api/v5/market/candles":       "candles.json",
+		"GET /api/v5/market/ticker":        "ticker.json",
+		"GET /api/v5/public/funding-rate":  "funding_rate.json",
+		"GET /api/v5/public/open-interest": "open_interest.json",
+	})
+	ox := exchange.NewOKXExchange(server.URL, "", "", "")
+
+	candles, err := ox.GetOHLCV("BTCUSDT", "1h", 3)
+	if err != nil {
+		t.Fatalf("GetOHLCV failed: %v", err)
+	}
+	if len(candles) != 3 || candles[0].Time != 1700000000000 || candles[2].Time != 1700000120000 {
+		t.Fatalf("Expected ascending candles, got %+v", candles)
+	}
+	if candles[2].Close != 37045.5 || candles[2].Volume != 3.124 {
+		t.Errorf("Expected close 37045.5 and base volume 3.124, got %+v", candles[2])
+	}
+	if q := requests()[0].Query; !strings.Contains(q, "bar=1H") || !strings.Contains(q, "instId=BTC-USDT-SWAP") {
+		t.Errorf("Unexpected candles query: %s", q)
+	}
+
+	price, err := ox.GetTickerPrice("BTCUSDT")
+	if err != nil || price != 37045.5 {
+		t.Errorf("GetTickerPrice = %f, %v", price, err)
+	}
+	rate, err := ox.GetFundingRate("BTCUSDT")
+	if err != nil || rate != 0.0001235 {
+		t.Errorf("GetFundingRate = %f, %v", rate, err)
+	}
+	oi, err := ox.GetOpenInterest("BTCUSDT")
+	if err != nil || oi != 25412.336 {
+		t.Errorf("GetOpenInterest = %f, %v", oi, err)
+	}
+}
+
+func TestOKXExchange_PlaceOrders(t *testing.T) {
+	useLiveTrading(t)
+
+	server, requests := newOKXReplayServer(t, map[string]string{
+		"GET /api/v5/public/instruments":  "instruments.json",
+		"POST /api/v5/trade/order":        "place_order.json",
+		"POST /api/v5/trade/order-algo":   "place_algo_order.json",
+		"POST /api/v5/trade/cancel-algos": "cancel_algos.json",
+	})
+	ox := exchange.NewOKXExchange(server.URL, "key", okxTestSecret, "pass")
+
+	// 0.0157 BTC = 1.57张，按lotSz 0.1向下取整为1.5张
+	price := 37001.3
+	order, err := ox.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "BUY",
+		PositionSide: "LONG",
+		OrderType:    "LIMIT",
+		Quantity:     0.0157,
+		Price:        &price,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if order.ID != "612345678901234567" || order.Symbol != "BTCUSDT" || !almostEqual(order.Quantity, 0.015) {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+
+	var body map[string]interface{}
+	reqs := requests()
+	json.Unmarshal([]byte(reqs[len(reqs)-1].Body), &body)
+	if body["instId"] != "BTC-USDT-SWAP" || body["sz"] != "1.5" || body["ordType"] != "limit" || body["posSide"] != "long" || body["px"] != "37001.3" {
+		t.Errorf("Unexpected order body: %v", body)
+	}
+
+	// 止损单走条件单接口
+	stop := 36000.0
+	stopOrder, err := ox.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "SELL",
+		PositionSide: "LONG",
+		OrderType:    "STOP_MARKET",
+		Quantity:     0.015,
+		StopPrice:    &stop,
+		ReduceOnly:   true,
+	})
+	if err != nil {
+		t.Fatalf("Place stop order failed: %v", err)
+	}
+	if stopOrder.ID != "algo_681096944655273984" {
+		t.Errorf("Expected algo order id, got %s", stopOrder.ID)
+	}
+	reqs = requests()
+	last := reqs[len(reqs)-1]
+	body = nil
+	json.Unmarshal([]byte(last.Body), &body)
+	if last.Path != "/api/v5/trade/order-algo" || body["ordType"] != "conditional" || body["slTriggerPx"] != "36000" || body["slOrdPx"] != "-1" || body["reduceOnly"] != true {
+		t.Errorf("Unexpected algo order request %s: %v", last.Path, body)
+	}
+
+	if err := ox.CancelOrder("BTCUSDT", stopOrder.ID); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	reqs = requests()
+	if last := reqs[len(reqs)-1]; last.Path != "/api/v5/trade/cancel-algos" || !strings.Contains(last.Body, `"algoId":"681096944655273984"`) {
+		t.Errorf("Unexpected cancel request %s: %s", last.Path, last.Body)
+	}
+
+	// 低于最小下单量
+	if _, err := ox.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: 0.0005}); err == nil {
+		t.Error("Expected error for quantity below minimum size")
+	}
+}
+
+func TestOKXExchange_OrderRejected(t *testing.T) {
+	useLiveTrading(t)
+
+	server, _ := newOKXReplayServer(t, map[string]string{
+		"GET /api/v5/public/instruments": "instruments.json",
+		"POST /api/v5/trade/order":       "place_order_rejected.json",
+	})
+	ox := exchange.NewOKXExchange(server.URL, "key", okxTestSecret, "pass")
+
+	_, err := ox.PlaceOrder(types.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: 0.01})
+	if err == nil || !strings.Contains(err.Error(), "51008") {
+		t.Errorf("Expected sCode 51008 in error, got %v", err)
+	}
+}
+
+func TestOKXExchange_AccountAndOrders(t *testing.T) {
+	useLiveTrading(t)
+
+	server, _ := newOKXReplayServer(t, map[string]string{
+		"GET /api/v5/public/instruments":        "instruments.json",
+		"GET /api/v5/account/balance":           "balance.json",
+		"GET /api/v5/account/positions":         "positions.json",
+		"GET /api/v5/trade/order":               "order.json",
+		"GET /api/v5/trade/orders-pending":      "orders_pending.json",
+		"GET /api/v5/trade/orders-algo-pending": "orders_algo_pending.json",
+	})
+	ox := exchange.NewOKXExchange(server.URL, "key", okxTestSecret, "pass")
+
+	balance, err := ox.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance["total"] != 1000.55 || balance["free"] != 812.45 || !almostEqual(balance["used"], 188.1) {
+		t.Errorf("Unexpected balance: %v", balance)
+	}
+
+	positions, err := ox.GetPositions()
+	if err != nil {
+		t.Fatalf("GetPositions failed: %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("Expected 2 positions, got %d", len(positions))
+	}
+	btc, eth := positions[0], positions[1]
+	if btc.Symbol != "BTCUSDT" || btc.Side != "LONG" || !almostEqual(btc.Size, 0.15) || btc.Leverage != 10 {
+		t.Errorf("Unexpected BTC position: %+v", btc)
+	}
+	// 单向持仓模式负数为空头
+	if eth.Symbol != "ETHUSDT" || eth.Side != "SHORT" || !almostEqual(eth.Size, 0.3) {
+		t.Errorf("Unexpected ETH position: %+v", eth)
+	}
+
+	order, err := ox.GetOrder("BTCUSDT", "612345678901234567")
+	if err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	if order.Status != "FILLED" || !almostEqual(order.FilledQty, 0.015) || order.AvgPrice != 37001.3 || order.PositionSide != "LONG" {
+		t.Errorf("Unexpected order: %+v", order)
+	}
+
+	openOrders, err := ox.GetOpenOrders("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetOpenOrders failed: %v", err)
+	}
+	if len(openOrders) != 2 {
+		t.Fatalf("Expected 2 open orders, got %d", len(openOrders))
+	}
+	if tp := openOrders[0]; tp.OrderType != "LIMIT" || !tp.ReduceOnly || tp.Price != 38500 || tp.Status != "NEW" {
+		t.Errorf("Unexpected limit order: %+v", tp)
+	}
+	if sl := openOrders[1]; sl.ID != "algo_681096944655273984" || sl.OrderType != "STOP_MARKET" || sl.StopPrice != 36000 || !almostEqual(sl.Quantity, 0.015) {
+		t.Errorf("Unexpected stop order: %+v", sl)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+)
+
+// newTestRedis 启动内存Redis并返回客户端
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestOrderLifecycle_CanTransition(t *testing.T) {
+	tests := []struct {
+		from     execution.OrderState
+		to       execution.OrderState
+		expected bool
+	}{
+		// 正常路径
+		{"", execution.StateQueued, true},
+		{execution.StateQueued, execution.StateSubmitted, true},
+		{execution.StateSubmitted, execution.StatePartiallyFilled, true},
+		{execution.StatePartiallyFilled, execution.StatePartiallyFilled, true},
+		{execution.StatePartiallyFilled, execution.StateFilled, true},
+		{execution.StateFilled, execution.StateProtected, true},
+		{execution.StateProtected, execution.StateClosing, true},
+		{execution.StateClosing, execution.StateClosed, true},
+
+		// 未经队列直接下单、拒绝和撤销
+		{"", execution.StateSubmitted, true},
+		{execution.StateQueued, execution.StateRejected, true},
+		{execution.StateSubmitted, execution.StateCancelled, true},
+		{execution.StateClosing, execution.StateProtected, true},
+
+		// 重复或乱序事件
+		{execution.StateFilled, execution.StateFilled, false},
+		{execution.StateProtected, execution.StateProtected, false},
+		{execution.StateFilled, execution.StatePartiallyFilled, false},
+		{execution.StateSubmitted, execution.StateProtected, false},
+		{execution.StateSubmitted, execution.StateClosed, false},
+		{"", execution.StateFilled, false},
+
+		// 终态
+		{execution.StateClosed, execution.StateProtected, false},
+		{execution.StateCancelled, execution.StateFilled, false},
+		{execution.StateRejected, execution.StateSubmitted, false},
+	}
+	for _, tt := range tests {
+		if got := execution.CanTransition(tt.from, tt.to); got != tt.expected {
+			t.Errorf("CanTransition(%q, %q) = %v, expected %v", tt.from, tt.to, got, tt.expected)
+		}
+	}
+
+	for _, state := range []execution.OrderState{execution.StateClosed, execution.StateCancelled, execution.StateRejected} {
+		if !state.IsTerminal() {
+			t.Errorf("Expected %s to be terminal", state)
+		}
+	}
+	if execution.StateProtected.IsTerminal() {
+		t.Error("Expected protected not to be terminal")
+	}
+}
+
+func TestLifecycleStore_TransitionTimeline(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	store := execution.NewLifecycleStore(newTestRedis(t))
+	steps := []execution.LifecycleTransition{
+		{To: execution.StateQueued, Source: execution.LifecycleSourceQueue, Symbol: "BTCUSDT", PositionSide: "long", Quantity: 0.1},
+		{To: execution.StateSubmitted, Source: execution.LifecycleSourceEngine, OrderID: "1001"},
+		{To: execution.StateFilled, Source: execution.LifecycleSourceStream, OrderID: "1001", FilledQty: 0.1, AvgPrice: 50000},
+	}
+	var prev execution.OrderState
+	for _, step := range steps {
+		applied, from, err := store.Transition(ctx, "sig-1", step)
+		if err != nil {
+			t.Fatalf("Transition to %s failed: %v", step.To, err)
+		}
+		if !applied || from != prev {
+			t.Fatalf("Transition to %s: expected applied from %q, got %v from %q", step.To, prev, applied, from)
+		}
+		prev = step.To
+	}
+
+	// 重复的成交事件被忽略，不写入时间线
+	if applied, from, _ := store.Transition(ctx, "sig-1", steps[2]); applied || from != execution.StateFilled {
+		t.Errorf("Expected duplicate filled to be ignored, got %v from %q", applied, from)
+	}
+
+	lc, err := store.Get(ctx, "sig-1")
+	if err != nil || lc == nil {
+		t.Fatalf("Get failed: %v %v", lc, err)
+	}
+	if lc.State != execution.StateFilled || lc.Symbol != "BTCUSDT" || lc.PositionSide != "LONG" || lc.FilledQty != 0.1 || lc.AvgPrice != 50000 {
+		t.Errorf("Unexpected lifecycle: %+v", lc)
+	}
+	expected := []struct{ from, to execution.OrderState }{
+		{"", execution.StateQueued},
+		{execution.StateQueued, execution.StateSubmitted},
+		{execution.StateSubmitted, execution.StateFilled},
+	}
+	if len(lc.Timeline) != len(expected) {
+		t.Fatalf("Expected %d timeline events, got %+v", len(expected), lc.Timeline)
+	}
+	for i, exp := range expected {
+		if got := lc.Timeline[i]; got.From != exp.from || got.To != exp.to {
+			t.Errorf("Timeline %d: expected %q -> %q, got %q -> %q", i, exp.from, exp.to, got.From, got.To)
+		}
+	}
+	if lc.Timeline[2].FilledQty != 0.1 || lc.Timeline[2].AvgPrice != 50000 || lc.Timeline[2].Source != execution.LifecycleSourceStream {
+		t.Errorf("Unexpected filled event: %+v", lc.Timeline[2])
+	}
+}
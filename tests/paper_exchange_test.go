@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func newTestPaperExchange(price float64) *exchange.PaperExchange {
+	pe := exchange.NewPaperExchange(nil, nil, 1000.0, 0)
+	pe.UpdatePrice("BTCUSDT", price)
+	return pe
+}
+
+func TestPaperExchange_MarketOrderOpensPosition(t *testing.T) {
+	pe := newTestPaperExchange(100.0)
+
+	order, err := pe.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "BUY",
+		PositionSide: "LONG",
+		OrderType:    "MARKET",
+		Quantity:     2,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if order.Status != "FILLED" {
+		t.Errorf("Expected market order to fill immediately, got %s", order.Status)
+	}
+
+	pe.UpdatePrice("BTCUSDT", 110.0)
+
+	pos, err := pe.GetPosition("BTCUSDT")
+	if err != nil || pos == nil {
+		t.Fatalf("Expected LONG position, got %v (err=%v)", pos, err)
+	}
+	if pos.Side != "LONG" || pos.Size != 2 || pos.EntryPrice != 100.0 {
+		t.Errorf("Unexpected position: %+v", pos)
+	}
+	if !almostEqual(pos.UnrealizedPnl, 20.0) {
+		t.Errorf("Expected unrealized PnL 20, got %f", pos.UnrealizedPnl)
+	}
+
+	balance, _ := pe.GetBalance()
+	if balance["total"] != 1000.0 {
+		t.Errorf("Expected wallet balance unchanged before close, got %f", balance["total"])
+	}
+	if !almostEqual(balance["used"], 10.0) {
+		t.Errorf("Expected used margin 10 at default leverage, got %f", balance["used"])
+	}
+}
+
+func TestPaperExchange_LimitOrderFillsWhenPriceCrosses(t *testing.T) {
+	pe := newTestPaperExchange(100.0)
+
+	order, err := pe.PlaceOrder(types.OrderRequest{
+		Symbol:       "BTCUSDT",
+		Side:         "BUY",
+		PositionSide: "LONG",
+		OrderType:    "LIMIT",
+		Quantity:     1,
+		Price:        floatPtr(95.0),
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if order.Status != "NEW" {
+		t.Fatalf("Expected limit order to rest, got %s", order.Status)
+	}
+
+	openOrders, _ := pe.GetOpenOrders("BTCUSDT")
+	if len(openOrders) != 1 {
+		t.Fatalf("Expected 1 open order, got %d", len(openOrders))
+	}
+
+	pe.UpdatePrice("BTCUSDT", 94.0)
+
+	openOrders, _ = pe.GetOpenOrders("BTCUSDT")
+	if len(openOrders) != 0 {
+		t.Errorf("Expected limit order to fill, still %d open", len(openOrders))
+	}
+	filled, _ := pe.GetOrder("BTCUSDT", order.ID)
+	if filled.Status != "FILLED" {
+		t.Errorf("Expected FILLED, got %s", filled.Status)
+	}
+}
+
+func TestPaperExchange_StopLossAndTakeProfit(t *testing.T) {
+	pe := newTestPaperExchange(100.0)
+
+	if _, err := pe.PlaceOrder(types.OrderRequest{
+		Symbol: "BTCUSDT", Side: "SELL", PositionSide: "SHORT", OrderType: "MARKET", Quantity: 2,
+	}); err != nil {
+		t.Fatalf("Open short failed: %v", err)
+	}
+
+	// 空单止盈：价格下穿触发，止损：价格上穿触发
+	tp, _ := pe.PlaceOrder(types.OrderRequest{
+		Symbol: "BTCUSDT", Side: "BUY", PositionSide: "SHORT", OrderType: "TAKE_PROFIT_MARKET",
+		Quantity: 1, StopPrice: floatPtr(90.0), ReduceOnly: true,
+	})
+	sl, _ := pe.PlaceOrder(types.OrderRequest{
+		Symbol: "BTCUSDT", Side: "BUY", PositionSide: "SHORT", OrderType: "STOP_MARKET",
+		Quantity: 5, StopPrice: floatPtr(105.0), ReduceOnly: true,
+	})
+	if tp.Status != "NEW" || sl.Status != "NEW" {
+		t.Fatalf("Expected protection orders to rest, got tp=%s sl=%s", tp.Status, sl.Status)
+	}
+
+	pe.UpdatePrice("BTCUSDT", 90.0)
+	pos, _ := pe.GetPosition("BTCUSDT")
+	if pos == nil || pos.Size != 1 {
+		t.Fatalf("Expected TP to close half the short, got %+v", pos)
+	}
+
+	pe.UpdatePrice("BTCUSDT", 106.0)
+	pos, _ = pe.GetPosition("BTCUSDT")
+	if pos != nil {
+		t.Errorf("Expected SL to close remaining short, got %+v", pos)
+	}
+
+	// 已实现盈亏：(100-90)*1 + (100-106)*1 = 4
+	balance, _ := pe.GetBalance()
+	if !almostEqual(balance["total"], 1004.0) {
+		t.Errorf("Expected balance 1004, got %f", balance["total"])
+	}
+}
+
+func TestPaperExchange_InsufficientMargin(t *testing.T) {
+	pe := newTestPaperExchange(100.0)
+
+	_, err := pe.PlaceOrder(types.OrderRequest{
+		Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "MARKET", Quantity: 1000,
+	})
+	if err == nil {
+		t.Error("Expected insufficient margin error")
+	}
+}
+
+func TestPaperExchange_CancelOrder(t *testing.T) {
+	pe := newTestPaperExchange(100.0)
+
+	order, _ := pe.PlaceOrder(types.OrderRequest{
+		Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "LIMIT",
+		Quantity: 1, Price: floatPtr(90.0),
+	})
+	if err := pe.CancelOrder("BTCUSDT", order.ID); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	if err := pe.CancelOrder("BTCUSDT", order.ID); err == nil {
+		t.Error("Expected error cancelling an already cancelled order")
+	}
+	if cancelled, _ := pe.GetOrder("BTCUSDT", order.ID); cancelled == nil || cancelled.Status != "CANCELED" {
+		t.Errorf("Expected CANCELED status, got %+v", cancelled)
+	}
+
+	pe.UpdatePrice("BTCUSDT", 80.0)
+	if pos, _ := pe.GetPosition("BTCUSDT"); pos != nil {
+		t.Errorf("Cancelled order should not fill, got %+v", pos)
+	}
+}
@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+func TestDetectReconcileIssues(t *testing.T) {
+	stop := func(id, symbol, side string, qty float64) *types.Order {
+		closeSide := "SELL"
+		if side == "SHORT" {
+			closeSide = "BUY"
+		}
+		return &types.Order{ID: id, Symbol: symbol, Side: closeSide, PositionSide: side, OrderType: "STOP_MARKET", Quantity: qty, ReduceOnly: true, Status: "NEW"}
+	}
+	takeProfit := func(id, symbol, side string, qty float64) *types.Order {
+		o := stop(id, symbol, side, qty)
+		o.OrderType = "TAKE_PROFIT_MARKET"
+		return o
+	}
+
+	state := &execution.ReconcileState{
+		Positions: []*types.Position{
+			{Symbol: "BTCUSDT", Side: "LONG", Size: 0.1},   // 保护完整
+			{Symbol: "ETHUSDT", Side: "SHORT", Size: 2},    // 止损数量不符（部分止盈后未调整）
+			{Symbol: "SOLUSDT", Side: "LONG", Size: 10},    // 系统开的仓，保护信息丢失
+			{Symbol: "DOGEUSDT", Side: "LONG", Size: 1000}, // 手动开仓
+			{Symbol: "XRPUSDT", Side: "LONG", Size: 0},     // 已平仓
+		},
+		OpenOrders: map[string][]*types.Order{
+			"BTCUSDT": {
+				stop("1", "BTCUSDT", "LONG", 0.1),
+				takeProfit("2", "BTCUSDT", "LONG", 0.05),
+				takeProfit("3", "BTCUSDT", "LONG", 0.05),
+				// 空仓方向残留的止损单
+				stop("4", "BTCUSDT", "SHORT", 0.2),
+				// 入场限价单不参与对账
+				{ID: "5", Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "LIMIT", Quantity: 0.1, Status: "NEW"},
+				// 手动挂的突破入场止损单（开仓方向）不是孤立单
+				{ID: "9", Symbol: "BTCUSDT", Side: "SELL", PositionSide: "SHORT", OrderType: "STOP_MARKET", Quantity: 0.1, StopPrice: 45000, Status: "NEW"},
+			},
+			"ETHUSDT": {
+				stop("6", "ETHUSDT", "SHORT", 4),
+				takeProfit("7", "ETHUSDT", "SHORT", 2),
+			},
+			"XRPUSDT": {
+				takeProfit("8", "XRPUSDT", "LONG", 50),
+			},
+		},
+		Protections: map[string]string{
+			"BTCUSDT:LONG":  "sig-btc",
+			"ETHUSDT:SHORT": "sig-eth",
+			"XRPUSDT:LONG":  "sig-xrp",
+		},
+		Known: map[string]bool{
+			"SOLUSDT:LONG": true,
+		},
+	}
+
+	issues := execution.DetectReconcileIssues(state)
+
+	expected := []struct {
+		issueType string
+		symbol    string
+		side      string
+		orderIDs  []string
+	}{
+		{execution.ReconcileOrphanOrder, "BTCUSDT", "SHORT", []string{"4"}},
+		{execution.ReconcileUnknownPosition, "DOGEUSDT", "LONG", nil},
+		{execution.ReconcileQuantityMismatch, "ETHUSDT", "SHORT", []string{"6"}},
+		{execution.ReconcileMissingProtection, "SOLUSDT", "LONG", nil},
+		{execution.ReconcileOrphanOrder, "XRPUSDT", "LONG", []string{"8"}},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, exp := range expected {
+		got := issues[i]
+		if got.Type != exp.issueType || got.Symbol != exp.symbol || got.PositionSide != exp.side {
+			t.Errorf("Issue %d: expected %s %s %s, got %s %s %s", i, exp.issueType, exp.symbol, exp.side, got.Type, got.Symbol, got.PositionSide)
+			continue
+		}
+		if len(got.OrderIDs) != len(exp.orderIDs) {
+			t.Errorf("Issue %d: expected orders %v, got %v", i, exp.orderIDs, got.OrderIDs)
+			continue
+		}
+		for j := range exp.orderIDs {
+			if got.OrderIDs[j] != exp.orderIDs[j] {
+				t.Errorf("Issue %d: expected orders %v, got %v", i, exp.orderIDs, got.OrderIDs)
+			}
+		}
+	}
+	if issues[2].SignalID != "sig-eth" || issues[2].OrderQty != 4 || issues[2].PositionSize != 2 {
+		t.Errorf("Expected mismatch for sig-eth with stop 4 vs position 2, got %+v", issues[2])
+	}
+
+	// 数量在量化误差内视为一致，止盈总量小于持仓不算不符
+	state = &execution.ReconcileState{
+		Positions: []*types.Position{{Symbol: "BTCUSDT", Side: "LONG", Size: 0.1}},
+		OpenOrders: map[string][]*types.Order{
+			"BTCUSDT": {stop("1", "BTCUSDT", "LONG", 0.10005), takeProfit("2", "BTCUSDT", "LONG", 0.05)},
+		},
+		Protections: map[string]string{"BTCUSDT:LONG": "sig-btc"},
+	}
+	if issues := execution.DetectReconcileIssues(state); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+
+	// 单向持仓的reduceOnly单和系统挂的保护单（nx前缀）按平仓单处理，手动突破单不统计
+	state = &execution.ReconcileState{
+		OpenOrders: map[string][]*types.Order{
+			"ETHUSDT": {
+				{ID: "1", Symbol: "ETHUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "STOP_MARKET", Quantity: 1, ReduceOnly: true, Status: "NEW"},
+				{ID: "2", Symbol: "ETHUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "TAKE_PROFIT_MARKET", Quantity: 1, ClientOrderID: execution.ClientOrderID("sig-eth", execution.OrderRoleTP1), Status: "NEW"},
+				{ID: "3", Symbol: "ETHUSDT", Side: "BUY", PositionSide: "LONG", OrderType: "STOP_MARKET", Quantity: 1, ClientOrderID: "manual_breakout", Status: "NEW"},
+			},
+		},
+	}
+	issues = execution.DetectReconcileIssues(state)
+	if len(issues) != 2 || issues[0].Type != execution.ReconcileOrphanOrder || issues[1].Type != execution.ReconcileOrphanOrder {
+		t.Fatalf("Expected two orphan issues, got %+v", issues)
+	}
+	ids := append(append([]string{}, issues[0].OrderIDs...), issues[1].OrderIDs...)
+	sort.Strings(ids)
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("Expected orphan orders [1 2], got %v", ids)
+	}
+}
+
+func TestReconcileOnce_APIReportNotSaved(t *testing.T) {
+	_, be := newFakeBinance(t)
+	rdb := newTestRedis(t)
+	engine := execution.NewExecutionEngine(be, rdb)
+	ctx := context.Background()
+
+	if _, err := engine.ReconcileOnce(ctx, execution.ReconcileTriggerSchedule, false); err != nil {
+		t.Fatalf("Scheduled reconcile failed: %v", err)
+	}
+	report, err := engine.ReconcileOnce(ctx, execution.ReconcileTriggerAPI, true)
+	if err != nil || report == nil || !report.DryRun {
+		t.Fatalf("API reconcile failed: %+v, %v", report, err)
+	}
+
+	// API触发的只读对账不覆盖定时对账的报告
+	saved, err := execution.LoadReconcileReport(ctx, rdb)
+	if err != nil || saved == nil {
+		t.Fatalf("Expected saved report, got %+v, %v", saved, err)
+	}
+	if saved.Trigger != execution.ReconcileTriggerSchedule || saved.DryRun {
+		t.Errorf("Expected scheduled report to be kept, got %+v", saved)
+	}
+}
+
+func TestReconcileAction(t *testing.T) {
+	cfg := &config.Config{
+		ReconcileUnprotectedPolicy: "adopt",
+		ReconcileUnknownPolicy:     "alert",
+		ReconcileOrphanPolicy:      "cancel",
+	}
+	tests := []struct {
+		issueType string
+		expected  string
+	}{
+		{execution.ReconcileMissingProtection, execution.ReconcileActionAdopt},
+		{execution.ReconcileUnknownPosition, execution.ReconcileActionAlert},
+		{execution.ReconcileOrphanOrder, execution.ReconcileActionCancel},
+		{execution.ReconcileQuantityMismatch, execution.ReconcileActionCancel},
+	}
+	for _, tt := range tests {
+		if got := execution.ReconcileAction(tt.issueType, cfg); got != tt.expected {
+			t.Errorf("ReconcileAction(%s) = %s, expected %s", tt.issueType, got, tt.expected)
+		}
+	}
+
+	// 策略与问题类型不匹配或无效时只告警
+	cfg.ReconcileUnprotectedPolicy = "cancel"
+	cfg.ReconcileOrphanPolicy = "delete"
+	if got := execution.ReconcileAction(execution.ReconcileMissingProtection, cfg); got != execution.ReconcileActionAlert {
+		t.Errorf("Expected alert for mismatched policy, got %s", got)
+	}
+	if got := execution.ReconcileAction(execution.ReconcileOrphanOrder, cfg); got != execution.ReconcileActionAlert {
+		t.Errorf("Expected alert for invalid policy, got %s", got)
+	}
+}
+
+func TestATRProtection(t *testing.T) {
+	sl, tp := execution.ATRProtection("LONG", 100, 2, 2, 3)
+	if !almostEqual(sl, 96) || !almostEqual(tp, 106) {
+		t.Errorf("Expected long SL 96 TP 106, got %v %v", sl, tp)
+	}
+	sl, tp = execution.ATRProtection("short", 100, 2, 2, 3)
+	if !almostEqual(sl, 104) || !almostEqual(tp, 94) {
+		t.Errorf("Expected short SL 104 TP 94, got %v %v", sl, tp)
+	}
+	if sl, tp = execution.ATRProtection("LONG", 100, 0, 2, 3); sl != 0 || tp != 0 {
+		t.Errorf("Expected no protection without ATR, got %v %v", sl, tp)
+	}
+	if sl, tp = execution.ATRProtection("SHORT", 10, 2, 2, 5); sl != 0 || tp != 0 {
+		t.Errorf("Expected no protection when take profit is not positive, got %v %v", sl, tp)
+	}
+}
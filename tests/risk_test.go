@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/execution"
+)
+
+func defaultRiskLimits() execution.RiskLimits {
+	return execution.RiskLimits{
+		MaxNotionalPerTrade:    50.0,
+		MaxLeverage:            10.0,
+		MaxConcurrentPositions: 2,
+	}
+}
+
+func TestEvaluateRisk_Allowed(t *testing.T) {
+	decision := execution.EvaluateRisk(execution.RiskInput{
+		Symbol:   "BTCUSDT",
+		Notional: 20.0,
+		Leverage: 5,
+	}, defaultRiskLimits())
+
+	if !decision.Allowed {
+		t.Fatalf("Expected signal to be allowed, got reason %s", decision.ReasonCode)
+	}
+	if decision.Notional != 20.0 || decision.Leverage != 5 {
+		t.Errorf("Expected unchanged notional/leverage, got %f/%d", decision.Notional, decision.Leverage)
+	}
+	if len(decision.Adjustments) != 0 {
+		t.Errorf("Expected no adjustments, got %v", decision.Adjustments)
+	}
+}
+
+func TestEvaluateRisk_ScalesNotionalAndLeverage(t *testing.T) {
+	decision := execution.EvaluateRisk(execution.RiskInput{
+		Symbol:   "BTCUSDT",
+		Notional: 200.0,
+		Leverage: 50,
+	}, defaultRiskLimits())
+
+	if !decision.Allowed {
+		t.Fatalf("Expected signal to be scaled, not rejected (%s)", decision.ReasonCode)
+	}
+	if decision.Notional != 50.0 {
+		t.Errorf("Expected notional scaled to 50, got %f", decision.Notional)
+	}
+	if decision.Leverage != 10 {
+		t.Errorf("Expected leverage scaled to 10, got %d", decision.Leverage)
+	}
+	if len(decision.Adjustments) != 2 {
+		t.Errorf("Expected 2 adjustments, got %v", decision.Adjustments)
+	}
+}
+
+func TestEvaluateRisk_Rejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		input execution.RiskInput
+		code  string
+	}{
+		{
+			name:  "invalid notional",
+			input: execution.RiskInput{Symbol: "BTCUSDT", Notional: 0},
+			code:  execution.RiskReasonInvalidNotional,
+		},
+		{
+			name:  "cooldown",
+			input: execution.RiskInput{Symbol: "BTCUSDT", Notional: 20, CooldownRemainingSec: 30},
+			code:  execution.RiskReasonSymbolCooldown,
+		},
+		{
+			name:  "max positions",
+			input: execution.RiskInput{Symbol: "BTCUSDT", Notional: 20, OpenSymbols: 2},
+			code:  execution.RiskReasonMaxPositions,
+		},
+	}
+
+	for _, tt := range tests {
+		decision := execution.EvaluateRisk(tt.input, defaultRiskLimits())
+		if decision.Allowed {
+			t.Errorf("%s: expected rejection", tt.name)
+			continue
+		}
+		if decision.ReasonCode != tt.code {
+			t.Errorf("%s: expected reason code %s, got %s", tt.name, tt.code, decision.ReasonCode)
+		}
+	}
+}
+
+func TestEvaluateRisk_ExistingPositionDoesNotCountAgainstLimit(t *testing.T) {
+	decision := execution.EvaluateRisk(execution.RiskInput{
+		Symbol:      "BTCUSDT",
+		Notional:    20.0,
+		OpenSymbols: 2,
+		HasPosition: true,
+	}, defaultRiskLimits())
+
+	if !decision.Allowed {
+		t.Errorf("Expected adding to an existing position to be allowed, got %s", decision.ReasonCode)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+	"github.com/yuechangmingzou/nofx-go/pkg/types"
+)
+
+// btcExchangeInfo exchangeInfo中BTCUSDT的交易对信息（节选）
+const btcExchangeInfo = `{
+	"symbol": "BTCUSDT",
+	"filters": [
+		{"filterType": "PRICE_FILTER", "minPrice": "556.80", "maxPrice": "4529764", "tickSize": "0.10"},
+		{"filterType": "LOT_SIZE", "minQty": "0.001", "maxQty": "1000", "stepSize": "0.001"},
+		{"filterType": "MARKET_LOT_SIZE", "minQty": "0.001", "maxQty": "120", "stepSize": "0.001"},
+		{"filterType": "MAX_NUM_ORDERS", "limit": 200},
+		{"filterType": "MIN_NOTIONAL", "notional": "100"},
+		{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500", "multiplierDown": "0.9500", "multiplierDecimal": "4"}
+	]
+}`
+
+func loadBTCFilters(t *testing.T) exchange.SymbolFilters {
+	t.Helper()
+	var market map[string]interface{}
+	if err := json.Unmarshal([]byte(btcExchangeInfo), &market); err != nil {
+		t.Fatalf("Failed to parse exchangeInfo: %v", err)
+	}
+	return exchange.ParseSymbolFilters(market)
+}
+
+func TestParseSymbolFilters(t *testing.T) {
+	f := loadBTCFilters(t)
+	if f.Symbol != "BTCUSDT" || f.TickSize != 0.1 || f.StepSize != 0.001 || f.MinQty != 0.001 || f.MinNotional != 100 {
+		t.Errorf("Unexpected filters: %+v", f)
+	}
+	if f.MarketMaxQty != 120 || f.MaxQty != 1000 || f.MinPrice != 556.8 {
+		t.Errorf("Unexpected market lot / price range: %+v", f)
+	}
+}
+
+func TestSymbolFilters_ApplyQuantizes(t *testing.T) {
+	f := loadBTCFilters(t)
+
+	entry := 37001.37
+	req := types.OrderRequest{
+		Symbol:    "BTCUSDT",
+		Side:      "BUY",
+		OrderType: "LIMIT",
+		Quantity:  500 / entry, // 0.013512...
+		Price:     &entry,
+	}
+	out, err := f.Apply(req, 0)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if out.Quantity != 0.013 || *out.Price != 37001.4 {
+		t.Errorf("Expected quantity 0.013 and price 37001.4, got %v %v", out.Quantity, *out.Price)
+	}
+	if entry != 37001.37 {
+		t.Errorf("Apply must not modify caller's price, got %v", entry)
+	}
+
+	// 浮点误差不应导致少一个步长
+	if q := f.QuantizeQuantity(0.3, "LIMIT"); q != 0.3 {
+		t.Errorf("QuantizeQuantity(0.3) = %v", q)
+	}
+
+	stop := 35999.96
+	out, err = f.Apply(types.OrderRequest{Symbol: "BTCUSDT", OrderType: "STOP_MARKET", Quantity: 0.0139, StopPrice: &stop, ReduceOnly: true}, 0)
+	if err != nil {
+		t.Fatalf("Apply stop failed: %v", err)
+	}
+	if out.Quantity != 0.013 || *out.StopPrice != 36000 {
+		t.Errorf("Unexpected stop order: %v %v", out.Quantity, *out.StopPrice)
+	}
+}
+
+func TestSymbolFilters_ApplyRejects(t *testing.T) {
+	f := loadBTCFilters(t)
+	price := 37000.0
+
+	cases := []struct {
+		name     string
+		req      types.OrderRequest
+		refPrice float64
+	}{
+		{"below min notional", types.OrderRequest{OrderType: "LIMIT", Quantity: 0.002, Price: &price}, 0},
+		{"below min qty", types.OrderRequest{OrderType: "LIMIT", Quantity: 0.0009, Price: &price}, 0},
+		{"above market max qty", types.OrderRequest{OrderType: "MARKET", Quantity: 150}, price},
+		{"market below min notional", types.OrderRequest{OrderType: "MARKET", Quantity: 0.001}, price},
+	}
+	for _, tc := range cases {
+		if _, err := f.Apply(tc.req, tc.refPrice); !errors.Is(err, exchange.ErrOrderFilter) {
+			t.Errorf("%s: expected ErrOrderFilter, got %v", tc.name, err)
+		}
+	}
+
+	// 只减仓订单不受最小名义价值限制
+	if _, err := f.Apply(types.OrderRequest{OrderType: "MARKET", Quantity: 0.001, ReduceOnly: true}, price); err != nil {
+		t.Errorf("Expected reduce-only order to pass, got %v", err)
+	}
+}
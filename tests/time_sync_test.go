@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/config"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+)
+
+func TestTimeSync_AppliesServerOffset(t *testing.T) {
+	const skew = 5 * time.Second
+	calls := 0
+	ts := exchange.NewTimeSync(func(ctx context.Context) (int64, error) {
+		calls++
+		return time.Now().Add(skew).UnixMilli(), nil
+	}, time.Minute)
+
+	// 首次取时间时自动同步
+	diff := ts.NowMillis() - time.Now().UnixMilli()
+	if diff < 4900 || diff > 5100 {
+		t.Errorf("Expected corrected timestamp ~+5000ms, got %+dms", diff)
+	}
+	if offset := ts.Offset(); offset < 4900*time.Millisecond || offset > 5100*time.Millisecond {
+		t.Errorf("Expected offset ~5s, got %v", offset)
+	}
+
+	// 同步间隔内不重复请求
+	ts.NowMillis()
+	if calls != 1 {
+		t.Errorf("Expected 1 sync within interval, got %d", calls)
+	}
+}
+
+func TestBinanceExchange_RetriesOnTimestampError(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("BINANCE_API_KEY", "test-key")
+	t.Setenv("BINANCE_SECRET_KEY", "test-secret")
+	t.Setenv("BINANCE_RECV_WINDOW_MS", "7000")
+
+	var mu sync.Mutex
+	serverOffset := int64(0) // 首次同步时服务器时间与本地一致，之后服务器时钟快进3秒
+	timeCalls := 0
+	var timestamps []int64
+	var recvWindows []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/fapi/v1/time":
+			timeCalls++
+			w.Write([]byte(`{"serverTime":` + strconv.FormatInt(time.Now().UnixMilli()+serverOffset, 10) + `}`))
+		case "/fapi/v2/balance":
+			ts, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+			timestamps = append(timestamps, ts)
+			recvWindows = append(recvWindows, r.URL.Query().Get("recvWindow"))
+			if len(timestamps) == 1 {
+				serverOffset = 3000
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`))
+				return
+			}
+			w.Write([]byte(`[{"asset":"USDT","balance":"100.5","availableBalance":"80.5"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("BINANCE_FAPI_BASE_URL", server.URL)
+	if err := config.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	be := exchange.NewBinanceExchange(exchange.NewHTTPClient(server.URL, 5*time.Second))
+	balance, err := be.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance failed after retry: %v", err)
+	}
+	if balance["total"] != 100.5 || balance["free"] != 80.5 {
+		t.Errorf("Unexpected balance: %v", balance)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(timestamps) != 2 {
+		t.Fatalf("Expected exactly one retry, got %d balance requests", len(timestamps))
+	}
+	if timeCalls != 2 {
+		t.Errorf("Expected initial sync plus one resync, got %d time requests", timeCalls)
+	}
+	if shift := timestamps[1] - timestamps[0]; shift < 2500 {
+		t.Errorf("Expected retry timestamp corrected by ~3000ms, got %dms", shift)
+	}
+	for _, rw := range recvWindows {
+		if rw != "7000" {
+			t.Errorf("Expected recvWindow=7000, got %q", rw)
+		}
+	}
+}
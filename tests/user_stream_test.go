@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+)
+
+const orderTradeUpdateMsg = `{"e":"ORDER_TRADE_UPDATE","E":1568879465651,"T":1568879465650,"o":{"s":"BTCUSDT","c":"entry_1","S":"SELL","o":"MARKET","f":"GTC","q":"0.002","p":"0","ap":"7100.5","sp":"7103.04","x":"TRADE","X":"FILLED","i":8886774,"l":"0.002","z":"0.002","L":"7100.5","N":"USDT","n":"0.0028","T":1568879465650,"t":1,"R":true,"ps":"LONG","ot":"STOP_MARKET","cp":false,"AP":"7476.89","rp":"-1.25"}}`
+
+const accountUpdateMsg = `{"e":"ACCOUNT_UPDATE","E":1564745798939,"T":1564745798938,"a":{"m":"ORDER","B":[{"a":"USDT","wb":"122624.12","cw":"100.12","bc":"50.12"}],"P":[{"s":"BTCUSDT","pa":"-0.5","ep":"6563.6","cr":"0","up":"2.1","mt":"cross","iw":"0","ps":"SHORT"}]}}`
+
+func TestParseUserDataEvent_OrderTradeUpdate(t *testing.T) {
+	event, err := exchange.ParseUserDataEvent([]byte(orderTradeUpdateMsg))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	order, ok := event.(*exchange.OrderUpdateEvent)
+	if !ok {
+		t.Fatalf("Expected *OrderUpdateEvent, got %T", event)
+	}
+	if order.OrderID != "8886774" || order.Symbol != "BTCUSDT" || order.Status != "FILLED" {
+		t.Errorf("Unexpected order event: %+v", order)
+	}
+	// 触发后的止损单应保留原始类型，均价不应被激活价覆盖
+	if order.OrderType != "STOP_MARKET" || order.AvgPrice != 7100.5 {
+		t.Errorf("Expected STOP_MARKET avg 7100.5, got %s avg %f", order.OrderType, order.AvgPrice)
+	}
+	if !order.ReduceOnly || order.PositionSide != "LONG" || order.RealizedProfit != -1.25 {
+		t.Errorf("Unexpected flags: %+v", order)
+	}
+}
+
+func TestParseUserDataEvent_AccountUpdate(t *testing.T) {
+	event, err := exchange.ParseUserDataEvent([]byte(accountUpdateMsg))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	account, ok := event.(*exchange.AccountUpdateEvent)
+	if !ok {
+		t.Fatalf("Expected *AccountUpdateEvent, got %T", event)
+	}
+	if account.Reason != "ORDER" || len(account.Balances) != 1 || len(account.Positions) != 1 {
+		t.Fatalf("Unexpected account event: %+v", account)
+	}
+	pos := account.Positions[0]
+	if pos.Side() != "SHORT" || pos.Size() != 0.5 || pos.EntryPrice != 6563.6 {
+		t.Errorf("Unexpected position update: %+v", pos)
+	}
+}
+
+func TestUserDataStream_ReceivesEvents(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	var keyRequests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fapi/v1/listenKey", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		keyRequests.Add(1)
+		w.Write([]byte(`{"listenKey":"abc123"}`))
+	})
+	mux.HandleFunc("/ws/abc123", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(orderTradeUpdateMsg))
+		conn.WriteMessage(websocket.TextMessage, []byte(accountUpdateMsg))
+		// 保持连接直到客户端断开
+		conn.ReadMessage()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	stream := exchange.NewUserDataStream(server.URL, wsURL, "test-key")
+
+	orders := make(chan *exchange.OrderUpdateEvent, 1)
+	accounts := make(chan *exchange.AccountUpdateEvent, 1)
+	stream.OnOrderUpdate(func(e *exchange.OrderUpdateEvent) { orders <- e })
+	stream.OnAccountUpdate(func(e *exchange.AccountUpdateEvent) { accounts <- e })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	select {
+	case e := <-orders:
+		if e.OrderID != "8886774" {
+			t.Errorf("Unexpected order id %s", e.OrderID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for order update")
+	}
+
+	select {
+	case e := <-accounts:
+		if len(e.Positions) != 1 {
+			t.Errorf("Unexpected account update: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for account update")
+	}
+
+	if !stream.IsConnected() {
+		t.Error("Expected stream to report connected")
+	}
+	if n := keyRequests.Load(); n != 1 {
+		t.Errorf("Expected 1 listenKey request, got %d", n)
+	}
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/yuechangmingzou/nofx-go/internal/config"
@@ -70,7 +71,7 @@ func TestValidateConfig_MissingBinanceKey(t *testing.T) {
 	os.Setenv("REDIS_PORT", "6379")
 	os.Setenv("WEB_BASIC_AUTH_USER", "admin")
 	os.Setenv("WEB_BASIC_AUTH_PASS", "strongpassword123")
-	os.Setenv("DRY_RUN", "false") // 非DRY_RUN模式
+	os.Setenv("DRY_RUN", "false")    // 非DRY_RUN模式
 	os.Setenv("BINANCE_API_KEY", "") // 缺少API Key
 
 	config.Load()
@@ -80,3 +81,42 @@ func TestValidateConfig_MissingBinanceKey(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_UnknownExchange(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("WEB_BASIC_AUTH_USER", "admin")
+	t.Setenv("WEB_BASIC_AUTH_PASS", "strongpassword123")
+	t.Setenv("DRY_RUN", "true")
+	t.Setenv("EXCHANGE", "papper")
+
+	config.Load()
+	err := config.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), "EXCHANGE must be one of") {
+		t.Errorf("Expected validation to fail for unknown exchange, got %v", err)
+	}
+}
+
+func TestValidateConfig_PaperExchangeWithoutKeys(t *testing.T) {
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("WEB_BASIC_AUTH_USER", "admin")
+	t.Setenv("WEB_BASIC_AUTH_PASS", "strongpassword123")
+	t.Setenv("DRY_RUN", "false")
+	t.Setenv("EXCHANGE", "paper")
+	t.Setenv("BINANCE_API_KEY", "")
+	t.Setenv("BINANCE_SECRET_KEY", "")
+
+	config.Load()
+	if err := config.ValidateConfig(); err != nil {
+		t.Errorf("Expected paper exchange to need no API keys, got %v", err)
+	}
+
+	// 切换到Bybit时需要Bybit密钥
+	t.Setenv("EXCHANGE", "bybit")
+	t.Setenv("BYBIT_API_KEY", "")
+	config.Load()
+	err := config.ValidateConfig()
+	if err == nil || !strings.Contains(err.Error(), "BYBIT_API_KEY") {
+		t.Errorf("Expected missing Bybit key error, got %v", err)
+	}
+}
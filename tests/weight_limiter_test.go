@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yuechangmingzou/nofx-go/internal/exchange"
+)
+
+func TestBinanceRequestCost(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		endpoint string
+		params   map[string]string
+		weight   int
+		orders   int
+		priority exchange.RequestPriority
+	}{
+		{"klines small", http.MethodGet, "/fapi/v1/klines", map[string]string{"limit": "50"}, 1, 0, exchange.PriorityNormal},
+		{"klines large", http.MethodGet, "/fapi/v1/klines", map[string]string{"limit": "1000"}, 5, 0, exchange.PriorityNormal},
+		{"klines default", http.MethodGet, "/fapi/v1/klines", map[string]string{}, 5, 0, exchange.PriorityNormal},
+		{"ticker all", http.MethodGet, "/fapi/v1/ticker/24hr", nil, 40, 0, exchange.PriorityNormal},
+		{"ticker symbol", http.MethodGet, "/fapi/v1/ticker/24hr", map[string]string{"symbol": "BTCUSDT"}, 1, 0, exchange.PriorityNormal},
+		{"balance", http.MethodGet, "/fapi/v2/balance", nil, 5, 0, exchange.PriorityNormal},
+		{"new order", http.MethodPost, "/fapi/v1/order", nil, 1, 1, exchange.PriorityOrder},
+		{"cancel order", http.MethodDelete, "/fapi/v1/order", nil, 1, 0, exchange.PriorityOrder},
+	}
+	for _, c := range cases {
+		cost := exchange.BinanceRequestCost(c.method, c.endpoint, c.params)
+		if cost.Weight != c.weight || cost.Orders != c.orders || cost.Priority != c.priority {
+			t.Errorf("%s: got %+v, want weight=%d orders=%d priority=%d", c.name, cost, c.weight, c.orders, c.priority)
+		}
+	}
+}
+
+func TestWeightLimiter_HeadersThrottleNormalBeforeOrders(t *testing.T) {
+	// 避开分钟窗口切换
+	if untilNext := time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)); untilNext < 2*time.Second {
+		time.Sleep(untilNext + 100*time.Millisecond)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "78")
+		w.Write([]byte(`{"serverTime":1700000000000}`))
+	}))
+	defer server.Close()
+
+	client := exchange.NewHTTPClient(server.URL, 5*time.Second)
+	limiter := client.Limiter()
+	limiter.SetLimits(100, 0, 0)
+
+	if _, err := client.FetchJSON(context.Background(), "/fapi/v1/time", nil); err != nil {
+		t.Fatalf("FetchJSON failed: %v", err)
+	}
+	if used, limit := limiter.Usage(); used != 78 || limit != 100 {
+		t.Fatalf("Expected usage 78/100 from headers, got %d/%d", used, limit)
+	}
+
+	// 普通请求只能使用80%的权重，应被限流
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, exchange.BinanceRequestCost(http.MethodGet, "/fapi/v1/klines", map[string]string{"limit": "1000"})); err == nil {
+		t.Error("Expected normal request to be throttled near the limit")
+	}
+
+	// 订单请求使用预留的权重
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+	if err := limiter.Wait(ctx2, exchange.BinanceRequestCost(http.MethodPost, "/fapi/v1/order", nil)); err != nil {
+		t.Errorf("Expected order request to proceed, got %v", err)
+	}
+}